@@ -0,0 +1,141 @@
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yebrai/go-tasks-microservice/pkg/auth"
+	"github.com/yebrai/go-tasks-microservice/pkg/tenant"
+)
+
+// KeyPrefix prefijo de las claves emitidas, para reconocerlas en logs y en
+// escáneres de secretos
+const KeyPrefix = "tsk_"
+
+// displayPrefixLength caracteres de la clave que se guardan en claro para
+// identificarla en los listados
+const displayPrefixLength = len(KeyPrefix) + 8
+
+var (
+	ErrAPIKeyNotFound     = errors.New("API key not found")
+	ErrAPIKeyRevoked      = errors.New("API key already revoked")
+	ErrInvalidAPIKeyData  = errors.New("invalid API key data")
+	errAPIKeyInactive     = errors.New("API key revoked or expired")
+	errAPIKeyUnrecognized = errors.New("unrecognized API key")
+)
+
+// Scopes scopes que se pueden conceder a una clave
+var Scopes = []string{auth.ScopeTasksRead, auth.ScopeTasksWrite, auth.ScopeAdmin}
+
+// APIKey clave de acceso para servicios. Solo se guarda el hash de la clave;
+// el valor en claro se muestra una única vez al crearla
+type APIKey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Owner      string     `json:"owner"`
+	Tenant     string     `json:"tenant,omitempty"`
+	Prefix     string     `json:"prefix"`
+	Hash       string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// New crea una clave y retorna también su valor en claro
+func New(id, name, owner string, scopes []string, expiresAt *time.Time, now time.Time) (*APIKey, string, error) {
+	if strings.TrimSpace(name) == "" || owner == "" {
+		return nil, "", ErrInvalidAPIKeyData
+	}
+	if len(scopes) == 0 {
+		return nil, "", fmt.Errorf("%w: at least one scope is required", ErrInvalidAPIKeyData)
+	}
+	for _, scope := range scopes {
+		if !validScope(scope) {
+			return nil, "", fmt.Errorf("%w: unknown scope %q", ErrInvalidAPIKeyData, scope)
+		}
+	}
+	if expiresAt != nil && !expiresAt.After(now) {
+		return nil, "", fmt.Errorf("%w: expires_at must be in the future", ErrInvalidAPIKeyData)
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	plaintext := KeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	return &APIKey{
+		ID:        id,
+		Name:      strings.TrimSpace(name),
+		Owner:     owner,
+		Prefix:    plaintext[:displayPrefixLength],
+		Hash:      Hash(plaintext),
+		Scopes:    scopes,
+		CreatedAt: now,
+		ExpiresAt: expiresAt,
+	}, plaintext, nil
+}
+
+// Hash calcula el hash con el que se guarda y busca una clave. Las claves
+// tienen 256 bits aleatorios, así que basta un hash rápido sin sal
+func Hash(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
+
+// Active indica si la clave no está revocada ni caducada
+func (k *APIKey) Active(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
+
+// Revoke revoca la clave
+func (k *APIKey) Revoke(now time.Time) error {
+	if k.RevokedAt != nil {
+		return ErrAPIKeyRevoked
+	}
+	k.RevokedAt = &now
+	return nil
+}
+
+// Principal retorna el principal con el que se autentica la clave: actúa
+// en nombre de su propietario, con los scopes de la clave y ligado al
+// tenant en el que se creó
+func (k *APIKey) Principal() *auth.Principal {
+	return &auth.Principal{
+		Subject:  k.Owner,
+		Name:     k.Name,
+		Scopes:   append([]string(nil), k.Scopes...),
+		Tenant:   k.TenantID(),
+		APIKeyID: k.ID,
+	}
+}
+
+// TenantID tenant de la clave. Las claves anteriores a los tenants
+// pertenecen al tenant por defecto
+func (k *APIKey) TenantID() string {
+	if k.Tenant == "" {
+		return tenant.DefaultID
+	}
+	return k.Tenant
+}
+
+// validScope indica si el scope se puede conceder
+func validScope(scope string) bool {
+	for _, allowed := range Scopes {
+		if scope == allowed {
+			return true
+		}
+	}
+	return false
+}
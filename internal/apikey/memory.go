@@ -0,0 +1,98 @@
+package apikey
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryRepository implementación en memoria de Repository para una sola
+// instancia
+type MemoryRepository struct {
+	keys map[string]APIKey
+	mu   sync.RWMutex
+}
+
+// NewMemoryRepository crea un repositorio en memoria
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		keys: make(map[string]APIKey),
+	}
+}
+
+// Save implementa la interfaz Repository
+func (r *MemoryRepository) Save(_ context.Context, key *APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.keys[key.ID] = *key
+	return nil
+}
+
+// FindByID implementa la interfaz Repository
+func (r *MemoryRepository) FindByID(_ context.Context, id string) (*APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	key, ok := r.keys[id]
+	if !ok {
+		return nil, ErrAPIKeyNotFound
+	}
+	return &key, nil
+}
+
+// FindByHash implementa la interfaz Repository
+func (r *MemoryRepository) FindByHash(_ context.Context, hash string) (*APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, key := range r.keys {
+		if key.Hash == hash {
+			return &key, nil
+		}
+	}
+	return nil, ErrAPIKeyNotFound
+}
+
+// FindAll implementa la interfaz Repository
+func (r *MemoryRepository) FindAll(_ context.Context) ([]*APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := make([]*APIKey, 0, len(r.keys))
+	for _, key := range r.keys {
+		key := key
+		keys = append(keys, &key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.After(keys[j].CreatedAt)
+	})
+	return keys, nil
+}
+
+// Update implementa la interfaz Repository
+func (r *MemoryRepository) Update(_ context.Context, key *APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.keys[key.ID]; !ok {
+		return ErrAPIKeyNotFound
+	}
+	r.keys[key.ID] = *key
+	return nil
+}
+
+// TouchLastUsed implementa la interfaz Repository
+func (r *MemoryRepository) TouchLastUsed(_ context.Context, id string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, ok := r.keys[id]
+	if !ok {
+		return ErrAPIKeyNotFound
+	}
+	key.LastUsedAt = &at
+	r.keys[id] = key
+	return nil
+}
@@ -0,0 +1,159 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/yebrai/go-tasks-microservice/internal/apikey"
+)
+
+// APIKeyRepository implementación MongoDB del repositorio de claves de API
+type APIKeyRepository struct {
+	collection *mongo.Collection
+}
+
+// NewAPIKeyRepository crea una nueva instancia del repositorio
+func NewAPIKeyRepository(db *mongo.Database) *APIKeyRepository {
+	return &APIKeyRepository{
+		collection: db.Collection("api_keys"),
+	}
+}
+
+// EnsureIndexes crea el índice único por hash con el que se autentican las
+// claves
+func (r *APIKeyRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "hash", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create API key indexes: %w", err)
+	}
+
+	return nil
+}
+
+// APIKeyDocument representa la estructura de documento en MongoDB
+type APIKeyDocument struct {
+	ID         string     `bson:"_id"`
+	Name       string     `bson:"name"`
+	Owner      string     `bson:"owner"`
+	Tenant     string     `bson:"tenant,omitempty"`
+	Prefix     string     `bson:"prefix"`
+	Hash       string     `bson:"hash"`
+	Scopes     []string   `bson:"scopes"`
+	CreatedAt  time.Time  `bson:"created_at"`
+	ExpiresAt  *time.Time `bson:"expires_at,omitempty"`
+	LastUsedAt *time.Time `bson:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `bson:"revoked_at,omitempty"`
+}
+
+// Save guarda una clave nueva
+func (r *APIKeyRepository) Save(ctx context.Context, key *apikey.APIKey) error {
+	if _, err := r.collection.InsertOne(ctx, toDocument(key)); err != nil {
+		return fmt.Errorf("failed to save API key: %w", err)
+	}
+	return nil
+}
+
+// FindByID busca una clave por su ID
+func (r *APIKeyRepository) FindByID(ctx context.Context, id string) (*apikey.APIKey, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+// FindByHash busca una clave por el hash de su valor
+func (r *APIKeyRepository) FindByHash(ctx context.Context, hash string) (*apikey.APIKey, error) {
+	return r.findOne(ctx, bson.M{"hash": hash})
+}
+
+// FindAll retorna todas las claves, de la más reciente a la más antigua
+func (r *APIKeyRepository) FindAll(ctx context.Context) ([]*apikey.APIKey, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find API keys: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var docs []APIKeyDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("failed to decode API keys: %w", err)
+	}
+
+	keys := make([]*apikey.APIKey, 0, len(docs))
+	for i := range docs {
+		keys = append(keys, fromDocument(&docs[i]))
+	}
+	return keys, nil
+}
+
+// Update reemplaza una clave existente
+func (r *APIKeyRepository) Update(ctx context.Context, key *apikey.APIKey) error {
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": key.ID}, toDocument(key))
+	if err != nil {
+		return fmt.Errorf("failed to update API key: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return apikey.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// TouchLastUsed registra el último uso de la clave
+func (r *APIKeyRepository) TouchLastUsed(ctx context.Context, id string, at time.Time) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"last_used_at": at}})
+	if err != nil {
+		return fmt.Errorf("failed to update API key usage: %w", err)
+	}
+	return nil
+}
+
+// findOne busca una clave por el filtro indicado
+func (r *APIKeyRepository) findOne(ctx context.Context, filter bson.M) (*apikey.APIKey, error) {
+	var doc APIKeyDocument
+	err := r.collection.FindOne(ctx, filter).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, apikey.ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find API key: %w", err)
+	}
+	return fromDocument(&doc), nil
+}
+
+func toDocument(key *apikey.APIKey) *APIKeyDocument {
+	return &APIKeyDocument{
+		ID:         key.ID,
+		Name:       key.Name,
+		Owner:      key.Owner,
+		Tenant:     key.Tenant,
+		Prefix:     key.Prefix,
+		Hash:       key.Hash,
+		Scopes:     key.Scopes,
+		CreatedAt:  key.CreatedAt,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+	}
+}
+
+func fromDocument(doc *APIKeyDocument) *apikey.APIKey {
+	return &apikey.APIKey{
+		ID:         doc.ID,
+		Name:       doc.Name,
+		Owner:      doc.Owner,
+		Tenant:     doc.Tenant,
+		Prefix:     doc.Prefix,
+		Hash:       doc.Hash,
+		Scopes:     doc.Scopes,
+		CreatedAt:  doc.CreatedAt,
+		ExpiresAt:  doc.ExpiresAt,
+		LastUsedAt: doc.LastUsedAt,
+		RevokedAt:  doc.RevokedAt,
+	}
+}
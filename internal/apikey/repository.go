@@ -0,0 +1,19 @@
+package apikey
+
+import (
+	"context"
+	"time"
+)
+
+// Repository persistencia de las claves de API
+type Repository interface {
+	Save(ctx context.Context, key *APIKey) error
+	FindByID(ctx context.Context, id string) (*APIKey, error)
+	FindByHash(ctx context.Context, hash string) (*APIKey, error)
+	// FindAll retorna todas las claves, también las revocadas, de la más
+	// reciente a la más antigua
+	FindAll(ctx context.Context) ([]*APIKey, error)
+	Update(ctx context.Context, key *APIKey) error
+	// TouchLastUsed registra el último uso sin modificar el resto de campos
+	TouchLastUsed(ctx context.Context, id string, at time.Time) error
+}
@@ -0,0 +1,151 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/yebrai/go-tasks-microservice/pkg/auth"
+	"github.com/yebrai/go-tasks-microservice/pkg/id"
+	"github.com/yebrai/go-tasks-microservice/pkg/tenant"
+)
+
+// lastUsedResolution precisión con la que se registra el último uso, para
+// no escribir en la base de datos en cada petición
+const lastUsedResolution = time.Minute
+
+// CreateRequest datos de una clave nueva
+type CreateRequest struct {
+	Name      string
+	Owner     string
+	Scopes    []string
+	ExpiresAt *time.Time
+}
+
+// Service gestiona las claves de API y autentica las peticiones que las
+// usan. Implementa auth.Authenticator
+type Service struct {
+	repository  Repository
+	idGenerator id.Generator
+	now         func() time.Time
+}
+
+// NewService crea el servicio de claves de API
+func NewService(repository Repository, idGenerator id.Generator) *Service {
+	return &Service{
+		repository:  repository,
+		idGenerator: idGenerator,
+		now:         time.Now,
+	}
+}
+
+// Create crea una clave en el tenant del contexto y retorna su valor en
+// claro, que no se puede recuperar después
+func (s *Service) Create(ctx context.Context, req CreateRequest) (*APIKey, string, error) {
+	key, plaintext, err := New(s.idGenerator.Generate(), req.Name, req.Owner, req.Scopes, req.ExpiresAt, s.now())
+	if err != nil {
+		return nil, "", err
+	}
+	key.Tenant = tenant.FromContext(ctx)
+
+	if err := s.repository.Save(ctx, key); err != nil {
+		return nil, "", fmt.Errorf("failed to save API key: %w", err)
+	}
+	return key, plaintext, nil
+}
+
+// List retorna las claves del tenant del contexto
+func (s *Service) List(ctx context.Context) ([]*APIKey, error) {
+	keys, err := s.repository.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tenantID := tenant.FromContext(ctx)
+	visible := make([]*APIKey, 0, len(keys))
+	for _, key := range keys {
+		if key.TenantID() == tenantID {
+			visible = append(visible, key)
+		}
+	}
+	return visible, nil
+}
+
+// Revoke revoca una clave del tenant del contexto; deja de autenticar
+// inmediatamente
+func (s *Service) Revoke(ctx context.Context, id string) (*APIKey, error) {
+	key, err := s.repository.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if key.TenantID() != tenant.FromContext(ctx) {
+		return nil, ErrAPIKeyNotFound
+	}
+	if err := key.Revoke(s.now()); err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.Update(ctx, key); err != nil {
+		return nil, fmt.Errorf("failed to revoke API key: %w", err)
+	}
+	return key, nil
+}
+
+// Pseudonymize sustituye el propietario y el nombre de las claves del owner
+// en el tenant del contexto por el seudónimo. Las claves siguen
+// autenticando, ya que su principal no depende de ellos. Retorna cuántas
+// cambió
+func (s *Service) Pseudonymize(ctx context.Context, owner, pseudonym string) (int, error) {
+	keys, err := s.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	updated := 0
+	for _, key := range keys {
+		if key.Owner != owner {
+			continue
+		}
+		key.Owner = pseudonym
+		key.Name = pseudonym
+		if err := s.repository.Update(ctx, key); err != nil {
+			return updated, fmt.Errorf("failed to pseudonymize API key %s: %w", key.ID, err)
+		}
+		updated++
+	}
+	return updated, nil
+}
+
+// Authenticate implementa auth.Authenticator para las claves de API
+func (s *Service) Authenticate(ctx context.Context, credential string) (*auth.Principal, error) {
+	if credential == "" {
+		return nil, auth.ErrMissingCredentials
+	}
+	if !strings.HasPrefix(credential, KeyPrefix) {
+		return nil, fmt.Errorf("%w: %v", auth.ErrInvalidCredentials, errAPIKeyUnrecognized)
+	}
+
+	key, err := s.repository.FindByHash(ctx, Hash(credential))
+	if errors.Is(err, ErrAPIKeyNotFound) {
+		return nil, fmt.Errorf("%w: %v", auth.ErrInvalidCredentials, errAPIKeyUnrecognized)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	if !key.Active(now) {
+		return nil, fmt.Errorf("%w: %v", auth.ErrInvalidCredentials, errAPIKeyInactive)
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedResolution {
+		if err := s.repository.TouchLastUsed(ctx, key.ID, now); err != nil {
+			log.Printf("⚠️  Failed to record API key %s usage: %v", key.ID, err)
+		}
+	}
+
+	return key.Principal(), nil
+}
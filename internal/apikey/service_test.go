@@ -0,0 +1,106 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yebrai/go-tasks-microservice/pkg/auth"
+	"github.com/yebrai/go-tasks-microservice/pkg/id"
+)
+
+func newTestService() (*Service, *MemoryRepository, *time.Time) {
+	repository := NewMemoryRepository()
+	service := NewService(repository, id.NewUniqueIDGenerator())
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+	return service, repository, &now
+}
+
+func TestService_CreatedKeyAuthenticatesUntilRevoked(t *testing.T) {
+	service, repository, _ := newTestService()
+	ctx := context.Background()
+
+	key, plaintext, err := service.Create(ctx, CreateRequest{Name: "cron", Owner: "ops", Scopes: []string{auth.ScopeTasksRead}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(plaintext, key.Prefix) || strings.Contains(key.Hash, plaintext) {
+		t.Fatalf("Expected only the hash and prefix to be stored, got %+v", key)
+	}
+
+	stored, _ := repository.FindByID(ctx, key.ID)
+	if stored.Hash != Hash(plaintext) {
+		t.Errorf("Expected the stored hash to match the key")
+	}
+
+	principal, err := service.Authenticate(ctx, plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if principal.Subject != key.Owner || principal.APIKeyID != key.ID || !principal.HasScope(auth.ScopeTasksRead) || principal.HasScope(auth.ScopeTasksWrite) {
+		t.Errorf("Unexpected principal %+v", principal)
+	}
+
+	if _, err := service.Revoke(ctx, key.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.Authenticate(ctx, plaintext); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Errorf("Expected a revoked key to be rejected, got %v", err)
+	}
+	if _, err := service.Revoke(ctx, key.ID); !errors.Is(err, ErrAPIKeyRevoked) {
+		t.Errorf("Expected ErrAPIKeyRevoked, got %v", err)
+	}
+}
+
+func TestService_RejectsExpiredAndUnknownKeys(t *testing.T) {
+	service, _, now := newTestService()
+	ctx := context.Background()
+
+	expiresAt := now.Add(time.Hour)
+	_, plaintext, err := service.Create(ctx, CreateRequest{Name: "temp", Owner: "ops", Scopes: []string{auth.ScopeTasksWrite}, ExpiresAt: &expiresAt})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	*now = now.Add(2 * time.Hour)
+	for name, credential := range map[string]string{"expired": plaintext, "unknown": KeyPrefix + "nope", "not a key": "static"} {
+		if _, err := service.Authenticate(ctx, credential); !errors.Is(err, auth.ErrInvalidCredentials) {
+			t.Errorf("%s: expected ErrInvalidCredentials, got %v", name, err)
+		}
+	}
+
+	past := now.Add(-time.Minute)
+	if _, _, err := service.Create(ctx, CreateRequest{Name: "old", Owner: "ops", Scopes: []string{auth.ScopeTasksRead}, ExpiresAt: &past}); !errors.Is(err, ErrInvalidAPIKeyData) {
+		t.Errorf("Expected a past expiry to be rejected, got %v", err)
+	}
+	if _, _, err := service.Create(ctx, CreateRequest{Name: "bad", Owner: "ops", Scopes: []string{"root"}}); !errors.Is(err, ErrInvalidAPIKeyData) {
+		t.Errorf("Expected an unknown scope to be rejected, got %v", err)
+	}
+}
+
+func TestService_ThrottlesLastUsedUpdates(t *testing.T) {
+	service, repository, now := newTestService()
+	ctx := context.Background()
+
+	key, plaintext, _ := service.Create(ctx, CreateRequest{Name: "cron", Owner: "ops", Scopes: []string{auth.ScopeTasksRead}})
+	first := *now
+
+	service.Authenticate(ctx, plaintext)
+	*now = now.Add(10 * time.Second)
+	service.Authenticate(ctx, plaintext)
+
+	stored, _ := repository.FindByID(ctx, key.ID)
+	if stored.LastUsedAt == nil || !stored.LastUsedAt.Equal(first) {
+		t.Fatalf("Expected last use at %v, got %v", first, stored.LastUsedAt)
+	}
+
+	*now = now.Add(lastUsedResolution)
+	service.Authenticate(ctx, plaintext)
+	stored, _ = repository.FindByID(ctx, key.ID)
+	if !stored.LastUsedAt.Equal(*now) {
+		t.Errorf("Expected last use to advance to %v, got %v", *now, stored.LastUsedAt)
+	}
+}
@@ -0,0 +1,85 @@
+package audit
+
+import (
+	"errors"
+	"time"
+)
+
+// Límites del número de entradas por consulta
+const (
+	DefaultLimit = 100
+	MaxLimit     = 1000
+)
+
+// ErrInvalidQuery los criterios de la consulta no son válidos
+var ErrInvalidQuery = errors.New("invalid audit query")
+
+// Outcome resultado de una acción auditada
+type Outcome string
+
+const (
+	OutcomeSucceeded Outcome = "succeeded"
+	OutcomeFailed    Outcome = "failed"
+	// OutcomeDenied la autenticación o la autorización rechazó la acción
+	OutcomeDenied Outcome = "denied"
+)
+
+// Entry registro inmutable de una acción: quién, desde dónde, sobre qué
+// tareas y con qué resultado
+type Entry struct {
+	ID         string    `json:"id"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Tenant     string    `json:"tenant"`
+	// Actor subject del principal; vacío si no se autenticó o la
+	// autenticación está deshabilitada
+	Actor     string `json:"actor,omitempty"`
+	ActorName string `json:"actor_name,omitempty"`
+	// APIKeyID clave de API con la que actuó el principal, si usó una
+	APIKeyID  string `json:"api_key_id,omitempty"`
+	SourceIP  string `json:"source_ip,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+	// Action tipo de comando o, en las peticiones rechazadas antes de
+	// emitirlo, la ruta HTTP ("POST /api/v1/tasks")
+	Action  string   `json:"action"`
+	TaskIDs []string `json:"task_ids,omitempty"`
+	Outcome Outcome  `json:"outcome"`
+	// Reason motivo del fallo o del rechazo
+	Reason string `json:"reason,omitempty"`
+}
+
+// Query criterios de búsqueda de entradas. Los campos vacíos no filtran
+type Query struct {
+	Tenant string
+	Actor  string
+	TaskID string
+	// From y To acotan OccurredAt, ambos incluidos
+	From  time.Time
+	To    time.Time
+	Limit int
+}
+
+// Matches indica si la entrada cumple los criterios
+func (q Query) Matches(entry *Entry) bool {
+	if q.Tenant != "" && entry.Tenant != q.Tenant {
+		return false
+	}
+	if q.Actor != "" && entry.Actor != q.Actor {
+		return false
+	}
+	if !q.From.IsZero() && entry.OccurredAt.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && entry.OccurredAt.After(q.To) {
+		return false
+	}
+	if q.TaskID == "" {
+		return true
+	}
+	for _, taskID := range entry.TaskIDs {
+		if taskID == q.TaskID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package audit
+
+import (
+	"context"
+	"log"
+	"sync"
+)
+
+type bufferKey struct{}
+
+// bufferedEntry entrada retenida junto al registro que debe guardarla
+type bufferedEntry struct {
+	log   *Log
+	entry *Entry
+}
+
+// Buffer retiene las entradas registradas durante una transacción. Se
+// guardan al terminar, fuera de ella, para que no se descarten con la
+// transacción si ésta falla
+type Buffer struct {
+	mu      sync.Mutex
+	entries []bufferedEntry
+}
+
+// WithBuffer retorna un contexto en el que Record retiene las entradas en el
+// buffer en lugar de guardarlas
+func WithBuffer(ctx context.Context) (context.Context, *Buffer) {
+	buffer := &Buffer{}
+	return context.WithValue(ctx, bufferKey{}, buffer), buffer
+}
+
+// bufferFromContext obtiene el buffer del contexto, si existe
+func bufferFromContext(ctx context.Context) *Buffer {
+	buffer, _ := ctx.Value(bufferKey{}).(*Buffer)
+	return buffer
+}
+
+// add retiene una entrada
+func (b *Buffer) add(l *Log, entry *Entry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.entries = append(b.entries, bufferedEntry{log: l, entry: entry})
+}
+
+// Flush guarda en orden las entradas retenidas y vacía el buffer. Si la
+// transacción se descartó (rollback distinto de nil), las acciones que
+// terminaron bien se registran como fallidas con ese motivo. ctx no debe
+// contener el propio buffer ni la sesión de la transacción
+func (b *Buffer) Flush(ctx context.Context, rollback error) {
+	b.mu.Lock()
+	pending := b.entries
+	b.entries = nil
+	b.mu.Unlock()
+
+	for _, buffered := range pending {
+		entry := buffered.entry
+		if rollback != nil && entry.Outcome == OutcomeSucceeded {
+			entry.Outcome, entry.Reason = OutcomeFailed, "rolled back: "+rollback.Error()
+		}
+		if err := buffered.log.repository.Append(ctx, entry); err != nil {
+			log.Printf("⚠️  Failed to record audit entry %s for %s: %v", entry.Action, entry.RequestID, err)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/yebrai/go-tasks-microservice/internal/task/authz"
+	"github.com/yebrai/go-tasks-microservice/pkg/auth"
+	"github.com/yebrai/go-tasks-microservice/pkg/cqrs"
+	"github.com/yebrai/go-tasks-microservice/pkg/events"
+	"github.com/yebrai/go-tasks-microservice/pkg/id"
+	"github.com/yebrai/go-tasks-microservice/pkg/tenant"
+)
+
+// Log registro de auditoría: guarda quién ejecutó cada comando y los
+// intentos rechazados, y permite consultarlos
+type Log struct {
+	repository  Repository
+	idGenerator id.Generator
+	now         func() time.Time
+}
+
+// NewLog crea el registro de auditoría
+func NewLog(repository Repository, idGenerator id.Generator) *Log {
+	return &Log{
+		repository:  repository,
+		idGenerator: idGenerator,
+		now:         time.Now,
+	}
+}
+
+// Record añade una entrada con el principal, la procedencia, el ID de
+// petición y el tenant del contexto. Un fallo al guardarla se registra en el
+// log pero no interrumpe la acción auditada. Dentro de una transacción con
+// WithBuffer la entrada se retiene hasta que ésta termina
+func (l *Log) Record(ctx context.Context, action string, taskIDs []string, outcome Outcome, reason string) {
+	origin := cqrs.OriginFromContext(ctx)
+	entry := &Entry{
+		ID:         l.idGenerator.Generate(),
+		OccurredAt: l.now(),
+		Tenant:     tenant.FromContext(ctx),
+		SourceIP:   origin.SourceIP,
+		UserAgent:  origin.UserAgent,
+		RequestID:  cqrs.RequestIDFromContext(ctx),
+		Action:     action,
+		TaskIDs:    taskIDs,
+		Outcome:    outcome,
+		Reason:     reason,
+	}
+	if principal, ok := auth.PrincipalFromContext(ctx); ok {
+		entry.Actor = principal.Subject
+		entry.ActorName = principal.Name
+		entry.APIKeyID = principal.APIKeyID
+	}
+
+	if buffer := bufferFromContext(ctx); buffer != nil {
+		buffer.add(l, entry)
+	} else if err := l.repository.Append(ctx, entry); err != nil {
+		log.Printf("⚠️  Failed to record audit entry %s for %s: %v", action, entry.RequestID, err)
+	}
+	if trail := trailFromContext(ctx); trail != nil {
+		trail.markRecorded()
+	}
+}
+
+// Find retorna las entradas del tenant del contexto que cumplen los
+// criterios, de la más reciente a la más antigua
+func (l *Log) Find(ctx context.Context, query Query) ([]*Entry, error) {
+	if !query.From.IsZero() && !query.To.IsZero() && query.From.After(query.To) {
+		return nil, fmt.Errorf("%w: from must not be after to", ErrInvalidQuery)
+	}
+	if query.Limit <= 0 {
+		query.Limit = DefaultLimit
+	}
+	if query.Limit > MaxLimit {
+		query.Limit = MaxLimit
+	}
+	query.Tenant = tenant.FromContext(ctx)
+
+	return l.repository.Find(ctx, query)
+}
+
+// ActorEntries retorna, sin límite, todas las entradas del actor en el
+// tenant del contexto, para exportarlas
+func (l *Log) ActorEntries(ctx context.Context, actor string) ([]*Entry, error) {
+	return l.repository.Find(ctx, Query{Tenant: tenant.FromContext(ctx), Actor: actor})
+}
+
+// Pseudonymize seudonimiza las entradas del actor en el tenant del
+// contexto. Retorna cuántas cambió
+func (l *Log) Pseudonymize(ctx context.Context, actor, pseudonym string) (int64, error) {
+	return l.repository.Pseudonymize(ctx, tenant.FromContext(ctx), actor, pseudonym)
+}
+
+// Middleware registra cada comando con las tareas a las que afectó y su
+// resultado. Debe ser el middleware más externo del bus para registrar
+// también los comandos que rechaza la autorización
+func (l *Log) Middleware() cqrs.Middleware {
+	return func(next cqrs.CommandHandler) cqrs.CommandHandler {
+		return cqrs.CommandHandlerFunc(func(ctx context.Context, cmd cqrs.Command) error {
+			traced, trace := events.WithTrace(ctx)
+			err := next.Handle(traced, cmd)
+
+			var taskIDs []string
+			if aggregateCmd, ok := cmd.(cqrs.AggregateCommand); ok {
+				taskIDs = append(taskIDs, aggregateCmd.AggregateID())
+			}
+			for _, taskID := range trace.AggregateIDs() {
+				if len(taskIDs) == 0 || taskID != taskIDs[0] {
+					taskIDs = append(taskIDs, taskID)
+				}
+			}
+
+			outcome, reason := OutcomeSucceeded, ""
+			switch {
+			case errors.Is(err, authz.ErrForbidden):
+				outcome, reason = OutcomeDenied, err.Error()
+			case err != nil:
+				outcome, reason = OutcomeFailed, err.Error()
+			}
+			l.Record(ctx, string(cmd.Type()), taskIDs, outcome, reason)
+
+			return err
+		})
+	}
+}
+
+type trailKey struct{}
+
+// Trail acompaña a una petición para saber si alguno de sus comandos ya
+// registró su resultado, y no registrar la petición dos veces
+type Trail struct {
+	recorded bool
+	mu       sync.Mutex
+}
+
+// WithTrail retorna un contexto en el que Record anota las entradas que
+// registra
+func WithTrail(ctx context.Context) (context.Context, *Trail) {
+	trail := &Trail{}
+	return context.WithValue(ctx, trailKey{}, trail), trail
+}
+
+// trailFromContext obtiene el Trail del contexto, si existe
+func trailFromContext(ctx context.Context) *Trail {
+	trail, _ := ctx.Value(trailKey{}).(*Trail)
+	return trail
+}
+
+// markRecorded anota que se registró una entrada
+func (t *Trail) markRecorded() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.recorded = true
+}
+
+// Recorded indica si ya se registró alguna entrada en la petición
+func (t *Trail) Recorded() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.recorded
+}
@@ -0,0 +1,121 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+	"github.com/yebrai/go-tasks-microservice/internal/task/authz"
+	"github.com/yebrai/go-tasks-microservice/internal/task/creator"
+	"github.com/yebrai/go-tasks-microservice/pkg/auth"
+	"github.com/yebrai/go-tasks-microservice/pkg/cqrs"
+	"github.com/yebrai/go-tasks-microservice/pkg/cqrs/inmem"
+	"github.com/yebrai/go-tasks-microservice/pkg/events"
+	"github.com/yebrai/go-tasks-microservice/pkg/id"
+	"github.com/yebrai/go-tasks-microservice/pkg/tenant"
+)
+
+func requestContext(subject, tenantID string) context.Context {
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: subject, Name: "Ada"})
+	ctx = tenant.WithID(ctx, tenantID)
+	ctx = cqrs.WithRequestID(ctx, "req-1")
+	return cqrs.WithOrigin(ctx, cqrs.Origin{SourceIP: "203.0.113.7", UserAgent: "tasks-cli/1.0"})
+}
+
+func TestMiddleware_RecordsCommandsWithActorAndAffectedTasks(t *testing.T) {
+	log := NewLog(NewMemoryRepository(), id.NewUniqueIDGenerator())
+	eventBus := events.NewObservableEventBus(events.NewNoOpEventBus())
+
+	var published *task.TaskCreatedEvent
+	bus := inmem.NewCommandBus()
+	bus.Use(log.Middleware())
+	bus.Register(creator.CreateTaskCommandType, cqrs.CommandHandlerFunc(func(ctx context.Context, cmd cqrs.Command) error {
+		created, _ := task.NewTask("6f1c2a9e-3b4d-4c5e-8f70-1a2b3c4d5e6f", "Nueva", "", nil)
+		published = task.NewTaskCreatedEvent(created)
+		return eventBus.Publish(ctx, published)
+	}))
+	bus.Register(creator.CompleteTaskCommandType, cqrs.CommandHandlerFunc(func(context.Context, cqrs.Command) error {
+		return fmt.Errorf("%w: %s", authz.ErrForbidden, authz.ActionComplete)
+	}))
+
+	ctx := requestContext("user-1", "acme")
+	if err := bus.Dispatch(ctx, creator.CreateTaskCommand{Title: "Nueva"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Dispatch(ctx, creator.CompleteTaskCommand{ID: "task-2"}); err == nil {
+		t.Fatal("Expected the command to be denied")
+	}
+
+	if published.Actor == nil || published.Actor.Subject != "user-1" || published.Actor.Name != "Ada" {
+		t.Errorf("Expected the actor on the event, got %+v", published.Actor)
+	}
+
+	entries, err := log.Find(ctx, Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+	denied, created := entries[0], entries[1]
+	if created.Action != string(creator.CreateTaskCommandType) || created.Outcome != OutcomeSucceeded ||
+		created.Actor != "user-1" || created.SourceIP != "203.0.113.7" || created.UserAgent != "tasks-cli/1.0" ||
+		created.RequestID != "req-1" || created.Tenant != "acme" ||
+		len(created.TaskIDs) != 1 || created.TaskIDs[0] != published.TaskID {
+		t.Errorf("Unexpected creation entry %+v", created)
+	}
+	if denied.Outcome != OutcomeDenied || len(denied.TaskIDs) != 1 || denied.TaskIDs[0] != "task-2" || denied.Reason == "" {
+		t.Errorf("Unexpected denied entry %+v", denied)
+	}
+}
+
+func TestLog_Find(t *testing.T) {
+	log := NewLog(NewMemoryRepository(), id.NewUniqueIDGenerator())
+	start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	now := start
+	log.now = func() time.Time { return now }
+
+	record := func(subject, tenantID, taskID string) {
+		log.Record(requestContext(subject, tenantID), "task.command.update", []string{taskID}, OutcomeSucceeded, "")
+		now = now.Add(time.Minute)
+	}
+	record("user-1", "acme", "task-1")
+	record("user-2", "acme", "task-1")
+	record("user-1", "acme", "task-2")
+	record("user-1", "globex", "task-3")
+
+	tests := []struct {
+		name    string
+		query   Query
+		want    int
+		wantErr bool
+	}{
+		{"whole tenant", Query{}, 3, false},
+		{"by actor", Query{Actor: "user-1"}, 2, false},
+		{"by task", Query{TaskID: "task-1"}, 2, false},
+		{"by actor and task", Query{Actor: "user-2", TaskID: "task-1"}, 1, false},
+		{"time range", Query{From: start.Add(time.Minute), To: start.Add(2 * time.Minute)}, 2, false},
+		{"limit", Query{Limit: 1}, 1, false},
+		{"other tenant task", Query{TaskID: "task-3"}, 0, false},
+		{"inverted range", Query{From: start.Add(time.Hour), To: start}, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := log.Find(tenant.WithID(context.Background(), "acme"), tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if len(entries) != tt.want {
+				t.Errorf("Expected %d entries, got %d", tt.want, len(entries))
+			}
+			for i := 1; i < len(entries); i++ {
+				if entries[i].OccurredAt.After(entries[i-1].OccurredAt) {
+					t.Errorf("Expected the newest entries first")
+				}
+			}
+		})
+	}
+}
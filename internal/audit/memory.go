@@ -0,0 +1,67 @@
+package audit
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryRepository implementación en memoria de Repository para una sola
+// instancia
+type MemoryRepository struct {
+	entries []Entry
+	mu      sync.RWMutex
+}
+
+// NewMemoryRepository crea un repositorio en memoria
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{}
+}
+
+// Append implementa la interfaz Repository
+func (r *MemoryRepository) Append(_ context.Context, entry *Entry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := *entry
+	stored.TaskIDs = append([]string(nil), entry.TaskIDs...)
+	r.entries = append(r.entries, stored)
+	return nil
+}
+
+// Pseudonymize implementa la interfaz Repository
+func (r *MemoryRepository) Pseudonymize(_ context.Context, tenantID, actor, pseudonym string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var updated int64
+	for i := range r.entries {
+		entry := &r.entries[i]
+		if entry.Tenant != tenantID || entry.Actor != actor {
+			continue
+		}
+		entry.Actor = pseudonym
+		entry.ActorName = ""
+		entry.SourceIP = ""
+		entry.UserAgent = ""
+		updated++
+	}
+	return updated, nil
+}
+
+// Find implementa la interfaz Repository. Las entradas se añaden en orden,
+// así que basta recorrerlas desde el final
+func (r *MemoryRepository) Find(_ context.Context, query Query) ([]*Entry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var entries []*Entry
+	for i := len(r.entries) - 1; i >= 0; i-- {
+		if query.Limit > 0 && len(entries) == query.Limit {
+			break
+		}
+		if entry := r.entries[i]; query.Matches(&entry) {
+			entries = append(entries, &entry)
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,165 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/yebrai/go-tasks-microservice/internal/audit"
+)
+
+// AuditRepository implementación MongoDB del registro de auditoría. Solo
+// inserta documentos y nunca los borra; únicamente los seudonimiza
+type AuditRepository struct {
+	collection *mongo.Collection
+}
+
+// NewAuditRepository crea una nueva instancia del repositorio
+func NewAuditRepository(db *mongo.Database) *AuditRepository {
+	return &AuditRepository{
+		collection: db.Collection("audit_log"),
+	}
+}
+
+// EnsureIndexes crea los índices de las consultas por actor y por tarea,
+// siempre dentro de un tenant y de la más reciente a la más antigua
+func (r *AuditRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "tenant", Value: 1}, {Key: "occurred_at", Value: -1}}},
+		{Keys: bson.D{{Key: "tenant", Value: 1}, {Key: "actor", Value: 1}, {Key: "occurred_at", Value: -1}}},
+		{Keys: bson.D{{Key: "tenant", Value: 1}, {Key: "task_ids", Value: 1}, {Key: "occurred_at", Value: -1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create audit indexes: %w", err)
+	}
+
+	return nil
+}
+
+// EntryDocument representa la estructura de documento en MongoDB
+type EntryDocument struct {
+	ID         string    `bson:"_id"`
+	OccurredAt time.Time `bson:"occurred_at"`
+	Tenant     string    `bson:"tenant"`
+	Actor      string    `bson:"actor,omitempty"`
+	ActorName  string    `bson:"actor_name,omitempty"`
+	APIKeyID   string    `bson:"api_key_id,omitempty"`
+	SourceIP   string    `bson:"source_ip,omitempty"`
+	UserAgent  string    `bson:"user_agent,omitempty"`
+	RequestID  string    `bson:"request_id,omitempty"`
+	Action     string    `bson:"action"`
+	TaskIDs    []string  `bson:"task_ids,omitempty"`
+	Outcome    string    `bson:"outcome"`
+	Reason     string    `bson:"reason,omitempty"`
+}
+
+// Append guarda una entrada nueva
+func (r *AuditRepository) Append(ctx context.Context, entry *audit.Entry) error {
+	if _, err := r.collection.InsertOne(ctx, toDocument(entry)); err != nil {
+		return fmt.Errorf("failed to save audit entry: %w", err)
+	}
+	return nil
+}
+
+// Find busca las entradas que cumplen los criterios, de la más reciente a
+// la más antigua
+func (r *AuditRepository) Find(ctx context.Context, query audit.Query) ([]*audit.Entry, error) {
+	filter := bson.M{}
+	if query.Tenant != "" {
+		filter["tenant"] = query.Tenant
+	}
+	if query.Actor != "" {
+		filter["actor"] = query.Actor
+	}
+	if query.TaskID != "" {
+		filter["task_ids"] = query.TaskID
+	}
+	occurredAt := bson.M{}
+	if !query.From.IsZero() {
+		occurredAt["$gte"] = query.From
+	}
+	if !query.To.IsZero() {
+		occurredAt["$lte"] = query.To
+	}
+	if len(occurredAt) > 0 {
+		filter["occurred_at"] = occurredAt
+	}
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "occurred_at", Value: -1}, {Key: "_id", Value: -1}})
+	if query.Limit > 0 {
+		findOptions.SetLimit(int64(query.Limit))
+	}
+
+	cursor, err := r.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find audit entries: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var docs []EntryDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("failed to decode audit entries: %w", err)
+	}
+
+	entries := make([]*audit.Entry, 0, len(docs))
+	for i := range docs {
+		entries = append(entries, fromDocument(&docs[i]))
+	}
+	return entries, nil
+}
+
+// Pseudonymize sustituye el actor de sus entradas por el seudónimo y borra
+// el resto de sus datos personales
+func (r *AuditRepository) Pseudonymize(ctx context.Context, tenantID, actor, pseudonym string) (int64, error) {
+	result, err := r.collection.UpdateMany(ctx,
+		bson.M{"tenant": tenantID, "actor": actor},
+		bson.M{
+			"$set":   bson.M{"actor": pseudonym},
+			"$unset": bson.M{"actor_name": "", "source_ip": "", "user_agent": ""},
+		},
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to pseudonymize audit entries: %w", err)
+	}
+	return result.ModifiedCount, nil
+}
+
+func toDocument(entry *audit.Entry) *EntryDocument {
+	return &EntryDocument{
+		ID:         entry.ID,
+		OccurredAt: entry.OccurredAt,
+		Tenant:     entry.Tenant,
+		Actor:      entry.Actor,
+		ActorName:  entry.ActorName,
+		APIKeyID:   entry.APIKeyID,
+		SourceIP:   entry.SourceIP,
+		UserAgent:  entry.UserAgent,
+		RequestID:  entry.RequestID,
+		Action:     entry.Action,
+		TaskIDs:    entry.TaskIDs,
+		Outcome:    string(entry.Outcome),
+		Reason:     entry.Reason,
+	}
+}
+
+func fromDocument(doc *EntryDocument) *audit.Entry {
+	return &audit.Entry{
+		ID:         doc.ID,
+		OccurredAt: doc.OccurredAt,
+		Tenant:     doc.Tenant,
+		Actor:      doc.Actor,
+		ActorName:  doc.ActorName,
+		APIKeyID:   doc.APIKeyID,
+		SourceIP:   doc.SourceIP,
+		UserAgent:  doc.UserAgent,
+		RequestID:  doc.RequestID,
+		Action:     doc.Action,
+		TaskIDs:    doc.TaskIDs,
+		Outcome:    audit.Outcome(doc.Outcome),
+		Reason:     doc.Reason,
+	}
+}
@@ -0,0 +1,16 @@
+package audit
+
+import "context"
+
+// Repository persistencia del registro de auditoría. Solo admite añadir
+// entradas: el registro no se borra y la única modificación permitida es
+// seudonimizar a un actor que ejerce su derecho de supresión
+type Repository interface {
+	Append(ctx context.Context, entry *Entry) error
+	// Find retorna, de la más reciente a la más antigua, hasta query.Limit
+	// entradas que cumplen los criterios; Limit 0 las retorna todas
+	Find(ctx context.Context, query Query) ([]*Entry, error)
+	// Pseudonymize sustituye el actor de sus entradas en el tenant por
+	// pseudonym y borra su nombre, IP y User-Agent. Retorna cuántas cambió
+	Pseudonymize(ctx context.Context, tenantID, actor, pseudonym string) (int64, error)
+}
@@ -0,0 +1,23 @@
+package privacy
+
+import "github.com/yebrai/go-tasks-microservice/pkg/cqrs"
+
+const EraseSubjectCommandType cqrs.CommandType = "privacy.command.erase_subject"
+
+// EraseSubjectCommand comando para seudonimizar los datos personales de un
+// interesado en el tenant del contexto
+type EraseSubjectCommand struct {
+	// ID identificador de la solicitud, generado por quien la emite para
+	// poder consultar después su registro
+	ID string
+	// Subject sujeto de los principales del interesado
+	Subject string
+	// Terms textos que lo identifican (nombre, email...) y se sustituyen en
+	// el título y la descripción de las tareas
+	Terms []string
+}
+
+// Type implementa la interfaz Command
+func (c EraseSubjectCommand) Type() cqrs.CommandType {
+	return EraseSubjectCommandType
+}
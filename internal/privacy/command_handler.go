@@ -0,0 +1,31 @@
+package privacy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/yebrai/go-tasks-microservice/pkg/cqrs"
+)
+
+// EraseSubjectCommandHandler maneja el comando de supresión
+type EraseSubjectCommandHandler struct {
+	service *Service
+}
+
+// NewEraseSubjectCommandHandler crea una nueva instancia del handler
+func NewEraseSubjectCommandHandler(service *Service) *EraseSubjectCommandHandler {
+	return &EraseSubjectCommandHandler{
+		service: service,
+	}
+}
+
+// Handle implementa la interfaz CommandHandler
+func (h *EraseSubjectCommandHandler) Handle(ctx context.Context, cmd cqrs.Command) error {
+	eraseCmd, ok := cmd.(EraseSubjectCommand)
+	if !ok {
+		return fmt.Errorf("invalid command type: expected EraseSubjectCommand")
+	}
+
+	_, err := h.service.Erase(ctx, eraseCmd.ID, eraseCmd.Subject, eraseCmd.Terms)
+	return err
+}
@@ -0,0 +1,58 @@
+package privacy
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// MemoryErasureRepository implementación en memoria de ErasureRepository
+// para una sola instancia
+type MemoryErasureRepository struct {
+	erasures map[string]Erasure
+	mu       sync.RWMutex
+}
+
+// NewMemoryErasureRepository crea un repositorio en memoria
+func NewMemoryErasureRepository() *MemoryErasureRepository {
+	return &MemoryErasureRepository{
+		erasures: make(map[string]Erasure),
+	}
+}
+
+// Save implementa la interfaz ErasureRepository
+func (r *MemoryErasureRepository) Save(_ context.Context, erasure *Erasure) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.erasures[erasure.ID] = *erasure
+	return nil
+}
+
+// FindByID implementa la interfaz ErasureRepository
+func (r *MemoryErasureRepository) FindByID(_ context.Context, id string) (*Erasure, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	erasure, ok := r.erasures[id]
+	if !ok {
+		return nil, ErrErasureNotFound
+	}
+	return &erasure, nil
+}
+
+// FindAll implementa la interfaz ErasureRepository
+func (r *MemoryErasureRepository) FindAll(_ context.Context) ([]*Erasure, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	erasures := make([]*Erasure, 0, len(r.erasures))
+	for _, erasure := range r.erasures {
+		erasure := erasure
+		erasures = append(erasures, &erasure)
+	}
+	sort.Slice(erasures, func(i, j int) bool {
+		return erasures[i].RequestedAt.After(erasures[j].RequestedAt)
+	})
+	return erasures, nil
+}
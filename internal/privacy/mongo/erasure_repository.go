@@ -0,0 +1,140 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/yebrai/go-tasks-microservice/internal/privacy"
+)
+
+// ErasureRepository implementación MongoDB del registro de solicitudes de
+// supresión
+type ErasureRepository struct {
+	collection *mongo.Collection
+}
+
+// NewErasureRepository crea una nueva instancia del repositorio
+func NewErasureRepository(db *mongo.Database) *ErasureRepository {
+	return &ErasureRepository{
+		collection: db.Collection("erasure_requests"),
+	}
+}
+
+// EnsureIndexes crea el índice con el que se listan las solicitudes de la
+// más reciente a la más antigua
+func (r *ErasureRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "requested_at", Value: -1}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create erasure indexes: %w", err)
+	}
+
+	return nil
+}
+
+// ErasureDocument representa la estructura de documento en MongoDB
+type ErasureDocument struct {
+	ID                        string    `bson:"_id"`
+	Tenant                    string    `bson:"tenant,omitempty"`
+	SubjectHash               string    `bson:"subject_hash"`
+	Pseudonym                 string    `bson:"pseudonym"`
+	Terms                     int       `bson:"terms"`
+	RequestedBy               string    `bson:"requested_by,omitempty"`
+	RequestedAt               time.Time `bson:"requested_at"`
+	CompletedAt               time.Time `bson:"completed_at"`
+	Status                    string    `bson:"status"`
+	TasksUpdated              int       `bson:"tasks_updated"`
+	AuditEntriesUpdated       int64     `bson:"audit_entries_updated"`
+	AccountsUpdated           int       `bson:"accounts_updated"`
+	APIKeysUpdated            int       `bson:"api_keys_updated"`
+	IdempotencyRecordsDeleted int       `bson:"idempotency_records_deleted"`
+	Error                     string    `bson:"error,omitempty"`
+}
+
+// Save guarda una solicitud nueva
+func (r *ErasureRepository) Save(ctx context.Context, erasure *privacy.Erasure) error {
+	if _, err := r.collection.InsertOne(ctx, toDocument(erasure)); err != nil {
+		return fmt.Errorf("failed to save erasure request: %w", err)
+	}
+	return nil
+}
+
+// FindByID busca una solicitud por su ID
+func (r *ErasureRepository) FindByID(ctx context.Context, id string) (*privacy.Erasure, error) {
+	var doc ErasureDocument
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, privacy.ErrErasureNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find erasure request: %w", err)
+	}
+	return fromDocument(&doc), nil
+}
+
+// FindAll retorna todas las solicitudes, de la más reciente a la más antigua
+func (r *ErasureRepository) FindAll(ctx context.Context) ([]*privacy.Erasure, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "requested_at", Value: -1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find erasure requests: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var docs []ErasureDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("failed to decode erasure requests: %w", err)
+	}
+
+	erasures := make([]*privacy.Erasure, 0, len(docs))
+	for i := range docs {
+		erasures = append(erasures, fromDocument(&docs[i]))
+	}
+	return erasures, nil
+}
+
+func toDocument(erasure *privacy.Erasure) *ErasureDocument {
+	return &ErasureDocument{
+		ID:                        erasure.ID,
+		Tenant:                    erasure.Tenant,
+		SubjectHash:               erasure.SubjectHash,
+		Pseudonym:                 erasure.Pseudonym,
+		Terms:                     erasure.Terms,
+		RequestedBy:               erasure.RequestedBy,
+		RequestedAt:               erasure.RequestedAt,
+		CompletedAt:               erasure.CompletedAt,
+		Status:                    string(erasure.Status),
+		TasksUpdated:              erasure.TasksUpdated,
+		AuditEntriesUpdated:       erasure.AuditEntriesUpdated,
+		AccountsUpdated:           erasure.AccountsUpdated,
+		APIKeysUpdated:            erasure.APIKeysUpdated,
+		IdempotencyRecordsDeleted: erasure.IdempotencyRecordsDeleted,
+		Error:                     erasure.Error,
+	}
+}
+
+func fromDocument(doc *ErasureDocument) *privacy.Erasure {
+	return &privacy.Erasure{
+		ID:                        doc.ID,
+		Tenant:                    doc.Tenant,
+		SubjectHash:               doc.SubjectHash,
+		Pseudonym:                 doc.Pseudonym,
+		Terms:                     doc.Terms,
+		RequestedBy:               doc.RequestedBy,
+		RequestedAt:               doc.RequestedAt,
+		CompletedAt:               doc.CompletedAt,
+		Status:                    privacy.ErasureStatus(doc.Status),
+		TasksUpdated:              doc.TasksUpdated,
+		AuditEntriesUpdated:       doc.AuditEntriesUpdated,
+		AccountsUpdated:           doc.AccountsUpdated,
+		APIKeysUpdated:            doc.APIKeysUpdated,
+		IdempotencyRecordsDeleted: doc.IdempotencyRecordsDeleted,
+		Error:                     doc.Error,
+	}
+}
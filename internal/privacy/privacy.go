@@ -0,0 +1,68 @@
+package privacy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrInvalidRequest la solicitud no indica el interesado o la supresión
+	// incluye términos demasiado cortos para sustituirlos con seguridad
+	ErrInvalidRequest = errors.New("invalid data subject request")
+	// ErrErasureNotFound no existe la solicitud de supresión en el tenant
+	ErrErasureNotFound = errors.New("erasure request not found")
+)
+
+// MinTermLength longitud mínima de cada término a seudonimizar; los
+// términos más cortos coincidirían con texto que no es del interesado
+const MinTermLength = 3
+
+// PseudonymPrefix prefijo de los seudónimos; les sigue el ID de la
+// solicitud de supresión
+const PseudonymPrefix = "erased:"
+
+// ErasureStatus resultado de una solicitud de supresión
+type ErasureStatus string
+
+const (
+	ErasureCompleted ErasureStatus = "completed"
+	ErasureFailed    ErasureStatus = "failed"
+)
+
+// Erasure registro de una solicitud de supresión para los informes de
+// cumplimiento. No guarda el interesado ni los términos en claro: solo el
+// hash del subject, para localizar la solicitud si vuelve a pedirla
+type Erasure struct {
+	ID                        string        `json:"id"`
+	Tenant                    string        `json:"tenant,omitempty"`
+	SubjectHash               string        `json:"subject_hash"`
+	Pseudonym                 string        `json:"pseudonym"`
+	Terms                     int           `json:"terms"`
+	RequestedBy               string        `json:"requested_by,omitempty"`
+	RequestedAt               time.Time     `json:"requested_at"`
+	CompletedAt               time.Time     `json:"completed_at"`
+	Status                    ErasureStatus `json:"status"`
+	TasksUpdated              int           `json:"tasks_updated"`
+	AuditEntriesUpdated       int64         `json:"audit_entries_updated"`
+	AccountsUpdated           int           `json:"accounts_updated"`
+	APIKeysUpdated            int           `json:"api_keys_updated"`
+	IdempotencyRecordsDeleted int           `json:"idempotency_records_deleted"`
+	Error                     string        `json:"error,omitempty"`
+}
+
+// HashSubject hash con el que se registra el interesado de una supresión
+func HashSubject(subject string) string {
+	sum := sha256.Sum256([]byte(subject))
+	return hex.EncodeToString(sum[:])
+}
+
+// Manifest describe el contenido de una exportación
+type Manifest struct {
+	Subject     string         `json:"subject"`
+	Tenant      string         `json:"tenant,omitempty"`
+	GeneratedAt time.Time      `json:"generated_at"`
+	Files       map[string]int `json:"files"`
+	Notes       []string       `json:"notes"`
+}
@@ -0,0 +1,13 @@
+package privacy
+
+import "context"
+
+// ErasureRepository persistencia de las solicitudes de supresión. Los
+// registros no se modifican ni se borran
+type ErasureRepository interface {
+	Save(ctx context.Context, erasure *Erasure) error
+	FindByID(ctx context.Context, id string) (*Erasure, error)
+	// FindAll retorna las solicitudes de todos los tenants, de la más
+	// reciente a la más antigua
+	FindAll(ctx context.Context) ([]*Erasure, error)
+}
@@ -0,0 +1,387 @@
+package privacy
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/yebrai/go-tasks-microservice/internal/apikey"
+	"github.com/yebrai/go-tasks-microservice/internal/audit"
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+	"github.com/yebrai/go-tasks-microservice/internal/user"
+	"github.com/yebrai/go-tasks-microservice/pkg/auth"
+	"github.com/yebrai/go-tasks-microservice/pkg/events"
+	"github.com/yebrai/go-tasks-microservice/pkg/id"
+	"github.com/yebrai/go-tasks-microservice/pkg/idempotency"
+	"github.com/yebrai/go-tasks-microservice/pkg/tenant"
+)
+
+// maxUpdateAttempts veces que se intenta seudonimizar una tarea que otra
+// escritura modifica a la vez
+const maxUpdateAttempts = 3
+
+// exportNotes aclaraciones sobre los datos que la exportación no incluye
+var exportNotes = []string{
+	"tasks.jsonl includes the tasks created by the subject, also those in the trash",
+	"the service does not store comments",
+	"domain events are not stored: they are published to the message broker and WebSocket clients, and audit.jsonl records the commands that produced them",
+}
+
+// Accounts servicios de cuentas cuyos datos se incluyen en la exportación;
+// son nil si están deshabilitados
+type Accounts struct {
+	APIKeys *apikey.Service
+	Users   *user.Service
+}
+
+// Service exporta y seudonimiza los datos personales de un interesado,
+// identificado por el sujeto de sus principales, dentro del tenant del
+// contexto
+type Service struct {
+	tasks       task.Repository
+	eventBus    events.EventBus
+	auditLog    *audit.Log
+	erasures    ErasureRepository
+	idGenerator id.Generator
+	accounts    Accounts
+	responses   idempotency.Store
+	now         func() time.Time
+}
+
+// NewService crea el servicio de datos personales. responses son las
+// respuestas guardadas por Idempotency-Key; nil si la cabecera está
+// desactivada
+func NewService(
+	tasks task.Repository,
+	eventBus events.EventBus,
+	auditLog *audit.Log,
+	erasures ErasureRepository,
+	idGenerator id.Generator,
+	accounts Accounts,
+	responses idempotency.Store,
+) *Service {
+	return &Service{
+		tasks:       tasks,
+		eventBus:    eventBus,
+		auditLog:    auditLog,
+		erasures:    erasures,
+		idGenerator: idGenerator,
+		accounts:    accounts,
+		responses:   responses,
+		now:         time.Now,
+	}
+}
+
+// Export escribe en w un zip con los datos del interesado: manifest.json,
+// sus tareas, sus entradas de auditoría, sus claves de API y su cuenta si
+// existe
+func (s *Service) Export(ctx context.Context, subject string, w io.Writer) error {
+	subject = strings.TrimSpace(subject)
+	if subject == "" {
+		return fmt.Errorf("%w: subject is required", ErrInvalidRequest)
+	}
+
+	var tasks []*task.Task
+	err := s.eachTask(ctx, func(t *task.Task) error {
+		if t.OwnedBy(subject) {
+			tasks = append(tasks, t)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	entries, err := s.auditLog.ActorEntries(ctx, subject)
+	if err != nil {
+		return err
+	}
+
+	var keys []*apikey.APIKey
+	if s.accounts.APIKeys != nil {
+		all, err := s.accounts.APIKeys.List(ctx)
+		if err != nil {
+			return err
+		}
+		for _, key := range all {
+			if key.Owner == subject {
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	var account *user.User
+	if s.accounts.Users != nil {
+		account, err = s.accounts.Users.FindBySubject(ctx, subject)
+		if err != nil && !errors.Is(err, user.ErrUserNotFound) {
+			return err
+		}
+	}
+
+	manifest := Manifest{
+		Subject:     subject,
+		Tenant:      tenant.FromContext(ctx),
+		GeneratedAt: s.now(),
+		Files: map[string]int{
+			"tasks.jsonl":   len(tasks),
+			"audit.jsonl":   len(entries),
+			"api_keys.json": len(keys),
+		},
+		Notes: exportNotes,
+	}
+	if account != nil {
+		manifest.Files["account.json"] = 1
+	}
+
+	archive := zip.NewWriter(w)
+	if err := writeJSON(archive, "manifest.json", manifest); err != nil {
+		return err
+	}
+	if err := writeJSONLines(archive, "tasks.jsonl", tasks); err != nil {
+		return err
+	}
+	if err := writeJSONLines(archive, "audit.jsonl", entries); err != nil {
+		return err
+	}
+	if keys == nil {
+		keys = []*apikey.APIKey{}
+	}
+	if err := writeJSON(archive, "api_keys.json", keys); err != nil {
+		return err
+	}
+	if account != nil {
+		if err := writeJSON(archive, "account.json", account); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// Erase seudonimiza los datos del interesado en el tenant del contexto:
+// sustituye su sujeto como propietario de las tareas y los terms en su
+// título y descripción, su actor en la auditoría, el nombre y el email de
+// su cuenta, que queda cerrada, y el propietario y el nombre de sus claves
+// de API. Borra además las respuestas guardadas por Idempotency-Key de sus
+// peticiones. El registro de la solicitud se guarda también si falla
+func (s *Service) Erase(ctx context.Context, id, subject string, terms []string) (*Erasure, error) {
+	subject = strings.TrimSpace(subject)
+	if subject == "" {
+		return nil, fmt.Errorf("%w: subject is required", ErrInvalidRequest)
+	}
+	cleaned := make([]string, 0, len(terms))
+	for _, term := range terms {
+		term = strings.TrimSpace(term)
+		if utf8.RuneCountInString(term) < MinTermLength {
+			return nil, fmt.Errorf("%w: terms must have at least %d characters", ErrInvalidRequest, MinTermLength)
+		}
+		cleaned = append(cleaned, term)
+	}
+	if id == "" {
+		id = s.idGenerator.Generate()
+	}
+
+	erasure := &Erasure{
+		ID:          id,
+		Tenant:      tenant.FromContext(ctx),
+		SubjectHash: HashSubject(subject),
+		Pseudonym:   PseudonymPrefix + id,
+		Terms:       len(cleaned),
+		RequestedAt: s.now(),
+	}
+	if principal, ok := auth.PrincipalFromContext(ctx); ok {
+		erasure.RequestedBy = principal.Subject
+	}
+
+	updated, err := s.pseudonymizeTasks(ctx, subject, erasure.Pseudonym, cleaned)
+	erasure.TasksUpdated = updated
+	if err == nil {
+		erasure.AuditEntriesUpdated, err = s.auditLog.Pseudonymize(ctx, subject, erasure.Pseudonym)
+	}
+	if err == nil {
+		erasure.AccountsUpdated, erasure.APIKeysUpdated, err = s.pseudonymizeAccounts(ctx, subject, erasure.Pseudonym)
+	}
+	if err == nil && s.responses != nil {
+		erasure.IdempotencyRecordsDeleted, err = s.responses.Purge(ctx, idempotency.Owner(erasure.Tenant, subject))
+	}
+
+	erasure.CompletedAt = s.now()
+	erasure.Status = ErasureCompleted
+	if err != nil {
+		erasure.Status = ErasureFailed
+		erasure.Error = err.Error()
+	}
+
+	if saveErr := s.erasures.Save(ctx, erasure); saveErr != nil {
+		return nil, errors.Join(err, fmt.Errorf("failed to save erasure request: %w", saveErr))
+	}
+	return erasure, err
+}
+
+// FindErasure retorna una solicitud de supresión del tenant del contexto
+func (s *Service) FindErasure(ctx context.Context, id string) (*Erasure, error) {
+	erasure, err := s.erasures.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if erasure.Tenant != tenant.FromContext(ctx) {
+		return nil, ErrErasureNotFound
+	}
+	return erasure, nil
+}
+
+// ListErasures retorna las solicitudes de supresión del tenant del
+// contexto, de la más reciente a la más antigua
+func (s *Service) ListErasures(ctx context.Context) ([]*Erasure, error) {
+	erasures, err := s.erasures.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tenantID := tenant.FromContext(ctx)
+	visible := make([]*Erasure, 0, len(erasures))
+	for _, erasure := range erasures {
+		if erasure.Tenant == tenantID {
+			visible = append(visible, erasure)
+		}
+	}
+	return visible, nil
+}
+
+// pseudonymizeAccounts seudonimiza la cuenta y las claves de API del
+// interesado. Retorna cuántas cuentas y claves cambió
+func (s *Service) pseudonymizeAccounts(ctx context.Context, subject, pseudonym string) (int, int, error) {
+	accounts := 0
+	if s.accounts.Users != nil {
+		updated, err := s.accounts.Users.Pseudonymize(ctx, subject, pseudonym)
+		if err != nil {
+			return 0, 0, err
+		}
+		if updated {
+			accounts = 1
+		}
+	}
+
+	keys := 0
+	if s.accounts.APIKeys != nil {
+		var err error
+		keys, err = s.accounts.APIKeys.Pseudonymize(ctx, subject, pseudonym)
+		if err != nil {
+			return accounts, keys, err
+		}
+	}
+	return accounts, keys, nil
+}
+
+// pseudonymizeTasks seudonimiza las tareas del tenant que contienen datos
+// del interesado. Retorna cuántas cambió
+func (s *Service) pseudonymizeTasks(ctx context.Context, subject, pseudonym string, terms []string) (int, error) {
+	// Se localizan primero y se actualizan después, para no modificar las
+	// tareas mientras se recorren
+	var taskIDs []string
+	err := s.eachTask(ctx, func(t *task.Task) error {
+		if t.Pseudonymize(subject, pseudonym, terms) {
+			taskIDs = append(taskIDs, t.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	updated := 0
+	for _, taskID := range taskIDs {
+		changed, err := s.pseudonymizeTask(ctx, taskID, subject, pseudonym, terms)
+		if err != nil {
+			return updated, fmt.Errorf("failed to pseudonymize task %s: %w", taskID, err)
+		}
+		if changed {
+			updated++
+		}
+	}
+	return updated, nil
+}
+
+// pseudonymizeTask relee la tarea y la seudonimiza, reintentando si otra
+// escritura se adelanta
+func (s *Service) pseudonymizeTask(ctx context.Context, taskID, subject, pseudonym string, terms []string) (bool, error) {
+	for attempt := 1; ; attempt++ {
+		t, err := s.tasks.FindByID(ctx, taskID)
+		if errors.Is(err, task.ErrTaskNotFound) {
+			// Se purgó mientras tanto
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if !t.Pseudonymize(subject, pseudonym, terms) {
+			return false, nil
+		}
+
+		err = s.tasks.Update(ctx, t)
+		if errors.Is(err, task.ErrConcurrentModification) && attempt < maxUpdateAttempts {
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+
+		event := task.NewTaskUpdatedEvent(t)
+		if err := s.eventBus.Publish(ctx, event); err != nil {
+			fmt.Printf("⚠️  Failed to publish task updated event: %v\n", err)
+		}
+		return true, nil
+	}
+}
+
+// eachTask recorre todas las tareas del tenant, también las de la papelera
+func (s *Service) eachTask(ctx context.Context, fn func(*task.Task) error) error {
+	if err := s.tasks.StreamMatching(ctx, task.Filter{}, fn); err != nil {
+		return fmt.Errorf("failed to read tasks: %w", err)
+	}
+
+	archived, err := s.tasks.FindArchived(ctx, time.Time{})
+	if err != nil {
+		return fmt.Errorf("failed to read archived tasks: %w", err)
+	}
+	for _, t := range archived {
+		if err := fn(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeJSON añade al zip un fichero con v en JSON
+func writeJSON(archive *zip.Writer, name string, v any) error {
+	file, err := archive.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// writeJSONLines añade al zip un fichero con un documento JSON por línea
+func writeJSONLines[T any](archive *zip.Writer, name string, items []T) error {
+	file, err := archive.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	encoder := json.NewEncoder(file)
+	for _, item := range items {
+		if err := encoder.Encode(item); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+	return nil
+}
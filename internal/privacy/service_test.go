@@ -0,0 +1,257 @@
+package privacy
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yebrai/go-tasks-microservice/internal/apikey"
+	"github.com/yebrai/go-tasks-microservice/internal/audit"
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+	"github.com/yebrai/go-tasks-microservice/internal/task/tasktest"
+	"github.com/yebrai/go-tasks-microservice/internal/user"
+	"github.com/yebrai/go-tasks-microservice/pkg/auth"
+	"github.com/yebrai/go-tasks-microservice/pkg/cqrs"
+	"github.com/yebrai/go-tasks-microservice/pkg/events"
+	"github.com/yebrai/go-tasks-microservice/pkg/id"
+	"github.com/yebrai/go-tasks-microservice/pkg/idempotency"
+	"github.com/yebrai/go-tasks-microservice/pkg/tenant"
+)
+
+const subject = "user:ada"
+
+type fixture struct {
+	service    *Service
+	repository *tasktest.Repository
+	auditLog   *audit.Log
+	apiKeys    *apikey.Service
+	users      *user.Service
+	responses  *idempotency.MemoryStore
+	ctx        context.Context
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+
+	newTask := func(id, title, description, owner string, archived bool) task.Task {
+		created, err := task.NewTask(id, title, description, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		created.Owner = owner
+		created.Tenant = "acme"
+		if archived {
+			if err := created.Archive(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return *created
+	}
+	repository := tasktest.NewRepository()
+	for _, seeded := range []task.Task{
+		newTask("task-1", "Call Ada Lovelace", "", subject, false),
+		newTask("task-2", "Review the report", "Send it to ADA@example.com", "user:grace", false),
+		newTask("task-3", "Unrelated", "", "user:grace", false),
+		newTask("task-4", "Old draft", "", subject, true),
+	} {
+		repository.Put(&seeded)
+	}
+
+	auditLog := audit.NewLog(audit.NewMemoryRepository(), id.NewUniqueIDGenerator())
+	apiKeys := apikey.NewService(apikey.NewMemoryRepository(), id.NewUniqueIDGenerator())
+
+	ctx := tenant.WithID(context.Background(), "acme")
+	actorCtx := cqrs.WithOrigin(auth.WithPrincipal(ctx, &auth.Principal{Subject: subject, Name: "Ada"}), cqrs.Origin{SourceIP: "203.0.113.7"})
+	auditLog.Record(actorCtx, "task.command.create", []string{"task-1"}, audit.OutcomeSucceeded, "")
+	auditLog.Record(tenant.WithID(actorCtx, "globex"), "task.command.create", nil, audit.OutcomeSucceeded, "")
+	for _, owner := range []string{subject, "user:grace"} {
+		if _, _, err := apiKeys.Create(ctx, apikey.CreateRequest{Name: "laptop", Owner: owner, Scopes: []string{auth.ScopeTasksRead}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	accounts := user.NewMemoryRepository()
+	if err := accounts.Save(ctx, &user.User{ID: "ada", Email: "ada@example.com", Name: "Ada Lovelace", Tenant: "acme"}); err != nil {
+		t.Fatal(err)
+	}
+	users := user.NewService(accounts, user.NewMemorySessionRepository(), nil, id.NewUniqueIDGenerator(), user.Options{})
+
+	responses := idempotency.NewMemoryStore(time.Hour)
+	for _, owner := range []string{idempotency.Owner("acme", subject), idempotency.Owner("acme", "user:grace"), idempotency.Owner("globex", subject)} {
+		if _, _, err := responses.Acquire(ctx, "key-"+owner, owner, "", time.Minute); err != nil {
+			t.Fatal(err)
+		}
+		if err := responses.Complete(ctx, "key-"+owner, idempotency.Response{Status: 201, Body: []byte(`{"title":"Call Ada Lovelace"}`)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	service := NewService(repository, events.NewNoOpEventBus(), auditLog, NewMemoryErasureRepository(), id.NewUniqueIDGenerator(), Accounts{APIKeys: apiKeys, Users: users}, responses)
+	ctx = auth.WithPrincipal(ctx, &auth.Principal{Subject: "dpo", Scopes: []string{auth.ScopeAdmin}})
+	return &fixture{service: service, repository: repository, auditLog: auditLog, apiKeys: apiKeys, users: users, responses: responses, ctx: ctx}
+}
+
+func TestService_Export(t *testing.T) {
+	f := newFixture(t)
+
+	var archive bytes.Buffer
+	if err := f.service.Export(f.ctx, subject, &archive); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(archive.Bytes()), int64(archive.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := make(map[string]int)
+	for _, file := range reader.File {
+		content, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		scanner := bufio.NewScanner(content)
+		for scanner.Scan() {
+			lines[file.Name]++
+		}
+		content.Close()
+	}
+
+	for name, want := range map[string]int{"tasks.jsonl": 2, "audit.jsonl": 1} {
+		if lines[name] != want {
+			t.Errorf("Expected %d lines in %s, got %d", want, name, lines[name])
+		}
+	}
+	for _, name := range []string{"manifest.json", "api_keys.json", "account.json"} {
+		if lines[name] == 0 {
+			t.Errorf("Expected %s in the archive", name)
+		}
+	}
+}
+
+func TestService_Erase(t *testing.T) {
+	f := newFixture(t)
+	f.repository.FailUpdates(1)
+
+	erasure, err := f.service.Erase(f.ctx, "", subject, []string{" Ada Lovelace ", "ada@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if erasure.Status != ErasureCompleted || erasure.TasksUpdated != 3 || erasure.AuditEntriesUpdated != 1 ||
+		erasure.AccountsUpdated != 1 || erasure.APIKeysUpdated != 1 || erasure.IdempotencyRecordsDeleted != 1 || erasure.RequestedBy != "dpo" || erasure.SubjectHash != HashSubject(subject) || erasure.Tenant != "acme" {
+		t.Errorf("Unexpected erasure %+v", erasure)
+	}
+
+	pseudonym := erasure.Pseudonym
+	for _, tt := range []struct {
+		id, owner, title, description string
+	}{
+		{"task-1", pseudonym, "Call " + pseudonym, ""},
+		{"task-2", "user:grace", "Review the report", "Send it to " + pseudonym},
+		{"task-3", "user:grace", "Unrelated", ""},
+		{"task-4", pseudonym, "Old draft", ""},
+	} {
+		got, err := f.repository.FindByID(f.ctx, tt.id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Owner != tt.owner || got.Title != tt.title || got.Description != tt.description {
+			t.Errorf("%s: expected %q/%q/%q, got %q/%q/%q", tt.id, tt.owner, tt.title, tt.description, got.Owner, got.Title, got.Description)
+		}
+	}
+
+	entries, err := f.auditLog.ActorEntries(f.ctx, subject)
+	if err != nil || len(entries) != 0 {
+		t.Errorf("Expected no audit entries left for the subject, got %d (%v)", len(entries), err)
+	}
+	entries, _ = f.auditLog.ActorEntries(f.ctx, pseudonym)
+	if len(entries) != 1 || entries[0].ActorName != "" || entries[0].SourceIP != "" {
+		t.Errorf("Expected the audit entry pseudonymized, got %+v", entries)
+	}
+	entries, _ = f.auditLog.ActorEntries(tenant.WithID(f.ctx, "globex"), subject)
+	if len(entries) != 1 {
+		t.Errorf("Expected other tenants untouched, got %d entries", len(entries))
+	}
+
+	account, err := f.users.FindBySubject(f.ctx, subject)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.Name != pseudonym || account.Email != "ada"+user.ErasedEmailDomain || account.PasswordHash != "" {
+		t.Errorf("Expected the account pseudonymized and closed, got %+v", account)
+	}
+	keys, _ := f.apiKeys.List(f.ctx)
+	for _, key := range keys {
+		if key.Owner == subject || (key.Owner == pseudonym) != (key.Name == pseudonym) {
+			t.Errorf("Expected only the subject's key pseudonymized, got %s/%s", key.Owner, key.Name)
+		}
+	}
+
+	for owner, want := range map[string]int{
+		idempotency.Owner("acme", subject):      0,
+		idempotency.Owner("acme", "user:grace"): 1,
+		idempotency.Owner("globex", subject):    1,
+	} {
+		if got, _ := f.responses.Purge(f.ctx, owner); got != want {
+			t.Errorf("Expected %d cached responses left for %s, got %d", want, owner, got)
+		}
+	}
+
+	erasures, _ := f.service.ListErasures(f.ctx)
+	if len(erasures) != 1 || erasures[0].ID != erasure.ID {
+		t.Errorf("Expected the erasure to be recorded, got %+v", erasures)
+	}
+	if erasures, _ := f.service.ListErasures(tenant.WithID(f.ctx, "globex")); len(erasures) != 0 {
+		t.Errorf("Expected erasures scoped to the tenant, got %d", len(erasures))
+	}
+}
+
+func TestService_Erase_RecordsFailures(t *testing.T) {
+	f := newFixture(t)
+	f.repository.FailUpdates(maxUpdateAttempts * 2)
+
+	erasure, err := f.service.Erase(f.ctx, "", subject, nil)
+	if !errors.Is(err, task.ErrConcurrentModification) {
+		t.Fatalf("Expected a concurrent modification, got %v", err)
+	}
+	if erasure.Status != ErasureFailed || erasure.Error == "" {
+		t.Errorf("Expected a failed erasure, got %+v", erasure)
+	}
+	if recorded, err := f.service.FindErasure(f.ctx, erasure.ID); err != nil || recorded.Status != ErasureFailed {
+		t.Errorf("Expected the failed erasure to be recorded, got %+v (%v)", recorded, err)
+	}
+}
+
+func TestService_Erase_Validation(t *testing.T) {
+	tests := []struct {
+		name    string
+		subject string
+		terms   []string
+	}{
+		{"missing subject", "  ", nil},
+		{"short term", subject, []string{"Ada Lovelace", " Al "}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			if _, err := f.service.Erase(f.ctx, "", tt.subject, tt.terms); !errors.Is(err, ErrInvalidRequest) {
+				t.Errorf("Expected ErrInvalidRequest, got %v", err)
+			}
+			if err := f.service.Export(f.ctx, tt.subject, io.Discard); tt.subject == "  " && !errors.Is(err, ErrInvalidRequest) {
+				t.Errorf("Expected the export to be rejected, got %v", err)
+			}
+			if erasures, _ := f.service.ListErasures(f.ctx); len(erasures) != 0 {
+				t.Errorf("Expected invalid requests not to be recorded")
+			}
+			if stored, _ := f.repository.FindByID(f.ctx, "task-1"); strings.Contains(stored.Owner, PseudonymPrefix) {
+				t.Errorf("Expected no changes")
+			}
+		})
+	}
+}
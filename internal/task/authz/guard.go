@@ -0,0 +1,67 @@
+package authz
+
+import (
+	"context"
+	"errors"
+
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+	"github.com/yebrai/go-tasks-microservice/internal/task/bulk"
+	"github.com/yebrai/go-tasks-microservice/internal/task/creator"
+	"github.com/yebrai/go-tasks-microservice/internal/task/importer"
+	"github.com/yebrai/go-tasks-microservice/pkg/auth"
+	"github.com/yebrai/go-tasks-microservice/pkg/cqrs"
+)
+
+// commandActions acción que autoriza cada comando
+var commandActions = map[cqrs.CommandType]Action{
+	creator.CreateTaskCommandType:   ActionCreate,
+	creator.UpdateTaskCommandType:   ActionUpdate,
+	creator.CompleteTaskCommandType: ActionComplete,
+	creator.CancelTaskCommandType:   ActionCancel,
+	creator.ArchiveTaskCommandType:  ActionDelete,
+	creator.RestoreTaskCommandType:  ActionRestore,
+	creator.PurgeTaskCommandType:    ActionPurge,
+	bulk.BulkTransitionCommandType:  ActionBulk,
+	bulk.BulkUpdateCommandType:      ActionBulk,
+	importer.ImportTasksCommandType: ActionBulk,
+}
+
+// Guard autoriza los comandos antes de su handler. Los comandos sobre una
+// tarea existente la cargan solo si la política depende del propietario;
+// los comandos sin acción asociada no se autorizan
+func Guard(policy *Policy, repository task.Repository) cqrs.Middleware {
+	return func(next cqrs.CommandHandler) cqrs.CommandHandler {
+		return cqrs.CommandHandlerFunc(func(ctx context.Context, cmd cqrs.Command) error {
+			action, ok := commandActions[cmd.Type()]
+			if !ok {
+				return next.Handle(ctx, cmd)
+			}
+
+			principal, _ := auth.PrincipalFromContext(ctx)
+			switch policy.Decide(principal, action) {
+			case Allow:
+				return next.Handle(ctx, cmd)
+			case Deny:
+				return policy.Authorize(ctx, action, nil)
+			}
+
+			aggregateCmd, ok := cmd.(cqrs.AggregateCommand)
+			if !ok {
+				return policy.Authorize(ctx, action, nil)
+			}
+			t, err := repository.FindByID(ctx, aggregateCmd.AggregateID())
+			if errors.Is(err, task.ErrTaskNotFound) {
+				// El handler responde que la tarea no existe
+				return next.Handle(ctx, cmd)
+			}
+			if err != nil {
+				return err
+			}
+			if err := policy.Authorize(ctx, action, t); err != nil {
+				return err
+			}
+
+			return next.Handle(ctx, cmd)
+		})
+	}
+}
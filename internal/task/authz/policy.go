@@ -0,0 +1,212 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+	"github.com/yebrai/go-tasks-microservice/pkg/auth"
+)
+
+// ErrForbidden el principal no tiene permiso para la acción
+var ErrForbidden = errors.New("forbidden")
+
+// Roles predefinidos de la política por defecto
+const (
+	RoleViewer = "viewer"
+	RoleMember = "member"
+	RoleAdmin  = "admin"
+)
+
+// Action acción sobre tareas sujeta a autorización
+type Action string
+
+const (
+	ActionRead     Action = "task.read"
+	ActionCreate   Action = "task.create"
+	ActionUpdate   Action = "task.update"
+	ActionComplete Action = "task.complete"
+	ActionCancel   Action = "task.cancel"
+	// ActionDelete mueve la tarea a la papelera
+	ActionDelete  Action = "task.delete"
+	ActionRestore Action = "task.restore"
+	ActionPurge   Action = "task.purge"
+	// ActionBulk lanza comandos masivos e importaciones; cada tarea se
+	// autoriza además con su propia acción
+	ActionBulk Action = "task.bulk"
+	// ActionAll comodín que cubre todas las acciones
+	ActionAll Action = "*"
+)
+
+// Actions lista las acciones que admite una regla
+var Actions = []Action{
+	ActionRead, ActionCreate, ActionUpdate, ActionComplete, ActionCancel,
+	ActionDelete, ActionRestore, ActionPurge, ActionBulk, ActionAll,
+}
+
+// Rule permite las acciones a los principales con alguno de los roles.
+// Con OwnerOnly solo sobre las tareas que crearon. No hay reglas por
+// pertenencia a un proyecto: las tareas aún no tienen proyecto
+type Rule struct {
+	Actions   []Action
+	Roles     []string
+	OwnerOnly bool
+}
+
+// Policy conjunto de reglas; una acción está permitida si alguna regla la
+// permite. Una Policy nil lo permite todo
+type Policy struct {
+	rules []Rule
+	// defaultRoles roles de los principales que no traen ninguno
+	defaultRoles []string
+}
+
+// NewPolicy crea una política. Las reglas vacías usan DefaultRules
+func NewPolicy(rules []Rule, defaultRoles []string) (*Policy, error) {
+	if len(rules) == 0 {
+		rules = DefaultRules()
+	}
+
+	for i, rule := range rules {
+		if len(rule.Actions) == 0 || len(rule.Roles) == 0 {
+			return nil, fmt.Errorf("authorization rule %d requires actions and roles", i)
+		}
+		for _, action := range rule.Actions {
+			if !validAction(action) {
+				return nil, fmt.Errorf("authorization rule %d: unknown action %q", i, action)
+			}
+		}
+	}
+
+	return &Policy{rules: rules, defaultRoles: defaultRoles}, nil
+}
+
+// DefaultRules viewer lee; member lee y crea, y modifica las tareas que
+// creó; admin puede todo
+func DefaultRules() []Rule {
+	return []Rule{
+		{Actions: []Action{ActionRead}, Roles: []string{RoleViewer, RoleMember}},
+		{Actions: []Action{ActionCreate, ActionBulk}, Roles: []string{RoleMember}},
+		{
+			Actions:   []Action{ActionUpdate, ActionComplete, ActionCancel, ActionDelete, ActionRestore},
+			Roles:     []string{RoleMember},
+			OwnerOnly: true,
+		},
+		{Actions: []Action{ActionAll}, Roles: []string{RoleAdmin}},
+	}
+}
+
+// Decision resultado de evaluar una acción para un principal
+type Decision int
+
+const (
+	// Deny ninguna regla permite la acción
+	Deny Decision = iota
+	// AllowOwned solo se permite sobre las tareas del principal
+	AllowOwned
+	// Allow se permite sobre cualquier tarea
+	Allow
+)
+
+// Decide evalúa la acción sin mirar la tarea. Los principales sin
+// identidad (autenticación deshabilitada) y los procesos internos, que no
+// llevan principal, no están sujetos a la política
+func (p *Policy) Decide(principal *auth.Principal, action Action) Decision {
+	if p == nil || !principal.Authenticated() {
+		return Allow
+	}
+
+	roles := principal.Roles
+	if len(roles) == 0 {
+		roles = p.defaultRoles
+	}
+
+	decision := Deny
+	for _, rule := range p.rules {
+		if !rule.covers(action) || !hasAnyRole(roles, rule.Roles) {
+			continue
+		}
+		if !rule.OwnerOnly {
+			return Allow
+		}
+		decision = AllowOwned
+	}
+	return decision
+}
+
+// Allowed indica si el principal puede ejecutar la acción sobre la tarea.
+// t es nil en las acciones que no actúan sobre una tarea existente
+func (p *Policy) Allowed(principal *auth.Principal, action Action, t *task.Task) bool {
+	switch p.Decide(principal, action) {
+	case Allow:
+		return true
+	case AllowOwned:
+		return t != nil && t.OwnedBy(principal.Subject)
+	default:
+		return false
+	}
+}
+
+// Authorize retorna ErrForbidden si el principal del contexto no puede
+// ejecutar la acción sobre la tarea
+func (p *Policy) Authorize(ctx context.Context, action Action, t *task.Task) error {
+	principal, _ := auth.PrincipalFromContext(ctx)
+	if !p.Allowed(principal, action, t) {
+		return fmt.Errorf("%w: %s", ErrForbidden, action)
+	}
+	return nil
+}
+
+// Visible filtra las tareas que el principal del contexto puede leer. Las
+// marcas de borrado no tienen propietario y las ve cualquiera que pueda
+// leer tareas. Retorna ErrForbidden si no puede leer ninguna
+func (p *Policy) Visible(ctx context.Context, tasks []*task.Task) ([]*task.Task, error) {
+	principal, _ := auth.PrincipalFromContext(ctx)
+	switch p.Decide(principal, ActionRead) {
+	case Allow:
+		return tasks, nil
+	case AllowOwned:
+		visible := make([]*task.Task, 0, len(tasks))
+		for _, t := range tasks {
+			if t.IsPurged() || t.OwnedBy(principal.Subject) {
+				visible = append(visible, t)
+			}
+		}
+		return visible, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrForbidden, ActionRead)
+	}
+}
+
+// covers indica si la regla incluye la acción
+func (r Rule) covers(action Action) bool {
+	for _, a := range r.Actions {
+		if a == action || a == ActionAll {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAnyRole indica si algún rol del principal está entre los permitidos
+func hasAnyRole(roles, allowed []string) bool {
+	for _, role := range roles {
+		for _, a := range allowed {
+			if role == a {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// validAction indica si la acción existe
+func validAction(action Action) bool {
+	for _, a := range Actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,189 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+	"github.com/yebrai/go-tasks-microservice/internal/task/creator"
+	"github.com/yebrai/go-tasks-microservice/pkg/auth"
+	"github.com/yebrai/go-tasks-microservice/pkg/cqrs"
+)
+
+func ownedTask(t *testing.T, id, owner string) *task.Task {
+	t.Helper()
+	tk, err := task.NewTask(id, "Task "+id, "", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tk.Owner = owner
+	return tk
+}
+
+func TestPolicy_Allowed(t *testing.T) {
+	policy, err := NewPolicy(nil, []string{RoleMember})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	alice := &auth.Principal{Subject: "alice", Roles: []string{RoleMember}}
+	bob := &auth.Principal{Subject: "bob", Roles: []string{RoleMember}}
+	viewer := &auth.Principal{Subject: "vera", Roles: []string{RoleViewer}}
+	admin := &auth.Principal{Subject: "root", Roles: []string{RoleAdmin}}
+	noRoles := &auth.Principal{Subject: "key"}
+	unknownRole := &auth.Principal{Subject: "eve", Roles: []string{"guest"}}
+	aliceTask := ownedTask(t, "t1", "alice")
+	legacyTask := ownedTask(t, "t2", "")
+
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		action    Action
+		task      *task.Task
+		want      bool
+	}{
+		{"viewer reads", viewer, ActionRead, aliceTask, true},
+		{"viewer cannot create", viewer, ActionCreate, nil, false},
+		{"viewer cannot complete", viewer, ActionComplete, aliceTask, false},
+		{"member creates", alice, ActionCreate, nil, true},
+		{"member launches bulk", alice, ActionBulk, nil, true},
+		{"owner completes", alice, ActionComplete, aliceTask, true},
+		{"owner deletes", alice, ActionDelete, aliceTask, true},
+		{"owner cannot purge", alice, ActionPurge, aliceTask, false},
+		{"other member cannot update", bob, ActionUpdate, aliceTask, false},
+		{"other member reads", bob, ActionRead, aliceTask, true},
+		{"task without owner is not owned", alice, ActionUpdate, legacyTask, false},
+		{"admin purges any task", admin, ActionPurge, aliceTask, true},
+		{"admin updates task without owner", admin, ActionUpdate, legacyTask, true},
+		{"no roles uses default roles", noRoles, ActionCreate, nil, true},
+		{"unknown role is denied", unknownRole, ActionRead, aliceTask, false},
+		{"unauthenticated is not subject to policy", auth.Anonymous(), ActionPurge, aliceTask, true},
+		{"internal process is not subject to policy", nil, ActionPurge, aliceTask, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.Allowed(tt.principal, tt.action, tt.task); got != tt.want {
+				t.Errorf("Allowed(%s) = %v, want %v", tt.action, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicy_NilAllowsEverything(t *testing.T) {
+	var policy *Policy
+	principal := &auth.Principal{Subject: "alice", Roles: []string{RoleViewer}}
+	if !policy.Allowed(principal, ActionPurge, nil) {
+		t.Error("expected nil policy to allow every action")
+	}
+}
+
+func TestPolicy_ConfiguredRules(t *testing.T) {
+	policy, err := NewPolicy([]Rule{
+		{Actions: []Action{ActionRead}, Roles: []string{"auditor"}, OwnerOnly: true},
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	auditor := &auth.Principal{Subject: "alice", Roles: []string{"auditor"}}
+	purged := ownedTask(t, "t3", "bob").Tombstone(time.Now())
+	tasks := []*task.Task{ownedTask(t, "t1", "alice"), ownedTask(t, "t2", "bob"), purged}
+
+	ctx := auth.WithPrincipal(context.Background(), auditor)
+	visible, err := policy.Visible(ctx, tasks)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(visible) != 2 || visible[0].ID != "t1" || visible[1].ID != "t3" {
+		t.Errorf("expected the owned task and the tombstone to be visible, got %d tasks", len(visible))
+	}
+
+	// Las reglas configuradas sustituyen a las de por defecto
+	if err := policy.Authorize(ctx, ActionCreate, nil); !errors.Is(err, ErrForbidden) {
+		t.Errorf("expected ErrForbidden, got %v", err)
+	}
+
+	member := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "bob", Roles: []string{RoleMember}})
+	if _, err := policy.Visible(member, tasks); !errors.Is(err, ErrForbidden) {
+		t.Errorf("expected ErrForbidden for a role without rules, got %v", err)
+	}
+}
+
+func TestNewPolicy_RejectsInvalidRules(t *testing.T) {
+	tests := []struct {
+		name string
+		rule Rule
+	}{
+		{"unknown action", Rule{Actions: []Action{"task.fly"}, Roles: []string{RoleMember}}},
+		{"missing roles", Rule{Actions: []Action{ActionRead}}},
+		{"missing actions", Rule{Roles: []string{RoleMember}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewPolicy([]Rule{tt.rule}, nil); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+// taskFinder repositorio que solo resuelve FindByID
+type taskFinder struct {
+	task.Repository
+	tasks map[string]*task.Task
+}
+
+func (f *taskFinder) FindByID(_ context.Context, id string) (*task.Task, error) {
+	if t, ok := f.tasks[id]; ok {
+		return t, nil
+	}
+	return nil, task.ErrTaskNotFound
+}
+
+func TestGuard(t *testing.T) {
+	policy, err := NewPolicy(nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	repository := &taskFinder{tasks: map[string]*task.Task{"t1": ownedTask(t, "t1", "alice")}}
+
+	alice := &auth.Principal{Subject: "alice", Roles: []string{RoleMember}}
+	bob := &auth.Principal{Subject: "bob", Roles: []string{RoleMember}}
+	viewer := &auth.Principal{Subject: "vera", Roles: []string{RoleViewer}}
+
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		cmd       cqrs.Command
+		wantErr   error
+	}{
+		{"owner completes", alice, &creator.CompleteTaskCommand{ID: "t1"}, nil},
+		{"other member cannot complete", bob, &creator.CompleteTaskCommand{ID: "t1"}, ErrForbidden},
+		{"viewer cannot create", viewer, &creator.CreateTaskCommand{Title: "x"}, ErrForbidden},
+		{"member creates", bob, &creator.CreateTaskCommand{Title: "x"}, nil},
+		{"missing task reaches the handler", bob, &creator.CancelTaskCommand{ID: "missing"}, nil},
+		{"member cannot purge", alice, &creator.PurgeTaskCommand{ID: "t1"}, ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handled := false
+			handler := Guard(policy, repository)(cqrs.CommandHandlerFunc(func(context.Context, cqrs.Command) error {
+				handled = true
+				return nil
+			}))
+
+			err := handler.Handle(auth.WithPrincipal(context.Background(), tt.principal), tt.cmd)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+			if handled != (tt.wantErr == nil) {
+				t.Errorf("handler called = %v", handled)
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/yebrai/go-tasks-microservice/internal/audit"
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+	"github.com/yebrai/go-tasks-microservice/internal/task/creator"
+	"github.com/yebrai/go-tasks-microservice/pkg/cqrs"
+	"github.com/yebrai/go-tasks-microservice/pkg/events"
+	"github.com/yebrai/go-tasks-microservice/pkg/id"
+)
+
+// DefaultMaxOperations máximo de operaciones por lote si no se configura
+const DefaultMaxOperations = 100
+
+// Op tipo de operación de un lote
+type Op string
+
+const (
+	OpCreate   Op = "create"
+	OpUpdate   Op = "update"
+	OpComplete Op = "complete"
+	OpCancel   Op = "cancel"
+	// OpDelete mueve la tarea a la papelera, como DELETE /tasks/:id
+	OpDelete Op = "delete"
+)
+
+// Status resultado de una operación
+type Status string
+
+const (
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+	// StatusRolledBack operación aplicada y deshecha al fallar otra del
+	// lote atómico
+	StatusRolledBack Status = "rolled_back"
+	// StatusSkipped operación no ejecutada porque otra anterior del lote
+	// atómico falló
+	StatusSkipped Status = "skipped"
+)
+
+// errOperationFailed interrumpe la transacción de un lote atómico
+var errOperationFailed = errors.New("batch operation failed")
+
+// Operation operación sobre una tarea. Los campos nil no se modifican
+type Operation struct {
+	Op     Op
+	TaskID string
+	// ExpectedVersion versión sobre la que se emite la operación; 0 la omite
+	ExpectedVersion int64
+	Title           *string
+	Description     *string
+	DueDate         *time.Time
+	ClearDueDate    bool
+}
+
+// Result resultado de una operación del lote
+type Result struct {
+	Index  int
+	Op     Op
+	TaskID string
+	Status Status
+	Err    error
+	// Task estado de la tarea tras la operación
+	Task *task.Task
+}
+
+// Request lote ordenado de operaciones
+type Request struct {
+	// Atomic aplica todas las operaciones o ninguna
+	Atomic     bool
+	Operations []Operation
+}
+
+// Response resultados del lote en el orden de las operaciones
+type Response struct {
+	Results []Result
+	// RolledBack indica que un lote atómico se descartó
+	RolledBack bool
+}
+
+// Service ejecuta lotes de operaciones a través del command bus
+type Service struct {
+	commandBus  cqrs.CommandBus
+	repository  task.Repository
+	transactor  task.Transactor
+	idGenerator id.Generator
+}
+
+// NewService crea el servicio de lotes. Los lotes atómicos requieren un
+// repositorio que implemente task.Transactor
+func NewService(commandBus cqrs.CommandBus, repository task.Repository, idGenerator id.Generator) *Service {
+	transactor, _ := repository.(task.Transactor)
+
+	return &Service{
+		commandBus:  commandBus,
+		repository:  repository,
+		transactor:  transactor,
+		idGenerator: idGenerator,
+	}
+}
+
+// Execute aplica las operaciones en orden. Sin Atomic cada operación se
+// confirma por separado y los fallos no detienen el lote
+func (s *Service) Execute(ctx context.Context, req Request) (*Response, error) {
+	if req.Atomic {
+		return s.executeAtomic(ctx, req.Operations)
+	}
+
+	results := make([]Result, 0, len(req.Operations))
+	for i, operation := range req.Operations {
+		results = append(results, s.apply(ctx, i, operation))
+	}
+
+	return &Response{Results: results}, nil
+}
+
+// executeAtomic aplica las operaciones en una transacción que se descarta
+// en el primer fallo. Los eventos se publican solo si se confirma; la
+// auditoría se guarda fuera de la transacción en ambos casos
+func (s *Service) executeAtomic(ctx context.Context, operations []Operation) (*Response, error) {
+	if s.transactor == nil {
+		return nil, task.ErrTransactionsUnsupported
+	}
+
+	var results []Result
+	var buffer *events.Buffer
+	var auditBuffer *audit.Buffer
+	err := s.transactor.WithTransaction(ctx, func(txCtx context.Context) error {
+		// La transacción puede reintentarse: cada intento empieza de cero
+		txCtx, buffer = events.WithBuffer(txCtx)
+		txCtx, auditBuffer = audit.WithBuffer(txCtx)
+		results = make([]Result, 0, len(operations))
+
+		for i, operation := range operations {
+			result := s.apply(txCtx, i, operation)
+			results = append(results, result)
+			if result.Err != nil {
+				return errOperationFailed
+			}
+		}
+		return nil
+	})
+	if auditBuffer != nil {
+		auditBuffer.Flush(ctx, err)
+	}
+
+	if errors.Is(err, errOperationFailed) {
+		return &Response{Results: rollBack(results, operations), RolledBack: true}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("batch transaction failed: %w", err)
+	}
+
+	if err := buffer.Flush(ctx); err != nil {
+		fmt.Printf("⚠️  Failed to publish batch events: %v\n", err)
+	}
+
+	return &Response{Results: results}, nil
+}
+
+// apply ejecuta una operación y obtiene el estado resultante de la tarea
+func (s *Service) apply(ctx context.Context, index int, operation Operation) Result {
+	result := Result{
+		Index:  index,
+		Op:     operation.Op,
+		TaskID: operation.TaskID,
+	}
+
+	if operation.Op == OpCreate && result.TaskID == "" {
+		result.TaskID = s.idGenerator.Generate()
+	}
+
+	cmd, err := command(result.TaskID, operation)
+	if err == nil {
+		err = s.commandBus.Dispatch(ctx, cmd)
+	}
+	if err != nil {
+		result.Status = StatusFailed
+		result.Err = err
+		return result
+	}
+
+	result.Status = StatusSucceeded
+	if current, err := s.repository.FindByID(ctx, result.TaskID); err == nil {
+		result.Task = current
+	}
+	return result
+}
+
+// command construye el comando de una operación
+func command(taskID string, operation Operation) (cqrs.Command, error) {
+	switch operation.Op {
+	case OpCreate:
+		cmd := creator.CreateTaskCommand{ID: taskID, DueDate: operation.DueDate}
+		if operation.Title != nil {
+			cmd.Title = *operation.Title
+		}
+		if operation.Description != nil {
+			cmd.Description = *operation.Description
+		}
+		return cmd, nil
+	case OpUpdate:
+		return creator.UpdateTaskCommand{
+			ID:              taskID,
+			Title:           operation.Title,
+			Description:     operation.Description,
+			DueDate:         operation.DueDate,
+			ClearDueDate:    operation.ClearDueDate,
+			ExpectedVersion: operation.ExpectedVersion,
+		}, nil
+	case OpComplete:
+		return creator.CompleteTaskCommand{ID: taskID, ExpectedVersion: operation.ExpectedVersion}, nil
+	case OpCancel:
+		return creator.CancelTaskCommand{ID: taskID, ExpectedVersion: operation.ExpectedVersion}, nil
+	case OpDelete:
+		return creator.ArchiveTaskCommand{ID: taskID, ExpectedVersion: operation.ExpectedVersion}, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown batch op %q", task.ErrInvalidTaskData, operation.Op)
+	}
+}
+
+// rollBack marca las operaciones de un lote atómico descartado: las
+// aplicadas como deshechas y las posteriores al fallo como omitidas
+func rollBack(results []Result, operations []Operation) []Result {
+	rolledBack := make([]Result, 0, len(operations))
+	for _, result := range results {
+		if result.Status == StatusSucceeded {
+			result.Status = StatusRolledBack
+			result.Task = nil
+		}
+		rolledBack = append(rolledBack, result)
+	}
+
+	for i := len(results); i < len(operations); i++ {
+		rolledBack = append(rolledBack, Result{
+			Index:  i,
+			Op:     operations[i].Op,
+			TaskID: operations[i].TaskID,
+			Status: StatusSkipped,
+		})
+	}
+
+	return rolledBack
+}
@@ -0,0 +1,186 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/yebrai/go-tasks-microservice/internal/audit"
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+	"github.com/yebrai/go-tasks-microservice/internal/task/creator"
+	"github.com/yebrai/go-tasks-microservice/internal/task/tasktest"
+	"github.com/yebrai/go-tasks-microservice/pkg/cqrs/inmem"
+	"github.com/yebrai/go-tasks-microservice/pkg/events"
+	"github.com/yebrai/go-tasks-microservice/pkg/id"
+)
+
+const missingTaskID = "6f1c2a9e-3b4d-4c5e-8f70-1a2b3c4d5e6f"
+
+// eventCounter cuenta los eventos entregados a los suscriptores
+type eventCounter struct {
+	mu    sync.Mutex
+	count int
+}
+
+func (c *eventCounter) Handle(_ context.Context, _ task.DomainEvent) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.count++
+	return nil
+}
+
+func (c *eventCounter) Count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.count
+}
+
+func newTestService(t *testing.T) (*Service, *tasktest.Repository, *eventCounter) {
+	t.Helper()
+
+	repository := tasktest.NewRepository()
+	eventBus := events.NewObservableEventBus(events.NewNoOpEventBus())
+	counter := &eventCounter{}
+	eventBus.Subscribe(counter)
+
+	bus := inmem.NewCommandBus()
+	must := func(err error) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	must(bus.Register(creator.CreateTaskCommandType, creator.NewCreateTaskCommandHandler(repository, id.NewUniqueIDGenerator(), eventBus, creator.Quotas{})))
+	must(bus.Register(creator.UpdateTaskCommandType, creator.NewUpdateTaskCommandHandler(repository, eventBus)))
+	must(bus.Register(creator.CompleteTaskCommandType, creator.NewCompleteTaskCommandHandler(repository, eventBus)))
+
+	return NewService(bus, repository, id.NewUniqueIDGenerator()), repository, counter
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func TestExecute_NonAtomicContinuesAfterFailure(t *testing.T) {
+	service, repository, counter := newTestService(t)
+
+	response, err := service.Execute(context.Background(), Request{Operations: []Operation{
+		{Op: OpCreate, Title: stringPtr("first")},
+		{Op: OpComplete, TaskID: missingTaskID},
+		{Op: OpCreate, Title: stringPtr("second")},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statuses := []Status{StatusSucceeded, StatusFailed, StatusSucceeded}
+	for i, want := range statuses {
+		if got := response.Results[i].Status; got != want {
+			t.Errorf("Operation %d: expected %s, got %s", i, want, got)
+		}
+	}
+	if !errors.Is(response.Results[1].Err, task.ErrTaskNotFound) {
+		t.Errorf("Expected ErrTaskNotFound, got %v", response.Results[1].Err)
+	}
+	if response.Results[0].Task == nil || response.Results[0].Task.Title != "first" {
+		t.Errorf("Expected the created task in the result, got %+v", response.Results[0].Task)
+	}
+	if repository.Len() != 2 || counter.Count() != 2 {
+		t.Errorf("Expected 2 tasks and 2 events, got %d and %d", repository.Len(), counter.Count())
+	}
+}
+
+func TestExecute_AtomicRollsBackWithoutPublishing(t *testing.T) {
+	service, repository, counter := newTestService(t)
+
+	response, err := service.Execute(context.Background(), Request{Atomic: true, Operations: []Operation{
+		{Op: OpCreate, Title: stringPtr("first")},
+		{Op: OpComplete, TaskID: missingTaskID},
+		{Op: OpCreate, Title: stringPtr("second")},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !response.RolledBack {
+		t.Error("Expected the batch to be rolled back")
+	}
+	statuses := []Status{StatusRolledBack, StatusFailed, StatusSkipped}
+	for i, want := range statuses {
+		if got := response.Results[i].Status; got != want {
+			t.Errorf("Operation %d: expected %s, got %s", i, want, got)
+		}
+	}
+	if repository.Len() != 0 || counter.Count() != 0 {
+		t.Errorf("Expected no tasks and no events, got %d and %d", repository.Len(), counter.Count())
+	}
+}
+
+func TestExecute_AtomicRecordsAuditOutsideTheTransaction(t *testing.T) {
+	repository := tasktest.NewRepository()
+	eventBus := events.NewNoOpEventBus()
+	auditLog := audit.NewLog(audit.NewMemoryRepository(), id.NewUniqueIDGenerator())
+
+	bus := inmem.NewCommandBus()
+	bus.Use(auditLog.Middleware())
+	if err := bus.Register(creator.CreateTaskCommandType, creator.NewCreateTaskCommandHandler(repository, id.NewUniqueIDGenerator(), eventBus, creator.Quotas{})); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Register(creator.CompleteTaskCommandType, creator.NewCompleteTaskCommandHandler(repository, eventBus)); err != nil {
+		t.Fatal(err)
+	}
+	service := NewService(bus, repository, id.NewUniqueIDGenerator())
+
+	response, err := service.Execute(context.Background(), Request{Atomic: true, Operations: []Operation{
+		{Op: OpCreate, Title: stringPtr("first")},
+		{Op: OpComplete, TaskID: missingTaskID},
+	}})
+	if err != nil || !response.RolledBack {
+		t.Fatalf("Expected a rolled back batch, got %+v (%v)", response, err)
+	}
+
+	entries, err := auditLog.Find(context.Background(), audit.Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	outcomes := make(map[string]audit.Outcome)
+	for _, entry := range entries {
+		outcomes[entry.Action] = entry.Outcome
+		if entry.Action == string(creator.CreateTaskCommandType) && !strings.HasPrefix(entry.Reason, "rolled back") {
+			t.Errorf("Expected the create to be recorded as rolled back, got %q", entry.Reason)
+		}
+	}
+	if len(entries) != 2 || outcomes[string(creator.CreateTaskCommandType)] != audit.OutcomeFailed || outcomes[string(creator.CompleteTaskCommandType)] != audit.OutcomeFailed {
+		t.Errorf("Expected both commands recorded as failed, got %+v", outcomes)
+	}
+}
+
+func TestExecute_AtomicPublishesAfterCommit(t *testing.T) {
+	service, repository, counter := newTestService(t)
+
+	response, err := service.Execute(context.Background(), Request{Atomic: true, Operations: []Operation{
+		{Op: OpCreate, TaskID: missingTaskID, Title: stringPtr("first")},
+		{Op: OpComplete, TaskID: missingTaskID, ExpectedVersion: 1},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.RolledBack || response.Results[1].Task.Status != task.StatusCompleted {
+		t.Errorf("Expected a committed batch with the completed task, got %+v", response)
+	}
+	if repository.Len() != 1 || counter.Count() != 2 {
+		t.Errorf("Expected 1 task and 2 events, got %d and %d", repository.Len(), counter.Count())
+	}
+}
+
+func TestExecute_AtomicRequiresTransactions(t *testing.T) {
+	service, _, _ := newTestService(t)
+	service.transactor = nil
+
+	_, err := service.Execute(context.Background(), Request{Atomic: true, Operations: []Operation{{Op: OpCreate, Title: stringPtr("x")}}})
+	if !errors.Is(err, task.ErrTransactionsUnsupported) {
+		t.Errorf("Expected ErrTransactionsUnsupported, got %v", err)
+	}
+}
@@ -1,14 +1,91 @@
 package bootstrap
 
+import "time"
+
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Mongo    MongoConfig    `mapstructure:"mongo"`
-	RabbitMQ RabbitMQConfig `mapstructure:"rabbitmq"`
+	Server        ServerConfig        `mapstructure:"server"`
+	Mongo         MongoConfig         `mapstructure:"mongo"`
+	RabbitMQ      RabbitMQConfig      `mapstructure:"rabbitmq"`
+	Auth          AuthConfig          `mapstructure:"auth"`
+	Users         UsersConfig         `mapstructure:"users"`
+	Authorization AuthorizationConfig `mapstructure:"authorization"`
+	Tenancy       TenancyConfig       `mapstructure:"tenancy"`
+	WebSocket     WebSocketConfig     `mapstructure:"websocket"`
+	Sync          SyncConfig          `mapstructure:"sync"`
+	Trash         TrashConfig         `mapstructure:"trash"`
+	Idempotency   IdempotencyConfig   `mapstructure:"idempotency"`
+	Batch         BatchConfig         `mapstructure:"batch"`
+	RateLimit     RateLimitConfig     `mapstructure:"rate_limit"`
+	Quotas        QuotasConfig        `mapstructure:"quotas"`
 }
 
-// ServerConfig configuración del servidor HTTP
+// ServerConfig configuración del servidor HTTP. Los timeouts a 0 usan los
+// valores por defecto
 type ServerConfig struct {
-	Address string `mapstructure:"address"`
+	Address           string        `mapstructure:"address"`
+	ReadTimeout       time.Duration `mapstructure:"read_timeout"`
+	ReadHeaderTimeout time.Duration `mapstructure:"read_header_timeout"`
+	WriteTimeout      time.Duration `mapstructure:"write_timeout"`
+	IdleTimeout       time.Duration `mapstructure:"idle_timeout"`
+	// MaxBodyBytes tamaño máximo del cuerpo de las peticiones; 0 usa 1 MiB
+	MaxBodyBytes int64 `mapstructure:"max_body_bytes"`
+	// RouteMaxBodyBytes tamaño máximo de rutas concretas, con la clave
+	// "MÉTODO /ruta" (p. ej. "POST /api/v1/tasks/import")
+	RouteMaxBodyBytes map[string]int64      `mapstructure:"route_max_body_bytes"`
+	CORS              CORSConfig            `mapstructure:"cors"`
+	SecurityHeaders   SecurityHeadersConfig `mapstructure:"security_headers"`
+	TLS               TLSConfig             `mapstructure:"tls"`
+}
+
+// TLSConfig HTTPS con certificados que se recargan al cambiar los ficheros
+// y, con client_ca_file, autenticación mutua (mTLS)
+type TLSConfig struct {
+	Enabled  bool   `mapstructure:"enabled"`
+	CertFile string `mapstructure:"cert_file"`
+	KeyFile  string `mapstructure:"key_file"`
+	// ReloadInterval cada cuánto se comprueba si los ficheros cambiaron
+	ReloadInterval time.Duration `mapstructure:"reload_interval"`
+	// ClientCAFile bundle PEM de CAs que firman los certificados de cliente;
+	// vacío deshabilita mTLS
+	ClientCAFile string `mapstructure:"client_ca_file"`
+	// ClientAuth "require" exige certificado de cliente en cada conexión;
+	// "optional" lo verifica solo si el cliente lo presenta
+	ClientAuth string `mapstructure:"client_auth"`
+	// ClientPrincipals principales asociados a los subjects de los
+	// certificados de cliente
+	ClientPrincipals []ClientPrincipalConfig `mapstructure:"client_principals"`
+}
+
+// ClientPrincipalConfig principal de los certificados de cliente con el
+// subject indicado
+type ClientPrincipalConfig struct {
+	// CertificateSubject subject del certificado, como "CN=sync-service,O=Acme"
+	CertificateSubject string   `mapstructure:"certificate_subject"`
+	Subject            string   `mapstructure:"subject"`
+	Name               string   `mapstructure:"name"`
+	Roles              []string `mapstructure:"roles"`
+	Scopes             []string `mapstructure:"scopes"`
+	Tenant             string   `mapstructure:"tenant"`
+}
+
+// CORSConfig peticiones REST de navegador desde otros orígenes. Sin
+// orígenes CORS queda deshabilitado; las listas vacías usan los valores por
+// defecto del servidor
+type CORSConfig struct {
+	AllowedOrigins   []string `mapstructure:"allowed_origins"`
+	AllowedMethods   []string `mapstructure:"allowed_methods"`
+	AllowedHeaders   []string `mapstructure:"allowed_headers"`
+	ExposedHeaders   []string `mapstructure:"exposed_headers"`
+	AllowCredentials bool     `mapstructure:"allow_credentials"`
+	// MaxAge tiempo que los navegadores cachean el preflight
+	MaxAge time.Duration `mapstructure:"max_age"`
+}
+
+// SecurityHeadersConfig cabeceras de seguridad de las respuestas
+type SecurityHeadersConfig struct {
+	// HSTSMaxAge max-age de Strict-Transport-Security; 0 no la envía
+	HSTSMaxAge            time.Duration `mapstructure:"hsts_max_age"`
+	HSTSIncludeSubdomains bool          `mapstructure:"hsts_include_subdomains"`
 }
 
 // MongoConfig configuración de la base de datos MongoDB
@@ -23,3 +100,146 @@ type RabbitMQConfig struct {
 	Exchange string `mapstructure:"exchange"`
 	Queue    string `mapstructure:"queue"`
 }
+
+// AuthConfig configuración de la autenticación de la API y del WebSocket
+type AuthConfig struct {
+	Enabled bool          `mapstructure:"enabled"`
+	Tokens  []TokenConfig `mapstructure:"tokens"`
+	JWT     JWTConfig     `mapstructure:"jwt"`
+}
+
+// JWTConfig validación de tokens JWT contra un JWKS. Se activa al indicar
+// jwks_url o jwks_file
+type JWTConfig struct {
+	Issuer   string `mapstructure:"issuer"`
+	Audience string `mapstructure:"audience"`
+	JWKSURL  string `mapstructure:"jwks_url"`
+	JWKSFile string `mapstructure:"jwks_file"`
+	// RefreshInterval cada cuánto se recargan las claves; los kids
+	// desconocidos fuerzan una recarga antes
+	RefreshInterval time.Duration `mapstructure:"refresh_interval"`
+	// Leeway margen para las diferencias de reloj en exp y nbf
+	Leeway time.Duration `mapstructure:"leeway"`
+}
+
+// TokenConfig token estático y el principal al que identifica
+type TokenConfig struct {
+	Token   string   `mapstructure:"token"`
+	Subject string   `mapstructure:"subject"`
+	Name    string   `mapstructure:"name"`
+	Roles   []string `mapstructure:"roles"`
+	Scopes  []string `mapstructure:"scopes"`
+	// Tenant liga el token a un tenant; vacío, el cliente lo elige con la
+	// cabecera X-Tenant-ID
+	Tenant string `mapstructure:"tenant"`
+}
+
+// UsersConfig cuentas de usuario propias con login por contraseña. Los
+// valores cero usan los de por defecto del paquete user
+type UsersConfig struct {
+	Enabled    bool          `mapstructure:"enabled"`
+	AccessTTL  time.Duration `mapstructure:"access_ttl"`
+	RefreshTTL time.Duration `mapstructure:"refresh_ttl"`
+	ResetTTL   time.Duration `mapstructure:"reset_ttl"`
+	// MaxFailedLogins intentos fallidos seguidos que bloquean la cuenta
+	// durante LockoutDuration
+	MaxFailedLogins int           `mapstructure:"max_failed_logins"`
+	LockoutDuration time.Duration `mapstructure:"lockout_duration"`
+	// LogResetTokens escribe los tokens de restablecimiento de contraseña
+	// en el log. Solo para desarrollo: quien lea el log puede tomar
+	// cualquier cuenta. Sin él no se pueden restablecer contraseñas
+	LogResetTokens bool `mapstructure:"log_reset_tokens"`
+}
+
+// AuthorizationConfig políticas de autorización de comandos y consultas
+type AuthorizationConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// DefaultRoles roles de los principales que no traen ninguno, como las
+	// claves de API
+	DefaultRoles []string `mapstructure:"default_roles"`
+	// Rules reglas de la política; vacío usa la política por defecto
+	Rules []RuleConfig `mapstructure:"rules"`
+}
+
+// RuleConfig regla que permite acciones a unos roles
+type RuleConfig struct {
+	Actions   []string `mapstructure:"actions"`
+	Roles     []string `mapstructure:"roles"`
+	OwnerOnly bool     `mapstructure:"owner_only"`
+}
+
+// TenancyConfig espacios de trabajo aislados (tenants). Deshabilitado, todos
+// los datos pertenecen al tenant por defecto
+type TenancyConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// SelectableTenants tenants que los principales sin tenant pueden elegir
+	// con X-Tenant-ID; "*" admite cualquiera. Vacío solo permite el tenant
+	// por defecto
+	SelectableTenants []string `mapstructure:"selectable_tenants"`
+}
+
+// WebSocketConfig configuración del endpoint /ws/events
+type WebSocketConfig struct {
+	AllowedOrigins []string      `mapstructure:"allowed_origins"`
+	TicketTTL      time.Duration `mapstructure:"ticket_ttl"`
+}
+
+// SyncConfig configuración de la sincronización de clientes offline
+type SyncConfig struct {
+	// ConflictPolicy "reject" o "last_writer_wins"
+	ConflictPolicy string `mapstructure:"conflict_policy"`
+}
+
+// TrashConfig configuración de la papelera de tareas
+type TrashConfig struct {
+	// RetentionDays días que una tarea archivada permanece en la papelera
+	// antes de purgarse; 0 desactiva la purga automática
+	RetentionDays int           `mapstructure:"retention_days"`
+	PurgeInterval time.Duration `mapstructure:"purge_interval"`
+}
+
+// IdempotencyConfig configuración de la cabecera Idempotency-Key
+type IdempotencyConfig struct {
+	// TTL tiempo que se conservan las respuestas para reproducirlas
+	TTL time.Duration `mapstructure:"ttl"`
+}
+
+// BatchConfig configuración del endpoint /batch
+type BatchConfig struct {
+	// MaxOperations máximo de operaciones por lote; 0 usa el valor por
+	// defecto
+	MaxOperations int `mapstructure:"max_operations"`
+}
+
+// RateLimitConfig límites de peticiones de la API con token buckets
+type RateLimitConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Store "memory" para una sola instancia o "mongo" para compartir los
+	// buckets entre instancias
+	Store string `mapstructure:"store"`
+	// Key "principal" (clave de API, usuario o IP) o "tenant"
+	Key string `mapstructure:"key"`
+	// Classes límite de cada clase de ruta: read, write, bulk y auth
+	Classes map[string]RateLimitClassConfig `mapstructure:"classes"`
+}
+
+// RateLimitClassConfig límite de una clase de rutas
+type RateLimitClassConfig struct {
+	Requests int           `mapstructure:"requests"`
+	Period   time.Duration `mapstructure:"period"`
+	// Burst peticiones admitidas de golpe; 0 usa Requests
+	Burst int `mapstructure:"burst"`
+}
+
+// QuotasConfig límites duros de cada tenant
+type QuotasConfig struct {
+	// MaxOpenTasks máximo de tareas pendientes por tenant; 0 no limita
+	MaxOpenTasks int64 `mapstructure:"max_open_tasks"`
+	// Tenants cuotas que sustituyen a las generales para algunos tenants
+	Tenants map[string]TenantQuotaConfig `mapstructure:"tenants"`
+}
+
+// TenantQuotaConfig cuotas de un tenant concreto
+type TenantQuotaConfig struct {
+	MaxOpenTasks int64 `mapstructure:"max_open_tasks"`
+}
@@ -3,16 +3,33 @@ package bootstrap
 import (
 	"context"
 	"fmt"
+	"github.com/yebrai/go-tasks-microservice/internal/apikey"
+	apikeymongo "github.com/yebrai/go-tasks-microservice/internal/apikey/mongo"
+	"github.com/yebrai/go-tasks-microservice/internal/audit"
+	auditmongo "github.com/yebrai/go-tasks-microservice/internal/audit/mongo"
+	"github.com/yebrai/go-tasks-microservice/internal/privacy"
+	privacymongo "github.com/yebrai/go-tasks-microservice/internal/privacy/mongo"
 	"github.com/yebrai/go-tasks-microservice/internal/task"
+	"github.com/yebrai/go-tasks-microservice/internal/task/authz"
+	"github.com/yebrai/go-tasks-microservice/internal/task/bulk"
 	"github.com/yebrai/go-tasks-microservice/internal/task/creator"
+	taskhttp "github.com/yebrai/go-tasks-microservice/internal/task/http"
+	"github.com/yebrai/go-tasks-microservice/internal/task/importer"
 	taskmongo "github.com/yebrai/go-tasks-microservice/internal/task/mongo"
+	"github.com/yebrai/go-tasks-microservice/internal/task/presence"
+	"github.com/yebrai/go-tasks-microservice/internal/user"
+	usermongo "github.com/yebrai/go-tasks-microservice/internal/user/mongo"
+	"github.com/yebrai/go-tasks-microservice/pkg/auth"
 	"github.com/yebrai/go-tasks-microservice/pkg/cqrs"
 	"github.com/yebrai/go-tasks-microservice/pkg/cqrs/inmem"
 	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"github.com/yebrai/go-tasks-microservice/pkg/events"
 	"github.com/yebrai/go-tasks-microservice/pkg/id"
+	"github.com/yebrai/go-tasks-microservice/pkg/idempotency"
 	"github.com/yebrai/go-tasks-microservice/pkg/rabbitmq"
+	"github.com/yebrai/go-tasks-microservice/pkg/ratelimit"
+	"github.com/yebrai/go-tasks-microservice/pkg/tenant"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
@@ -27,15 +44,57 @@ type Providers struct {
 	// REPOSITORIOS (DOMAIN LAYER)
 	TaskRepository task.Repository
 
+	// CLAVES DE API de los servicios
+	APIKeys *apikey.Service
+
+	// REGISTRO DE AUDITORÍA
+	AuditLog *audit.Log
+
+	// CUENTAS DE USUARIO (nil si están deshabilitadas)
+	Users *user.Service
+
+	// DATOS PERSONALES: exportación y supresión
+	ErasureRepository privacy.ErasureRepository
+	Privacy           *privacy.Service
+
+	// IDEMPOTENCIA de las escrituras HTTP
+	IdempotencyStore idempotency.Store
+
+	// LÍMITES DE PETICIONES (nil si están deshabilitados)
+	RateLimiter ratelimit.Limiter
+
 	// CQRS (APPLICATION LAYER)
 	CommandBus cqrs.CommandBus
 
+	// PROGRESO DE LOS COMANDOS MASIVOS
+	JobStore bulk.JobStore
+
+	// AUTORIZACIÓN (nil si está deshabilitada)
+	Policy *authz.Policy
+
+	// PRESENCIA Y RESERVAS DE EDICIÓN
+	PresenceRegistry *presence.Registry
+
+	// AUTENTICACIÓN (nil si está deshabilitada)
+	Authenticator      auth.Authenticator
+	TicketStore        *auth.TicketStore
+	ClientCertificates *auth.CertificateAuthenticator
+
 	// EVENT SYSTEM (APPLICATION LAYER) - NUEVO
 	EventBus events.EventBus
 
 	// COMMAND HANDLERS (APPLICATION LAYER)
-	CreateTaskHandler   *creator.CreateTaskCommandHandler
-	CompleteTaskHandler *creator.CompleteTaskCommandHandler
+	CreateTaskHandler     *creator.CreateTaskCommandHandler
+	CompleteTaskHandler   *creator.CompleteTaskCommandHandler
+	UpdateTaskHandler     *creator.UpdateTaskCommandHandler
+	CancelTaskHandler     *creator.CancelTaskCommandHandler
+	ArchiveTaskHandler    *creator.ArchiveTaskCommandHandler
+	RestoreTaskHandler    *creator.RestoreTaskCommandHandler
+	PurgeTaskHandler      *creator.PurgeTaskCommandHandler
+	BulkTransitionHandler *bulk.BulkTransitionCommandHandler
+	BulkUpdateHandler     *bulk.BulkUpdateCommandHandler
+	ImportTasksHandler    *importer.ImportTasksCommandHandler
+	EraseSubjectHandler   *privacy.EraseSubjectCommandHandler
 }
 
 // NewProviders crea e inicializa todas las dependencias del sistema
@@ -50,12 +109,12 @@ func NewProviders(ctx context.Context, config *Config) (*Providers, error) {
 	}
 
 	// 2. Repositorios (Domain → Infrastructure adapters)
-	if err := providers.initRepositories(config); err != nil {
+	if err := providers.initRepositories(ctx, config); err != nil {
 		return nil, fmt.Errorf("repository initialization failed: %w", err)
 	}
 
 	// 3. CQRS buses
-	if err := providers.initCQRS(); err != nil {
+	if err := providers.initCQRS(config); err != nil {
 		return nil, fmt.Errorf("CQRS initialization failed: %w", err)
 	}
 
@@ -65,10 +124,20 @@ func NewProviders(ctx context.Context, config *Config) (*Providers, error) {
 	}
 
 	// 5. Command handlers
-	if err := providers.initCommandHandlers(); err != nil {
+	if err := providers.initCommandHandlers(config); err != nil {
 		return nil, fmt.Errorf("command handlers initialization failed: %w", err)
 	}
 
+	// 6. Autenticación
+	if err := providers.initAuth(config); err != nil {
+		return nil, fmt.Errorf("auth initialization failed: %w", err)
+	}
+
+	// 7. Límites de peticiones
+	if err := providers.initRateLimit(ctx, config); err != nil {
+		return nil, fmt.Errorf("rate limit initialization failed: %w", err)
+	}
+
 	return providers, nil
 }
 
@@ -103,24 +172,129 @@ func (p *Providers) initInfrastructure(ctx context.Context, config *Config) erro
 }
 
 // FASE 2: REPOSITORIOS
-func (p *Providers) initRepositories(config *Config) error {
+func (p *Providers) initRepositories(ctx context.Context, config *Config) error {
 	database := p.MongoClient.Database(config.Mongo.Database)
 
 	// Repository de tareas para operaciones CRUD
-	p.TaskRepository = taskmongo.NewTaskRepository(database)
+	taskRepository := taskmongo.NewTaskRepository(database)
+	if err := taskRepository.EnsureIndexes(ctx); err != nil {
+		return err
+	}
+	p.TaskRepository = taskRepository
+
+	// Respuestas de las peticiones con Idempotency-Key
+	idempotencyStore := idempotency.NewMongoStore(database, config.Idempotency.TTL)
+	if err := idempotencyStore.EnsureIndexes(ctx); err != nil {
+		return err
+	}
+	p.IdempotencyStore = idempotencyStore
+
+	// Claves de API, guardadas como hash
+	apiKeyRepository := apikeymongo.NewAPIKeyRepository(database)
+	if err := apiKeyRepository.EnsureIndexes(ctx); err != nil {
+		return err
+	}
+	p.APIKeys = apikey.NewService(apiKeyRepository, p.IDGenerator)
+
+	// Registro de auditoría, solo se añaden entradas
+	auditRepository := auditmongo.NewAuditRepository(database)
+	if err := auditRepository.EnsureIndexes(ctx); err != nil {
+		return err
+	}
+	p.AuditLog = audit.NewLog(auditRepository, p.IDGenerator)
+
+	// Registro de las solicitudes de supresión de datos personales
+	erasureRepository := privacymongo.NewErasureRepository(database)
+	if err := erasureRepository.EnsureIndexes(ctx); err != nil {
+		return err
+	}
+	p.ErasureRepository = erasureRepository
+
+	// Cuentas de usuario y sus sesiones
+	if config.Users.Enabled {
+		userRepository := usermongo.NewUserRepository(database)
+		if err := userRepository.EnsureIndexes(ctx); err != nil {
+			return err
+		}
+		sessionRepository := usermongo.NewSessionRepository(database)
+		if err := sessionRepository.EnsureIndexes(ctx); err != nil {
+			return err
+		}
+		var notifier user.ResetNotifier
+		if config.Users.LogResetTokens {
+			fmt.Printf("⚠️  users.log_reset_tokens is enabled: password reset tokens are written to the log (development only)\n")
+			notifier = user.LogResetNotifier{}
+		}
+		p.Users = user.NewService(userRepository, sessionRepository, notifier, p.IDGenerator, user.Options{
+			AccessTTL:       config.Users.AccessTTL,
+			RefreshTTL:      config.Users.RefreshTTL,
+			ResetTTL:        config.Users.ResetTTL,
+			MaxFailedLogins: config.Users.MaxFailedLogins,
+			LockoutDuration: config.Users.LockoutDuration,
+		})
+	}
 
 	fmt.Printf("✅ Repositories initialized\n")
 	fmt.Printf("   - TaskRepository: MongoDB\n")
+	fmt.Printf("   - IdempotencyStore: MongoDB\n")
+	fmt.Printf("   - APIKeyRepository: MongoDB\n")
+	fmt.Printf("   - AuditRepository: MongoDB\n")
+	fmt.Printf("   - ErasureRepository: MongoDB\n")
+	if p.Users != nil {
+		fmt.Printf("   - UserRepository: MongoDB\n")
+	}
 
 	return nil
 }
 
 // FASE 3: CQRS BUSES
-func (p *Providers) initCQRS() error {
-	p.CommandBus = inmem.NewCommandBus()
+func (p *Providers) initCQRS(config *Config) error {
+	p.PresenceRegistry = presence.NewRegistry()
+
+	if err := p.initPolicy(config); err != nil {
+		return err
+	}
+
+	commandBus := inmem.NewCommandBus()
+	commandBus.Use(p.AuditLog.Middleware(), authz.Guard(p.Policy, p.TaskRepository), presence.LeaseGuard(p.PresenceRegistry))
+	p.CommandBus = commandBus
+	p.JobStore = bulk.NewMemoryJobStore(bulk.DefaultJobRetention)
 
 	fmt.Printf("✅ CQRS buses initialized\n")
 	fmt.Printf("   - CommandBus: in-memory\n")
+	fmt.Printf("   - Middlewares: audit, authorization, lease guard\n")
+
+	return nil
+}
+
+// initPolicy crea la política de autorización; nil si está deshabilitada
+func (p *Providers) initPolicy(config *Config) error {
+	if !config.Authorization.Enabled {
+		fmt.Printf("⚠️  Authorization disabled - every principal may run every command\n")
+		return nil
+	}
+
+	rules := make([]authz.Rule, 0, len(config.Authorization.Rules))
+	for _, rule := range config.Authorization.Rules {
+		actions := make([]authz.Action, 0, len(rule.Actions))
+		for _, action := range rule.Actions {
+			actions = append(actions, authz.Action(action))
+		}
+		rules = append(rules, authz.Rule{Actions: actions, Roles: rule.Roles, OwnerOnly: rule.OwnerOnly})
+	}
+
+	policy, err := authz.NewPolicy(rules, config.Authorization.DefaultRoles)
+	if err != nil {
+		return err
+	}
+	p.Policy = policy
+
+	fmt.Printf("✅ Authorization initialized\n")
+	if len(rules) == 0 {
+		fmt.Printf("   - Rules: default (viewer, member, admin)\n")
+	} else {
+		fmt.Printf("   - Rules: %d from config\n", len(rules))
+	}
 
 	return nil
 }
@@ -129,7 +303,7 @@ func (p *Providers) initCQRS() error {
 func (p *Providers) initEventSystem(config *Config) error {
 	if !config.RabbitMQ.Enabled {
 		// RABBITMQ DESHABILITADO - usar NoOp EventBus
-		p.EventBus = events.NewNoOpEventBus()
+		p.EventBus = events.NewObservableEventBus(events.NewNoOpEventBus())
 		fmt.Printf("⚠️  RabbitMQ disabled - using NoOp EventBus\n")
 		return nil
 	}
@@ -149,8 +323,8 @@ func (p *Providers) initEventSystem(config *Config) error {
 
 	p.RabbitMQClient = client
 
-	// Crear EventBus usando RabbitMQ
-	p.EventBus = events.NewRabbitMQEventBus(client)
+	// Crear EventBus usando RabbitMQ, observable para los clientes WebSocket
+	p.EventBus = events.NewObservableEventBus(events.NewRabbitMQEventBus(client))
 
 	fmt.Printf("✅ RabbitMQ EventBus initialized\n")
 	fmt.Printf("   - URL: %s\n", config.RabbitMQ.URL)
@@ -160,12 +334,21 @@ func (p *Providers) initEventSystem(config *Config) error {
 }
 
 // FASE 5: COMMAND HANDLERS
-func (p *Providers) initCommandHandlers() error {
-	// Handler para crear tareas CON EventBus inyectado
+func (p *Providers) initCommandHandlers(config *Config) error {
+	// Handler para crear tareas CON EventBus inyectado y las cuotas de
+	// cada tenant
+	quotas := creator.Quotas{
+		MaxOpenTasks:       config.Quotas.MaxOpenTasks,
+		TenantMaxOpenTasks: make(map[string]int64, len(config.Quotas.Tenants)),
+	}
+	for tenantID, quota := range config.Quotas.Tenants {
+		quotas.TenantMaxOpenTasks[tenantID] = quota.MaxOpenTasks
+	}
 	p.CreateTaskHandler = creator.NewCreateTaskCommandHandler(
 		p.TaskRepository,
 		p.IDGenerator,
 		p.EventBus,
+		quotas,
 	)
 
 	// Handler para completar tareas CON EventBus inyectado
@@ -174,6 +357,60 @@ func (p *Providers) initCommandHandlers() error {
 		p.EventBus,
 	)
 
+	// Handlers para modificar y cancelar tareas
+	p.UpdateTaskHandler = creator.NewUpdateTaskCommandHandler(
+		p.TaskRepository,
+		p.EventBus,
+	)
+	p.CancelTaskHandler = creator.NewCancelTaskCommandHandler(
+		p.TaskRepository,
+		p.EventBus,
+	)
+
+	// Handlers de la papelera
+	p.ArchiveTaskHandler = creator.NewArchiveTaskCommandHandler(
+		p.TaskRepository,
+		p.EventBus,
+	)
+	p.RestoreTaskHandler = creator.NewRestoreTaskCommandHandler(
+		p.TaskRepository,
+		p.EventBus,
+		quotas,
+	)
+	p.PurgeTaskHandler = creator.NewPurgeTaskCommandHandler(
+		p.TaskRepository,
+		p.EventBus,
+	)
+
+	// Handlers de los comandos masivos: despachan un comando por tarea
+	p.BulkTransitionHandler = bulk.NewBulkTransitionCommandHandler(
+		p.TaskRepository,
+		p.CommandBus,
+		p.JobStore,
+	)
+	p.BulkUpdateHandler = bulk.NewBulkUpdateCommandHandler(
+		p.TaskRepository,
+		p.CommandBus,
+		p.JobStore,
+	)
+	p.ImportTasksHandler = importer.NewImportTasksCommandHandler(
+		p.CommandBus,
+		p.JobStore,
+		p.IDGenerator,
+	)
+
+	// Exportación y supresión de los datos personales de un interesado
+	p.Privacy = privacy.NewService(
+		p.TaskRepository,
+		p.EventBus,
+		p.AuditLog,
+		p.ErasureRepository,
+		p.IDGenerator,
+		privacy.Accounts{APIKeys: p.APIKeys, Users: p.Users},
+		p.IdempotencyStore,
+	)
+	p.EraseSubjectHandler = privacy.NewEraseSubjectCommandHandler(p.Privacy)
+
 	// Registrar handlers en el command bus
 	if err := p.CommandBus.Register(creator.CreateTaskCommandType, p.CreateTaskHandler); err != nil {
 		return fmt.Errorf("failed to register CreateTaskCommandHandler: %w", err)
@@ -183,9 +420,192 @@ func (p *Providers) initCommandHandlers() error {
 		return fmt.Errorf("failed to register CompleteTaskCommandHandler: %w", err)
 	}
 
+	if err := p.CommandBus.Register(creator.UpdateTaskCommandType, p.UpdateTaskHandler); err != nil {
+		return fmt.Errorf("failed to register UpdateTaskCommandHandler: %w", err)
+	}
+
+	if err := p.CommandBus.Register(creator.CancelTaskCommandType, p.CancelTaskHandler); err != nil {
+		return fmt.Errorf("failed to register CancelTaskCommandHandler: %w", err)
+	}
+
+	if err := p.CommandBus.Register(creator.ArchiveTaskCommandType, p.ArchiveTaskHandler); err != nil {
+		return fmt.Errorf("failed to register ArchiveTaskCommandHandler: %w", err)
+	}
+
+	if err := p.CommandBus.Register(creator.RestoreTaskCommandType, p.RestoreTaskHandler); err != nil {
+		return fmt.Errorf("failed to register RestoreTaskCommandHandler: %w", err)
+	}
+
+	if err := p.CommandBus.Register(creator.PurgeTaskCommandType, p.PurgeTaskHandler); err != nil {
+		return fmt.Errorf("failed to register PurgeTaskCommandHandler: %w", err)
+	}
+
+	if err := p.CommandBus.Register(bulk.BulkTransitionCommandType, p.BulkTransitionHandler); err != nil {
+		return fmt.Errorf("failed to register BulkTransitionCommandHandler: %w", err)
+	}
+
+	if err := p.CommandBus.Register(bulk.BulkUpdateCommandType, p.BulkUpdateHandler); err != nil {
+		return fmt.Errorf("failed to register BulkUpdateCommandHandler: %w", err)
+	}
+
+	if err := p.CommandBus.Register(importer.ImportTasksCommandType, p.ImportTasksHandler); err != nil {
+		return fmt.Errorf("failed to register ImportTasksCommandHandler: %w", err)
+	}
+
+	if err := p.CommandBus.Register(privacy.EraseSubjectCommandType, p.EraseSubjectHandler); err != nil {
+		return fmt.Errorf("failed to register EraseSubjectCommandHandler: %w", err)
+	}
+
 	fmt.Printf("✅ Command handlers registered\n")
 	fmt.Printf("   - CreateTaskCommand: ✓ (with EventBus)\n")
 	fmt.Printf("   - CompleteTaskCommand: ✓ (with EventBus)\n")
+	fmt.Printf("   - UpdateTaskCommand: ✓ (with EventBus)\n")
+	fmt.Printf("   - CancelTaskCommand: ✓ (with EventBus)\n")
+	fmt.Printf("   - Archive/Restore/PurgeTaskCommand: ✓ (with EventBus)\n")
+	fmt.Printf("   - BulkTransition/BulkUpdateCommand: ✓ (per-task commands)\n")
+	fmt.Printf("   - ImportTasksCommand: ✓ (per-row commands)\n")
+	fmt.Printf("   - EraseSubjectCommand: ✓ (with EventBus)\n")
+
+	return nil
+}
+
+// FASE 6: AUTENTICACIÓN
+func (p *Providers) initAuth(config *Config) error {
+	p.TicketStore = auth.NewTicketStore(config.WebSocket.TicketTTL)
+
+	if !config.Auth.Enabled {
+		fmt.Printf("⚠️  Authentication disabled - requests are anonymous\n")
+		return nil
+	}
+
+	tokens := make([]auth.StaticToken, 0, len(config.Auth.Tokens))
+	for _, token := range config.Auth.Tokens {
+		if token.Token == "" || token.Subject == "" {
+			return fmt.Errorf("auth tokens require token and subject")
+		}
+		if token.Tenant != "" {
+			if err := tenant.Validate(token.Tenant); err != nil {
+				return fmt.Errorf("auth token %s: %w", token.Subject, err)
+			}
+		}
+		tokens = append(tokens, auth.StaticToken{
+			Token: token.Token,
+			Principal: auth.Principal{
+				Subject: token.Subject,
+				Name:    token.Name,
+				Roles:   token.Roles,
+				Scopes:  token.Scopes,
+				Tenant:  token.Tenant,
+			},
+		})
+	}
+	authenticators := []auth.Authenticator{auth.NewStaticTokenAuthenticator(tokens)}
+
+	jwtConfig := config.Auth.JWT
+	if jwtConfig.JWKSURL != "" || jwtConfig.JWKSFile != "" {
+		if jwtConfig.JWKSURL != "" && jwtConfig.JWKSFile != "" {
+			return fmt.Errorf("auth jwt accepts jwks_url or jwks_file, not both")
+		}
+		if jwtConfig.Issuer == "" || jwtConfig.Audience == "" {
+			return fmt.Errorf("auth jwt requires issuer and audience")
+		}
+
+		jwks := auth.NewJWKSFromFile(jwtConfig.JWKSFile, jwtConfig.RefreshInterval)
+		if jwtConfig.JWKSURL != "" {
+			jwks = auth.NewJWKSFromURL(jwtConfig.JWKSURL, jwtConfig.RefreshInterval)
+		}
+		authenticators = append(authenticators, auth.NewJWTAuthenticator(jwks, auth.JWTOptions{
+			Issuer:   jwtConfig.Issuer,
+			Audience: jwtConfig.Audience,
+			Leeway:   jwtConfig.Leeway,
+		}))
+	}
+	if p.Users != nil {
+		authenticators = append(authenticators, p.Users)
+	}
+	p.Authenticator = auth.NewChainAuthenticator(authenticators...)
+
+	tlsConfig := config.Server.TLS
+	if tlsConfig.Enabled && tlsConfig.ClientCAFile != "" && len(tlsConfig.ClientPrincipals) > 0 {
+		certificates := make([]auth.ClientCertificate, 0, len(tlsConfig.ClientPrincipals))
+		for _, client := range tlsConfig.ClientPrincipals {
+			if client.CertificateSubject == "" || client.Subject == "" {
+				return fmt.Errorf("tls client principals require certificate_subject and subject")
+			}
+			if client.Tenant != "" {
+				if err := tenant.Validate(client.Tenant); err != nil {
+					return fmt.Errorf("tls client principal %s: %w", client.Subject, err)
+				}
+			}
+			certificates = append(certificates, auth.ClientCertificate{
+				Subject: client.CertificateSubject,
+				Principal: auth.Principal{
+					Subject: client.Subject,
+					Name:    client.Name,
+					Roles:   client.Roles,
+					Scopes:  client.Scopes,
+					Tenant:  client.Tenant,
+				},
+			})
+		}
+		p.ClientCertificates = auth.NewCertificateAuthenticator(certificates)
+	}
+
+	fmt.Printf("✅ Authentication initialized\n")
+	fmt.Printf("   - Static tokens: %d\n", len(tokens))
+	fmt.Printf("   - API keys: ✓ (Authorization: ApiKey)\n")
+	if jwtConfig.JWKSURL != "" || jwtConfig.JWKSFile != "" {
+		fmt.Printf("   - JWT: ✓ (iss %s, aud %s)\n", jwtConfig.Issuer, jwtConfig.Audience)
+	}
+	if p.ClientCertificates != nil {
+		fmt.Printf("   - Client certificates: %d (mTLS)\n", len(tlsConfig.ClientPrincipals))
+	}
+	if p.Users != nil {
+		fmt.Printf("   - User accounts: ✓ (POST /api/v1/auth/login)\n")
+	}
+
+	return nil
+}
+
+// FASE 7: LÍMITES DE PETICIONES
+func (p *Providers) initRateLimit(ctx context.Context, config *Config) error {
+	if !config.RateLimit.Enabled {
+		return nil
+	}
+
+	switch config.RateLimit.Key {
+	case "", "principal", "tenant":
+	default:
+		return fmt.Errorf("rate_limit key must be principal or tenant, got %q", config.RateLimit.Key)
+	}
+	for class := range config.RateLimit.Classes {
+		switch class {
+		case taskhttp.RouteClassRead, taskhttp.RouteClassWrite, taskhttp.RouteClassBulk, taskhttp.RouteClassAuth:
+		default:
+			return fmt.Errorf("unknown rate_limit class %q", class)
+		}
+	}
+
+	store := config.RateLimit.Store
+	if store == "" {
+		store = "memory"
+	}
+	switch store {
+	case "memory":
+		p.RateLimiter = ratelimit.NewMemoryLimiter()
+	case "mongo":
+		limiter := ratelimit.NewMongoLimiter(p.MongoClient.Database(config.Mongo.Database))
+		if err := limiter.EnsureIndexes(ctx); err != nil {
+			return err
+		}
+		p.RateLimiter = limiter
+	default:
+		return fmt.Errorf("rate_limit store must be memory or mongo, got %q", store)
+	}
+
+	fmt.Printf("✅ Rate limiting initialized\n")
+	fmt.Printf("   - Store: %s\n", store)
+	fmt.Printf("   - Classes: %d\n", len(config.RateLimit.Classes))
 
 	return nil
 }
@@ -2,11 +2,16 @@ package bootstrap
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net/http"
 	"time"
 
 	taskhttp "github.com/yebrai/go-tasks-microservice/internal/task/http"
+	"github.com/yebrai/go-tasks-microservice/internal/task/retention"
+	"github.com/yebrai/go-tasks-microservice/internal/task/syncer"
+	"github.com/yebrai/go-tasks-microservice/pkg/certs"
+	"github.com/yebrai/go-tasks-microservice/pkg/ratelimit"
 	"github.com/yebrai/go-tasks-microservice/pkg/runner"
 )
 
@@ -15,6 +20,8 @@ type Service struct {
 	config    *Config
 	server    *http.Server
 	providers *Providers
+	// tls certificados del servidor; nil sirve HTTP sin cifrar
+	tls *certs.Reloader
 }
 
 // NewService crea una nueva instancia del servicio
@@ -46,7 +53,10 @@ func (s *Service) Run(ctx context.Context, config runner.Config) error {
 		return fmt.Errorf("failed to setup HTTP server: %w", err)
 	}
 
-	// 4. Ejecutar servidor
+	// 4. Iniciar la purga de la papelera
+	s.startRetention(ctx)
+
+	// 5. Ejecutar servidor
 	return s.serve(ctx)
 }
 
@@ -67,6 +77,33 @@ func (s *Service) configure(config runner.Config) error {
 	if s.config.Mongo.Database == "" {
 		return fmt.Errorf("mongo database name is required")
 	}
+	jwtEnabled := s.config.Auth.JWT.JWKSURL != "" || s.config.Auth.JWT.JWKSFile != ""
+	if s.config.Auth.Enabled && len(s.config.Auth.Tokens) == 0 && !jwtEnabled && !s.config.Users.Enabled {
+		return fmt.Errorf("auth is enabled but no tokens, JWT or user accounts are configured")
+	}
+	if s.config.Users.Enabled && !s.config.Auth.Enabled {
+		return fmt.Errorf("user accounts require auth to be enabled")
+	}
+	if _, err := syncer.ParsePolicy(s.config.Sync.ConflictPolicy); err != nil {
+		return err
+	}
+	if s.config.Trash.RetentionDays < 0 {
+		return fmt.Errorf("trash retention days cannot be negative")
+	}
+	if s.config.Batch.MaxOperations < 0 {
+		return fmt.Errorf("batch max operations cannot be negative")
+	}
+	if _, err := clientAuthType(s.config.Server.TLS.ClientAuth); err != nil {
+		return err
+	}
+	if tlsConfig := s.config.Server.TLS; tlsConfig.Enabled {
+		if tlsConfig.CertFile == "" || tlsConfig.KeyFile == "" {
+			return fmt.Errorf("TLS requires cert_file and key_file")
+		}
+		if len(tlsConfig.ClientPrincipals) > 0 && tlsConfig.ClientCAFile == "" {
+			return fmt.Errorf("TLS client principals require client_ca_file")
+		}
+	}
 
 	return nil
 }
@@ -87,22 +124,146 @@ func (s *Service) initProviders(ctx context.Context) (*Providers, error) {
 
 // setupHTTPServer configura el servidor HTTP con todos los handlers
 func (s *Service) setupHTTPServer() error {
+	conflictPolicy, err := syncer.ParsePolicy(s.config.Sync.ConflictPolicy)
+	if err != nil {
+		return err
+	}
+
+	serverConfig := s.config.Server
+	if serverConfig.TLS.Enabled {
+		reloader, err := certs.NewReloader(serverConfig.TLS.CertFile, serverConfig.TLS.KeyFile, serverConfig.TLS.ClientCAFile, serverConfig.TLS.ReloadInterval)
+		if err != nil {
+			return err
+		}
+		s.tls = reloader
+	}
+	cors := taskhttp.CORSOptions{
+		AllowedOrigins:   serverConfig.CORS.AllowedOrigins,
+		AllowedMethods:   serverConfig.CORS.AllowedMethods,
+		AllowedHeaders:   serverConfig.CORS.AllowedHeaders,
+		ExposedHeaders:   serverConfig.CORS.ExposedHeaders,
+		AllowCredentials: serverConfig.CORS.AllowCredentials,
+		MaxAge:           serverConfig.CORS.MaxAge,
+	}
+	securityHeaders := taskhttp.SecurityHeadersOptions{
+		HSTSMaxAge:            serverConfig.SecurityHeaders.HSTSMaxAge,
+		HSTSIncludeSubdomains: serverConfig.SecurityHeaders.HSTSIncludeSubdomains,
+	}
+
 	// Crear servidor HTTP con todas las dependencias inyectadas
-	httpServer := taskhttp.NewServer(s.providers.CommandBus, s.providers.TaskRepository, s.providers.EventBus)
+	httpServer := taskhttp.NewServer(
+		s.providers.CommandBus,
+		s.providers.TaskRepository,
+		s.providers.EventBus,
+		s.providers.PresenceRegistry,
+		taskhttp.Options{
+			Authenticator:      s.providers.Authenticator,
+			Tickets:            s.providers.TicketStore,
+			ClientCertificates: s.providers.ClientCertificates,
+			TLS:                s.tls,
+			AllowedOrigins:     s.config.WebSocket.AllowedOrigins,
+			ConflictPolicy:     conflictPolicy,
+			Idempotency:        s.providers.IdempotencyStore,
+			Jobs:               s.providers.JobStore,
+			APIKeys:            s.providers.APIKeys,
+			Audit:              s.providers.AuditLog,
+			Privacy:            s.providers.Privacy,
+			Users:              s.providers.Users,
+			Policy:             s.providers.Policy,
+			MultiTenant:        s.config.Tenancy.Enabled,
+			SelectableTenants:  s.config.Tenancy.SelectableTenants,
+			RateLimit:          s.rateLimitOptions(),
+			CORS:               cors,
+			SecurityHeaders:    securityHeaders,
+			MaxBodyBytes:       serverConfig.MaxBodyBytes,
+			RouteMaxBodyBytes:  serverConfig.RouteMaxBodyBytes,
+			MaxBatchOperations: s.config.Batch.MaxOperations,
+		},
+	)
 
 	// Configurar servidor HTTP con timeouts apropiados
 	s.server = &http.Server{
-		Addr:         s.config.Server.Address,
-		Handler:      httpServer.Handler(),
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		Addr:              serverConfig.Address,
+		Handler:           httpServer.Handler(),
+		ReadTimeout:       durationOr(serverConfig.ReadTimeout, 15*time.Second),
+		ReadHeaderTimeout: durationOr(serverConfig.ReadHeaderTimeout, 5*time.Second),
+		WriteTimeout:      durationOr(serverConfig.WriteTimeout, 15*time.Second),
+		IdleTimeout:       durationOr(serverConfig.IdleTimeout, 60*time.Second),
+	}
+	if s.tls != nil {
+		clientAuth, _ := clientAuthType(serverConfig.TLS.ClientAuth)
+		s.server.TLSConfig = s.tls.TLSConfig(clientAuth)
 	}
 
 	fmt.Printf("✅ HTTP server configured on %s\n", s.config.Server.Address)
+	if s.tls != nil {
+		status := s.tls.Status()
+		fmt.Printf("   - TLS: ✓ (%s, expires %s)\n", status.Subject, status.NotAfter.Format(time.RFC3339))
+		if serverConfig.TLS.ClientCAFile != "" {
+			fmt.Printf("   - mTLS: ✓ (client certificates %s)\n", clientAuthName(serverConfig.TLS.ClientAuth))
+		}
+	}
 	return nil
 }
 
+// clientAuthType traduce server.tls.client_auth; vacío equivale a "require"
+func clientAuthType(name string) (tls.ClientAuthType, error) {
+	switch clientAuthName(name) {
+	case "require":
+		return tls.RequireAndVerifyClientCert, nil
+	case "optional":
+		return tls.VerifyClientCertIfGiven, nil
+	default:
+		return tls.NoClientCert, fmt.Errorf("TLS client_auth must be require or optional, got %q", name)
+	}
+}
+
+// clientAuthName nombre efectivo de server.tls.client_auth
+func clientAuthName(name string) string {
+	if name == "" {
+		return "require"
+	}
+	return name
+}
+
+// durationOr retorna d o, si no es positiva, def
+func durationOr(d, def time.Duration) time.Duration {
+	if d <= 0 {
+		return def
+	}
+	return d
+}
+
+// rateLimitOptions traduce la configuración de los límites de peticiones
+func (s *Service) rateLimitOptions() taskhttp.RateLimitOptions {
+	classes := make(map[string]ratelimit.Limit, len(s.config.RateLimit.Classes))
+	for class, limit := range s.config.RateLimit.Classes {
+		classes[class] = ratelimit.Limit{Requests: limit.Requests, Period: limit.Period, Burst: limit.Burst}
+	}
+
+	return taskhttp.RateLimitOptions{
+		Limiter:  s.providers.RateLimiter,
+		Classes:  classes,
+		ByTenant: s.config.RateLimit.Key == "tenant",
+	}
+}
+
+// startRetention lanza el job que purga las tareas archivadas tras el
+// periodo de retención
+func (s *Service) startRetention(ctx context.Context) {
+	if s.config.Trash.RetentionDays == 0 {
+		fmt.Printf("⚠️  Trash retention disabled - archived tasks are kept until purged\n")
+		return
+	}
+
+	retentionPeriod := time.Duration(s.config.Trash.RetentionDays) * 24 * time.Hour
+	purger := retention.NewPurger(s.providers.CommandBus, s.providers.TaskRepository, retentionPeriod, s.config.Trash.PurgeInterval)
+	go purger.Run(ctx)
+
+	fmt.Printf("✅ Trash retention started\n")
+	fmt.Printf("   - Retention: %d days\n", s.config.Trash.RetentionDays)
+}
+
 // serve inicia el servidor HTTP y maneja el ciclo de vida
 func (s *Service) serve(ctx context.Context) error {
 	errChan := make(chan error, 1)
@@ -113,12 +274,40 @@ func (s *Service) serve(ctx context.Context) error {
 		fmt.Printf("📋 Available endpoints:\n")
 		fmt.Printf("   - GET  /health\n")
 		fmt.Printf("   - GET  /ws/events (WebSocket)\n")
+		fmt.Printf("   - GET  /api/v1/openapi.json\n")
+		fmt.Printf("   - GET  /api/v1/docs\n")
+		fmt.Printf("   - POST /api/v1/ws/tickets\n")
 		fmt.Printf("   - GET  /api/v1/tasks\n")
 		fmt.Printf("   - POST /api/v1/tasks\n")
+		fmt.Printf("   - GET  /api/v1/tasks/export\n")
+		fmt.Printf("   - POST /api/v1/tasks/bulk/transition\n")
+		fmt.Printf("   - POST /api/v1/tasks/bulk/update\n")
+		fmt.Printf("   - POST /api/v1/tasks/import\n")
 		fmt.Printf("   - GET  /api/v1/tasks/:id\n")
 		fmt.Printf("   - PUT  /api/v1/tasks/:id\n")
+		fmt.Printf("   - PATCH /api/v1/tasks/:id\n")
+		fmt.Printf("   - DELETE /api/v1/tasks/:id\n")
+		fmt.Printf("   - GET  /api/v1/trash\n")
+		fmt.Printf("   - POST /api/v1/trash/:id/restore\n")
+		fmt.Printf("   - DELETE /api/v1/trash/:id\n")
+		fmt.Printf("   - GET  /api/v1/changes\n")
+		fmt.Printf("   - POST /api/v1/sync\n")
+		fmt.Printf("   - POST /api/v1/batch\n")
+		fmt.Printf("   - GET  /api/v1/jobs/:id\n")
+		fmt.Printf("   - GET|POST /api/v1/admin/api-keys\n")
+		fmt.Printf("   - DELETE /api/v1/admin/api-keys/:id\n")
+		fmt.Printf("   - POST /api/v1/auth/{register,login,refresh,logout,revoke}\n")
+		fmt.Printf("   - POST /api/v1/auth/password-reset[/confirm]\n")
 
-		if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if s.tls != nil {
+			// Los certificados los aporta TLSConfig y se recargan en caliente
+			go s.tls.Run(ctx)
+			err = s.server.ListenAndServeTLS("", "")
+		} else {
+			err = s.server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			errChan <- fmt.Errorf("HTTP server failed: %w", err)
 		}
 	}()
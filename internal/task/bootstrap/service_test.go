@@ -1,12 +1,8 @@
 package bootstrap
 
 import (
-	"context"
 	"net/http"
 	"testing"
-	"time"
-
-	"github.com/yebrai/go-tasks-microservice/pkg/runner"
 )
 
 // MockConfig implementa runner.Config para testing
@@ -0,0 +1,71 @@
+package bulk
+
+import (
+	"time"
+
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+	"github.com/yebrai/go-tasks-microservice/pkg/cqrs"
+)
+
+const BulkTransitionCommandType cqrs.CommandType = "task.command.bulk_transition"
+const BulkUpdateCommandType cqrs.CommandType = "task.command.bulk_update"
+
+// Transition estado de destino de un BulkTransitionCommand
+type Transition string
+
+const (
+	TransitionComplete Transition = "complete"
+	TransitionCancel   Transition = "cancel"
+	// TransitionArchive mueve las tareas a la papelera
+	TransitionArchive Transition = "archive"
+)
+
+// Command comando masivo cuyo progreso se registra en un job
+type Command interface {
+	cqrs.Command
+	// WithJob retorna el comando asociado al job indicado
+	WithJob(jobID string) Command
+}
+
+// BulkTransitionCommand comando para cambiar el estado de todas las tareas
+// que cumplen el filtro
+type BulkTransitionCommand struct {
+	Filter     task.Filter
+	Transition Transition
+	// JobID job donde se registra el progreso; vacío no lo registra
+	JobID string
+}
+
+// Type implementa la interfaz Command
+func (c BulkTransitionCommand) Type() cqrs.CommandType {
+	return BulkTransitionCommandType
+}
+
+// WithJob implementa la interfaz Command
+func (c BulkTransitionCommand) WithJob(jobID string) Command {
+	c.JobID = jobID
+	return c
+}
+
+// BulkUpdateCommand comando para modificar los datos de todas las tareas
+// que cumplen el filtro. Los campos nil no se modifican
+type BulkUpdateCommand struct {
+	Filter       task.Filter
+	Title        *string
+	Description  *string
+	DueDate      *time.Time
+	ClearDueDate bool
+	// JobID job donde se registra el progreso; vacío no lo registra
+	JobID string
+}
+
+// Type implementa la interfaz Command
+func (c BulkUpdateCommand) Type() cqrs.CommandType {
+	return BulkUpdateCommandType
+}
+
+// WithJob implementa la interfaz Command
+func (c BulkUpdateCommand) WithJob(jobID string) Command {
+	c.JobID = jobID
+	return c
+}
@@ -0,0 +1,179 @@
+package bulk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+	"github.com/yebrai/go-tasks-microservice/internal/task/creator"
+	"github.com/yebrai/go-tasks-microservice/pkg/cqrs"
+)
+
+// ChunkSize tareas que se leen y procesan en cada paso
+const ChunkSize = 100
+
+// errSkip la tarea ya está en el estado pedido
+var errSkip = errors.New("task skipped")
+
+// processor recorre por páginas las tareas de un filtro y despacha un
+// comando por tarea, de modo que cada una emite sus propios eventos
+type processor struct {
+	repository task.Repository
+	commandBus cqrs.CommandBus
+	jobs       JobStore
+}
+
+// run procesa las tareas que cumplen el filtro registrando el progreso en
+// el job, si lo hay
+func (p *processor) run(ctx context.Context, jobID string, filter task.Filter, commandFor func(t *task.Task) (cqrs.Command, error)) error {
+	total, err := p.repository.CountMatching(ctx, filter)
+	if err != nil {
+		return p.fail(jobID, err)
+	}
+	p.update(jobID, func(job *Job) {
+		job.Start(total)
+	})
+
+	afterID := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return p.fail(jobID, err)
+		}
+
+		chunk, err := p.repository.FindMatching(ctx, filter, afterID, ChunkSize)
+		if err != nil {
+			return p.fail(jobID, err)
+		}
+
+		for _, t := range chunk {
+			err := p.apply(ctx, t, commandFor)
+			p.update(jobID, func(job *Job) {
+				job.Processed++
+				switch {
+				case err == nil:
+					job.Succeeded++
+				case errors.Is(err, errSkip):
+					job.Skipped++
+				default:
+					job.RecordError(JobError{TaskID: t.ID, Error: err.Error()})
+				}
+			})
+		}
+
+		if len(chunk) < ChunkSize {
+			break
+		}
+		afterID = chunk[len(chunk)-1].ID
+	}
+
+	p.update(jobID, func(job *Job) {
+		job.Complete()
+	})
+	return nil
+}
+
+// apply despacha el comando de una tarea
+func (p *processor) apply(ctx context.Context, t *task.Task, commandFor func(t *task.Task) (cqrs.Command, error)) error {
+	cmd, err := commandFor(t)
+	if err != nil {
+		return err
+	}
+	return p.commandBus.Dispatch(ctx, cmd)
+}
+
+// update modifica el job si el comando tiene uno
+func (p *processor) update(jobID string, fn func(job *Job)) {
+	if jobID == "" || p.jobs == nil {
+		return
+	}
+	if err := p.jobs.Update(jobID, fn); err != nil {
+		fmt.Printf("⚠️  Failed to update job %s: %v\n", jobID, err)
+	}
+}
+
+// fail marca el job como fallido y retorna el error
+func (p *processor) fail(jobID string, err error) error {
+	p.update(jobID, func(job *Job) {
+		job.Fail(err)
+	})
+	return fmt.Errorf("bulk command failed: %w", err)
+}
+
+// BulkTransitionCommandHandler maneja el comando BulkTransitionCommand
+type BulkTransitionCommandHandler struct {
+	processor processor
+}
+
+// NewBulkTransitionCommandHandler crea una nueva instancia del handler
+func NewBulkTransitionCommandHandler(
+	repository task.Repository,
+	commandBus cqrs.CommandBus,
+	jobs JobStore,
+) *BulkTransitionCommandHandler {
+	return &BulkTransitionCommandHandler{
+		processor: processor{repository: repository, commandBus: commandBus, jobs: jobs},
+	}
+}
+
+// Handle maneja el comando BulkTransitionCommand
+func (h *BulkTransitionCommandHandler) Handle(ctx context.Context, cmd cqrs.Command) error {
+	transitionCmd, ok := cmd.(BulkTransitionCommand)
+	if !ok {
+		return fmt.Errorf("invalid command type: expected BulkTransitionCommand")
+	}
+
+	return h.processor.run(ctx, transitionCmd.JobID, transitionCmd.Filter, func(t *task.Task) (cqrs.Command, error) {
+		switch transitionCmd.Transition {
+		case TransitionComplete:
+			if t.Status == task.StatusCompleted {
+				return nil, errSkip
+			}
+			return creator.CompleteTaskCommand{ID: t.ID, ExpectedVersion: t.Version}, nil
+		case TransitionCancel:
+			if t.Status == task.StatusCancelled {
+				return nil, errSkip
+			}
+			return creator.CancelTaskCommand{ID: t.ID, ExpectedVersion: t.Version}, nil
+		case TransitionArchive:
+			return creator.ArchiveTaskCommand{ID: t.ID, ExpectedVersion: t.Version}, nil
+		default:
+			return nil, fmt.Errorf("%w: unknown transition %q", task.ErrInvalidTaskData, transitionCmd.Transition)
+		}
+	})
+}
+
+// BulkUpdateCommandHandler maneja el comando BulkUpdateCommand
+type BulkUpdateCommandHandler struct {
+	processor processor
+}
+
+// NewBulkUpdateCommandHandler crea una nueva instancia del handler
+func NewBulkUpdateCommandHandler(
+	repository task.Repository,
+	commandBus cqrs.CommandBus,
+	jobs JobStore,
+) *BulkUpdateCommandHandler {
+	return &BulkUpdateCommandHandler{
+		processor: processor{repository: repository, commandBus: commandBus, jobs: jobs},
+	}
+}
+
+// Handle maneja el comando BulkUpdateCommand
+func (h *BulkUpdateCommandHandler) Handle(ctx context.Context, cmd cqrs.Command) error {
+	updateCmd, ok := cmd.(BulkUpdateCommand)
+	if !ok {
+		return fmt.Errorf("invalid command type: expected BulkUpdateCommand")
+	}
+
+	return h.processor.run(ctx, updateCmd.JobID, updateCmd.Filter, func(t *task.Task) (cqrs.Command, error) {
+		return creator.UpdateTaskCommand{
+			ID:              t.ID,
+			Title:           updateCmd.Title,
+			Description:     updateCmd.Description,
+			DueDate:         updateCmd.DueDate,
+			ClearDueDate:    updateCmd.ClearDueDate,
+			ExpectedVersion: t.Version,
+		}, nil
+	})
+}
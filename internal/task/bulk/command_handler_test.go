@@ -0,0 +1,123 @@
+package bulk
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+	"github.com/yebrai/go-tasks-microservice/internal/task/creator"
+	"github.com/yebrai/go-tasks-microservice/internal/task/tasktest"
+	"github.com/yebrai/go-tasks-microservice/pkg/cqrs/inmem"
+	"github.com/yebrai/go-tasks-microservice/pkg/events"
+	"github.com/yebrai/go-tasks-microservice/pkg/id"
+)
+
+// eventCounter cuenta los eventos por nombre
+type eventCounter struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (c *eventCounter) Handle(_ context.Context, event task.DomainEvent) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counts[event.EventName()]++
+	return nil
+}
+
+func (c *eventCounter) Count(name string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counts[name]
+}
+
+func TestBulkTransition_CompletesOverdueTasksInChunks(t *testing.T) {
+	repository := tasktest.NewRepository()
+	generator := id.NewUniqueIDGenerator()
+
+	past := time.Now().Add(-48 * time.Hour)
+	future := time.Now().Add(48 * time.Hour)
+	const overdue = ChunkSize*2 + 5
+	for i := 0; i < overdue+10; i++ {
+		dueDate := &past
+		if i >= overdue {
+			dueDate = &future
+		}
+		newTask, err := task.NewTask(generator.Generate(), "task", "", dueDate)
+		if err != nil {
+			t.Fatal(err)
+		}
+		repository.Put(newTask)
+	}
+
+	eventBus := events.NewObservableEventBus(events.NewNoOpEventBus())
+	counter := &eventCounter{counts: make(map[string]int)}
+	eventBus.Subscribe(counter)
+
+	bus := inmem.NewCommandBus()
+	jobs := NewMemoryJobStore(DefaultJobRetention)
+	if err := bus.Register(creator.CompleteTaskCommandType, creator.NewCompleteTaskCommandHandler(repository, eventBus)); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Register(BulkTransitionCommandType, NewBulkTransitionCommandHandler(repository, bus, jobs)); err != nil {
+		t.Fatal(err)
+	}
+
+	launcher := NewLauncher(bus, jobs, generator)
+	job, err := launcher.Launch(context.Background(), BulkTransitionCommand{
+		Filter:     task.Filter{Overdue: true},
+		Transition: TransitionComplete,
+	}, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !job.Finished() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Job did not finish: %+v", job)
+		}
+		time.Sleep(10 * time.Millisecond)
+		if job, err = launcher.Job(job.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if job.Status != JobCompleted || job.Total != overdue || job.Succeeded != overdue || job.Failed != 0 || job.Owner != "alice" {
+		t.Errorf("Unexpected job %+v", job)
+	}
+	if got := counter.Count("task.completed"); got != overdue {
+		t.Errorf("Expected %d task.completed events, got %d", overdue, got)
+	}
+
+	completed := 0
+	for _, stored := range repository.Tasks() {
+		if stored.Status == task.StatusCompleted {
+			completed++
+		}
+	}
+	if completed != overdue {
+		t.Errorf("Expected %d completed tasks, got %d", overdue, completed)
+	}
+}
+
+func TestLauncher_FailsJobWithoutHandler(t *testing.T) {
+	jobs := NewMemoryJobStore(DefaultJobRetention)
+	launcher := NewLauncher(inmem.NewCommandBus(), jobs, id.NewUniqueIDGenerator())
+
+	job, err := launcher.Launch(context.Background(), BulkUpdateCommand{}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for !job.Finished() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+		job, _ = launcher.Job(job.ID)
+	}
+	if job.Status != JobFailed || job.Error == "" {
+		t.Errorf("Expected a failed job, got %+v", job)
+	}
+}
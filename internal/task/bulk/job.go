@@ -0,0 +1,176 @@
+package bulk
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// DefaultJobRetention tiempo que se conservan los jobs terminados
+const DefaultJobRetention = time.Hour
+
+// maxJobErrors máximo de errores por tarea que se guardan en un job
+const maxJobErrors = 100
+
+// ErrJobNotFound el job no existe o ya expiró
+var ErrJobNotFound = errors.New("job not found")
+
+// JobStatus estado de un job
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobCompleted JobStatus = "completed"
+	// JobFailed el job se interrumpió; los fallos de tareas individuales no
+	// hacen fallar el job
+	JobFailed JobStatus = "failed"
+)
+
+// JobError fallo al procesar una tarea o una fila de una importación
+type JobError struct {
+	TaskID string `json:"task_id,omitempty"`
+	Row    int    `json:"row,omitempty"`
+	Error  string `json:"error"`
+}
+
+// Job progreso de un comando masivo
+type Job struct {
+	ID     string    `json:"id"`
+	Type   string    `json:"type"`
+	Status JobStatus `json:"status"`
+	// Owner sujeto del principal que lanzó el job
+	Owner string `json:"-"`
+	// Tenant tenant en el que se lanzó el job
+	Tenant string `json:"-"`
+	// Total tareas que cumplían el filtro al empezar
+	Total      int64      `json:"total"`
+	Processed  int64      `json:"processed"`
+	Succeeded  int64      `json:"succeeded"`
+	Skipped    int64      `json:"skipped"`
+	Failed     int64      `json:"failed"`
+	Errors     []JobError `json:"errors,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// Finished indica si el job terminó
+func (j *Job) Finished() bool {
+	return j.Status == JobCompleted || j.Status == JobFailed
+}
+
+// Start marca el job en ejecución con el total de elementos a procesar
+func (j *Job) Start(total int64) {
+	now := time.Now()
+	j.Status = JobRunning
+	j.StartedAt = &now
+	j.Total = total
+}
+
+// Complete marca el job como terminado
+func (j *Job) Complete() {
+	now := time.Now()
+	j.Status = JobCompleted
+	j.FinishedAt = &now
+}
+
+// Fail marca el job como interrumpido por err
+func (j *Job) Fail(err error) {
+	now := time.Now()
+	j.Status = JobFailed
+	j.Error = err.Error()
+	j.FinishedAt = &now
+}
+
+// RecordError cuenta un fallo y guarda su detalle hasta el máximo
+func (j *Job) RecordError(jobErr JobError) {
+	j.Failed++
+	if len(j.Errors) < maxJobErrors {
+		j.Errors = append(j.Errors, jobErr)
+	}
+}
+
+// JobStore almacena el progreso de los jobs
+type JobStore interface {
+	// Create registra un job nuevo en estado queued
+	Create(job *Job) error
+	// Get retorna una copia del job
+	Get(id string) (*Job, error)
+	// Update modifica el job de forma atómica
+	Update(id string, fn func(job *Job)) error
+}
+
+// MemoryJobStore almacén de jobs en memoria. Los jobs solo se pueden
+// consultar en la instancia que los ejecuta
+type MemoryJobStore struct {
+	jobs      map[string]*Job
+	retention time.Duration
+	mu        sync.Mutex
+}
+
+// NewMemoryJobStore crea un almacén que conserva los jobs terminados
+// durante retention
+func NewMemoryJobStore(retention time.Duration) *MemoryJobStore {
+	if retention <= 0 {
+		retention = DefaultJobRetention
+	}
+
+	return &MemoryJobStore{
+		jobs:      make(map[string]*Job),
+		retention: retention,
+	}
+}
+
+// Create implementa JobStore
+func (s *MemoryJobStore) Create(job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.purgeExpired(time.Now())
+	stored := *job
+	s.jobs[job.ID] = &stored
+	return nil
+}
+
+// Get implementa JobStore
+func (s *MemoryJobStore) Get(id string) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	return job.copy(), nil
+}
+
+// Update implementa JobStore
+func (s *MemoryJobStore) Update(id string, fn func(job *Job)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return ErrJobNotFound
+	}
+	fn(job)
+	return nil
+}
+
+// purgeExpired elimina los jobs terminados hace más de retention
+func (s *MemoryJobStore) purgeExpired(now time.Time) {
+	for id, job := range s.jobs {
+		if job.FinishedAt != nil && now.Sub(*job.FinishedAt) > s.retention {
+			delete(s.jobs, id)
+		}
+	}
+}
+
+// copy copia el job para leerlo fuera del lock
+func (j *Job) copy() *Job {
+	c := *j
+	c.Errors = append([]JobError(nil), j.Errors...)
+	return &c
+}
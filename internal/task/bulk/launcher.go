@@ -0,0 +1,69 @@
+package bulk
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/yebrai/go-tasks-microservice/pkg/cqrs"
+	"github.com/yebrai/go-tasks-microservice/pkg/id"
+	"github.com/yebrai/go-tasks-microservice/pkg/tenant"
+)
+
+// Launcher registra el job de un comando masivo y lo despacha en segundo
+// plano, para que las operaciones grandes no dependan de la petición HTTP
+type Launcher struct {
+	commandBus  cqrs.CommandBus
+	jobs        JobStore
+	idGenerator id.Generator
+}
+
+// NewLauncher crea un lanzador de comandos masivos
+func NewLauncher(commandBus cqrs.CommandBus, jobs JobStore, idGenerator id.Generator) *Launcher {
+	return &Launcher{
+		commandBus:  commandBus,
+		jobs:        jobs,
+		idGenerator: idGenerator,
+	}
+}
+
+// Launch crea el job del comando y lo ejecuta. El comando conserva los
+// valores de ctx (principal, request ID, tenant) pero no su cancelación
+func (l *Launcher) Launch(ctx context.Context, cmd Command, owner string) (*Job, error) {
+	job := &Job{
+		ID:        l.idGenerator.Generate(),
+		Type:      string(cmd.Type()),
+		Status:    JobQueued,
+		Owner:     owner,
+		Tenant:    tenant.FromContext(ctx),
+		CreatedAt: time.Now(),
+	}
+	if err := l.jobs.Create(job); err != nil {
+		return nil, fmt.Errorf("failed to create job: %w", err)
+	}
+
+	background := context.WithoutCancel(ctx)
+	go func() {
+		if err := l.commandBus.Dispatch(background, cmd.WithJob(job.ID)); err != nil {
+			fmt.Printf("⚠️  Bulk job %s failed: %v\n", job.ID, err)
+			l.markFailed(job.ID, err)
+		}
+	}()
+
+	return job, nil
+}
+
+// Job retorna el estado de un job
+func (l *Launcher) Job(id string) (*Job, error) {
+	return l.jobs.Get(id)
+}
+
+// markFailed marca como fallido un job que el handler no llegó a terminar,
+// por ejemplo si el comando no tiene handler registrado
+func (l *Launcher) markFailed(jobID string, err error) {
+	_ = l.jobs.Update(jobID, func(job *Job) {
+		if !job.Finished() {
+			job.Fail(err)
+		}
+	})
+}
@@ -8,9 +8,16 @@ import (
 
 const CreateTaskCommandType cqrs.CommandType = "task.command.create"
 const CompleteTaskCommandType cqrs.CommandType = "task.command.complete"
+const UpdateTaskCommandType cqrs.CommandType = "task.command.update"
+const CancelTaskCommandType cqrs.CommandType = "task.command.cancel"
+const ArchiveTaskCommandType cqrs.CommandType = "task.command.archive"
+const RestoreTaskCommandType cqrs.CommandType = "task.command.restore"
+const PurgeTaskCommandType cqrs.CommandType = "task.command.purge"
 
 // CreateTaskCommand comando para crear una nueva tarea
 type CreateTaskCommand struct {
+	// ID opcional generado por el cliente (UUID); vacío para generarlo
+	ID          string
 	Title       string
 	Description string
 	DueDate     *time.Time
@@ -24,9 +31,106 @@ func (c CreateTaskCommand) Type() cqrs.CommandType {
 // CompleteTaskCommand comando para completar una tarea
 type CompleteTaskCommand struct {
 	ID string
+	// ExpectedVersion versión sobre la que se emite el comando; 0 la omite
+	ExpectedVersion int64
 }
 
 // Type implementa la interfaz Command
 func (c CompleteTaskCommand) Type() cqrs.CommandType {
 	return CompleteTaskCommandType
 }
+
+// AggregateID implementa la interfaz AggregateCommand
+func (c CompleteTaskCommand) AggregateID() string {
+	return c.ID
+}
+
+// UpdateTaskCommand comando para modificar los datos de una tarea. Los
+// campos nil no se modifican
+type UpdateTaskCommand struct {
+	ID           string
+	Title        *string
+	Description  *string
+	DueDate      *time.Time
+	ClearDueDate bool
+	// ExpectedVersion versión sobre la que se emite el comando; 0 la omite
+	ExpectedVersion int64
+}
+
+// Type implementa la interfaz Command
+func (c UpdateTaskCommand) Type() cqrs.CommandType {
+	return UpdateTaskCommandType
+}
+
+// AggregateID implementa la interfaz AggregateCommand
+func (c UpdateTaskCommand) AggregateID() string {
+	return c.ID
+}
+
+// CancelTaskCommand comando para cancelar una tarea
+type CancelTaskCommand struct {
+	ID string
+	// ExpectedVersion versión sobre la que se emite el comando; 0 la omite
+	ExpectedVersion int64
+}
+
+// Type implementa la interfaz Command
+func (c CancelTaskCommand) Type() cqrs.CommandType {
+	return CancelTaskCommandType
+}
+
+// AggregateID implementa la interfaz AggregateCommand
+func (c CancelTaskCommand) AggregateID() string {
+	return c.ID
+}
+
+// ArchiveTaskCommand comando para mover una tarea a la papelera
+type ArchiveTaskCommand struct {
+	ID string
+	// ExpectedVersion versión sobre la que se emite el comando; 0 la omite
+	ExpectedVersion int64
+}
+
+// Type implementa la interfaz Command
+func (c ArchiveTaskCommand) Type() cqrs.CommandType {
+	return ArchiveTaskCommandType
+}
+
+// AggregateID implementa la interfaz AggregateCommand
+func (c ArchiveTaskCommand) AggregateID() string {
+	return c.ID
+}
+
+// RestoreTaskCommand comando para sacar una tarea de la papelera
+type RestoreTaskCommand struct {
+	ID string
+	// ExpectedVersion versión sobre la que se emite el comando; 0 la omite
+	ExpectedVersion int64
+}
+
+// Type implementa la interfaz Command
+func (c RestoreTaskCommand) Type() cqrs.CommandType {
+	return RestoreTaskCommandType
+}
+
+// AggregateID implementa la interfaz AggregateCommand
+func (c RestoreTaskCommand) AggregateID() string {
+	return c.ID
+}
+
+// PurgeTaskCommand comando para eliminar definitivamente una tarea de la papelera
+type PurgeTaskCommand struct {
+	ID string
+	// ExpectedVersion versión sobre la que se emite el comando; 0 la omite
+	ExpectedVersion int64
+}
+
+// Type implementa la interfaz Command
+func (c PurgeTaskCommand) Type() cqrs.CommandType {
+	return PurgeTaskCommandType
+}
+
+// AggregateID implementa la interfaz AggregateCommand
+func (c PurgeTaskCommand) AggregateID() string {
+	return c.ID
+}
@@ -5,16 +5,37 @@ import (
 	"fmt"
 
 	"github.com/yebrai/go-tasks-microservice/internal/task"
+	"github.com/yebrai/go-tasks-microservice/pkg/auth"
 	"github.com/yebrai/go-tasks-microservice/pkg/cqrs"
 	"github.com/yebrai/go-tasks-microservice/pkg/events"
 	"github.com/yebrai/go-tasks-microservice/pkg/id"
+	"github.com/yebrai/go-tasks-microservice/pkg/tenant"
 )
 
+// Quotas límites de cada tenant que se comprueban al crear tareas y al
+// sacar tareas pendientes de la papelera
+type Quotas struct {
+	// MaxOpenTasks máximo de tareas pendientes fuera de la papelera; 0 no
+	// limita
+	MaxOpenTasks int64
+	// TenantMaxOpenTasks sustituye MaxOpenTasks para los tenants indicados
+	TenantMaxOpenTasks map[string]int64
+}
+
+// maxOpenTasks cuota de tareas abiertas del tenant
+func (q Quotas) maxOpenTasks(tenantID string) int64 {
+	if limit, ok := q.TenantMaxOpenTasks[tenantID]; ok {
+		return limit
+	}
+	return q.MaxOpenTasks
+}
+
 // ACTUALIZAR STRUCT para incluir EventBus
 type CreateTaskCommandHandler struct {
 	repository  task.Repository
 	idGenerator id.Generator
 	eventBus    events.EventBus
+	quotas      Quotas
 }
 
 // ACTUALIZAR CONSTRUCTOR
@@ -22,11 +43,13 @@ func NewCreateTaskCommandHandler(
 	repository task.Repository,
 	idGenerator id.Generator,
 	eventBus events.EventBus,
+	quotas Quotas,
 ) *CreateTaskCommandHandler {
 	return &CreateTaskCommandHandler{
 		repository:  repository,
 		idGenerator: idGenerator,
 		eventBus:    eventBus, // ASIGNAR
+		quotas:      quotas,
 	}
 }
 
@@ -37,14 +60,25 @@ func (h *CreateTaskCommandHandler) Handle(ctx context.Context, cmd cqrs.Command)
 		return fmt.Errorf("invalid command type: expected CreateTaskCommand")
 	}
 
-	// 1. Generar ID único para la tarea
-	taskID := h.idGenerator.Generate()
+	// 1. Usar el ID aportado por el cliente o generar uno único
+	taskID := createCmd.ID
+	if taskID == "" {
+		taskID = h.idGenerator.Generate()
+	} else if _, err := task.NewID(taskID); err != nil {
+		return fmt.Errorf("invalid task ID: %w", err)
+	}
 
 	// 2. Crear nueva tarea (lógica de dominio)
 	newTask, err := task.NewTask(taskID, createCmd.Title, createCmd.Description, createCmd.DueDate)
 	if err != nil {
 		return fmt.Errorf("failed to create task: %w", err)
 	}
+	if principal, ok := auth.PrincipalFromContext(ctx); ok && principal.Authenticated() {
+		newTask.Owner = principal.Subject
+	}
+	if err := h.quotas.check(ctx, h.repository); err != nil {
+		return err
+	}
 
 	// 3. Persistir la tarea (operación principal)
 	if err := h.repository.Save(ctx, newTask); err != nil {
@@ -61,6 +95,26 @@ func (h *CreateTaskCommandHandler) Handle(ctx context.Context, cmd cqrs.Command)
 	return nil
 }
 
+// check rechaza una tarea abierta más si el tenant del contexto ya tiene su
+// máximo. Escrituras simultáneas pueden superar la cuota por el número de
+// peticiones en vuelo
+func (q Quotas) check(ctx context.Context, repository task.Repository) error {
+	limit := q.maxOpenTasks(tenant.FromContext(ctx))
+	if limit <= 0 {
+		return nil
+	}
+
+	open, err := repository.CountMatching(ctx, task.Filter{Statuses: []task.Status{task.StatusPending}})
+	if err != nil {
+		return fmt.Errorf("failed to count open tasks: %w", err)
+	}
+	if open >= limit {
+		return fmt.Errorf("%w: %d open tasks allowed", task.ErrQuotaExceeded, limit)
+	}
+
+	return nil
+}
+
 // CompleteTaskCommandHandler maneja el comando para completar tareas
 type CompleteTaskCommandHandler struct {
 	repository task.Repository
@@ -98,6 +152,9 @@ func (h *CompleteTaskCommandHandler) Handle(ctx context.Context, cmd cqrs.Comman
 	}
 
 	// 3. Completar la tarea (lógica de dominio)
+	if err := existingTask.CheckVersion(completeCmd.ExpectedVersion); err != nil {
+		return err
+	}
 	if err := existingTask.Complete(); err != nil {
 		return fmt.Errorf("failed to complete task: %w", err)
 	}
@@ -115,3 +172,280 @@ func (h *CompleteTaskCommandHandler) Handle(ctx context.Context, cmd cqrs.Comman
 
 	return nil
 }
+
+// UpdateTaskCommandHandler maneja el comando para modificar tareas
+type UpdateTaskCommandHandler struct {
+	repository task.Repository
+	eventBus   events.EventBus
+}
+
+// NewUpdateTaskCommandHandler crea una nueva instancia del handler
+func NewUpdateTaskCommandHandler(
+	repository task.Repository,
+	eventBus events.EventBus,
+) *UpdateTaskCommandHandler {
+	return &UpdateTaskCommandHandler{
+		repository: repository,
+		eventBus:   eventBus,
+	}
+}
+
+// Handle maneja el comando UpdateTaskCommand
+func (h *UpdateTaskCommandHandler) Handle(ctx context.Context, cmd cqrs.Command) error {
+	updateCmd, ok := cmd.(UpdateTaskCommand)
+	if !ok {
+		return fmt.Errorf("invalid command type: expected UpdateTaskCommand")
+	}
+
+	taskID, err := task.NewID(updateCmd.ID)
+	if err != nil {
+		return fmt.Errorf("invalid task ID: %w", err)
+	}
+
+	existingTask, err := h.repository.FindByID(ctx, string(taskID))
+	if err != nil {
+		return fmt.Errorf("task not found: %w", err)
+	}
+
+	if err := existingTask.CheckVersion(updateCmd.ExpectedVersion); err != nil {
+		return err
+	}
+	if err := existingTask.Update(updateCmd.Title, updateCmd.Description, updateCmd.DueDate, updateCmd.ClearDueDate); err != nil {
+		return fmt.Errorf("failed to update task: %w", err)
+	}
+
+	if err := h.repository.Update(ctx, existingTask); err != nil {
+		return fmt.Errorf("failed to update task: %w", err)
+	}
+
+	event := task.NewTaskUpdatedEvent(existingTask)
+	if err := h.eventBus.Publish(ctx, event); err != nil {
+		fmt.Printf("⚠️  Failed to publish task updated event: %v\n", err)
+	}
+
+	return nil
+}
+
+// CancelTaskCommandHandler maneja el comando para cancelar tareas
+type CancelTaskCommandHandler struct {
+	repository task.Repository
+	eventBus   events.EventBus
+}
+
+// NewCancelTaskCommandHandler crea una nueva instancia del handler
+func NewCancelTaskCommandHandler(
+	repository task.Repository,
+	eventBus events.EventBus,
+) *CancelTaskCommandHandler {
+	return &CancelTaskCommandHandler{
+		repository: repository,
+		eventBus:   eventBus,
+	}
+}
+
+// Handle maneja el comando CancelTaskCommand
+func (h *CancelTaskCommandHandler) Handle(ctx context.Context, cmd cqrs.Command) error {
+	cancelCmd, ok := cmd.(CancelTaskCommand)
+	if !ok {
+		return fmt.Errorf("invalid command type: expected CancelTaskCommand")
+	}
+
+	taskID, err := task.NewID(cancelCmd.ID)
+	if err != nil {
+		return fmt.Errorf("invalid task ID: %w", err)
+	}
+
+	existingTask, err := h.repository.FindByID(ctx, string(taskID))
+	if err != nil {
+		return fmt.Errorf("task not found: %w", err)
+	}
+
+	if err := existingTask.CheckVersion(cancelCmd.ExpectedVersion); err != nil {
+		return err
+	}
+	if err := existingTask.Cancel(); err != nil {
+		return fmt.Errorf("failed to cancel task: %w", err)
+	}
+
+	if err := h.repository.Update(ctx, existingTask); err != nil {
+		return fmt.Errorf("failed to update task: %w", err)
+	}
+
+	event := task.NewTaskCancelledEvent(existingTask)
+	if err := h.eventBus.Publish(ctx, event); err != nil {
+		fmt.Printf("⚠️  Failed to publish task cancelled event: %v\n", err)
+	}
+
+	return nil
+}
+
+// ArchiveTaskCommandHandler maneja el comando para mover tareas a la papelera
+type ArchiveTaskCommandHandler struct {
+	repository task.Repository
+	eventBus   events.EventBus
+}
+
+// NewArchiveTaskCommandHandler crea una nueva instancia del handler
+func NewArchiveTaskCommandHandler(
+	repository task.Repository,
+	eventBus events.EventBus,
+) *ArchiveTaskCommandHandler {
+	return &ArchiveTaskCommandHandler{
+		repository: repository,
+		eventBus:   eventBus,
+	}
+}
+
+// Handle maneja el comando ArchiveTaskCommand
+func (h *ArchiveTaskCommandHandler) Handle(ctx context.Context, cmd cqrs.Command) error {
+	archiveCmd, ok := cmd.(ArchiveTaskCommand)
+	if !ok {
+		return fmt.Errorf("invalid command type: expected ArchiveTaskCommand")
+	}
+
+	taskID, err := task.NewID(archiveCmd.ID)
+	if err != nil {
+		return fmt.Errorf("invalid task ID: %w", err)
+	}
+
+	existingTask, err := h.repository.FindByID(ctx, string(taskID))
+	if err != nil {
+		return fmt.Errorf("task not found: %w", err)
+	}
+
+	if err := existingTask.CheckVersion(archiveCmd.ExpectedVersion); err != nil {
+		return err
+	}
+	if err := existingTask.Archive(); err != nil {
+		return fmt.Errorf("failed to archive task: %w", err)
+	}
+
+	if err := h.repository.Update(ctx, existingTask); err != nil {
+		return fmt.Errorf("failed to update task: %w", err)
+	}
+
+	event := task.NewTaskArchivedEvent(existingTask)
+	if err := h.eventBus.Publish(ctx, event); err != nil {
+		fmt.Printf("⚠️  Failed to publish task archived event: %v\n", err)
+	}
+
+	return nil
+}
+
+// RestoreTaskCommandHandler maneja el comando para sacar tareas de la papelera
+type RestoreTaskCommandHandler struct {
+	repository task.Repository
+	eventBus   events.EventBus
+	quotas     Quotas
+}
+
+// NewRestoreTaskCommandHandler crea una nueva instancia del handler. Las
+// tareas pendientes que salen de la papelera cuentan para la cuota de
+// tareas abiertas igual que las nuevas
+func NewRestoreTaskCommandHandler(
+	repository task.Repository,
+	eventBus events.EventBus,
+	quotas Quotas,
+) *RestoreTaskCommandHandler {
+	return &RestoreTaskCommandHandler{
+		repository: repository,
+		eventBus:   eventBus,
+		quotas:     quotas,
+	}
+}
+
+// Handle maneja el comando RestoreTaskCommand
+func (h *RestoreTaskCommandHandler) Handle(ctx context.Context, cmd cqrs.Command) error {
+	restoreCmd, ok := cmd.(RestoreTaskCommand)
+	if !ok {
+		return fmt.Errorf("invalid command type: expected RestoreTaskCommand")
+	}
+
+	taskID, err := task.NewID(restoreCmd.ID)
+	if err != nil {
+		return fmt.Errorf("invalid task ID: %w", err)
+	}
+
+	existingTask, err := h.repository.FindByID(ctx, string(taskID))
+	if err != nil {
+		return fmt.Errorf("task not found: %w", err)
+	}
+
+	if err := existingTask.CheckVersion(restoreCmd.ExpectedVersion); err != nil {
+		return err
+	}
+	if err := existingTask.Restore(); err != nil {
+		return fmt.Errorf("failed to restore task: %w", err)
+	}
+	if existingTask.Status == task.StatusPending {
+		if err := h.quotas.check(ctx, h.repository); err != nil {
+			return err
+		}
+	}
+
+	if err := h.repository.Update(ctx, existingTask); err != nil {
+		return fmt.Errorf("failed to update task: %w", err)
+	}
+
+	event := task.NewTaskRestoredEvent(existingTask)
+	if err := h.eventBus.Publish(ctx, event); err != nil {
+		fmt.Printf("⚠️  Failed to publish task restored event: %v\n", err)
+	}
+
+	return nil
+}
+
+// PurgeTaskCommandHandler maneja el comando para eliminar definitivamente
+// tareas de la papelera
+type PurgeTaskCommandHandler struct {
+	repository task.Repository
+	eventBus   events.EventBus
+}
+
+// NewPurgeTaskCommandHandler crea una nueva instancia del handler
+func NewPurgeTaskCommandHandler(
+	repository task.Repository,
+	eventBus events.EventBus,
+) *PurgeTaskCommandHandler {
+	return &PurgeTaskCommandHandler{
+		repository: repository,
+		eventBus:   eventBus,
+	}
+}
+
+// Handle maneja el comando PurgeTaskCommand. Solo se eliminan tareas que ya
+// están en la papelera
+func (h *PurgeTaskCommandHandler) Handle(ctx context.Context, cmd cqrs.Command) error {
+	purgeCmd, ok := cmd.(PurgeTaskCommand)
+	if !ok {
+		return fmt.Errorf("invalid command type: expected PurgeTaskCommand")
+	}
+
+	taskID, err := task.NewID(purgeCmd.ID)
+	if err != nil {
+		return fmt.Errorf("invalid task ID: %w", err)
+	}
+
+	existingTask, err := h.repository.FindByID(ctx, string(taskID))
+	if err != nil {
+		return fmt.Errorf("task not found: %w", err)
+	}
+
+	if err := existingTask.CheckVersion(purgeCmd.ExpectedVersion); err != nil {
+		return err
+	}
+	if !existingTask.IsArchived() {
+		return task.ErrTaskNotArchived
+	}
+
+	if err := h.repository.Delete(ctx, existingTask.ID); err != nil {
+		return fmt.Errorf("failed to purge task: %w", err)
+	}
+
+	event := task.NewTaskPurgedEvent(existingTask)
+	if err := h.eventBus.Publish(ctx, event); err != nil {
+		fmt.Printf("⚠️  Failed to publish task purged event: %v\n", err)
+	}
+
+	return nil
+}
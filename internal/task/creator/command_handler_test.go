@@ -0,0 +1,105 @@
+package creator
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+	"github.com/yebrai/go-tasks-microservice/internal/task/tasktest"
+	"github.com/yebrai/go-tasks-microservice/pkg/events"
+	"github.com/yebrai/go-tasks-microservice/pkg/id"
+	"github.com/yebrai/go-tasks-microservice/pkg/tenant"
+)
+
+// tenantRepository cuenta las tareas pendientes de cada tenant. Solo
+// implementa lo que usa CreateTaskCommandHandler
+type tenantRepository struct {
+	task.Repository
+	open map[string]int64
+}
+
+func (r *tenantRepository) Save(ctx context.Context, _ *task.Task) error {
+	r.open[tenant.FromContext(ctx)]++
+	return nil
+}
+
+func (r *tenantRepository) CountMatching(ctx context.Context, filter task.Filter) (int64, error) {
+	if len(filter.Statuses) != 1 || filter.Statuses[0] != task.StatusPending {
+		return 0, errors.New("expected to count pending tasks")
+	}
+	return r.open[tenant.FromContext(ctx)], nil
+}
+
+func TestCreateTaskCommandHandler_OpenTasksQuota(t *testing.T) {
+	quotas := Quotas{MaxOpenTasks: 2, TenantMaxOpenTasks: map[string]int64{"acme": 3, "unlimited": 0}}
+
+	tests := []struct {
+		tenant  string
+		created int
+	}{
+		{tenant.DefaultID, 2},
+		{"acme", 3},
+		{"unlimited", 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tenant, func(t *testing.T) {
+			repository := &tenantRepository{open: make(map[string]int64)}
+			handler := NewCreateTaskCommandHandler(repository, id.NewUniqueIDGenerator(), events.NewNoOpEventBus(), quotas)
+			ctx := tenant.WithID(context.Background(), tt.tenant)
+
+			created := 0
+			for i := 0; i < 5; i++ {
+				err := handler.Handle(ctx, CreateTaskCommand{Title: "Nueva"})
+				if errors.Is(err, task.ErrQuotaExceeded) {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				created++
+			}
+
+			if created != tt.created {
+				t.Errorf("Expected %d tasks before the quota, got %d", tt.created, created)
+			}
+		})
+	}
+}
+
+func TestRestoreTaskCommandHandler_OpenTasksQuota(t *testing.T) {
+	repository := tasktest.NewRepository()
+	eventBus := events.NewNoOpEventBus()
+	quotas := Quotas{MaxOpenTasks: 1}
+	ctx := context.Background()
+
+	create := NewCreateTaskCommandHandler(repository, id.NewUniqueIDGenerator(), eventBus, quotas)
+	archive := NewArchiveTaskCommandHandler(repository, eventBus)
+	restore := NewRestoreTaskCommandHandler(repository, eventBus, quotas)
+	complete := NewCompleteTaskCommandHandler(repository, eventBus)
+
+	const archivedID, openID = "6f1c2a9e-3b4d-4c5e-8f70-1a2b3c4d5e6f", "8f14e45f-ceea-467f-a8f0-2b5c1a2e3d4c"
+	must := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	must(create.Handle(ctx, CreateTaskCommand{ID: archivedID, Title: "Archivada"}))
+	must(archive.Handle(ctx, ArchiveTaskCommand{ID: archivedID}))
+	must(create.Handle(ctx, CreateTaskCommand{ID: openID, Title: "Abierta"}))
+
+	// Archivar, crear otra y restaurar no debe saltarse la cuota
+	if err := restore.Handle(ctx, RestoreTaskCommand{ID: archivedID}); !errors.Is(err, task.ErrQuotaExceeded) {
+		t.Fatalf("Expected ErrQuotaExceeded, got %v", err)
+	}
+	if stored, _ := repository.FindByID(ctx, archivedID); !stored.IsArchived() {
+		t.Errorf("Expected the task to stay in the trash")
+	}
+
+	must(complete.Handle(ctx, CompleteTaskCommand{ID: openID}))
+	if err := restore.Handle(ctx, RestoreTaskCommand{ID: archivedID}); err != nil {
+		t.Errorf("Expected the restore to fit the quota once a task is closed, got %v", err)
+	}
+}
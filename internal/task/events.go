@@ -10,10 +10,86 @@ type DomainEvent interface {
 	OccurredOn() time.Time
 }
 
+// CorrelatedEvent lo implementan los eventos que pueden asociarse al ID de
+// la petición que los originó
+type CorrelatedEvent interface {
+	DomainEvent
+	Correlate(requestID string)
+	CorrelationID() string
+}
+
+// TenantEvent lo implementan los eventos que pertenecen a un tenant
+type TenantEvent interface {
+	DomainEvent
+	AssignTenant(tenantID string)
+	TenantID() string
+}
+
+// AttributedEvent lo implementan los eventos que registran quién los
+// originó
+type AttributedEvent interface {
+	DomainEvent
+	AssignActor(actor Actor)
+	EventActor() *Actor
+}
+
+// OwnedEvent lo implementan los eventos que conocen el propietario de la
+// tarea, para entregarlos solo a quien puede leerla
+type OwnedEvent interface {
+	DomainEvent
+	TaskOwner() string
+}
+
+// Actor principal que originó un evento
+type Actor struct {
+	Subject string `json:",omitempty"`
+	Name    string `json:",omitempty"`
+}
+
 // BaseDomainEvent implementa la funcionalidad común de todos los eventos
 type BaseDomainEvent struct {
 	ID         string
 	OccurredAt time.Time
+	RequestID  string `json:",omitempty"`
+	Tenant     string `json:",omitempty"`
+	Owner      string `json:",omitempty"`
+	// Actor nil en los eventos de procesos internos
+	Actor *Actor `json:",omitempty"`
+}
+
+// Correlate asocia el evento al ID de la petición que lo originó
+func (e *BaseDomainEvent) Correlate(requestID string) {
+	e.RequestID = requestID
+}
+
+// CorrelationID retorna el ID de la petición que originó el evento
+func (e *BaseDomainEvent) CorrelationID() string {
+	return e.RequestID
+}
+
+// AssignTenant asocia el evento al tenant de la tarea
+func (e *BaseDomainEvent) AssignTenant(tenantID string) {
+	e.Tenant = tenantID
+}
+
+// TenantID retorna el tenant de la tarea del evento
+func (e *BaseDomainEvent) TenantID() string {
+	return e.Tenant
+}
+
+// TaskOwner retorna el propietario de la tarea del evento
+func (e *BaseDomainEvent) TaskOwner() string {
+	return e.Owner
+}
+
+// AssignActor asocia el evento al principal que lo originó
+func (e *BaseDomainEvent) AssignActor(actor Actor) {
+	e.Actor = &actor
+}
+
+// EventActor retorna el principal que originó el evento, si se conoce
+func (e *BaseDomainEvent) EventActor() *Actor {
+	return e.Actor
 }
 
 type TaskCreatedEvent struct {
@@ -29,6 +105,7 @@ func NewTaskCreatedEvent(task *Task) *TaskCreatedEvent {
 		BaseDomainEvent: BaseDomainEvent{
 			ID:         task.ID,
 			OccurredAt: time.Now(),
+			Owner:      task.Owner,
 		},
 		TaskID:      task.ID,
 		Title:       task.Title,
@@ -59,6 +136,7 @@ func NewTaskCompletedEvent(task *Task) *TaskCompletedEvent {
 		BaseDomainEvent: BaseDomainEvent{
 			ID:         task.ID,
 			OccurredAt: time.Now(),
+			Owner:      task.Owner,
 		},
 		TaskID: task.ID,
 	}
@@ -86,6 +164,7 @@ func NewTaskCancelledEvent(task *Task) *TaskCancelledEvent {
 		BaseDomainEvent: BaseDomainEvent{
 			ID:         task.ID,
 			OccurredAt: time.Now(),
+			Owner:      task.Owner,
 		},
 		TaskID: task.ID,
 	}
@@ -102,3 +181,123 @@ func (e TaskCancelledEvent) AggregateID() string {
 func (e TaskCancelledEvent) OccurredOn() time.Time {
 	return e.OccurredAt
 }
+
+type TaskUpdatedEvent struct {
+	BaseDomainEvent
+	TaskID      string
+	Title       string
+	Description string
+	DueDate     *time.Time
+}
+
+func NewTaskUpdatedEvent(task *Task) *TaskUpdatedEvent {
+	return &TaskUpdatedEvent{
+		BaseDomainEvent: BaseDomainEvent{
+			ID:         task.ID,
+			OccurredAt: time.Now(),
+			Owner:      task.Owner,
+		},
+		TaskID:      task.ID,
+		Title:       task.Title,
+		Description: task.Description,
+		DueDate:     task.DueDate,
+	}
+}
+
+func (e TaskUpdatedEvent) EventName() string {
+	return "task.updated"
+}
+
+func (e TaskUpdatedEvent) AggregateID() string {
+	return e.TaskID
+}
+
+func (e TaskUpdatedEvent) OccurredOn() time.Time {
+	return e.OccurredAt
+}
+
+type TaskArchivedEvent struct {
+	BaseDomainEvent
+	TaskID     string
+	ArchivedAt time.Time
+}
+
+func NewTaskArchivedEvent(task *Task) *TaskArchivedEvent {
+	return &TaskArchivedEvent{
+		BaseDomainEvent: BaseDomainEvent{
+			ID:         task.ID,
+			OccurredAt: time.Now(),
+			Owner:      task.Owner,
+		},
+		TaskID:     task.ID,
+		ArchivedAt: *task.ArchivedAt,
+	}
+}
+
+func (e TaskArchivedEvent) EventName() string {
+	return "task.archived"
+}
+
+func (e TaskArchivedEvent) AggregateID() string {
+	return e.TaskID
+}
+
+func (e TaskArchivedEvent) OccurredOn() time.Time {
+	return e.OccurredAt
+}
+
+type TaskRestoredEvent struct {
+	BaseDomainEvent
+	TaskID string
+}
+
+func NewTaskRestoredEvent(task *Task) *TaskRestoredEvent {
+	return &TaskRestoredEvent{
+		BaseDomainEvent: BaseDomainEvent{
+			ID:         task.ID,
+			OccurredAt: time.Now(),
+			Owner:      task.Owner,
+		},
+		TaskID: task.ID,
+	}
+}
+
+func (e TaskRestoredEvent) EventName() string {
+	return "task.restored"
+}
+
+func (e TaskRestoredEvent) AggregateID() string {
+	return e.TaskID
+}
+
+func (e TaskRestoredEvent) OccurredOn() time.Time {
+	return e.OccurredAt
+}
+
+type TaskPurgedEvent struct {
+	BaseDomainEvent
+	TaskID string
+}
+
+func NewTaskPurgedEvent(task *Task) *TaskPurgedEvent {
+	return &TaskPurgedEvent{
+		BaseDomainEvent: BaseDomainEvent{
+			ID:         task.ID,
+			OccurredAt: time.Now(),
+			Owner:      task.Owner,
+		},
+		TaskID: task.ID,
+	}
+}
+
+func (e TaskPurgedEvent) EventName() string {
+	return "task.purged"
+}
+
+func (e TaskPurgedEvent) AggregateID() string {
+	return e.TaskID
+}
+
+func (e TaskPurgedEvent) OccurredOn() time.Time {
+	return e.OccurredAt
+}
@@ -0,0 +1,69 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+)
+
+// csvHeader columnas de la exportación CSV
+var csvHeader = []string{"id", "title", "description", "status", "due_date", "created_at", "updated_at", "version"}
+
+// csvFormulaPrefixes caracteres con los que una hoja de cálculo interpreta
+// la celda como fórmula
+const csvFormulaPrefixes = "=+-@\t\r"
+
+// csvWriter escribe tareas como CSV según RFC 4180: líneas CRLF y campos
+// entre comillas cuando contienen comas, comillas o saltos de línea
+type csvWriter struct {
+	w *csv.Writer
+}
+
+// NewCSVWriter crea un Writer CSV con cabecera
+func NewCSVWriter(w io.Writer, _ time.Time) (Writer, error) {
+	writer := csv.NewWriter(w)
+	writer.UseCRLF = true
+
+	if err := writer.Write(csvHeader); err != nil {
+		return nil, err
+	}
+	return &csvWriter{w: writer}, nil
+}
+
+// Write implementa Writer
+func (w *csvWriter) Write(t *task.Task) error {
+	dueDate := ""
+	if t.DueDate != nil {
+		dueDate = t.DueDate.Format("2006-01-02")
+	}
+
+	return w.w.Write([]string{
+		t.ID,
+		escapeFormula(t.Title),
+		escapeFormula(t.Description),
+		string(t.Status),
+		dueDate,
+		t.CreatedAt.UTC().Format(time.RFC3339),
+		t.UpdatedAt.UTC().Format(time.RFC3339),
+		strconv.FormatInt(t.Version, 10),
+	})
+}
+
+// escapeFormula antepone una comilla simple a los textos que una hoja de
+// cálculo evaluaría como fórmula, para que se muestren tal cual
+func escapeFormula(value string) string {
+	if value != "" && strings.ContainsRune(csvFormulaPrefixes, rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// Close implementa Writer
+func (w *csvWriter) Close() error {
+	w.w.Flush()
+	return w.w.Error()
+}
@@ -0,0 +1,69 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+)
+
+// Writer escribe tareas en un formato de exportación
+type Writer interface {
+	// Write escribe una tarea
+	Write(t *task.Task) error
+	// Close escribe el pie del formato y vacía los buffers. No cierra el
+	// io.Writer subyacente
+	Close() error
+}
+
+// Format formato de exportación
+type Format struct {
+	Name        string
+	ContentType string
+	Extension   string
+	// New crea un Writer sobre w; now es el instante de la exportación
+	New func(w io.Writer, now time.Time) (Writer, error)
+}
+
+// formats formatos disponibles por nombre
+var formats = map[string]Format{
+	"csv": {
+		Name:        "csv",
+		ContentType: "text/csv; charset=utf-8",
+		Extension:   "csv",
+		New:         NewCSVWriter,
+	},
+	"jsonl": {
+		Name:        "jsonl",
+		ContentType: "application/x-ndjson",
+		Extension:   "jsonl",
+		New:         NewJSONLWriter,
+	},
+	"ics": {
+		Name:        "ics",
+		ContentType: "text/calendar; charset=utf-8",
+		Extension:   "ics",
+		New:         NewICSWriter,
+	},
+}
+
+// Lookup retorna el formato con el nombre indicado
+func Lookup(name string) (Format, error) {
+	format, ok := formats[name]
+	if !ok {
+		return Format{}, fmt.Errorf("unknown export format %q", name)
+	}
+	return format, nil
+}
+
+// Names lista los nombres de los formatos disponibles
+func Names() []string {
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,136 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+)
+
+func newExportTestTask() *task.Task {
+	created := time.Date(2025, 5, 1, 9, 30, 0, 0, time.UTC)
+	due := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	return &task.Task{
+		ID:          "6f1c2a9e-3b4d-4c5e-8f70-1a2b3c4d5e6f",
+		Title:       `Revisar "informe", parte 1`,
+		Description: "línea 1\nlínea 2; con punto y coma",
+		Status:      task.StatusCompleted,
+		CreatedAt:   created,
+		UpdatedAt:   created,
+		DueDate:     &due,
+		Version:     3,
+	}
+}
+
+func export(t *testing.T, name string) string {
+	t.Helper()
+
+	format, err := Lookup(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	writer, err := format.New(&buf, time.Date(2025, 6, 2, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Write(newExportTestTask()); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestCSVWriter_EscapesFields(t *testing.T) {
+	want := "id,title,description,status,due_date,created_at,updated_at,version\r\n" +
+		"6f1c2a9e-3b4d-4c5e-8f70-1a2b3c4d5e6f,\"Revisar \"\"informe\"\", parte 1\",\"línea 1\r\nlínea 2; con punto y coma\"," +
+		"completed,2025-06-01,2025-05-01T09:30:00Z,2025-05-01T09:30:00Z,3\r\n"
+
+	if got := export(t, "csv"); got != want {
+		t.Errorf("Unexpected CSV:\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestICSWriter_MapsVTODO(t *testing.T) {
+	got := export(t, "ics")
+
+	for _, line := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"UID:6f1c2a9e-3b4d-4c5e-8f70-1a2b3c4d5e6f\r\n",
+		"DTSTAMP:20250602T120000Z\r\n",
+		"CREATED:20250501T093000Z\r\n",
+		"SUMMARY:Revisar \"informe\"\\, parte 1\r\n",
+		"DESCRIPTION:línea 1\\nlínea 2\\; con punto y coma\r\n",
+		"DUE;VALUE=DATE:20250601\r\n",
+		"STATUS:COMPLETED\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(got, line) {
+			t.Errorf("Expected %q in:\n%s", line, got)
+		}
+	}
+}
+
+func TestICSWriter_FoldsLongLines(t *testing.T) {
+	var buf bytes.Buffer
+	writer, _ := NewICSWriter(&buf, time.Now())
+	long := newExportTestTask()
+	long.Title = strings.Repeat("ñandú ", 40)
+	if err := writer.Write(long); err != nil {
+		t.Fatal(err)
+	}
+	_ = writer.Close()
+
+	for _, line := range strings.Split(buf.String(), "\r\n") {
+		if len(line) > icsLineLimit {
+			t.Errorf("Line exceeds %d octets: %q", icsLineLimit, line)
+		}
+	}
+
+	unfolded := strings.ReplaceAll(buf.String(), "\r\n ", "")
+	if !strings.Contains(unfolded, "SUMMARY:"+long.Title+"\r\n") {
+		t.Errorf("Folded summary does not unfold to the title:\n%s", buf.String())
+	}
+}
+
+func TestCSVWriter_EscapesFormulas(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"=HYPERLINK(\"http://evil\")", "'=HYPERLINK(\"http://evil\")"},
+		{"+1+2", "'+1+2"},
+		{"-2+3", "'-2+3"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"\tcmd", "'\tcmd"},
+		{"a=b", "a=b"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			var buf bytes.Buffer
+			writer, _ := NewCSVWriter(&buf, time.Now())
+			formula := newExportTestTask()
+			formula.Title, formula.Description = tt.value, tt.value
+			if err := writer.Write(formula); err != nil {
+				t.Fatal(err)
+			}
+			_ = writer.Close()
+
+			records, err := csv.NewReader(&buf).ReadAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := records[1]; got[1] != tt.want || got[2] != tt.want {
+				t.Errorf("Expected %q, got title %q and description %q", tt.want, got[1], got[2])
+			}
+		})
+	}
+}
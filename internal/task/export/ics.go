@@ -0,0 +1,134 @@
+package export
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+)
+
+const (
+	// icsProductID identificador del producto que genera el calendario
+	icsProductID = "-//go-tasks-microservice//Tasks Export//ES"
+	// icsDateTime formato DATE-TIME en UTC de RFC 5545
+	icsDateTime = "20060102T150405Z"
+	// icsDate formato DATE de RFC 5545
+	icsDate = "20060102"
+	// icsLineLimit longitud máxima de una línea en octetos sin el CRLF
+	icsLineLimit = 75
+)
+
+// icsStatus estado VTODO de cada estado de tarea
+var icsStatus = map[task.Status]string{
+	task.StatusPending:   "NEEDS-ACTION",
+	task.StatusCompleted: "COMPLETED",
+	task.StatusCancelled: "CANCELLED",
+}
+
+// icsTextEscaper escapa los valores TEXT de RFC 5545
+var icsTextEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+	"\r", `\n`,
+)
+
+// icsWriter escribe las tareas como VTODO de un VCALENDAR (RFC 5545)
+type icsWriter struct {
+	w     *bufio.Writer
+	stamp string
+	err   error
+}
+
+// NewICSWriter crea un Writer iCalendar
+func NewICSWriter(w io.Writer, now time.Time) (Writer, error) {
+	writer := &icsWriter{
+		w:     bufio.NewWriter(w),
+		stamp: now.UTC().Format(icsDateTime),
+	}
+
+	writer.line("BEGIN:VCALENDAR")
+	writer.line("VERSION:2.0")
+	writer.line("PRODID:" + icsProductID)
+	writer.line("CALSCALE:GREGORIAN")
+	if writer.err != nil {
+		return nil, writer.err
+	}
+	return writer, nil
+}
+
+// Write implementa Writer
+func (w *icsWriter) Write(t *task.Task) error {
+	w.line("BEGIN:VTODO")
+	w.line("UID:" + t.ID)
+	w.line("DTSTAMP:" + w.stamp)
+	w.line("CREATED:" + t.CreatedAt.UTC().Format(icsDateTime))
+	w.line("LAST-MODIFIED:" + t.UpdatedAt.UTC().Format(icsDateTime))
+	w.line("SEQUENCE:" + strconv.FormatInt(t.Version-1, 10))
+	w.line("SUMMARY:" + icsTextEscaper.Replace(t.Title))
+	if t.Description != "" {
+		w.line("DESCRIPTION:" + icsTextEscaper.Replace(t.Description))
+	}
+	if t.DueDate != nil {
+		w.line(icsDue(*t.DueDate))
+	}
+	if status, ok := icsStatus[t.Status]; ok {
+		w.line("STATUS:" + status)
+	}
+	w.line("END:VTODO")
+
+	return w.err
+}
+
+// Close implementa Writer
+func (w *icsWriter) Close() error {
+	w.line("END:VCALENDAR")
+	if w.err != nil {
+		return w.err
+	}
+	return w.w.Flush()
+}
+
+// icsDue formatea la fecha de vencimiento: las fechas sin hora (el formato
+// de entrada YYYY-MM-DD) como DATE y el resto como DATE-TIME en UTC
+func icsDue(due time.Time) string {
+	utc := due.UTC()
+	if utc.Hour() == 0 && utc.Minute() == 0 && utc.Second() == 0 && utc.Nanosecond() == 0 {
+		return "DUE;VALUE=DATE:" + utc.Format(icsDate)
+	}
+	return "DUE:" + utc.Format(icsDateTime)
+}
+
+// line escribe una línea de contenido plegada a 75 octetos sin partir
+// caracteres UTF-8
+func (w *icsWriter) line(content string) {
+	if w.err != nil {
+		return
+	}
+
+	limit := icsLineLimit
+	for len(content) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(content[cut]) {
+			cut--
+		}
+		w.write(content[:cut] + "\r\n ")
+		content = content[cut:]
+		// Las líneas de continuación empiezan con un espacio
+		limit = icsLineLimit - 1
+	}
+	w.write(content + "\r\n")
+}
+
+// write escribe y conserva el primer error
+func (w *icsWriter) write(s string) {
+	if w.err == nil {
+		_, w.err = w.w.WriteString(s)
+	}
+}
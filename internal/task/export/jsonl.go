@@ -0,0 +1,32 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+)
+
+// jsonlWriter escribe una tarea JSON por línea, con la misma representación
+// que el API
+type jsonlWriter struct {
+	encoder *json.Encoder
+}
+
+// NewJSONLWriter crea un Writer JSON Lines
+func NewJSONLWriter(w io.Writer, _ time.Time) (Writer, error) {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	return &jsonlWriter{encoder: encoder}, nil
+}
+
+// Write implementa Writer
+func (w *jsonlWriter) Write(t *task.Task) error {
+	return w.encoder.Encode(t)
+}
+
+// Close implementa Writer
+func (w *jsonlWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,72 @@
+package task
+
+import (
+	"strings"
+	"time"
+)
+
+// Filter criterios de selección de tareas fuera de la papelera. Los
+// criterios vacíos no filtran y los indicados se combinan con AND
+type Filter struct {
+	// Statuses estados admitidos
+	Statuses []Status
+	// DueBefore tareas que vencen antes de este instante
+	DueBefore *time.Time
+	// DueAfter tareas que vencen en este instante o después
+	DueAfter *time.Time
+	// Overdue tareas pendientes cuya fecha de vencimiento ya pasó
+	Overdue bool
+	// Search texto contenido en el título o la descripción, sin distinguir
+	// mayúsculas
+	Search string
+}
+
+// IsEmpty indica si el filtro selecciona todas las tareas
+func (f Filter) IsEmpty() bool {
+	return len(f.Statuses) == 0 && f.DueBefore == nil && f.DueAfter == nil && !f.Overdue && f.Search == ""
+}
+
+// Matches indica si la tarea cumple el filtro en el instante now
+func (f Filter) Matches(t *Task, now time.Time) bool {
+	if t.IsArchived() {
+		return false
+	}
+
+	if len(f.Statuses) > 0 {
+		matched := false
+		for _, status := range f.Statuses {
+			if t.Status == status {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if f.DueBefore != nil && (t.DueDate == nil || !t.DueDate.Before(*f.DueBefore)) {
+		return false
+	}
+	if f.DueAfter != nil && (t.DueDate == nil || t.DueDate.Before(*f.DueAfter)) {
+		return false
+	}
+	if f.Overdue && !t.IsOverdue(now) {
+		return false
+	}
+
+	if f.Search != "" {
+		search := strings.ToLower(f.Search)
+		if !strings.Contains(strings.ToLower(t.Title), search) && !strings.Contains(strings.ToLower(t.Description), search) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// IsOverdue indica si la tarea sigue pendiente después de su fecha de
+// vencimiento
+func (t *Task) IsOverdue(now time.Time) bool {
+	return t.Status == StatusPending && t.DueDate != nil && t.DueDate.Before(now)
+}
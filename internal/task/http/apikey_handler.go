@@ -0,0 +1,97 @@
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yebrai/go-tasks-microservice/internal/apikey"
+)
+
+// APIKeyHandler administra las claves de API de los servicios
+type APIKeyHandler struct {
+	service *apikey.Service
+}
+
+// NewAPIKeyHandler crea una nueva instancia del handler
+func NewAPIKeyHandler(service *apikey.Service) *APIKeyHandler {
+	return &APIKeyHandler{
+		service: service,
+	}
+}
+
+// CreateAPIKeyRequest datos de una clave nueva. Sin owner, la clave
+// pertenece a quien la crea
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required"`
+	Owner     string     `json:"owner,omitempty"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1,dive,oneof=tasks:read tasks:write admin"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// CreateAPIKey crea una clave y retorna su valor, que solo se muestra aquí
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	var req CreateAPIKeyRequest
+	if err := bindJSON(c, &req); err != nil {
+		writeProblem(c, err)
+		return
+	}
+
+	owner := req.Owner
+	if owner == "" {
+		owner = principalSubject(c)
+	}
+	if owner == "" {
+		writeProblem(c, fieldError("owner", "required", "owner is required when authentication is disabled"))
+		return
+	}
+
+	key, plaintext, err := h.service.Create(c.Request.Context(), apikey.CreateRequest{
+		Name:      req.Name,
+		Owner:     owner,
+		Scopes:    req.Scopes,
+		ExpiresAt: req.ExpiresAt,
+	})
+	if err != nil {
+		writeProblem(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"data": gin.H{
+			"api_key": key,
+			"key":     plaintext,
+		},
+		"message": "Store the key now: it cannot be retrieved again",
+		"success": true,
+	})
+}
+
+// ListAPIKeys retorna todas las claves, sin su valor
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	keys, err := h.service.List(c.Request.Context())
+	if err != nil {
+		writeProblem(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    keys,
+		"success": true,
+	})
+}
+
+// RevokeAPIKey revoca una clave
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	key, err := h.service.Revoke(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeProblem(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    key,
+		"message": "API key revoked",
+		"success": true,
+	})
+}
@@ -0,0 +1,88 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yebrai/go-tasks-microservice/internal/audit"
+)
+
+// AuditHandler consulta el registro de auditoría y registra las peticiones
+// rechazadas antes de emitir un comando
+type AuditHandler struct {
+	log *audit.Log
+}
+
+// NewAuditHandler crea una nueva instancia del handler
+func NewAuditHandler(log *audit.Log) *AuditHandler {
+	return &AuditHandler{
+		log: log,
+	}
+}
+
+// GetAudit retorna las entradas del tenant filtradas por
+// ?actor=&task_id=&from=&to=, de la más reciente a la más antigua
+func (h *AuditHandler) GetAudit(c *gin.Context) {
+	query := audit.Query{
+		Actor:  c.Query("actor"),
+		TaskID: c.Query("task_id"),
+	}
+
+	for field, target := range map[string]*time.Time{"from": &query.From, "to": &query.To} {
+		value := c.Query(field)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			abortWithProblem(c, fieldError(field, "date-time", "expected an RFC 3339 timestamp"))
+			return
+		}
+		*target = parsed
+	}
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > audit.MaxLimit {
+			abortWithProblem(c, fieldError("limit", "range", "limit must be between 1 and "+strconv.Itoa(audit.MaxLimit)))
+			return
+		}
+		query.Limit = limit
+	}
+
+	entries, err := h.log.Find(c.Request.Context(), query)
+	if err != nil {
+		writeProblem(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    entries,
+		"success": true,
+	})
+}
+
+// recordDenied registra las peticiones que terminan en 401 o 403 sin haber
+// emitido ningún comando, que ya registra su propio resultado: credenciales
+// inválidas, scopes insuficientes y consultas que la política no permite
+func (h *AuditHandler) recordDenied() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, trail := audit.WithTrail(c.Request.Context())
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		if (status != http.StatusUnauthorized && status != http.StatusForbidden) || trail.Recorded() {
+			return
+		}
+
+		var taskIDs []string
+		if id := c.Param("id"); id != "" && (strings.HasPrefix(c.FullPath(), "/api/v1/tasks/") || strings.HasPrefix(c.FullPath(), "/api/v1/trash/")) {
+			taskIDs = []string{id}
+		}
+		h.log.Record(c.Request.Context(), c.Request.Method+" "+c.FullPath(), taskIDs, audit.OutcomeDenied, http.StatusText(status))
+	}
+}
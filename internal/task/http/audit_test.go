@@ -0,0 +1,123 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/yebrai/go-tasks-microservice/internal/audit"
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+	"github.com/yebrai/go-tasks-microservice/internal/task/authz"
+	"github.com/yebrai/go-tasks-microservice/internal/task/creator"
+	"github.com/yebrai/go-tasks-microservice/internal/task/presence"
+	"github.com/yebrai/go-tasks-microservice/pkg/auth"
+	"github.com/yebrai/go-tasks-microservice/pkg/cqrs"
+	"github.com/yebrai/go-tasks-microservice/pkg/cqrs/inmem"
+	"github.com/yebrai/go-tasks-microservice/pkg/events"
+	"github.com/yebrai/go-tasks-microservice/pkg/id"
+)
+
+func TestAudit_RecordsCommandsAndDeniedRequests(t *testing.T) {
+	const taskID = "6f1c2a9e-3b4d-4c5e-8f70-1a2b3c4d5e6f"
+	log := audit.NewLog(audit.NewMemoryRepository(), id.NewUniqueIDGenerator())
+	eventBus := events.NewObservableEventBus(events.NewNoOpEventBus())
+	bus := inmem.NewCommandBus()
+	bus.Use(log.Middleware())
+	bus.Register(creator.CreateTaskCommandType, cqrs.CommandHandlerFunc(func(ctx context.Context, _ cqrs.Command) error {
+		// El intern puede escribir pero la política no le deja crear tareas
+		if principal, _ := auth.PrincipalFromContext(ctx); principal.Subject == "intern" {
+			return fmt.Errorf("%w: %s", authz.ErrForbidden, authz.ActionCreate)
+		}
+		created, _ := task.NewTask(taskID, "Nueva", "", nil)
+		return eventBus.Publish(ctx, task.NewTaskCreatedEvent(created))
+	}))
+
+	handler := NewServer(bus, nil, eventBus, presence.NewRegistry(), Options{
+		Authenticator: auth.NewStaticTokenAuthenticator([]auth.StaticToken{
+			{Token: "writer", Principal: auth.Principal{Subject: "writer", Scopes: []string{auth.ScopeTasksWrite}}},
+			{Token: "intern", Principal: auth.Principal{Subject: "intern", Scopes: []string{auth.ScopeTasksWrite}}},
+			{Token: "reader", Principal: auth.Principal{Subject: "reader", Scopes: []string{auth.ScopeTasksRead}}},
+			{Token: "auditor", Principal: auth.Principal{Subject: "auditor", Scopes: []string{auth.ScopeAdmin}}},
+		}),
+		Audit: log,
+	}).Handler()
+
+	request := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "audit-test")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for _, step := range []struct {
+		method, path, token, body string
+		want                      int
+	}{
+		{http.MethodPost, "/api/v1/tasks", "writer", `{"title":"Nueva"}`, http.StatusCreated},
+		{http.MethodPost, "/api/v1/tasks", "reader", `{"title":"Nueva"}`, http.StatusForbidden},
+		{http.MethodPost, "/api/v1/tasks", "", `{"title":"Nueva"}`, http.StatusUnauthorized},
+		{http.MethodPost, "/api/v1/tasks", "intern", `{"title":"Nueva"}`, http.StatusForbidden},
+		{http.MethodGet, "/api/v1/audit", "writer", "", http.StatusForbidden},
+	} {
+		if rec := request(step.method, step.path, step.token, step.body); rec.Code != step.want {
+			t.Fatalf("%s %s as %q: expected %d, got %d: %s", step.method, step.path, step.token, step.want, rec.Code, rec.Body.String())
+		}
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"everything", "", []string{
+			"denied GET /api/v1/audit",
+			"denied task.command.create",
+			"denied POST /api/v1/tasks",
+			"denied POST /api/v1/tasks",
+			"succeeded task.command.create",
+		}},
+		{"by actor", "?actor=reader", []string{"denied POST /api/v1/tasks"}},
+		{"by task", "?task_id=" + taskID, []string{"succeeded task.command.create"}},
+		{"denied commands are recorded once", "?actor=intern", []string{"denied task.command.create"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := request(http.MethodGet, "/api/v1/audit"+tt.query, "auditor", "")
+			if rec.Code != http.StatusOK {
+				t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+			}
+			var body struct {
+				Data []audit.Entry `json:"data"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, entry := range body.Data {
+				got = append(got, string(entry.Outcome)+" "+entry.Action)
+				if entry.UserAgent != "audit-test" || entry.SourceIP == "" || entry.RequestID == "" {
+					t.Errorf("Expected the request origin, got %+v", entry)
+				}
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	rec := request(http.MethodGet, "/api/v1/audit?from=yesterday", "auditor", "")
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected an invalid date to be rejected, got %d", rec.Code)
+	}
+}
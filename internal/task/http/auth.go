@@ -0,0 +1,246 @@
+package http
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yebrai/go-tasks-microservice/internal/task/presence"
+	"github.com/yebrai/go-tasks-microservice/pkg/auth"
+	"github.com/yebrai/go-tasks-microservice/pkg/tenant"
+)
+
+// webSocketAuthProtocol subprotocolo con el que los navegadores envían el
+// token en Sec-WebSocket-Protocol: new WebSocket(url, ["bearer", token])
+const webSocketAuthProtocol = "bearer"
+
+// authGate resuelve el principal de las peticiones REST y WebSocket con el
+// mismo autenticador
+type authGate struct {
+	authenticator auth.Authenticator
+	// apiKeys autentica el esquema "ApiKey"; nil lo rechaza
+	apiKeys        auth.Authenticator
+	tickets        *auth.TicketStore
+	allowedOrigins []string
+	// multiTenant resuelve el tenant de cada petición; deshabilitado todas
+	// usan tenant.DefaultID
+	multiTenant bool
+	// selectableTenants tenants que los principales sin tenant pueden
+	// elegir con X-Tenant-ID
+	selectableTenants []string
+	// certificates autentica los certificados de cliente mTLS; nil los
+	// ignora
+	certificates *auth.CertificateAuthenticator
+}
+
+// enabled indica si la autenticación está activa
+func (g *authGate) enabled() bool {
+	return g.authenticator != nil
+}
+
+// fromAuthorization autentica una cabecera Authorization con un token
+// "Bearer" o una clave "ApiKey"
+func (g *authGate) fromAuthorization(ctx context.Context, header string) (*auth.Principal, error) {
+	if !g.enabled() {
+		return auth.Anonymous(), nil
+	}
+
+	scheme, credential, ok := auth.Credentials(header)
+	switch {
+	case !ok:
+		return nil, auth.ErrMissingCredentials
+	case strings.EqualFold(scheme, auth.SchemeBearer):
+		return g.authenticator.Authenticate(ctx, credential)
+	case strings.EqualFold(scheme, auth.SchemeAPIKey) && g.apiKeys != nil:
+		return g.apiKeys.Authenticate(ctx, credential)
+	default:
+		return nil, auth.ErrMissingCredentials
+	}
+}
+
+// fromRequest autentica una petición con la cabecera Authorization o, si no
+// la trae, con el certificado de cliente verificado en el handshake mTLS
+func (g *authGate) fromRequest(r *http.Request) (*auth.Principal, error) {
+	header := r.Header.Get("Authorization")
+	if header == "" && g.enabled() && g.certificates != nil && r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+		return g.certificates.AuthenticateConnection(r.TLS)
+	}
+
+	return g.fromAuthorization(r.Context(), header)
+}
+
+// fromWebSocket autentica una petición de upgrade mediante un ticket de un
+// solo uso, el subprotocolo "bearer", la cabecera Authorization o el
+// certificado de cliente. Retorna
+// las cabeceras que deben acompañar a la respuesta del upgrade
+func (g *authGate) fromWebSocket(r *http.Request) (*auth.Principal, http.Header, error) {
+	if ticket := r.URL.Query().Get("ticket"); ticket != "" {
+		principal, err := g.tickets.Redeem(ticket)
+		return principal, nil, err
+	}
+
+	if token, ok := protocolToken(r); ok {
+		responseHeader := http.Header{"Sec-WebSocket-Protocol": {webSocketAuthProtocol}}
+		if !g.enabled() {
+			return auth.Anonymous(), responseHeader, nil
+		}
+		principal, err := g.authenticator.Authenticate(r.Context(), token)
+		return principal, responseHeader, err
+	}
+
+	principal, err := g.fromRequest(r)
+	return principal, nil, err
+}
+
+// tenantFor resuelve el tenant de una petición del principal indicado a
+// partir del tenant pedido por el cliente
+func (g *authGate) tenantFor(principal *auth.Principal, requested string) (string, error) {
+	if !g.multiTenant {
+		return tenant.DefaultID, nil
+	}
+
+	var own string
+	if principal != nil {
+		own = principal.Tenant
+	}
+	return tenant.Resolve(own, requested, g.selectableTenants)
+}
+
+// checkOrigin acepta peticiones sin Origin (clientes no navegador), las del
+// mismo host y las de los orígenes configurados ("*" acepta cualquiera)
+func (g *authGate) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	for _, allowed := range g.allowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+
+	log.Printf("🚫 WebSocket origin rejected: %s", origin)
+	return false
+}
+
+// protocolToken extrae el token del par ["bearer", token] en
+// Sec-WebSocket-Protocol
+func protocolToken(r *http.Request) (string, bool) {
+	var protocols []string
+	for _, header := range r.Header.Values("Sec-WebSocket-Protocol") {
+		for _, protocol := range strings.Split(header, ",") {
+			protocols = append(protocols, strings.TrimSpace(protocol))
+		}
+	}
+
+	for i := 0; i+1 < len(protocols); i++ {
+		if protocols[i] == webSocketAuthProtocol {
+			return protocols[i+1], protocols[i+1] != ""
+		}
+	}
+
+	return "", false
+}
+
+// withPrincipal asocia el principal al contexto de la petición. Los
+// principales autenticados son además los titulares de las reservas de
+// edición
+func withPrincipal(ctx context.Context, principal *auth.Principal) context.Context {
+	ctx = auth.WithPrincipal(ctx, principal)
+	if principal.Authenticated() {
+		ctx = presence.WithHolder(ctx, principal.Subject)
+	}
+	return ctx
+}
+
+// authMiddleware exige credenciales válidas cuando la autenticación está
+// habilitada: token, clave de API o certificado de cliente
+func (s *Server) authMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, err := s.auth.fromRequest(c.Request)
+		if err != nil {
+			writeProblem(c, err)
+			return
+		}
+
+		c.Request = c.Request.WithContext(withPrincipal(c.Request.Context(), principal))
+		c.Next()
+	}
+}
+
+// tenantMiddleware asocia al contexto el tenant de la petición: el del
+// principal autenticado o, si no tiene, el de la cabecera X-Tenant-ID
+func (s *Server) tenantMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, _ := auth.PrincipalFromContext(c.Request.Context())
+		tenantID, err := s.auth.tenantFor(principal, c.GetHeader(tenant.Header))
+		if err != nil {
+			writeProblem(c, err)
+			return
+		}
+
+		c.Request = c.Request.WithContext(tenant.WithID(c.Request.Context(), tenantID))
+		c.Next()
+	}
+}
+
+// accountTenantMiddleware asocia el tenant por defecto a las rutas anónimas
+// de /auth. El cliente no puede elegir otro: registrarse con X-Tenant-ID
+// daría acceso a las tareas de cualquier tenant
+func (s *Server) accountTenantMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if requested := c.GetHeader(tenant.Header); s.auth.multiTenant && requested != "" && requested != tenant.DefaultID {
+			writeProblem(c, tenant.ErrTenantNotAllowed)
+			return
+		}
+
+		c.Request = c.Request.WithContext(tenant.WithID(c.Request.Context(), tenant.DefaultID))
+		c.Next()
+	}
+}
+
+// requireScope rechaza las peticiones cuyo principal no tiene el scope
+func requireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, _ := auth.PrincipalFromContext(c.Request.Context())
+		if !principal.HasScope(scope) {
+			abortWithProblem(c, NewProblem(http.StatusForbidden, CodeForbidden, "missing scope "+scope))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// issueWebSocketTicket emite un ticket de un solo uso para abrir /ws/events.
+// El ticket queda ligado al tenant de la petición
+func (s *Server) issueWebSocketTicket(c *gin.Context) {
+	principal, _ := auth.PrincipalFromContext(c.Request.Context())
+	if s.auth.multiTenant {
+		bound := *principal
+		bound.Tenant = tenant.FromContext(c.Request.Context())
+		principal = &bound
+	}
+
+	ticket, expiresAt, err := s.auth.tickets.Issue(principal)
+	if err != nil {
+		writeProblem(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"data": gin.H{
+			"ticket":     ticket,
+			"expires_at": expiresAt,
+		},
+		"success": true,
+	})
+}
@@ -0,0 +1,367 @@
+package http
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/yebrai/go-tasks-microservice/internal/apikey"
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+	"github.com/yebrai/go-tasks-microservice/internal/task/authz"
+	"github.com/yebrai/go-tasks-microservice/internal/task/creator"
+	"github.com/yebrai/go-tasks-microservice/internal/task/presence"
+	"github.com/yebrai/go-tasks-microservice/internal/task/tasktest"
+	"github.com/yebrai/go-tasks-microservice/internal/user"
+	"github.com/yebrai/go-tasks-microservice/pkg/auth"
+	"github.com/yebrai/go-tasks-microservice/pkg/cqrs"
+	"github.com/yebrai/go-tasks-microservice/pkg/cqrs/inmem"
+	"github.com/yebrai/go-tasks-microservice/pkg/events"
+	"github.com/yebrai/go-tasks-microservice/pkg/id"
+)
+
+var authTestSecret = []byte("0123456789abcdef0123456789abcdef")
+
+// mintHS256 firma un token con el secreto local de los tests
+func mintHS256(claims map[string]interface{}) string {
+	encode := base64.RawURLEncoding.EncodeToString
+	header, _ := json.Marshal(map[string]string{"alg": "HS256", "typ": "JWT", "kid": "hs-1"})
+	payload, _ := json.Marshal(claims)
+	input := encode(header) + "." + encode(payload)
+
+	mac := hmac.New(sha256.New, authTestSecret)
+	mac.Write([]byte(input))
+	return input + "." + encode(mac.Sum(nil))
+}
+
+// principalRecorder handler de CreateTaskCommand que guarda el principal
+// recibido en el contexto
+type principalRecorder struct {
+	principal *auth.Principal
+}
+
+func (r *principalRecorder) Handle(ctx context.Context, _ cqrs.Command) error {
+	r.principal, _ = auth.PrincipalFromContext(ctx)
+	return nil
+}
+
+func newJWTTestServer(t *testing.T, recorder *principalRecorder) http.Handler {
+	t.Helper()
+
+	jwks, _ := json.Marshal(map[string]interface{}{"keys": []map[string]string{
+		{"kty": "oct", "kid": "hs-1", "k": base64.RawURLEncoding.EncodeToString(authTestSecret)},
+	}})
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, jwks, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	bus := inmem.NewCommandBus()
+	if err := bus.Register(creator.CreateTaskCommandType, recorder); err != nil {
+		t.Fatal(err)
+	}
+
+	authenticator := auth.NewJWTAuthenticator(auth.NewJWKSFromFile(path, time.Minute), auth.JWTOptions{
+		Issuer:   "https://issuer.example.com",
+		Audience: "tasks-api",
+	})
+	return NewServer(bus, nil, events.NewNoOpEventBus(), presence.NewRegistry(), Options{Authenticator: authenticator}).Handler()
+}
+
+func TestAuthMiddleware_JWTPrincipalReachesCommandHandlers(t *testing.T) {
+	recorder := &principalRecorder{}
+	handler := newJWTTestServer(t, recorder)
+
+	claims := map[string]interface{}{
+		"iss":   "https://issuer.example.com",
+		"aud":   "tasks-api",
+		"sub":   "user-1",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"scope": "tasks:write",
+	}
+
+	createTask := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/tasks", strings.NewReader(`{"title":"Nueva"}`))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := createTask(mintHS256(claims)); rec.Code == http.StatusUnauthorized || rec.Code == http.StatusForbidden {
+		t.Fatalf("Expected the token to be accepted, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if recorder.principal == nil || recorder.principal.Subject != "user-1" {
+		t.Errorf("Expected the command handler to receive the principal, got %+v", recorder.principal)
+	}
+
+	claims["exp"] = time.Now().Add(-time.Hour).Unix()
+	for name, token := range map[string]string{"missing": "", "expired": mintHS256(claims)} {
+		rec := createTask(token)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s: expected 401, got %d", name, rec.Code)
+			continue
+		}
+		if got := rec.Header().Get("Content-Type"); got != ProblemContentType {
+			t.Errorf("%s: expected %s, got %s", name, ProblemContentType, got)
+		}
+		if rec.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("%s: expected a WWW-Authenticate challenge", name)
+		}
+	}
+}
+
+func TestAuthMiddleware_APIKeyScopesPerRoute(t *testing.T) {
+	apiKeys := apikey.NewService(apikey.NewMemoryRepository(), id.NewUniqueIDGenerator())
+	ctx := context.Background()
+	_, readOnly, _ := apiKeys.Create(ctx, apikey.CreateRequest{Name: "reports", Owner: "ops", Scopes: []string{auth.ScopeTasksRead}})
+	_, admin, _ := apiKeys.Create(ctx, apikey.CreateRequest{Name: "provisioning", Owner: "ops", Scopes: []string{auth.ScopeAdmin}})
+
+	handler := NewServer(inmem.NewCommandBus(), nil, events.NewNoOpEventBus(), presence.NewRegistry(), Options{
+		Authenticator: auth.NewStaticTokenAuthenticator(nil),
+		APIKeys:       apiKeys,
+	}).Handler()
+
+	request := func(method, path, authorization, body string) int {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", authorization)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	tests := []struct {
+		name          string
+		method, path  string
+		authorization string
+		body          string
+		want          int
+	}{
+		{"read scope cannot manage keys", http.MethodGet, "/api/v1/admin/api-keys", "ApiKey " + readOnly, "", http.StatusForbidden},
+		{"read scope cannot write", http.MethodPost, "/api/v1/tasks", "ApiKey " + readOnly, `{"title":"x"}`, http.StatusForbidden},
+		{"admin lists keys", http.MethodGet, "/api/v1/admin/api-keys", "apikey " + admin, "", http.StatusOK},
+		{"admin creates keys", http.MethodPost, "/api/v1/admin/api-keys", "ApiKey " + admin, `{"name":"ci","scopes":["tasks:write"]}`, http.StatusCreated},
+		{"unknown key", http.MethodGet, "/api/v1/admin/api-keys", "ApiKey " + apikey.KeyPrefix + "nope", "", http.StatusUnauthorized},
+		{"key sent as bearer", http.MethodGet, "/api/v1/admin/api-keys", "Bearer " + admin, "", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := request(tt.method, tt.path, tt.authorization, tt.body); got != tt.want {
+				t.Errorf("Expected %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestAuthMiddleware_APIKeysActForTheirOwner(t *testing.T) {
+	const (
+		adaTask   = "6f1c2a9e-3b4d-4c5e-8f70-1a2b3c4d5e6f"
+		graceTask = "8f14e45f-ceea-467f-a8f0-2b5c1a2e3d4c"
+	)
+
+	apiKeys := apikey.NewService(apikey.NewMemoryRepository(), id.NewUniqueIDGenerator())
+	_, key, err := apiKeys.Create(context.Background(), apikey.CreateRequest{Name: "cron", Owner: "user:ada", Scopes: []string{auth.ScopeTasksWrite}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy, err := authz.NewPolicy([]authz.Rule{
+		{Actions: []authz.Action{authz.ActionCreate}, Roles: []string{authz.RoleMember}},
+		{Actions: []authz.Action{authz.ActionComplete}, Roles: []string{authz.RoleMember}, OwnerOnly: true},
+	}, []string{authz.RoleMember})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repository := tasktest.NewRepository()
+	for taskID, owner := range map[string]string{adaTask: "user:ada", graceTask: "user:grace"} {
+		owned, _ := task.NewTask(taskID, "task", "", nil)
+		owned.Owner = owner
+		repository.Put(owned)
+	}
+	eventBus := events.NewNoOpEventBus()
+	bus := inmem.NewCommandBus()
+	bus.Use(authz.Guard(policy, repository))
+	if err := bus.Register(creator.CreateTaskCommandType, creator.NewCreateTaskCommandHandler(repository, id.NewUniqueIDGenerator(), eventBus, creator.Quotas{})); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Register(creator.CompleteTaskCommandType, creator.NewCompleteTaskCommandHandler(repository, eventBus)); err != nil {
+		t.Fatal(err)
+	}
+	handler := NewServer(bus, repository, eventBus, presence.NewRegistry(), Options{
+		Authenticator: auth.NewStaticTokenAuthenticator(nil),
+		APIKeys:       apiKeys,
+		Policy:        policy,
+	}).Handler()
+
+	request := func(method, path, contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("Authorization", "ApiKey "+key)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := request(http.MethodPost, "/api/v1/tasks", "application/json", `{"title":"nightly"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	for _, created := range repository.Tasks() {
+		if created.Title == "nightly" && created.Owner != "user:ada" {
+			t.Errorf("Expected the key's task to belong to user:ada, got %q", created.Owner)
+		}
+	}
+	if repository.Len() != 3 {
+		t.Fatalf("Expected the key to create one task, got %d tasks", repository.Len())
+	}
+	if rec := request(http.MethodPatch, "/api/v1/tasks/"+adaTask, MergePatchContentType, `{"status":"completed"}`); rec.Code != http.StatusOK {
+		t.Errorf("Expected the key to complete its owner's task, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := request(http.MethodPatch, "/api/v1/tasks/"+graceTask, MergePatchContentType, `{"status":"completed"}`); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 on another owner's task, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestUserAccounts_SessionTokensAuthenticateRESTAndWebSocket(t *testing.T) {
+	recorder := &principalRecorder{}
+	bus := inmem.NewCommandBus()
+	if err := bus.Register(creator.CreateTaskCommandType, recorder); err != nil {
+		t.Fatal(err)
+	}
+
+	users := user.NewService(user.NewMemoryRepository(), user.NewMemorySessionRepository(), user.LogResetNotifier{}, id.NewUniqueIDGenerator(), user.Options{})
+	handler := NewServer(bus, nil, events.NewNoOpEventBus(), presence.NewRegistry(), Options{
+		Authenticator: auth.NewChainAuthenticator(auth.NewStaticTokenAuthenticator(nil), users),
+		Users:         users,
+	}).Handler()
+
+	request := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := request(http.MethodPost, "/api/v1/auth/register", "", `{"email":"ada@example.com","name":"Ada","password":"correct horse"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := request(http.MethodPost, "/api/v1/auth/login", "", `{"email":"ada@example.com","password":"wrong password"}`); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for a wrong password, got %d", rec.Code)
+	}
+
+	rec := request(http.MethodPost, "/api/v1/auth/login", "", `{"email":"ada@example.com","password":"correct horse"}`)
+	var login struct {
+		Data user.Tokens `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &login); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("Expected a login response, got %d: %s", rec.Code, rec.Body.String())
+	}
+	token := login.Data.AccessToken
+
+	if rec := request(http.MethodPost, "/api/v1/tasks", token, `{"title":"Nueva"}`); rec.Code == http.StatusUnauthorized || rec.Code == http.StatusForbidden {
+		t.Fatalf("Expected the access token to be accepted, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if recorder.principal == nil || !strings.HasPrefix(recorder.principal.Subject, "user:") {
+		t.Errorf("Expected the command handler to receive the user principal, got %+v", recorder.principal)
+	}
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	dialer := websocket.Dialer{Subprotocols: []string{webSocketAuthProtocol, token}}
+	conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws/events", nil)
+	if err != nil {
+		t.Fatalf("Expected the WebSocket upgrade to accept the access token: %v", err)
+	}
+	conn.Close()
+
+	if rec := request(http.MethodPost, "/api/v1/auth/logout", token, ""); rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 on logout, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := request(http.MethodPost, "/api/v1/tasks", token, `{"title":"Nueva"}`); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected a logged out token to be rejected, got %d", rec.Code)
+	}
+
+	disabled := NewServer(inmem.NewCommandBus(), nil, events.NewNoOpEventBus(), presence.NewRegistry(), Options{}).Handler()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(`{"email":"ada@example.com","password":"x"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	disabled.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 with user accounts disabled, got %d", rec.Code)
+	}
+}
+
+func TestUserAccounts_OnlyAnAdminGrantsRoles(t *testing.T) {
+	users := user.NewService(user.NewMemoryRepository(), user.NewMemorySessionRepository(), nil, id.NewUniqueIDGenerator(), user.Options{})
+	handler := NewServer(inmem.NewCommandBus(), nil, events.NewNoOpEventBus(), presence.NewRegistry(), Options{
+		Authenticator: auth.NewChainAuthenticator(auth.NewStaticTokenAuthenticator([]auth.StaticToken{
+			{Token: "operator", Principal: auth.Principal{Subject: "operator", Scopes: []string{auth.ScopeAdmin}}},
+		}), users),
+		Users: users,
+	}).Handler()
+
+	request := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := request(http.MethodPost, "/api/v1/auth/register", "", `{"email":"root@example.com","name":"Root","password":"correct horse"}`)
+	var registered struct {
+		Data user.User `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &registered); err != nil || rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = request(http.MethodPost, "/api/v1/auth/login", "", `{"email":"root@example.com","password":"correct horse"}`)
+	var login struct {
+		Data user.Tokens `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &login); err != nil {
+		t.Fatal(err)
+	}
+	token := login.Data.AccessToken
+	rolesPath := "/api/v1/admin/users/" + registered.Data.ID + "/roles"
+
+	if rec := request(http.MethodGet, "/api/v1/audit", token, ""); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected a fresh account not to be admin, got %d", rec.Code)
+	}
+	if rec := request(http.MethodPut, rolesPath, token, `{"roles":["admin"]}`); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected accounts not to grant themselves roles, got %d", rec.Code)
+	}
+	if rec := request(http.MethodPut, "/api/v1/admin/users/missing/roles", "operator", `{"roles":["admin"]}`); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown account, got %d", rec.Code)
+	}
+	if rec := request(http.MethodPut, rolesPath, "operator", `{"roles":["admin"]}`); rec.Code != http.StatusOK {
+		t.Fatalf("Expected the operator to grant admin, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := request(http.MethodGet, "/api/v1/audit", token, ""); rec.Code != http.StatusOK {
+		t.Errorf("Expected the granted account to be admin, got %d", rec.Code)
+	}
+
+	if rec := request(http.MethodPost, "/api/v1/auth/password-reset", "", `{"email":"root@example.com"}`); rec.Code != http.StatusNotImplemented {
+		t.Errorf("Expected 501 without a reset notifier, got %d", rec.Code)
+	}
+}
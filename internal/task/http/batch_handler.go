@@ -0,0 +1,146 @@
+package http
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+	"github.com/yebrai/go-tasks-microservice/internal/task/batch"
+)
+
+// BatchHandler maneja la ejecución de lotes de operaciones
+type BatchHandler struct {
+	service       *batch.Service
+	maxOperations int
+}
+
+// NewBatchHandler crea una nueva instancia del handler
+func NewBatchHandler(service *batch.Service, maxOperations int) *BatchHandler {
+	if maxOperations <= 0 {
+		maxOperations = batch.DefaultMaxOperations
+	}
+
+	return &BatchHandler{
+		service:       service,
+		maxOperations: maxOperations,
+	}
+}
+
+// BatchOperationRequest operación de un lote
+type BatchOperationRequest struct {
+	Op              string  `json:"op" binding:"required,oneof=create update complete cancel delete"`
+	TaskID          string  `json:"task_id"`
+	ExpectedVersion int64   `json:"expected_version"`
+	Title           *string `json:"title,omitempty"`
+	Description     *string `json:"description,omitempty"`
+	DueDate         *string `json:"due_date,omitempty"` // "" elimina la fecha
+}
+
+// BatchRequest lote ordenado de operaciones
+type BatchRequest struct {
+	Atomic     bool                    `json:"atomic"`
+	Operations []BatchOperationRequest `json:"operations" binding:"required,min=1,dive"`
+}
+
+// BatchResult resultado de una operación del lote
+type BatchResult struct {
+	Index  int          `json:"index"`
+	Op     batch.Op     `json:"op"`
+	TaskID string       `json:"task_id,omitempty"`
+	Status batch.Status `json:"status"`
+	Error  *Problem     `json:"error,omitempty"`
+	Task   *task.Task   `json:"task,omitempty"`
+}
+
+// BatchResponse resultados del lote en el orden de las operaciones
+type BatchResponse struct {
+	Atomic     bool          `json:"atomic"`
+	RolledBack bool          `json:"rolled_back"`
+	Succeeded  int           `json:"succeeded"`
+	Failed     int           `json:"failed"`
+	Results    []BatchResult `json:"results"`
+}
+
+// ExecuteBatch aplica un lote de operaciones sobre tareas
+func (h *BatchHandler) ExecuteBatch(c *gin.Context) {
+	var req BatchRequest
+	if err := bindJSON(c, &req); err != nil {
+		writeProblem(c, err)
+		return
+	}
+
+	if len(req.Operations) > h.maxOperations {
+		abortWithProblem(c, NewProblem(http.StatusRequestEntityTooLarge, CodePayloadTooLarge,
+			"a batch accepts at most "+strconv.Itoa(h.maxOperations)+" operations"))
+		return
+	}
+
+	operations := make([]batch.Operation, 0, len(req.Operations))
+	for i, op := range req.Operations {
+		operation := batch.Operation{
+			Op:              batch.Op(op.Op),
+			TaskID:          op.TaskID,
+			ExpectedVersion: op.ExpectedVersion,
+			Title:           op.Title,
+			Description:     op.Description,
+		}
+
+		if op.DueDate != nil {
+			if *op.DueDate == "" {
+				operation.ClearDueDate = true
+			} else {
+				dueDate, err := parseDueDate(*op.DueDate)
+				if err != nil {
+					field := "operations[" + strconv.Itoa(i) + "].due_date"
+					abortWithProblem(c, fieldError(field, "date", "expected format: YYYY-MM-DD"))
+					return
+				}
+				operation.DueDate = dueDate
+			}
+		}
+
+		operations = append(operations, operation)
+	}
+
+	result, err := h.service.Execute(c.Request.Context(), batch.Request{
+		Atomic:     req.Atomic,
+		Operations: operations,
+	})
+	if err != nil {
+		writeProblem(c, err)
+		return
+	}
+
+	response := BatchResponse{
+		Atomic:     req.Atomic,
+		RolledBack: result.RolledBack,
+		Results:    make([]BatchResult, 0, len(result.Results)),
+	}
+	for _, r := range result.Results {
+		item := BatchResult{
+			Index:  r.Index,
+			Op:     r.Op,
+			TaskID: r.TaskID,
+			Status: r.Status,
+			Task:   r.Task,
+		}
+		switch r.Status {
+		case batch.StatusSucceeded:
+			response.Succeeded++
+		case batch.StatusFailed:
+			item.Error = ProblemFromError(r.Err)
+			if item.Error.Status >= http.StatusInternalServerError {
+				log.Printf("❌ batch operation %d (%s) failed: %v", r.Index, r.Op, r.Err)
+			}
+			response.Failed++
+		}
+		response.Results = append(response.Results, item)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    response,
+		"success": true,
+	})
+}
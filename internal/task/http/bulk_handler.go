@@ -0,0 +1,149 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yebrai/go-tasks-microservice/internal/task/authz"
+	"github.com/yebrai/go-tasks-microservice/internal/task/bulk"
+	"github.com/yebrai/go-tasks-microservice/pkg/auth"
+	"github.com/yebrai/go-tasks-microservice/pkg/tenant"
+)
+
+// BulkHandler lanza comandos masivos y expone el progreso de sus jobs
+type BulkHandler struct {
+	launcher *bulk.Launcher
+	policy   *authz.Policy
+}
+
+// NewBulkHandler crea una nueva instancia del handler
+func NewBulkHandler(launcher *bulk.Launcher, policy *authz.Policy) *BulkHandler {
+	return &BulkHandler{
+		launcher: launcher,
+		policy:   policy,
+	}
+}
+
+// BulkTransitionRequest cambio de estado de las tareas que cumplen el filtro
+type BulkTransitionRequest struct {
+	Filter     TaskFilterRequest `json:"filter"`
+	Transition string            `json:"transition" binding:"required,oneof=complete cancel archive"`
+}
+
+// BulkUpdateRequest modificación de las tareas que cumplen el filtro. Los
+// campos ausentes no se modifican
+type BulkUpdateRequest struct {
+	Filter      TaskFilterRequest `json:"filter"`
+	Title       *string           `json:"title,omitempty"`
+	Description *string           `json:"description,omitempty"`
+	DueDate     *string           `json:"due_date,omitempty"` // "" elimina la fecha
+}
+
+// BulkTransition lanza un BulkTransitionCommand y responde con su job
+func (h *BulkHandler) BulkTransition(c *gin.Context) {
+	var req BulkTransitionRequest
+	if err := bindJSON(c, &req); err != nil {
+		writeProblem(c, err)
+		return
+	}
+
+	filter, err := req.Filter.toFilter("filter.")
+	if err != nil {
+		writeProblem(c, err)
+		return
+	}
+
+	h.launch(c, bulk.BulkTransitionCommand{
+		Filter:     filter,
+		Transition: bulk.Transition(req.Transition),
+	})
+}
+
+// BulkUpdate lanza un BulkUpdateCommand y responde con su job
+func (h *BulkHandler) BulkUpdate(c *gin.Context) {
+	var req BulkUpdateRequest
+	if err := bindJSON(c, &req); err != nil {
+		writeProblem(c, err)
+		return
+	}
+
+	filter, err := req.Filter.toFilter("filter.")
+	if err != nil {
+		writeProblem(c, err)
+		return
+	}
+
+	cmd := bulk.BulkUpdateCommand{
+		Filter:      filter,
+		Title:       req.Title,
+		Description: req.Description,
+	}
+	if cmd.Title != nil && *cmd.Title == "" {
+		writeProblem(c, fieldError("title", "required", "title cannot be empty"))
+		return
+	}
+	if req.DueDate != nil {
+		if *req.DueDate == "" {
+			cmd.ClearDueDate = true
+		} else if cmd.DueDate, err = parseDueDate(*req.DueDate); err != nil {
+			writeProblem(c, fieldError("due_date", "date", "expected format: YYYY-MM-DD"))
+			return
+		}
+	}
+	if cmd.Title == nil && cmd.Description == nil && cmd.DueDate == nil && !cmd.ClearDueDate {
+		writeProblem(c, fieldError("", "required", "at least one of title, description or due_date is required"))
+		return
+	}
+
+	h.launch(c, cmd)
+}
+
+// launch crea el job y responde 202 con su ubicación. El permiso para
+// lanzarlo se comprueba aquí, porque el job corre fuera de la petición
+func (h *BulkHandler) launch(c *gin.Context, cmd bulk.Command) {
+	if err := h.policy.Authorize(c.Request.Context(), authz.ActionBulk, nil); err != nil {
+		writeProblem(c, err)
+		return
+	}
+
+	job, err := h.launcher.Launch(c.Request.Context(), cmd, principalSubject(c))
+	if err != nil {
+		writeProblem(c, err)
+		return
+	}
+
+	c.Header("Location", "/api/v1/jobs/"+job.ID)
+	c.JSON(http.StatusAccepted, gin.H{
+		"data":    job,
+		"message": "Bulk command accepted",
+		"success": true,
+	})
+}
+
+// GetJob retorna el progreso de un job. Solo lo consulta quien lo lanzó,
+// desde el mismo tenant
+func (h *BulkHandler) GetJob(c *gin.Context) {
+	job, err := h.launcher.Job(c.Param("id"))
+	if err != nil {
+		writeProblem(c, err)
+		return
+	}
+	if job.Owner != principalSubject(c) || job.Tenant != tenant.FromContext(c.Request.Context()) {
+		writeProblem(c, bulk.ErrJobNotFound)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    job,
+		"success": true,
+	})
+}
+
+// principalSubject retorna el sujeto autenticado, o "" si la autenticación
+// está deshabilitada
+func principalSubject(c *gin.Context) string {
+	if principal, ok := auth.PrincipalFromContext(c.Request.Context()); ok {
+		return principal.Subject
+	}
+	return ""
+}
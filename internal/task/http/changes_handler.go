@@ -0,0 +1,217 @@
+package http
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+	"github.com/yebrai/go-tasks-microservice/internal/task/authz"
+	"github.com/yebrai/go-tasks-microservice/pkg/events"
+)
+
+const (
+	// defaultChangesWait espera por defecto del long-poll
+	defaultChangesWait = 30 * time.Second
+	// maxChangesWait espera máxima aceptada en ?wait=
+	maxChangesWait = 60 * time.Second
+	// changesPageSize máximo de tareas por respuesta
+	changesPageSize = 500
+	// changesPollInterval reconsulta periódica para detectar cambios hechos
+	// por otras instancias del servicio
+	changesPollInterval = 2 * time.Second
+	// syncTokenPrefix versión del formato del token de sincronización
+	syncTokenPrefix = "v1:"
+)
+
+var errInvalidSyncToken = errors.New("invalid sync token")
+
+// changeNotifier despierta a las peticiones en espera cuando se publica un
+// evento de tarea en esta instancia
+type changeNotifier struct {
+	ch chan struct{}
+	mu sync.Mutex
+}
+
+// newChangeNotifier crea un notificador sin esperas pendientes
+func newChangeNotifier() *changeNotifier {
+	return &changeNotifier{ch: make(chan struct{})}
+}
+
+// wait retorna un canal que se cierra en el próximo cambio
+func (n *changeNotifier) wait() <-chan struct{} {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.ch
+}
+
+// Handle implementa events.EventHandler
+func (n *changeNotifier) Handle(_ context.Context, _ task.DomainEvent) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	close(n.ch)
+	n.ch = make(chan struct{})
+	return nil
+}
+
+// ChangesHandler sirve el feed de cambios por long-poll
+type ChangesHandler struct {
+	repository task.Repository
+	policy     *authz.Policy
+	notifier   *changeNotifier
+}
+
+// NewChangesHandler crea el handler y lo suscribe al bus de eventos si éste
+// admite suscriptores locales
+func NewChangesHandler(repository task.Repository, eventBus events.EventBus, policy *authz.Policy) *ChangesHandler {
+	notifier := newChangeNotifier()
+	if subscriber, ok := eventBus.(events.Subscriber); ok {
+		subscriber.Subscribe(notifier)
+	}
+
+	return &ChangesHandler{
+		repository: repository,
+		policy:     policy,
+		notifier:   notifier,
+	}
+}
+
+// ChangesResponse página del feed de cambios
+type ChangesResponse struct {
+	Changes   []*task.Task `json:"changes"`
+	SyncToken string       `json:"sync_token"`
+	HasMore   bool         `json:"has_more"`
+}
+
+// GetChanges retorna las tareas modificadas desde ?since=. Sin token retorna
+// todas las tareas; si no hay cambios espera hasta ?wait= antes de responder
+func (h *ChangesHandler) GetChanges(c *gin.Context) {
+	wait := defaultChangesWait
+	if value := c.Query("wait"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed < 0 {
+			abortWithProblem(c, fieldError("wait", "duration", "expected a duration such as 30s"))
+			return
+		}
+		wait = parsed
+	}
+	if wait > maxChangesWait {
+		wait = maxChangesWait
+	}
+
+	ctx := c.Request.Context()
+
+	since := c.Query("since")
+	if since == "" {
+		h.snapshot(c)
+		return
+	}
+
+	position, err := decodeSyncToken(since)
+	if err != nil {
+		writeProblem(c, err)
+		return
+	}
+
+	// La espera supera el WriteTimeout del servidor
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Now().Add(wait + 10*time.Second))
+
+	deadline := time.NewTimer(wait)
+	defer deadline.Stop()
+	ticker := time.NewTicker(changesPollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Tomar el canal antes de consultar para no perder cambios intermedios
+		changed := h.notifier.wait()
+
+		tasks, last, err := h.repository.FindChangedSince(ctx, position, changesPageSize)
+		if err != nil {
+			writeProblem(c, err)
+			return
+		}
+
+		if len(tasks) > 0 {
+			h.respond(c, tasks, last, len(tasks) == changesPageSize)
+			return
+		}
+
+		select {
+		case <-changed:
+		case <-ticker.C:
+		case <-deadline.C:
+			h.respond(c, []*task.Task{}, position, false)
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// snapshot retorna todas las tareas y el token de la posición actual
+func (h *ChangesHandler) snapshot(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// Leer la posición antes que las tareas: un cambio intermedio se
+	// entregará de nuevo en la siguiente consulta, nunca se pierde
+	position, err := h.repository.CurrentPosition(ctx)
+	if err == nil {
+		var tasks []*task.Task
+		tasks, err = h.repository.FindAll(ctx)
+		if err == nil {
+			if tasks == nil {
+				tasks = []*task.Task{}
+			}
+			h.respond(c, tasks, position, false)
+			return
+		}
+	}
+
+	writeProblem(c, err)
+}
+
+// respond escribe una página del feed con las tareas que el principal
+// puede leer
+func (h *ChangesHandler) respond(c *gin.Context, tasks []*task.Task, position int64, hasMore bool) {
+	tasks, err := h.policy.Visible(c.Request.Context(), tasks)
+	if err != nil {
+		writeProblem(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": ChangesResponse{
+			Changes:   tasks,
+			SyncToken: encodeSyncToken(position),
+			HasMore:   hasMore,
+		},
+		"success": true,
+	})
+}
+
+// encodeSyncToken codifica una posición del feed como token opaco
+func encodeSyncToken(position int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(syncTokenPrefix + strconv.FormatInt(position, 10)))
+}
+
+// decodeSyncToken obtiene la posición de un token de sincronización
+func decodeSyncToken(token string) (int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || !strings.HasPrefix(string(raw), syncTokenPrefix) {
+		return 0, errInvalidSyncToken
+	}
+
+	position, err := strconv.ParseInt(strings.TrimPrefix(string(raw), syncTokenPrefix), 10, 64)
+	if err != nil || position < 0 {
+		return 0, errInvalidSyncToken
+	}
+
+	return position, nil
+}
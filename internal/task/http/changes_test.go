@@ -0,0 +1,110 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+	"github.com/yebrai/go-tasks-microservice/internal/task/presence"
+	"github.com/yebrai/go-tasks-microservice/internal/task/tasktest"
+	"github.com/yebrai/go-tasks-microservice/pkg/cqrs/inmem"
+	"github.com/yebrai/go-tasks-microservice/pkg/events"
+	"github.com/yebrai/go-tasks-microservice/pkg/id"
+)
+
+func TestSyncToken_RoundTrip(t *testing.T) {
+	for _, position := range []int64{0, 1, 1 << 40} {
+		if got, err := decodeSyncToken(encodeSyncToken(position)); err != nil || got != position {
+			t.Errorf("Expected %d back, got %d (%v)", position, got, err)
+		}
+	}
+}
+
+func TestChanges_Feed(t *testing.T) {
+	repository := tasktest.NewRepository()
+	generator := id.NewUniqueIDGenerator()
+	for _, title := range []string{"first", "second"} {
+		created, err := task.NewTask(generator.Generate(), title, "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		repository.Put(created)
+	}
+	eventBus := events.NewObservableEventBus(events.NewNoOpEventBus())
+	handler := NewServer(inmem.NewCommandBus(), repository, eventBus, presence.NewRegistry(), Options{}).Handler()
+
+	get := func(query string) (*httptest.ResponseRecorder, ChangesResponse) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/changes"+query, nil))
+		var body struct {
+			Data ChangesResponse `json:"data"`
+		}
+		_ = json.Unmarshal(rec.Body.Bytes(), &body)
+		return rec, body.Data
+	}
+
+	rec, snapshot := get("")
+	if rec.Code != http.StatusOK || len(snapshot.Changes) != 2 {
+		t.Fatalf("Expected a snapshot with 2 tasks, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if position, err := decodeSyncToken(snapshot.SyncToken); err != nil || position != 2 {
+		t.Errorf("Expected the snapshot token at position 2, got %d (%v)", position, err)
+	}
+
+	rec, page := get("?since=" + snapshot.SyncToken + "&wait=0s")
+	if rec.Code != http.StatusOK || len(page.Changes) != 0 || page.SyncToken != snapshot.SyncToken {
+		t.Errorf("Expected no changes and the same token, got %d %+v", rec.Code, page)
+	}
+
+	for _, token := range []string{"not-base64!", encodeSyncToken(1)[:3], "djI6Mw"} {
+		rec, _ := get("?since=" + token)
+		var problem Problem
+		if rec.Code != http.StatusBadRequest || json.Unmarshal(rec.Body.Bytes(), &problem) != nil || problem.Code != CodeInvalidSyncToken {
+			t.Errorf("%q: expected 400 %s, got %d: %s", token, CodeInvalidSyncToken, rec.Code, rec.Body.String())
+		}
+	}
+
+	// Un cambio publicado durante la espera despierta la petición sin
+	// esperar a la siguiente reconsulta
+	type result struct {
+		rec  *httptest.ResponseRecorder
+		page ChangesResponse
+	}
+	done := make(chan result, 1)
+	started := time.Now()
+	go func() {
+		rec, page := get("?since=" + snapshot.SyncToken + "&wait=30s")
+		done <- result{rec, page}
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	changed, err := task.NewTask(generator.Generate(), "third", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repository.Save(context.Background(), changed); err != nil {
+		t.Fatal(err)
+	}
+	if err := eventBus.Publish(context.Background(), task.NewTaskCreatedEvent(changed)); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case got := <-done:
+		if elapsed := time.Since(started); elapsed >= changesPollInterval {
+			t.Errorf("Expected the change to wake the request, took %s", elapsed)
+		}
+		if got.rec.Code != http.StatusOK || len(got.page.Changes) != 1 || got.page.Changes[0].ID != changed.ID {
+			t.Fatalf("Expected the new task, got %d: %s", got.rec.Code, got.rec.Body.String())
+		}
+		if position, _ := decodeSyncToken(got.page.SyncToken); position != 3 {
+			t.Errorf("Expected the token to advance to 3, got %d", position)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Long-poll did not return after a change")
+	}
+}
@@ -0,0 +1,67 @@
+package http
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+)
+
+// errPreconditionFailed la versión de If-Match no es la actual
+var errPreconditionFailed = errors.New("the task does not match the If-Match precondition")
+
+// taskETag etiqueta fuerte derivada de la versión de la tarea
+func taskETag(t *task.Task) string {
+	return `"` + strconv.FormatInt(t.Version, 10) + `"`
+}
+
+// setTaskETag añade la cabecera ETag de la tarea a la respuesta
+func setTaskETag(c *gin.Context, t *task.Task) {
+	c.Header("ETag", taskETag(t))
+}
+
+// etagMatches compara una lista de etiquetas de If-Match/If-None-Match con
+// la de la tarea. weak permite la comparación débil de If-None-Match
+func etagMatches(header string, t *task.Task, weak bool) bool {
+	current := taskETag(t)
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if weak {
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == current {
+			return true
+		}
+	}
+	return false
+}
+
+// notModified indica si If-None-Match coincide con la tarea
+func notModified(c *gin.Context, t *task.Task) bool {
+	header := c.GetHeader("If-None-Match")
+	return header != "" && etagMatches(header, t, true)
+}
+
+// checkIfMatch verifica la precondición If-Match contra la tarea actual y
+// retorna la versión que deben esperar los comandos (0 si no hay cabecera o
+// es "*")
+func checkIfMatch(c *gin.Context, t *task.Task) (int64, error) {
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		return 0, nil
+	}
+
+	if !etagMatches(header, t, false) {
+		return 0, errPreconditionFailed
+	}
+
+	if strings.TrimSpace(header) == "*" {
+		return 0, nil
+	}
+	return t.Version, nil
+}
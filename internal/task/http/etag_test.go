@@ -0,0 +1,87 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+	"github.com/yebrai/go-tasks-microservice/internal/task/creator"
+	"github.com/yebrai/go-tasks-microservice/internal/task/presence"
+	"github.com/yebrai/go-tasks-microservice/internal/task/tasktest"
+	"github.com/yebrai/go-tasks-microservice/pkg/cqrs/inmem"
+	"github.com/yebrai/go-tasks-microservice/pkg/events"
+)
+
+func TestConditionalRequests(t *testing.T) {
+	const taskID = "6f1c2a9e-3b4d-4c5e-8f70-1a2b3c4d5e6f"
+
+	tests := []struct {
+		name       string
+		method     string
+		header     string
+		value      string
+		conflicts  int
+		wantStatus int
+		wantCode   string
+		wantETag   string
+	}{
+		{"get returns the version", http.MethodGet, "", "", 0, http.StatusOK, "", `"1"`},
+		{"get not modified", http.MethodGet, "If-None-Match", `"1"`, 0, http.StatusNotModified, "", `"1"`},
+		{"get not modified weak", http.MethodGet, "If-None-Match", `"7", W/"1"`, 0, http.StatusNotModified, "", `"1"`},
+		{"get modified", http.MethodGet, "If-None-Match", `"0"`, 0, http.StatusOK, "", `"1"`},
+		{"put current version", http.MethodPut, "If-Match", `"1"`, 0, http.StatusOK, "", `"2"`},
+		{"put stale version", http.MethodPut, "If-Match", `"0"`, 0, http.StatusPreconditionFailed, CodePreconditionFailed, ""},
+		{"put weak etag", http.MethodPut, "If-Match", `W/"1"`, 0, http.StatusPreconditionFailed, CodePreconditionFailed, ""},
+		{"conditional put overtaken", http.MethodPut, "If-Match", `"1"`, 1, http.StatusPreconditionFailed, CodePreconditionFailed, ""},
+		{"put overtaken", http.MethodPut, "", "", 1, http.StatusConflict, CodeConcurrentModification, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repository := tasktest.NewRepository()
+			current, err := task.NewTask(taskID, "title", "", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			repository.Put(current)
+			repository.FailUpdates(tt.conflicts)
+
+			bus := inmem.NewCommandBus()
+			if err := bus.Register(creator.UpdateTaskCommandType, creator.NewUpdateTaskCommandHandler(repository, events.NewNoOpEventBus())); err != nil {
+				t.Fatal(err)
+			}
+			handler := NewServer(bus, repository, events.NewNoOpEventBus(), presence.NewRegistry(), Options{}).Handler()
+
+			body := ""
+			if tt.method == http.MethodPut {
+				body = `{"title":"renamed","status":"pending"}`
+			}
+			req := httptest.NewRequest(tt.method, "/api/v1/tasks/"+taskID, strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("Expected %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+			if got := rec.Header().Get("ETag"); got != tt.wantETag {
+				t.Errorf("Expected ETag %q, got %q", tt.wantETag, got)
+			}
+			if tt.wantStatus == http.StatusNotModified && rec.Body.Len() != 0 {
+				t.Errorf("Expected an empty 304 body, got %s", rec.Body.String())
+			}
+			if tt.wantCode != "" {
+				var problem Problem
+				if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil || problem.Code != tt.wantCode {
+					t.Errorf("Expected %s, got %s", tt.wantCode, rec.Body.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package http
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+	"github.com/yebrai/go-tasks-microservice/internal/task/authz"
+	"github.com/yebrai/go-tasks-microservice/internal/task/export"
+	"github.com/yebrai/go-tasks-microservice/pkg/auth"
+)
+
+const (
+	// exportFlushEvery tareas escritas entre cada envío al cliente
+	exportFlushEvery = 100
+	// exportWriteWindow plazo de escritura que se concede tras cada envío,
+	// para que una exportación grande no agote el WriteTimeout del servidor
+	exportWriteWindow = 15 * time.Second
+)
+
+// ExportTasks exporta las tareas que cumplen los filtros del listado en
+// ?format=csv|jsonl|ics, leyéndolas de un cursor a medida que se escriben
+func (h *TaskHandler) ExportTasks(c *gin.Context) {
+	name := c.DefaultQuery("format", "csv")
+	format, err := export.Lookup(name)
+	if err != nil {
+		writeProblem(c, fieldError("format", "oneof", "format must be one of: "+strings.Join(export.Names(), " ")))
+		return
+	}
+
+	query, err := taskFilterFromQuery(c)
+	if err != nil {
+		writeProblem(c, err)
+		return
+	}
+	filter, err := query.toFilter("")
+	if err != nil {
+		writeProblem(c, err)
+		return
+	}
+
+	// La autorización se resuelve antes de empezar la respuesta
+	principal, _ := auth.PrincipalFromContext(c.Request.Context())
+	if h.policy.Decide(principal, authz.ActionRead) == authz.Deny {
+		writeProblem(c, h.policy.Authorize(c.Request.Context(), authz.ActionRead, nil))
+		return
+	}
+
+	now := time.Now()
+	controller := http.NewResponseController(c.Writer)
+	_ = controller.SetWriteDeadline(now.Add(exportWriteWindow))
+
+	c.Header("Content-Type", format.ContentType)
+	c.Header("Content-Disposition", `attachment; filename="tasks-`+now.Format("20060102")+"."+format.Extension+`"`)
+	c.Status(http.StatusOK)
+
+	writer, err := format.New(c.Writer, now)
+	if err != nil {
+		log.Printf("❌ export failed: %v", err)
+		return
+	}
+
+	written := 0
+	err = h.repository.StreamMatching(c.Request.Context(), filter, func(t *task.Task) error {
+		if !h.policy.Allowed(principal, authz.ActionRead, t) {
+			return nil
+		}
+		if err := writer.Write(t); err != nil {
+			return err
+		}
+		written++
+		if written%exportFlushEvery == 0 {
+			_ = controller.Flush()
+			_ = controller.SetWriteDeadline(time.Now().Add(exportWriteWindow))
+		}
+		return nil
+	})
+	if err == nil {
+		err = writer.Close()
+	}
+
+	// Con la respuesta ya empezada solo queda cortarla: el cliente recibe un
+	// fichero incompleto en lugar de un error
+	if err != nil {
+		log.Printf("❌ export failed after %d tasks: %v", written, err)
+		c.Abort()
+	}
+}
@@ -0,0 +1,190 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yebrai/go-tasks-microservice/pkg/auth"
+	"github.com/yebrai/go-tasks-microservice/pkg/idempotency"
+	"github.com/yebrai/go-tasks-microservice/pkg/tenant"
+)
+
+const (
+	// IdempotencyKeyHeader cabecera con la clave de idempotencia del cliente
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader marca las respuestas reproducidas
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	// maxIdempotencyKeyLength longitud máxima de la clave
+	maxIdempotencyKeyLength = 255
+	// idempotencyWait espera máxima a que termine una petición en curso con
+	// la misma clave
+	idempotencyWait = 10 * time.Second
+	// idempotencyPollInterval frecuencia con la que se consulta esa petición
+	idempotencyPollInterval = 100 * time.Millisecond
+)
+
+// replayedHeaders cabeceras de la respuesta original que se reproducen
+var replayedHeaders = []string{"Content-Type", "Location", "ETag"}
+
+// capturingWriter copia el cuerpo de la respuesta mientras se escribe
+type capturingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+// Write implementa io.Writer
+func (w *capturingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString implementa io.StringWriter
+func (w *capturingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// idempotencyMiddleware reproduce la respuesta de las escrituras repetidas
+// con la misma cabecera Idempotency-Key. Las claves son por tenant y
+// principal; una clave reutilizada con otra petición responde 422 y las
+// peticiones simultáneas con la misma clave esperan a la primera
+func (s *Server) idempotencyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if s.idempotency == nil || key == "" || !unsafeMethod(c.Request.Method) {
+			c.Next()
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			abortWithProblem(c, NewProblem(http.StatusBadRequest, CodeInvalidIdempotencyKey,
+				"Idempotency-Key must be at most 255 characters"))
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			writeProblem(c, err)
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request.Context()
+		var subject string
+		if principal, ok := auth.PrincipalFromContext(ctx); ok {
+			subject = principal.Subject
+		}
+		owner := idempotency.Owner(tenant.FromContext(ctx), subject)
+		scopedKey := idempotency.Fingerprint([]byte(tenant.FromContext(ctx)), []byte(subject), []byte(key))
+		fingerprint := idempotency.Fingerprint(
+			[]byte(c.Request.Method),
+			[]byte(c.Request.URL.RequestURI()),
+			[]byte(c.ContentType()),
+			body,
+		)
+
+		record, ok := s.acquireIdempotencyKey(c, scopedKey, owner, fingerprint)
+		if !ok {
+			return
+		}
+		if record.Completed() {
+			replayResponse(c, record.Response)
+			return
+		}
+
+		writer := &capturingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		// Guardar la respuesta aunque el cliente se haya desconectado
+		storeCtx := context.WithoutCancel(ctx)
+		if writer.Status() >= http.StatusInternalServerError {
+			if err := s.idempotency.Release(storeCtx, scopedKey); err != nil {
+				log.Printf("⚠️  Failed to release idempotency key: %v", err)
+			}
+			return
+		}
+
+		response := idempotency.Response{
+			Status: writer.Status(),
+			Header: http.Header{},
+			Body:   writer.body.Bytes(),
+		}
+		for _, name := range replayedHeaders {
+			if value := writer.Header().Get(name); value != "" {
+				response.Header.Set(name, value)
+			}
+		}
+		if err := s.idempotency.Complete(storeCtx, scopedKey, response); err != nil {
+			log.Printf("⚠️  Failed to store idempotent response: %v", err)
+		}
+	}
+}
+
+// acquireIdempotencyKey reserva la clave o espera a que la petición que la
+// tiene reservada termine. Retorna false si ya se respondió con un error
+func (s *Server) acquireIdempotencyKey(c *gin.Context, key, owner, fingerprint string) (*idempotency.Record, bool) {
+	ctx := c.Request.Context()
+	deadline := time.Now().Add(idempotencyWait)
+
+	for {
+		record, acquired, err := s.idempotency.Acquire(ctx, key, owner, fingerprint, idempotency.DefaultLockTTL)
+		if err != nil {
+			writeProblem(c, err)
+			return nil, false
+		}
+
+		if record.Fingerprint != fingerprint {
+			abortWithProblem(c, NewProblem(http.StatusUnprocessableEntity, CodeIdempotencyKeyReused,
+				"the Idempotency-Key was already used with a different request"))
+			return nil, false
+		}
+
+		if acquired || record.Completed() {
+			return record, true
+		}
+
+		if time.Now().After(deadline) {
+			c.Header("Retry-After", "1")
+			abortWithProblem(c, NewProblem(http.StatusConflict, CodeIdempotencyInProgress,
+				"a request with the same Idempotency-Key is still in progress"))
+			return nil, false
+		}
+
+		select {
+		case <-ctx.Done():
+			c.Abort()
+			return nil, false
+		case <-time.After(idempotencyPollInterval):
+		}
+	}
+}
+
+// replayResponse reproduce una respuesta almacenada
+func replayResponse(c *gin.Context, response *idempotency.Response) {
+	for name, values := range response.Header {
+		for _, value := range values {
+			c.Writer.Header().Add(name, value)
+		}
+	}
+	c.Header(IdempotentReplayedHeader, "true")
+
+	c.Abort()
+	c.Status(response.Status)
+	_, _ = c.Writer.Write(response.Body)
+}
+
+// unsafeMethod indica si el método modifica recursos
+func unsafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	default:
+		return true
+	}
+}
@@ -0,0 +1,143 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yebrai/go-tasks-microservice/internal/apikey"
+	"github.com/yebrai/go-tasks-microservice/internal/task/presence"
+	"github.com/yebrai/go-tasks-microservice/pkg/auth"
+	"github.com/yebrai/go-tasks-microservice/pkg/cqrs/inmem"
+	"github.com/yebrai/go-tasks-microservice/pkg/events"
+	"github.com/yebrai/go-tasks-microservice/pkg/id"
+	"github.com/yebrai/go-tasks-microservice/pkg/idempotency"
+)
+
+func newIdempotencyTestRouter(calls *int32, delay time.Duration) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	server := &Server{idempotency: idempotency.NewMemoryStore(time.Hour)}
+	router := gin.New()
+	router.Use(server.idempotencyMiddleware())
+	router.POST("/tasks", func(c *gin.Context) {
+		n := atomic.AddInt32(calls, 1)
+		time.Sleep(delay)
+		c.JSON(http.StatusCreated, gin.H{"call": n})
+	})
+	return router
+}
+
+func postWithKey(router http.Handler, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(IdempotencyKeyHeader, key)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestIdempotencyMiddleware_ReplaysSameRequest(t *testing.T) {
+	var calls int32
+	router := newIdempotencyTestRouter(&calls, 0)
+
+	first := postWithKey(router, "k1", `{"title":"a"}`)
+	second := postWithKey(router, "k1", `{"title":"a"}`)
+
+	if calls != 1 {
+		t.Fatalf("Expected the handler to run once, ran %d times", calls)
+	}
+	if second.Code != http.StatusCreated || second.Body.String() != first.Body.String() {
+		t.Errorf("Expected replay of %d %s, got %d %s", first.Code, first.Body, second.Code, second.Body)
+	}
+	if second.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Errorf("Expected the replay to be marked with %s", IdempotentReplayedHeader)
+	}
+}
+
+func TestIdempotencyMiddleware_RejectsDifferentPayload(t *testing.T) {
+	var calls int32
+	router := newIdempotencyTestRouter(&calls, 0)
+
+	postWithKey(router, "k1", `{"title":"a"}`)
+	resp := postWithKey(router, "k1", `{"title":"b"}`)
+
+	if resp.Code != http.StatusUnprocessableEntity || !strings.Contains(resp.Body.String(), CodeIdempotencyKeyReused) {
+		t.Errorf("Expected 422 %s, got %d %s", CodeIdempotencyKeyReused, resp.Code, resp.Body)
+	}
+	if calls != 1 {
+		t.Errorf("Expected the handler to run once, ran %d times", calls)
+	}
+}
+
+func TestIdempotencyMiddleware_SerializesConcurrentRequests(t *testing.T) {
+	var calls int32
+	router := newIdempotencyTestRouter(&calls, 200*time.Millisecond)
+
+	var wg sync.WaitGroup
+	responses := make([]*httptest.ResponseRecorder, 3)
+	for i := range responses {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			responses[i] = postWithKey(router, "k1", `{"title":"a"}`)
+		}(i)
+	}
+	wg.Wait()
+
+	if calls != 1 {
+		t.Fatalf("Expected the handler to run once, ran %d times", calls)
+	}
+	for _, resp := range responses {
+		if resp.Code != http.StatusCreated || resp.Body.String() != responses[0].Body.String() {
+			t.Errorf("Expected every response to match the first, got %d %s", resp.Code, resp.Body)
+		}
+	}
+}
+
+func TestIdempotencyMiddleware_SkipsSecrets(t *testing.T) {
+	handler := NewServer(inmem.NewCommandBus(), nil, events.NewNoOpEventBus(), presence.NewRegistry(), Options{
+		Authenticator: auth.NewStaticTokenAuthenticator([]auth.StaticToken{
+			{Token: "admin", Principal: auth.Principal{Subject: "svc:admin", Scopes: []string{auth.ScopeAdmin}}},
+		}),
+		APIKeys:     apikey.NewService(apikey.NewMemoryRepository(), id.NewUniqueIDGenerator()),
+		Idempotency: idempotency.NewMemoryStore(time.Hour),
+	}).Handler()
+
+	tests := []struct {
+		name string
+		path string
+		body string
+	}{
+		{"websocket ticket", "/api/v1/ws/tickets", ""},
+		{"api key", "/api/v1/admin/api-keys", `{"name":"ci","scopes":["tasks:read"]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issue := func() *httptest.ResponseRecorder {
+				req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set("Authorization", "Bearer admin")
+				req.Header.Set(IdempotencyKeyHeader, "k1")
+				recorder := httptest.NewRecorder()
+				handler.ServeHTTP(recorder, req)
+				return recorder
+			}
+
+			first, second := issue(), issue()
+			if first.Code != http.StatusCreated || second.Code != http.StatusCreated {
+				t.Fatalf("Expected two secrets, got %d %s and %d %s", first.Code, first.Body, second.Code, second.Body)
+			}
+			if second.Header().Get(IdempotentReplayedHeader) != "" || second.Body.String() == first.Body.String() {
+				t.Errorf("Expected a fresh secret, got a replay of %s", first.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yebrai/go-tasks-microservice/internal/task/importer"
+)
+
+// MaxImportBytes tamaño máximo por defecto del fichero de importación
+const MaxImportBytes = 10 << 20
+
+// ImportReport resultado de validar un fichero de importación
+type ImportReport struct {
+	Format  string       `json:"format"`
+	Total   int          `json:"total"`
+	Valid   int          `json:"valid"`
+	Invalid int          `json:"invalid"`
+	Errors  []FieldError `json:"errors"`
+}
+
+// ImportTasks importa las tareas del fichero enviado en el cuerpo, en el
+// formato de ?format=. Con ?dry_run=true solo retorna el informe de
+// validación; si no, lanza un ImportTasksCommand y responde con su job. Un
+// fichero con filas inválidas no se importa
+func (h *BulkHandler) ImportTasks(c *gin.Context) {
+	format := c.Query("format")
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+	if err != nil {
+		writeProblem(c, fieldError("dry_run", "boolean", "dry_run must be a boolean"))
+		return
+	}
+
+	// El tamaño del fichero lo limita bodyLimitMiddleware
+	rows, err := importer.Parse(format, c.Request.Body)
+	if err != nil {
+		writeProblem(c, err)
+		return
+	}
+
+	report := newImportReport(format, rows)
+	if dryRun {
+		c.JSON(http.StatusOK, gin.H{
+			"data":    report,
+			"success": true,
+		})
+		return
+	}
+
+	if report.Invalid > 0 {
+		problem := NewProblem(http.StatusUnprocessableEntity, CodeImportInvalidRows,
+			"the import file has "+strconv.Itoa(report.Invalid)+" invalid rows; nothing was imported")
+		problem.Errors = report.Errors
+		abortWithProblem(c, problem)
+		return
+	}
+	if report.Total == 0 {
+		writeProblem(c, fieldError("", "required", "the import file has no rows"))
+		return
+	}
+
+	h.launch(c, importer.ImportTasksCommand{Rows: rows})
+}
+
+// newImportReport resume la validación de las filas. Los campos de los
+// errores se indican como rows[línea].campo
+func newImportReport(format string, rows []importer.Row) ImportReport {
+	report := ImportReport{Format: format, Total: len(rows), Errors: []FieldError{}}
+	for _, row := range rows {
+		if row.Valid() {
+			report.Valid++
+			continue
+		}
+
+		report.Invalid++
+		prefix := "rows[" + strconv.Itoa(row.Line) + "]"
+		for _, rowErr := range row.Errors {
+			field := prefix
+			if rowErr.Field != "" {
+				field += "." + rowErr.Field
+			}
+			report.Errors = append(report.Errors, FieldError{Field: field, Code: rowErr.Code, Message: rowErr.Message})
+		}
+	}
+	return report
+}
@@ -0,0 +1,135 @@
+package http
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"io"
+	"net/http"
+	"regexp"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yebrai/go-tasks-microservice/pkg/openapi"
+)
+
+// openAPISpec documento OpenAPI del API, mantenido junto a las rutas
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+var (
+	openAPIOnce sync.Once
+	openAPIDoc  *openapi.Document
+	openAPIErr  error
+)
+
+// OpenAPIDocument retorna el documento OpenAPI embebido ya decodificado
+func OpenAPIDocument() (*openapi.Document, error) {
+	openAPIOnce.Do(func() {
+		openAPIDoc, openAPIErr = openapi.Parse(openAPISpec)
+	})
+	return openAPIDoc, openAPIErr
+}
+
+// docsContentSecurityPolicy política de la página de documentación: Redoc
+// se carga de su CDN, inyecta estilos en línea y busca en un worker
+const docsContentSecurityPolicy = "default-src 'none'; script-src https://cdn.redoc.ly; style-src 'unsafe-inline'; " +
+	"img-src 'self' data: https:; font-src 'self' data:; connect-src 'self'; worker-src blob:; " +
+	"base-uri 'none'; form-action 'none'; frame-ancestors 'none'"
+
+// docsPage página de documentación interactiva sobre el documento servido
+const docsPage = `<!DOCTYPE html>
+<html lang="es">
+<head>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <title>Tasks API</title>
+</head>
+<body>
+  <redoc spec-url="/api/v1/openapi.json"></redoc>
+  <script src="https://cdn.redoc.ly/redoc/latest/bundles/redoc.standalone.js"></script>
+</body>
+</html>
+`
+
+// ginParam parámetros de ruta de Gin (:id)
+var ginParam = regexp.MustCompile(`:([^/]+)`)
+
+// openAPIPath traduce una ruta de Gin al formato de OpenAPI
+func openAPIPath(fullPath string) string {
+	return ginParam.ReplaceAllString(fullPath, "{$1}")
+}
+
+// serveOpenAPI retorna el documento OpenAPI
+func (s *Server) serveOpenAPI(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json; charset=utf-8", openAPISpec)
+}
+
+// serveDocs retorna la página de documentación
+func (s *Server) serveDocs(c *gin.Context) {
+	c.Header("Content-Security-Policy", docsContentSecurityPolicy)
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(docsPage))
+}
+
+// openAPIValidation valida los parámetros y el cuerpo de la petición contra
+// el documento OpenAPI antes de llegar al handler
+func (s *Server) openAPIValidation() gin.HandlerFunc {
+	doc, err := OpenAPIDocument()
+	if err != nil {
+		panic("invalid embedded OpenAPI document: " + err.Error())
+	}
+
+	return func(c *gin.Context) {
+		req := openapi.Request{
+			Method:      c.Request.Method,
+			Path:        openAPIPath(c.FullPath()),
+			PathParams:  make(map[string]string, len(c.Params)),
+			Query:       c.Request.URL.Query(),
+			Header:      c.Request.Header,
+			ContentType: c.ContentType(),
+		}
+		for _, param := range c.Params {
+			req.PathParams[param.Key] = param.Value
+		}
+		// Los clientes que no declaran el media type envían JSON
+		if req.ContentType == "" {
+			req.ContentType = "application/json"
+		}
+
+		if c.Request.Body != nil && c.Request.Body != http.NoBody {
+			body, err := io.ReadAll(c.Request.Body)
+			if err != nil {
+				writeProblem(c, err)
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+			req.Body = body
+		}
+
+		validationErrs, err := doc.ValidateRequest(req)
+		if errors.Is(err, openapi.ErrUnsupportedMediaType) {
+			abortWithProblem(c, NewProblem(http.StatusUnsupportedMediaType, CodeUnsupportedMediaType,
+				"unsupported content type "+req.ContentType))
+			return
+		}
+		if err != nil {
+			writeProblem(c, err)
+			return
+		}
+		if len(validationErrs) > 0 {
+			problem := NewProblem(http.StatusBadRequest, CodeValidationFailed, "the request is invalid")
+			for _, validationErr := range validationErrs {
+				problem.Errors = append(problem.Errors, FieldError{
+					Field:   validationErr.Field,
+					Code:    validationErr.Code,
+					Message: validationErr.Message,
+				})
+			}
+			abortWithProblem(c, problem)
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,99 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yebrai/go-tasks-microservice/internal/task/presence"
+	"github.com/yebrai/go-tasks-microservice/pkg/cqrs/inmem"
+	"github.com/yebrai/go-tasks-microservice/pkg/events"
+)
+
+func newOpenAPITestServer() http.Handler {
+	server := NewServer(inmem.NewCommandBus(), nil, events.NewNoOpEventBus(), presence.NewRegistry(), Options{})
+	return server.Handler()
+}
+
+func TestOpenAPI_CoversEveryRoute(t *testing.T) {
+	doc, err := OpenAPIDocument()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	documented := make(map[string]bool)
+	for _, operation := range doc.Operations() {
+		documented[operation] = true
+	}
+
+	for _, route := range newOpenAPITestServer().(*gin.Engine).Routes() {
+		operation := route.Method + " " + openAPIPath(route.Path)
+		if !documented[operation] {
+			t.Errorf("Route %s is missing from openapi.json", operation)
+		}
+		delete(documented, operation)
+	}
+
+	for operation := range documented {
+		t.Errorf("openapi.json documents %s but no route serves it", operation)
+	}
+}
+
+func TestOpenAPI_ValidatesRequests(t *testing.T) {
+	handler := newOpenAPITestServer()
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		contentType string
+		body        string
+		wantStatus  int
+		wantField   string
+	}{
+		{"missing title", http.MethodPost, "/api/v1/tasks", "application/json", `{"description":"x"}`, http.StatusBadRequest, "title"},
+		{"wrong type", http.MethodPost, "/api/v1/tasks", "application/json", `{"title":3}`, http.StatusBadRequest, "title"},
+		{"bad status", http.MethodPut, "/api/v1/tasks/6f1c2a9e-3b4d-4c5e-8f70-1a2b3c4d5e6f", "application/json", `{"title":"t","status":"done"}`, http.StatusBadRequest, "status"},
+		{"mutation without id", http.MethodPost, "/api/v1/sync", "application/json", `{"mutations":[{"op":"create"}]}`, http.StatusBadRequest, "mutations[0].mutation_id"},
+		{"unsupported media type", http.MethodPost, "/api/v1/tasks", "text/plain", `title`, http.StatusUnsupportedMediaType, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("Expected %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+			if tt.wantField == "" {
+				return
+			}
+
+			var problem Problem
+			if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+				t.Fatal(err)
+			}
+			if problem.Code != CodeValidationFailed || len(problem.Errors) == 0 || problem.Errors[0].Field != tt.wantField {
+				t.Errorf("Expected a validation error on %s, got %+v", tt.wantField, problem)
+			}
+		})
+	}
+}
+
+func TestOpenAPI_ServesDocument(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newOpenAPITestServer().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/openapi.json", nil))
+
+	var doc struct {
+		OpenAPI string `json:"openapi"`
+	}
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &doc) != nil || doc.OpenAPI != "3.1.0" {
+		t.Errorf("Expected the OpenAPI 3.1 document, got %d: %.80s", rec.Code, rec.Body.String())
+	}
+}
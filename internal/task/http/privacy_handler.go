@@ -0,0 +1,96 @@
+package http
+
+import (
+	"bytes"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yebrai/go-tasks-microservice/internal/privacy"
+	"github.com/yebrai/go-tasks-microservice/pkg/cqrs"
+	"github.com/yebrai/go-tasks-microservice/pkg/id"
+)
+
+// PrivacyHandler atiende las solicitudes de los interesados sobre sus
+// datos personales: exportación y supresión
+type PrivacyHandler struct {
+	commandBus  cqrs.CommandBus
+	service     *privacy.Service
+	idGenerator id.Generator
+}
+
+// NewPrivacyHandler crea una nueva instancia del handler
+func NewPrivacyHandler(commandBus cqrs.CommandBus, service *privacy.Service, idGenerator id.Generator) *PrivacyHandler {
+	return &PrivacyHandler{
+		commandBus:  commandBus,
+		service:     service,
+		idGenerator: idGenerator,
+	}
+}
+
+// EraseSubjectRequest interesado cuyos datos se seudonimizan y textos que lo
+// identifican en las tareas
+type EraseSubjectRequest struct {
+	Subject string   `json:"subject" binding:"required"`
+	Terms   []string `json:"terms,omitempty"`
+}
+
+// ExportSubject retorna un zip con los datos del interesado en el tenant
+func (h *PrivacyHandler) ExportSubject(c *gin.Context) {
+	// Se genera completo antes de responder para poder informar de un
+	// error con su código
+	var archive bytes.Buffer
+	if err := h.service.Export(c.Request.Context(), c.Param("subject"), &archive); err != nil {
+		writeProblem(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="subject-export-`+time.Now().Format("20060102")+`.zip"`)
+	c.Data(http.StatusOK, "application/zip", archive.Bytes())
+}
+
+// EraseSubject seudonimiza los datos del interesado mediante el comando de
+// supresión, que queda auditado, y retorna el registro de la solicitud
+func (h *PrivacyHandler) EraseSubject(c *gin.Context) {
+	var req EraseSubjectRequest
+	if err := bindJSON(c, &req); err != nil {
+		writeProblem(c, err)
+		return
+	}
+
+	cmd := privacy.EraseSubjectCommand{
+		ID:      h.idGenerator.Generate(),
+		Subject: req.Subject,
+		Terms:   req.Terms,
+	}
+	if err := h.commandBus.Dispatch(c.Request.Context(), cmd); err != nil {
+		writeProblem(c, err)
+		return
+	}
+
+	erasure, err := h.service.FindErasure(c.Request.Context(), cmd.ID)
+	if err != nil {
+		writeProblem(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"data":    erasure,
+		"success": true,
+	})
+}
+
+// ListErasures retorna las solicitudes de supresión del tenant, de la más
+// reciente a la más antigua
+func (h *PrivacyHandler) ListErasures(c *gin.Context) {
+	erasures, err := h.service.ListErasures(c.Request.Context())
+	if err != nil {
+		writeProblem(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    erasures,
+		"success": true,
+	})
+}
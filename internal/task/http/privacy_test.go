@@ -0,0 +1,117 @@
+package http
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yebrai/go-tasks-microservice/internal/audit"
+	"github.com/yebrai/go-tasks-microservice/internal/privacy"
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+	"github.com/yebrai/go-tasks-microservice/internal/task/presence"
+	"github.com/yebrai/go-tasks-microservice/pkg/auth"
+	"github.com/yebrai/go-tasks-microservice/pkg/cqrs/inmem"
+	"github.com/yebrai/go-tasks-microservice/pkg/events"
+	"github.com/yebrai/go-tasks-microservice/pkg/id"
+)
+
+// emptyRepository repositorio sin tareas
+type emptyRepository struct {
+	task.Repository
+}
+
+func (emptyRepository) StreamMatching(context.Context, task.Filter, func(*task.Task) error) error {
+	return nil
+}
+
+func (emptyRepository) FindArchived(context.Context, time.Time) ([]*task.Task, error) {
+	return nil, nil
+}
+
+func TestPrivacy_ExportAndErase(t *testing.T) {
+	log := audit.NewLog(audit.NewMemoryRepository(), id.NewUniqueIDGenerator())
+	eventBus := events.NewNoOpEventBus()
+	service := privacy.NewService(emptyRepository{}, eventBus, log, privacy.NewMemoryErasureRepository(), id.NewUniqueIDGenerator(), privacy.Accounts{}, nil)
+	bus := inmem.NewCommandBus()
+	bus.Use(log.Middleware())
+	bus.Register(privacy.EraseSubjectCommandType, privacy.NewEraseSubjectCommandHandler(service))
+
+	handler := NewServer(bus, emptyRepository{}, eventBus, presence.NewRegistry(), Options{
+		Authenticator: auth.NewStaticTokenAuthenticator([]auth.StaticToken{
+			{Token: "writer", Principal: auth.Principal{Subject: "user:ada", Scopes: []string{auth.ScopeTasksWrite}}},
+			{Token: "dpo", Principal: auth.Principal{Subject: "dpo", Scopes: []string{auth.ScopeAdmin}}},
+		}),
+		Audit:   log,
+		Privacy: service,
+	}).Handler()
+
+	request := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Una petición denegada deja una entrada de auditoría del interesado
+	if rec := request(http.MethodGet, "/api/v1/admin/erasures", "writer", ""); rec.Code != http.StatusForbidden {
+		t.Fatalf("Expected 403 without the admin scope, got %d", rec.Code)
+	}
+
+	rec := request(http.MethodGet, "/api/v1/admin/subjects/user:ada/export", "dpo", "")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/zip" {
+		t.Fatalf("Expected a zip, got %d %s: %s", rec.Code, rec.Header().Get("Content-Type"), rec.Body.String())
+	}
+	archive, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, file := range archive.File {
+		names = append(names, file.Name)
+	}
+	if strings.Join(names, ",") != "manifest.json,tasks.jsonl,audit.jsonl,api_keys.json" {
+		t.Errorf("Unexpected archive contents %v", names)
+	}
+
+	if rec := request(http.MethodPost, "/api/v1/admin/erasures", "dpo", `{"subject":"user:ada","terms":["Ada","ad"]}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected short terms to be rejected, got %d", rec.Code)
+	}
+
+	rec = request(http.MethodPost, "/api/v1/admin/erasures", "dpo", `{"subject":"user:ada","terms":["Ada Lovelace"]}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var created struct {
+		Data privacy.Erasure `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	if created.Data.Status != privacy.ErasureCompleted || created.Data.AuditEntriesUpdated != 1 || created.Data.RequestedBy != "dpo" {
+		t.Errorf("Unexpected erasure %+v", created.Data)
+	}
+
+	rec = request(http.MethodGet, "/api/v1/admin/erasures", "dpo", "")
+	var listed struct {
+		Data []privacy.Erasure `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &listed); err != nil {
+		t.Fatal(err)
+	}
+	if len(listed.Data) != 1 || listed.Data[0].ID != created.Data.ID {
+		t.Errorf("Expected the erasure in the list, got %+v", listed.Data)
+	}
+
+	entries, _ := log.Find(context.Background(), audit.Query{Actor: created.Data.Pseudonym})
+	if len(entries) != 1 {
+		t.Errorf("Expected the subject's audit entry pseudonymized, got %d", len(entries))
+	}
+}
@@ -0,0 +1,341 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/yebrai/go-tasks-microservice/internal/apikey"
+	"github.com/yebrai/go-tasks-microservice/internal/audit"
+	"github.com/yebrai/go-tasks-microservice/internal/privacy"
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+	"github.com/yebrai/go-tasks-microservice/internal/task/authz"
+	"github.com/yebrai/go-tasks-microservice/internal/task/bulk"
+	"github.com/yebrai/go-tasks-microservice/internal/task/importer"
+	"github.com/yebrai/go-tasks-microservice/internal/task/presence"
+	"github.com/yebrai/go-tasks-microservice/internal/task/syncer"
+	"github.com/yebrai/go-tasks-microservice/internal/user"
+	"github.com/yebrai/go-tasks-microservice/pkg/auth"
+	"github.com/yebrai/go-tasks-microservice/pkg/cqrs"
+	"github.com/yebrai/go-tasks-microservice/pkg/jsonpatch"
+	"github.com/yebrai/go-tasks-microservice/pkg/tenant"
+)
+
+// ProblemContentType media type de las respuestas de error (RFC 7807)
+const ProblemContentType = "application/problem+json"
+
+// problemTypeBase prefijo de los URI que identifican cada tipo de problema
+const problemTypeBase = "/problems/"
+
+// Códigos de error estables expuestos en las respuestas problem+json
+const (
+	CodeTaskNotFound            = "task_not_found"
+	CodeTaskAlreadyExists       = "task_already_exists"
+	CodeInvalidTaskID           = "invalid_task_id"
+	CodeInvalidTaskData         = "invalid_task_data"
+	CodeTaskAlreadyCompleted    = "task_already_completed"
+	CodeTaskAlreadyCancelled    = "task_already_cancelled"
+	CodeTaskLocked              = "task_locked"
+	CodeTaskArchived            = "task_archived"
+	CodeTaskNotArchived         = "task_not_archived"
+	CodeJobNotFound             = "job_not_found"
+	CodeAPIKeyNotFound          = "api_key_not_found"
+	CodeAPIKeyRevoked           = "api_key_revoked"
+	CodeInvalidAPIKeyData       = "invalid_api_key_data"
+	CodeEmailTaken              = "email_taken"
+	CodeInvalidUserData         = "invalid_user_data"
+	CodeInvalidLogin            = "invalid_login"
+	CodeAccountLocked           = "account_locked"
+	CodeInvalidToken            = "invalid_token"
+	CodeUserNotFound            = "user_not_found"
+	CodeResetUnavailable        = "password_reset_unavailable"
+	CodeInvalidImportFile       = "invalid_import_file"
+	CodeImportInvalidRows       = "import_invalid_rows"
+	CodeConcurrentModification  = "concurrent_modification"
+	CodePreconditionFailed      = "precondition_failed"
+	CodeInvalidStatusTransition = "invalid_status_transition"
+	CodeInvalidPatch            = "invalid_patch"
+	CodePatchTargetNotFound     = "patch_target_not_found"
+	CodePatchTestFailed         = "patch_test_failed"
+	CodeUnsupportedMediaType    = "unsupported_media_type"
+	CodeLeaseNotHeld            = "lease_not_held"
+	CodeValidationFailed        = "validation_failed"
+	CodeInvalidSyncToken        = "invalid_sync_token"
+	CodeInvalidSyncMutation     = "invalid_sync_mutation"
+	CodeInvalidAuditQuery       = "invalid_audit_query"
+	CodeInvalidPrivacyRequest   = "invalid_privacy_request"
+	CodeErasureNotFound         = "erasure_not_found"
+	CodeUnauthorized            = "unauthorized"
+	CodeAuthUnavailable         = "authentication_unavailable"
+	CodeForbidden               = "forbidden"
+	CodeInvalidTenant           = "invalid_tenant"
+	CodeTenantMismatch          = "tenant_mismatch"
+	CodeTenantNotAllowed        = "tenant_not_allowed"
+	CodeRateLimited             = "rate_limited"
+	CodeQuotaExceeded           = "quota_exceeded"
+	CodeNotFound                = "not_found"
+	CodePayloadTooLarge         = "payload_too_large"
+	CodeCommandNotSupported     = "command_not_supported"
+	CodeTransactionsUnsupported = "transactions_unsupported"
+	CodeInvalidIdempotencyKey   = "invalid_idempotency_key"
+	CodeIdempotencyKeyReused    = "idempotency_key_reused"
+	CodeIdempotencyInProgress   = "idempotency_request_in_progress"
+	CodeInternal                = "internal_error"
+)
+
+// FieldError detalle de un campo inválido
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Problem respuesta de error según RFC 7807
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      string       `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// Error implementa la interfaz error
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return p.Detail
+	}
+	return p.Title
+}
+
+// NewProblem crea un problema con el código y estado indicados
+func NewProblem(status int, code, detail string) *Problem {
+	return &Problem{
+		Type:   problemTypeBase + strings.ReplaceAll(code, "_", "-"),
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// errorMapping traduce un error de dominio a estado HTTP y código
+type errorMapping struct {
+	err    error
+	status int
+	code   string
+}
+
+// errorMappings errores conocidos; el primero que coincide con errors.Is gana
+var errorMappings = []errorMapping{
+	{task.ErrTaskNotFound, http.StatusNotFound, CodeTaskNotFound},
+	{task.ErrTaskAlreadyExists, http.StatusConflict, CodeTaskAlreadyExists},
+	{task.ErrInvalidTaskID, http.StatusBadRequest, CodeInvalidTaskID},
+	{task.ErrInvalidID, http.StatusBadRequest, CodeInvalidTaskID},
+	{task.ErrInvalidTaskData, http.StatusUnprocessableEntity, CodeInvalidTaskData},
+	{task.ErrTaskAlreadyCompleted, http.StatusConflict, CodeTaskAlreadyCompleted},
+	{task.ErrTaskAlreadyCancelled, http.StatusConflict, CodeTaskAlreadyCancelled},
+	{task.ErrTaskLocked, http.StatusLocked, CodeTaskLocked},
+	{task.ErrTaskArchived, http.StatusConflict, CodeTaskArchived},
+	{task.ErrTaskNotArchived, http.StatusConflict, CodeTaskNotArchived},
+	{task.ErrQuotaExceeded, http.StatusForbidden, CodeQuotaExceeded},
+	{bulk.ErrJobNotFound, http.StatusNotFound, CodeJobNotFound},
+	{apikey.ErrAPIKeyNotFound, http.StatusNotFound, CodeAPIKeyNotFound},
+	{apikey.ErrAPIKeyRevoked, http.StatusConflict, CodeAPIKeyRevoked},
+	{apikey.ErrInvalidAPIKeyData, http.StatusUnprocessableEntity, CodeInvalidAPIKeyData},
+	{user.ErrEmailTaken, http.StatusConflict, CodeEmailTaken},
+	{user.ErrInvalidUserData, http.StatusUnprocessableEntity, CodeInvalidUserData},
+	{user.ErrInvalidLogin, http.StatusUnauthorized, CodeInvalidLogin},
+	{user.ErrAccountLocked, http.StatusLocked, CodeAccountLocked},
+	{user.ErrInvalidToken, http.StatusUnauthorized, CodeInvalidToken},
+	{user.ErrUserNotFound, http.StatusNotFound, CodeUserNotFound},
+	{user.ErrPasswordResetUnavailable, http.StatusNotImplemented, CodeResetUnavailable},
+	{importer.ErrUnknownFormat, http.StatusBadRequest, CodeInvalidImportFile},
+	{importer.ErrMalformedFile, http.StatusBadRequest, CodeInvalidImportFile},
+	{importer.ErrTooManyRows, http.StatusRequestEntityTooLarge, CodePayloadTooLarge},
+	{task.ErrConcurrentModification, http.StatusConflict, CodeConcurrentModification},
+	{errPreconditionFailed, http.StatusPreconditionFailed, CodePreconditionFailed},
+	{presence.ErrLeaseNotHeld, http.StatusConflict, CodeLeaseNotHeld},
+	{jsonpatch.ErrInvalidPatch, http.StatusBadRequest, CodeInvalidPatch},
+	{jsonpatch.ErrPathNotFound, http.StatusUnprocessableEntity, CodePatchTargetNotFound},
+	{jsonpatch.ErrTestFailed, http.StatusConflict, CodePatchTestFailed},
+	{errInvalidSyncToken, http.StatusBadRequest, CodeInvalidSyncToken},
+	{syncer.ErrInvalidMutation, http.StatusUnprocessableEntity, CodeInvalidSyncMutation},
+	{audit.ErrInvalidQuery, http.StatusBadRequest, CodeInvalidAuditQuery},
+	{privacy.ErrInvalidRequest, http.StatusUnprocessableEntity, CodeInvalidPrivacyRequest},
+	{privacy.ErrErasureNotFound, http.StatusNotFound, CodeErasureNotFound},
+	{auth.ErrMissingCredentials, http.StatusUnauthorized, CodeUnauthorized},
+	{auth.ErrInvalidCredentials, http.StatusUnauthorized, CodeUnauthorized},
+	{auth.ErrInvalidTicket, http.StatusUnauthorized, CodeUnauthorized},
+	{authz.ErrForbidden, http.StatusForbidden, CodeForbidden},
+	{tenant.ErrInvalidTenant, http.StatusBadRequest, CodeInvalidTenant},
+	{tenant.ErrTenantMismatch, http.StatusForbidden, CodeTenantMismatch},
+	{tenant.ErrTenantNotAllowed, http.StatusForbidden, CodeTenantNotAllowed},
+	{auth.ErrKeySetUnavailable, http.StatusServiceUnavailable, CodeAuthUnavailable},
+	{cqrs.ErrHandlerNotFound, http.StatusNotImplemented, CodeCommandNotSupported},
+	{task.ErrTransactionsUnsupported, http.StatusNotImplemented, CodeTransactionsUnsupported},
+}
+
+// ProblemFromError traduce cualquier error a un Problem: errores de dominio,
+// de validación y del command bus. Los errores desconocidos se ocultan tras
+// un 500 genérico
+func ProblemFromError(err error) *Problem {
+	var problem *Problem
+	if errors.As(err, &problem) {
+		return problem
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return NewProblem(http.StatusRequestEntityTooLarge, CodePayloadTooLarge,
+			"the request body accepts at most "+strconv.FormatInt(maxBytesErr.Limit, 10)+" bytes")
+	}
+
+	for _, mapping := range errorMappings {
+		if errors.Is(err, mapping.err) {
+			return NewProblem(mapping.status, mapping.code, mapping.err.Error())
+		}
+	}
+
+	if fieldErrors := validationErrors(err); fieldErrors != nil {
+		problem := NewProblem(http.StatusBadRequest, CodeValidationFailed, "the request body is invalid")
+		problem.Errors = fieldErrors
+		return problem
+	}
+
+	return NewProblem(http.StatusInternalServerError, CodeInternal, "an unexpected error occurred")
+}
+
+// writeProblem traduce el error y aborta la petición con problem+json
+func writeProblem(c *gin.Context, err error) {
+	problem := ProblemFromError(err)
+	if problem.Status >= http.StatusInternalServerError {
+		log.Printf("❌ %s %s failed: %v", c.Request.Method, c.Request.URL.Path, err)
+	}
+
+	abortWithProblem(c, problem)
+}
+
+// abortWithProblem aborta la petición con el problema indicado
+func abortWithProblem(c *gin.Context, problem *Problem) {
+	response := *problem
+	response.Instance = c.Request.URL.Path
+	response.RequestID = cqrs.RequestIDFromContext(c.Request.Context())
+
+	if response.Status == http.StatusUnauthorized {
+		c.Header("WWW-Authenticate", `Bearer realm="go-tasks-microservice"`)
+		c.Writer.Header().Add("WWW-Authenticate", `ApiKey realm="go-tasks-microservice"`)
+	}
+
+	body, err := json.Marshal(response)
+	if err != nil {
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.Abort()
+	c.Data(response.Status, ProblemContentType, body)
+}
+
+// validationErrors extrae los detalles por campo de los errores de binding
+func validationErrors(err error) []FieldError {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fieldErrors := make([]FieldError, 0, len(validationErrs))
+		for _, fieldErr := range validationErrs {
+			fieldErrors = append(fieldErrors, FieldError{
+				Field:   fieldPath(fieldErr.Namespace()),
+				Code:    fieldErr.Tag(),
+				Message: "failed on the '" + fieldErr.Tag() + "' rule",
+			})
+		}
+		return fieldErrors
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return []FieldError{{
+			Field:   typeErr.Field,
+			Code:    "type",
+			Message: "expected " + typeErr.Type.String(),
+		}}
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) || errors.Is(err, errEmptyBody) {
+		return []FieldError{{
+			Field:   "",
+			Code:    "json",
+			Message: err.Error(),
+		}}
+	}
+
+	return nil
+}
+
+// errEmptyBody cuerpo de la petición vacío
+var errEmptyBody = errors.New("request body is empty")
+
+// fieldPath elimina el nombre del struct raíz del namespace del validador
+func fieldPath(namespace string) string {
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return namespace
+}
+
+// fieldError crea un problema de validación para un único campo
+func fieldError(field, code, message string) *Problem {
+	problem := NewProblem(http.StatusBadRequest, CodeValidationFailed, message)
+	problem.Errors = []FieldError{{Field: field, Code: code, Message: message}}
+	return problem
+}
+
+var registerJSONNames sync.Once
+
+// useJSONFieldNames hace que el validador reporte los nombres JSON de los
+// campos en lugar de los nombres Go
+func useJSONFieldNames() {
+	registerJSONNames.Do(func() {
+		engine, ok := binding.Validator.Engine().(*validator.Validate)
+		if !ok {
+			return
+		}
+		engine.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+			if name == "-" || name == "" {
+				return field.Name
+			}
+			return name
+		})
+	})
+}
+
+// bindJSON decodifica el cuerpo y retorna los errores listos para writeProblem
+func bindJSON(c *gin.Context, obj interface{}) error {
+	if c.Request.Body == nil || c.Request.ContentLength == 0 {
+		return errEmptyBody
+	}
+	return c.ShouldBindJSON(obj)
+}
+
+// notFound responde a las rutas inexistentes
+func notFound(c *gin.Context) {
+	abortWithProblem(c, NewProblem(http.StatusNotFound, CodeNotFound, "no route for "+c.Request.Method+" "+c.Request.URL.Path))
+}
+
+// recovery traduce los pánicos a un problema 500
+func recovery(c *gin.Context, recovered interface{}) {
+	log.Printf("❌ panic serving %s %s: %v", c.Request.Method, c.Request.URL.Path, recovered)
+	abortWithProblem(c, NewProblem(http.StatusInternalServerError, CodeInternal, "an unexpected error occurred"))
+}
@@ -0,0 +1,37 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+	"github.com/yebrai/go-tasks-microservice/pkg/cqrs"
+)
+
+func TestProblemFromError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{"wrapped not found", fmt.Errorf("task not found: %w", task.ErrTaskNotFound), http.StatusNotFound, CodeTaskNotFound},
+		{"already completed", fmt.Errorf("failed to complete task: %w", task.ErrTaskAlreadyCompleted), http.StatusConflict, CodeTaskAlreadyCompleted},
+		{"invalid id", task.ErrInvalidID, http.StatusBadRequest, CodeInvalidTaskID},
+		{"locked", task.ErrTaskLocked, http.StatusLocked, CodeTaskLocked},
+		{"missing handler", fmt.Errorf("%w: x", cqrs.ErrHandlerNotFound), http.StatusNotImplemented, CodeCommandNotSupported},
+		{"empty body", errEmptyBody, http.StatusBadRequest, CodeValidationFailed},
+		{"unknown", errors.New("connection reset"), http.StatusInternalServerError, CodeInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problem := ProblemFromError(tt.err)
+			if problem.Status != tt.wantStatus || problem.Code != tt.wantCode {
+				t.Errorf("Expected %d/%s, got %d/%s", tt.wantStatus, tt.wantCode, problem.Status, problem.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package http
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yebrai/go-tasks-microservice/pkg/auth"
+	"github.com/yebrai/go-tasks-microservice/pkg/ratelimit"
+	"github.com/yebrai/go-tasks-microservice/pkg/tenant"
+)
+
+// Clases de rutas con límites de peticiones propios
+const (
+	RouteClassRead  = "read"
+	RouteClassWrite = "write"
+	RouteClassBulk  = "bulk"
+	RouteClassAuth  = "auth"
+)
+
+// Cabeceras de las respuestas limitadas (draft-ietf-httpapi-ratelimit-headers)
+const (
+	RateLimitLimitHeader     = "RateLimit-Limit"
+	RateLimitRemainingHeader = "RateLimit-Remaining"
+	RateLimitResetHeader     = "RateLimit-Reset"
+	RetryAfterHeader         = "Retry-After"
+)
+
+// bulkRoutes rutas que procesan muchas tareas por petición
+var bulkRoutes = map[string]bool{
+	"/api/v1/tasks/bulk/transition": true,
+	"/api/v1/tasks/bulk/update":     true,
+	"/api/v1/tasks/import":          true,
+	"/api/v1/tasks/export":          true,
+	"/api/v1/sync":                  true,
+	"/api/v1/batch":                 true,
+}
+
+// RateLimitOptions límites de peticiones de la API
+type RateLimitOptions struct {
+	// Limiter guarda los token buckets; nil deshabilita los límites
+	Limiter ratelimit.Limiter
+	// Classes límite de cada clase de ruta; las clases sin límite no se
+	// restringen
+	Classes map[string]ratelimit.Limit
+	// ByTenant comparte un bucket por tenant en lugar de uno por clave de
+	// API, usuario o IP
+	ByTenant bool
+}
+
+// routeClass clasifica la ruta de la petición
+func routeClass(c *gin.Context) string {
+	path := c.FullPath()
+	switch {
+	case strings.HasPrefix(path, "/api/v1/auth/"):
+		return RouteClassAuth
+	case bulkRoutes[path]:
+		return RouteClassBulk
+	case c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead:
+		return RouteClassRead
+	default:
+		return RouteClassWrite
+	}
+}
+
+// rateLimitKey identifica el bucket de la petición: el tenant, la clave de
+// API, el principal autenticado o, sin autenticación, la IP del cliente
+func (s *Server) rateLimitKey(c *gin.Context, class string) string {
+	ctx := c.Request.Context()
+	if s.rateLimit.ByTenant {
+		return class + ":tenant:" + tenant.FromContext(ctx)
+	}
+	if principal, ok := auth.PrincipalFromContext(ctx); ok && principal.APIKeyID != "" {
+		return class + ":apikey:" + principal.APIKeyID
+	}
+	if principal, ok := auth.PrincipalFromContext(ctx); ok && principal.Authenticated() {
+		return class + ":principal:" + principal.Subject
+	}
+	return class + ":ip:" + c.ClientIP()
+}
+
+// rateLimitMiddleware aplica el límite de la clase de la ruta. Las
+// peticiones que lo superan responden 429 con Retry-After. Si el limitador
+// falla la petición pasa
+func (s *Server) rateLimitMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		class := routeClass(c)
+		limit, ok := s.rateLimit.Classes[class]
+		if s.rateLimit.Limiter == nil || !ok || !limit.Enabled() {
+			c.Next()
+			return
+		}
+
+		decision, err := s.rateLimit.Limiter.Allow(c.Request.Context(), s.rateLimitKey(c, class), limit)
+		if err != nil {
+			log.Printf("⚠️  Rate limiter unavailable, allowing %s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+			c.Next()
+			return
+		}
+
+		c.Header(RateLimitLimitHeader, strconv.Itoa(decision.Limit))
+		c.Header(RateLimitRemainingHeader, strconv.Itoa(decision.Remaining))
+		c.Header(RateLimitResetHeader, ceilSeconds(decision.Reset))
+		if !decision.Allowed {
+			c.Header(RetryAfterHeader, ceilSeconds(decision.RetryAfter))
+			abortWithProblem(c, NewProblem(http.StatusTooManyRequests, CodeRateLimited, "rate limit exceeded for "+class+" requests"))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// ceilSeconds formatea una duración en segundos enteros redondeando hacia
+// arriba
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,120 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/yebrai/go-tasks-microservice/internal/task/creator"
+	"github.com/yebrai/go-tasks-microservice/internal/task/presence"
+	"github.com/yebrai/go-tasks-microservice/pkg/auth"
+	"github.com/yebrai/go-tasks-microservice/pkg/cqrs/inmem"
+	"github.com/yebrai/go-tasks-microservice/pkg/events"
+	"github.com/yebrai/go-tasks-microservice/pkg/ratelimit"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	authenticator := auth.NewStaticTokenAuthenticator([]auth.StaticToken{
+		{Token: "first-token", Principal: auth.Principal{Subject: "first-app", Scopes: []string{auth.ScopeTasksWrite}, Tenant: "acme"}},
+		{Token: "second-token", Principal: auth.Principal{Subject: "second-app", Scopes: []string{auth.ScopeTasksWrite}, Tenant: "acme"}},
+	})
+
+	tests := []struct {
+		name       string
+		byTenant   bool
+		wantSecond int
+	}{
+		{"each principal has its own bucket", false, http.StatusCreated},
+		{"principals of a tenant share its bucket", true, http.StatusTooManyRequests},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := inmem.NewCommandBus()
+			if err := bus.Register(creator.CreateTaskCommandType, &tenantRecorder{}); err != nil {
+				t.Fatal(err)
+			}
+			handler := NewServer(bus, nil, events.NewNoOpEventBus(), presence.NewRegistry(), Options{
+				Authenticator: authenticator,
+				MultiTenant:   true,
+				RateLimit: RateLimitOptions{
+					Limiter:  ratelimit.NewMemoryLimiter(),
+					Classes:  map[string]ratelimit.Limit{RouteClassWrite: ratelimit.PerMinute(60, 2)},
+					ByTenant: tt.byTenant,
+				},
+			}).Handler()
+
+			create := func(token string) *httptest.ResponseRecorder {
+				req := httptest.NewRequest(http.MethodPost, "/api/v1/tasks", strings.NewReader(`{"title":"Nueva"}`))
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set("Authorization", "Bearer "+token)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+				return rec
+			}
+
+			for i, wantRemaining := range []string{"1", "0"} {
+				rec := create("first-token")
+				if rec.Code != http.StatusCreated {
+					t.Fatalf("Request %d: expected 201, got %d: %s", i+1, rec.Code, rec.Body.String())
+				}
+				if got := rec.Header().Get(RateLimitRemainingHeader); got != wantRemaining {
+					t.Errorf("Request %d: expected %s remaining, got %q", i+1, wantRemaining, got)
+				}
+			}
+
+			rec := create("first-token")
+			if rec.Code != http.StatusTooManyRequests {
+				t.Fatalf("Expected 429 once the burst is spent, got %d", rec.Code)
+			}
+			var problem Problem
+			if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil || problem.Code != CodeRateLimited {
+				t.Errorf("Expected code %s, got %s", CodeRateLimited, rec.Body.String())
+			}
+			for header, want := range map[string]string{
+				RetryAfterHeader:         "1",
+				RateLimitLimitHeader:     "2",
+				RateLimitRemainingHeader: "0",
+				RateLimitResetHeader:     "2",
+			} {
+				if got := rec.Header().Get(header); got != want {
+					t.Errorf("Expected %s %q, got %q", header, want, got)
+				}
+			}
+
+			if rec := create("second-token"); rec.Code != tt.wantSecond {
+				t.Errorf("Expected %d for the second principal, got %d", tt.wantSecond, rec.Code)
+			}
+		})
+	}
+}
+
+func TestRouteClasses(t *testing.T) {
+	handler := NewServer(inmem.NewCommandBus(), nil, events.NewNoOpEventBus(), presence.NewRegistry(), Options{
+		RateLimit: RateLimitOptions{
+			Limiter: ratelimit.NewMemoryLimiter(),
+			Classes: map[string]ratelimit.Limit{RouteClassBulk: ratelimit.PerMinute(1, 1)},
+		},
+	}).Handler()
+
+	tests := []struct {
+		method  string
+		path    string
+		limited bool
+	}{
+		{http.MethodPost, "/api/v1/tasks/bulk/transition", true},
+		{http.MethodPost, "/api/v1/batch", true},
+		{http.MethodPost, "/api/v1/tasks", false},
+		{http.MethodGet, "/api/v1/jobs/8f14e45f-ceea-467f-a8f0-2b5c1a2e3d4c", false},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+		if limited := rec.Header().Get(RateLimitLimitHeader) != ""; limited != tt.limited {
+			t.Errorf("%s %s: expected limited=%v", tt.method, tt.path, tt.limited)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultMaxBodyBytes tamaño máximo por defecto del cuerpo de una petición
+const DefaultMaxBodyBytes = 1 << 20
+
+// DefaultRouteMaxBodyBytes rutas con un tamaño máximo propio, con la clave
+// "MÉTODO /ruta" en el formato de Gin
+var DefaultRouteMaxBodyBytes = map[string]int64{
+	http.MethodPost + " /api/v1/tasks/import": MaxImportBytes,
+}
+
+// Valores por defecto de CORS
+var (
+	defaultCORSMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE"}
+	defaultCORSHeaders = []string{"Content-Type", "Authorization", "X-Request-ID", "X-Tenant-ID", "If-Match", "If-None-Match", "Idempotency-Key"}
+	defaultCORSExposed = []string{"ETag", "Location", "X-Request-ID", "Idempotent-Replayed", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After"}
+)
+
+// apiContentSecurityPolicy política de las respuestas del API, que nunca
+// deben interpretarse como documentos
+const apiContentSecurityPolicy = "default-src 'none'; frame-ancestors 'none'"
+
+// CORSOptions peticiones de navegador desde otros orígenes
+type CORSOptions struct {
+	// AllowedOrigins orígenes admitidos; "*" admite cualquiera y vacío
+	// deshabilita CORS
+	AllowedOrigins []string
+	// AllowedMethods métodos admitidos; vacío usa GET, POST, PUT, PATCH y
+	// DELETE
+	AllowedMethods []string
+	// AllowedHeaders cabeceras que puede enviar el cliente; vacío usa las
+	// que entiende el API
+	AllowedHeaders []string
+	// ExposedHeaders cabeceras de la respuesta visibles para el cliente;
+	// vacío usa las que emite el API
+	ExposedHeaders []string
+	// AllowCredentials permite enviar cookies y cabecera Authorization del
+	// navegador. Con "*" se responde con el origen concreto de la petición
+	AllowCredentials bool
+	// MaxAge tiempo que el navegador puede cachear la respuesta al preflight
+	MaxAge time.Duration
+}
+
+// SecurityHeadersOptions cabeceras de seguridad de las respuestas
+type SecurityHeadersOptions struct {
+	// HSTSMaxAge max-age de Strict-Transport-Security; 0 no la envía.
+	// Solo debe activarse si el servicio se publica únicamente por HTTPS
+	HSTSMaxAge            time.Duration
+	HSTSIncludeSubdomains bool
+}
+
+// allowsOrigin indica si el origen está admitido
+func (o CORSOptions) allowsOrigin(origin string) bool {
+	for _, allowed := range o.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowOriginValue valor de Access-Control-Allow-Origin para el origen
+func (o CORSOptions) allowOriginValue(origin string) string {
+	if !o.AllowCredentials && len(o.AllowedOrigins) == 1 && o.AllowedOrigins[0] == "*" {
+		return "*"
+	}
+	return origin
+}
+
+// orDefault retorna values o, si está vacío, defaults
+func orDefault(values, defaults []string) []string {
+	if len(values) == 0 {
+		return defaults
+	}
+	return values
+}
+
+// corsMiddleware responde a los preflight y añade las cabeceras CORS a las
+// peticiones de los orígenes admitidos. Las de otros orígenes se atienden
+// sin cabeceras CORS y el navegador las bloquea
+func (s *Server) corsMiddleware() gin.HandlerFunc {
+	cors := s.cors
+	methods := strings.Join(orDefault(cors.AllowedMethods, defaultCORSMethods), ", ")
+	headers := strings.Join(orDefault(cors.AllowedHeaders, defaultCORSHeaders), ", ")
+	exposed := strings.Join(orDefault(cors.ExposedHeaders, defaultCORSExposed), ", ")
+	maxAge := strconv.Itoa(int(cors.MaxAge.Seconds()))
+
+	return func(c *gin.Context) {
+		if len(cors.AllowedOrigins) == 0 {
+			c.Next()
+			return
+		}
+
+		origin := c.GetHeader("Origin")
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+
+		// La respuesta depende del origen aunque no se admita, así que las
+		// cachés no deben compartirla entre orígenes
+		c.Writer.Header().Add("Vary", "Origin")
+		if origin == "" || !cors.allowsOrigin(origin) {
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		c.Header("Access-Control-Allow-Origin", cors.allowOriginValue(origin))
+		if cors.AllowCredentials {
+			c.Header("Access-Control-Allow-Credentials", "true")
+		}
+
+		if preflight {
+			c.Writer.Header().Add("Vary", "Access-Control-Request-Method")
+			c.Writer.Header().Add("Vary", "Access-Control-Request-Headers")
+			c.Header("Access-Control-Allow-Methods", methods)
+			c.Header("Access-Control-Allow-Headers", headers)
+			if cors.MaxAge > 0 {
+				c.Header("Access-Control-Max-Age", maxAge)
+			}
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		c.Header("Access-Control-Expose-Headers", exposed)
+		c.Next()
+	}
+}
+
+// securityHeadersMiddleware añade las cabeceras de seguridad. Las páginas
+// HTML sustituyen la Content-Security-Policy por la suya
+func (s *Server) securityHeadersMiddleware() gin.HandlerFunc {
+	var hsts string
+	if s.securityHeaders.HSTSMaxAge > 0 {
+		hsts = "max-age=" + strconv.Itoa(int(s.securityHeaders.HSTSMaxAge.Seconds()))
+		if s.securityHeaders.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+	}
+
+	return func(c *gin.Context) {
+		header := c.Writer.Header()
+		header.Set("X-Content-Type-Options", "nosniff")
+		header.Set("X-Frame-Options", "DENY")
+		header.Set("Referrer-Policy", "no-referrer")
+		header.Set("Content-Security-Policy", apiContentSecurityPolicy)
+		if hsts != "" {
+			header.Set("Strict-Transport-Security", hsts)
+		}
+
+		c.Next()
+	}
+}
+
+// bodyLimitMiddleware limita el tamaño del cuerpo según la ruta. Los cuerpos
+// que declaran un Content-Length mayor se rechazan sin leerlos; el resto
+// fallan con *http.MaxBytesError al superar el límite
+func (s *Server) bodyLimitMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, ok := s.routeMaxBodyBytes[c.Request.Method+" "+c.FullPath()]
+		if !ok {
+			limit = s.maxBodyBytes
+		}
+
+		if c.Request.ContentLength > limit {
+			writeProblem(c, &http.MaxBytesError{Limit: limit})
+			return
+		}
+		if c.Request.Body != nil && c.Request.Body != http.NoBody {
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		}
+
+		c.Next()
+	}
+}
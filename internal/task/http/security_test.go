@@ -0,0 +1,171 @@
+package http
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yebrai/go-tasks-microservice/internal/task/creator"
+	"github.com/yebrai/go-tasks-microservice/internal/task/presence"
+	"github.com/yebrai/go-tasks-microservice/pkg/cqrs/inmem"
+	"github.com/yebrai/go-tasks-microservice/pkg/events"
+)
+
+func newSecurityTestServer(t *testing.T, options Options) http.Handler {
+	bus := inmem.NewCommandBus()
+	if err := bus.Register(creator.CreateTaskCommandType, &tenantRecorder{}); err != nil {
+		t.Fatal(err)
+	}
+	return NewServer(bus, nil, events.NewNoOpEventBus(), presence.NewRegistry(), options).Handler()
+}
+
+func TestCORSMiddleware(t *testing.T) {
+	tests := []struct {
+		name        string
+		cors        CORSOptions
+		method      string
+		origin      string
+		wantStatus  int
+		wantHeaders map[string]string
+	}{
+		{
+			name:       "disabled without origins",
+			method:     http.MethodOptions,
+			origin:     "https://app.example.com",
+			wantStatus: http.StatusNotFound,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "",
+			},
+		},
+		{
+			name:       "preflight from an allowed origin",
+			cors:       CORSOptions{AllowedOrigins: []string{"https://app.example.com"}, MaxAge: 10 * time.Minute},
+			method:     http.MethodOptions,
+			origin:     "https://app.example.com",
+			wantStatus: http.StatusNoContent,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":  "https://app.example.com",
+				"Access-Control-Allow-Methods": "GET, POST, PUT, PATCH, DELETE",
+				"Access-Control-Max-Age":       "600",
+			},
+		},
+		{
+			name:       "preflight from another origin",
+			cors:       CORSOptions{AllowedOrigins: []string{"https://app.example.com"}},
+			method:     http.MethodOptions,
+			origin:     "https://evil.example.com",
+			wantStatus: http.StatusForbidden,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "",
+			},
+		},
+		{
+			name:       "request from an allowed origin",
+			cors:       CORSOptions{AllowedOrigins: []string{"*"}},
+			method:     http.MethodPost,
+			origin:     "https://app.example.com",
+			wantStatus: http.StatusCreated,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "*",
+				"Access-Control-Allow-Credentials": "",
+				"Vary":                             "Origin",
+			},
+		},
+		{
+			name:       "credentials never use the wildcard",
+			cors:       CORSOptions{AllowedOrigins: []string{"*"}, AllowCredentials: true},
+			method:     http.MethodPost,
+			origin:     "https://app.example.com",
+			wantStatus: http.StatusCreated,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "https://app.example.com",
+				"Access-Control-Allow-Credentials": "true",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := newSecurityTestServer(t, Options{CORS: tt.cors})
+
+			req := httptest.NewRequest(tt.method, "/api/v1/tasks", strings.NewReader(`{"title":"Nueva"}`))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Origin", tt.origin)
+			if tt.method == http.MethodOptions {
+				req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("Expected %d, got %d", tt.wantStatus, rec.Code)
+			}
+			for header, want := range tt.wantHeaders {
+				if got := rec.Header().Get(header); got != want {
+					t.Errorf("Expected %s %q, got %q", header, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	handler := newSecurityTestServer(t, Options{SecurityHeaders: SecurityHeadersOptions{HSTSMaxAge: 365 * 24 * time.Hour}})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/openapi.json", nil))
+	for header, want := range map[string]string{
+		"X-Content-Type-Options":    "nosniff",
+		"Content-Security-Policy":   apiContentSecurityPolicy,
+		"Strict-Transport-Security": "max-age=31536000",
+	} {
+		if got := rec.Header().Get(header); got != want {
+			t.Errorf("Expected %s %q, got %q", header, want, got)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/docs", nil))
+	if got := rec.Header().Get("Content-Security-Policy"); got != docsContentSecurityPolicy {
+		t.Errorf("Expected the docs page to use its own policy, got %q", got)
+	}
+}
+
+func TestBodyLimitMiddleware(t *testing.T) {
+	handler := newSecurityTestServer(t, Options{
+		MaxBodyBytes:      64,
+		RouteMaxBodyBytes: map[string]int64{"POST /api/v1/batch": 16},
+	})
+	large := `{"title":"` + strings.Repeat("a", 100) + `"}`
+
+	tests := []struct {
+		name       string
+		path       string
+		body       io.Reader
+		wantStatus int
+	}{
+		{"within the limit", "/api/v1/tasks", strings.NewReader(`{"title":"Nueva"}`), http.StatusCreated},
+		{"declared length over the limit", "/api/v1/tasks", strings.NewReader(large), http.StatusRequestEntityTooLarge},
+		{"unknown length over the limit", "/api/v1/tasks", io.MultiReader(strings.NewReader(large)), http.StatusRequestEntityTooLarge},
+		{"route limit", "/api/v1/batch", strings.NewReader(`{"operations":[]}`), http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, tt.body)
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("Expected %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+			if tt.wantStatus == http.StatusRequestEntityTooLarge && !strings.Contains(rec.Body.String(), CodePayloadTooLarge) {
+				t.Errorf("Expected code %s, got %s", CodePayloadTooLarge, rec.Body.String())
+			}
+		})
+	}
+}
@@ -5,27 +5,186 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/yebrai/go-tasks-microservice/internal/apikey"
+	"github.com/yebrai/go-tasks-microservice/internal/audit"
+	"github.com/yebrai/go-tasks-microservice/internal/privacy"
 	"github.com/yebrai/go-tasks-microservice/internal/task"
+	"github.com/yebrai/go-tasks-microservice/internal/task/authz"
+	"github.com/yebrai/go-tasks-microservice/internal/task/batch"
+	"github.com/yebrai/go-tasks-microservice/internal/task/bulk"
+	"github.com/yebrai/go-tasks-microservice/internal/task/presence"
+	"github.com/yebrai/go-tasks-microservice/internal/task/syncer"
+	"github.com/yebrai/go-tasks-microservice/internal/user"
+	"github.com/yebrai/go-tasks-microservice/pkg/auth"
+	"github.com/yebrai/go-tasks-microservice/pkg/certs"
 	"github.com/yebrai/go-tasks-microservice/pkg/cqrs"
 	"github.com/yebrai/go-tasks-microservice/pkg/events"
+	"github.com/yebrai/go-tasks-microservice/pkg/id"
+	"github.com/yebrai/go-tasks-microservice/pkg/idempotency"
 )
 
+// Options configuración opcional del servidor HTTP
+type Options struct {
+	// Authenticator valida los tokens de REST y WebSocket; nil deshabilita
+	// la autenticación
+	Authenticator auth.Authenticator
+	// Tickets emite los tickets de un solo uso para abrir el WebSocket
+	Tickets *auth.TicketStore
+	// ClientCertificates autentica los certificados de cliente mTLS de las
+	// peticiones sin cabecera Authorization; nil los ignora
+	ClientCertificates *auth.CertificateAuthenticator
+	// TLS certificados que sirve el servidor; nil omite su estado en /health
+	TLS *certs.Reloader
+	// AllowedOrigins orígenes aceptados en el upgrade a WebSocket
+	AllowedOrigins []string
+	// CORS peticiones REST de navegador desde otros orígenes
+	CORS CORSOptions
+	// SecurityHeaders cabeceras de seguridad de las respuestas
+	SecurityHeaders SecurityHeadersOptions
+	// MaxBodyBytes tamaño máximo del cuerpo de las peticiones; 0 usa
+	// DefaultMaxBodyBytes
+	MaxBodyBytes int64
+	// RouteMaxBodyBytes sustituye MaxBodyBytes en las rutas indicadas como
+	// "MÉTODO /ruta" (p. ej. "POST /api/v1/tasks/import"). Se añade a
+	// DefaultRouteMaxBodyBytes
+	RouteMaxBodyBytes map[string]int64
+	// ConflictPolicy política de conflictos de la sincronización offline
+	ConflictPolicy syncer.Policy
+	// Idempotency almacena las respuestas de las peticiones con
+	// Idempotency-Key; nil desactiva la cabecera
+	Idempotency idempotency.Store
+	// Jobs almacena el progreso de los comandos masivos; debe ser el mismo
+	// que usan sus handlers. nil usa un almacén en memoria
+	Jobs bulk.JobStore
+	// APIKeys gestiona las claves de API y autentica el esquema "ApiKey".
+	// nil usa un repositorio en memoria
+	APIKeys *apikey.Service
+	// Audit registro de auditoría que consulta /audit; debe ser el mismo
+	// que usa el command bus. nil usa un repositorio en memoria
+	Audit *audit.Log
+	// Privacy exporta y suprime los datos personales; debe ser el mismo
+	// servicio que usa el handler de privacy.EraseSubjectCommand. nil usa
+	// un registro de supresiones en memoria
+	Privacy *privacy.Service
+	// Users gestiona las cuentas de usuario propias; sus access tokens se
+	// validan con Authenticator. nil deshabilita las rutas /auth
+	Users *user.Service
+	// Policy autoriza las consultas; debe ser la misma que aplica el command
+	// bus. nil deshabilita la autorización
+	Policy *authz.Policy
+	// MultiTenant aísla las tareas por tenant, resuelto del principal o de
+	// la cabecera X-Tenant-ID. false usa siempre el tenant por defecto
+	MultiTenant bool
+	// SelectableTenants tenants que los principales sin tenant (tokens
+	// estáticos, JWT sin claim tenant, la autenticación deshabilitada)
+	// pueden elegir con X-Tenant-ID; "*" admite cualquiera. Vacío solo
+	// permite el tenant por defecto
+	SelectableTenants []string
+	// RateLimit límites de peticiones por clase de ruta
+	RateLimit RateLimitOptions
+	// MaxBatchOperations máximo de operaciones por lote en /batch; 0 usa
+	// batch.DefaultMaxOperations
+	MaxBatchOperations int
+}
+
 // Server maneja el servidor HTTP
 type Server struct {
-	commandBus cqrs.CommandBus
-	repository task.Repository
-	handler    *TaskHandler
-	wsHandler  *WebSocketHandler
+	commandBus  cqrs.CommandBus
+	repository  task.Repository
+	handler     *TaskHandler
+	changes     *ChangesHandler
+	sync        *SyncHandler
+	batch       *BatchHandler
+	bulk        *BulkHandler
+	apiKeys     *APIKeyHandler
+	users       *UserHandler
+	audit       *AuditHandler
+	privacy     *PrivacyHandler
+	wsHandler   *WebSocketHandler
+	auth        *authGate
+	idempotency idempotency.Store
+	rateLimit   RateLimitOptions
+	tls         *certs.Reloader
+
+	cors              CORSOptions
+	securityHeaders   SecurityHeadersOptions
+	maxBodyBytes      int64
+	routeMaxBodyBytes map[string]int64
 }
 
 // NewServer crea una nueva instancia del servidor HTTP
-func NewServer(commandBus cqrs.CommandBus, repository task.Repository, eventBus events.EventBus) *Server {
-	wsHandler := NewWebSocketHandler(eventBus)
+func NewServer(
+	commandBus cqrs.CommandBus,
+	repository task.Repository,
+	eventBus events.EventBus,
+	presenceRegistry *presence.Registry,
+	options Options,
+) *Server {
+	if options.Tickets == nil {
+		options.Tickets = auth.NewTicketStore(auth.DefaultTicketTTL)
+	}
+	if options.Jobs == nil {
+		options.Jobs = bulk.NewMemoryJobStore(bulk.DefaultJobRetention)
+	}
+	if options.APIKeys == nil {
+		options.APIKeys = apikey.NewService(apikey.NewMemoryRepository(), id.NewUniqueIDGenerator())
+	}
+	if options.Audit == nil {
+		options.Audit = audit.NewLog(audit.NewMemoryRepository(), id.NewUniqueIDGenerator())
+	}
+	if options.Privacy == nil {
+		accounts := privacy.Accounts{APIKeys: options.APIKeys, Users: options.Users}
+		options.Privacy = privacy.NewService(repository, eventBus, options.Audit, privacy.NewMemoryErasureRepository(), id.NewUniqueIDGenerator(), accounts, options.Idempotency)
+	}
+	if options.ConflictPolicy == "" {
+		options.ConflictPolicy = syncer.PolicyReject
+	}
+	if options.MaxBodyBytes <= 0 {
+		options.MaxBodyBytes = DefaultMaxBodyBytes
+	}
+	routeMaxBodyBytes := make(map[string]int64, len(DefaultRouteMaxBodyBytes)+len(options.RouteMaxBodyBytes))
+	for route, limit := range DefaultRouteMaxBodyBytes {
+		routeMaxBodyBytes[route] = limit
+	}
+	for route, limit := range options.RouteMaxBodyBytes {
+		routeMaxBodyBytes[route] = limit
+	}
+
+	gate := &authGate{
+		authenticator:  options.Authenticator,
+		apiKeys:        options.APIKeys,
+		certificates:   options.ClientCertificates,
+		tickets:        options.Tickets,
+		allowedOrigins: options.AllowedOrigins,
+		multiTenant:    options.MultiTenant,
+
+		selectableTenants: options.SelectableTenants,
+	}
+
+	wsHandler := NewWebSocketHandler(eventBus, commandBus, repository, presenceRegistry, gate, options.Policy)
 	return &Server{
-		commandBus: commandBus,
-		repository: repository,
-		handler:    NewTaskHandler(commandBus, repository, wsHandler),
-		wsHandler:  wsHandler,
+		commandBus:  commandBus,
+		repository:  repository,
+		handler:     NewTaskHandler(commandBus, repository, options.Policy),
+		changes:     NewChangesHandler(repository, eventBus, options.Policy),
+		sync:        NewSyncHandler(syncer.NewService(commandBus, repository, options.ConflictPolicy), options.Policy),
+		bulk:        NewBulkHandler(bulk.NewLauncher(commandBus, options.Jobs, id.NewUniqueIDGenerator()), options.Policy),
+		apiKeys:     NewAPIKeyHandler(options.APIKeys),
+		users:       NewUserHandler(options.Users),
+		audit:       NewAuditHandler(options.Audit),
+		privacy:     NewPrivacyHandler(commandBus, options.Privacy, id.NewUniqueIDGenerator()),
+		batch:       NewBatchHandler(batch.NewService(commandBus, repository, id.NewUniqueIDGenerator()), options.MaxBatchOperations),
+		wsHandler:   wsHandler,
+		auth:        gate,
+		idempotency: options.Idempotency,
+		rateLimit:   options.RateLimit,
+		tls:         options.TLS,
+
+		cors:              options.CORS,
+		securityHeaders:   options.SecurityHeaders,
+		maxBodyBytes:      options.MaxBodyBytes,
+		routeMaxBodyBytes: routeMaxBodyBytes,
 	}
 }
 
@@ -37,13 +196,20 @@ func (s *Server) Handler() http.Handler {
 	// Crear router
 	router := gin.New()
 
+	// Los errores de validación usan los nombres JSON de los campos
+	useJSONFieldNames()
+
 	// Middleware
-	router.Use(gin.Recovery())
+	router.Use(s.requestIDMiddleware())
+	router.Use(gin.CustomRecovery(recovery))
 	router.Use(s.loggingMiddleware())
+	router.Use(s.securityHeadersMiddleware())
 	router.Use(s.corsMiddleware())
+	router.Use(s.bodyLimitMiddleware())
 
 	// Configurar rutas
 	s.setupRoutes(router)
+	router.NoRoute(notFound)
 
 	return router
 }
@@ -56,15 +222,84 @@ func (s *Server) setupRoutes(router *gin.Engine) {
 	// WebSocket endpoint
 	router.GET("/ws/events", s.wsHandler.HandleWebSocket)
 
+	// Documentación del API
+	router.GET("/api/v1/openapi.json", s.serveOpenAPI)
+	router.GET("/api/v1/docs", s.serveDocs)
+
+	// Cuentas de usuario: rutas públicas, sin autenticación
+	accounts := router.Group("/api/v1/auth")
+	accounts.Use(s.users.enabled, s.accountTenantMiddleware(), s.rateLimitMiddleware(), s.openAPIValidation())
+	{
+		accounts.POST("/register", s.users.Register)
+		accounts.POST("/login", s.users.Login)
+		accounts.POST("/refresh", s.users.Refresh)
+		accounts.POST("/revoke", s.users.Revoke)
+		accounts.POST("/password-reset", s.users.RequestPasswordReset)
+		accounts.POST("/password-reset/confirm", s.users.ConfirmPasswordReset)
+	}
+
+	// Rutas que devuelven secretos: quedan fuera de Idempotency-Key para que
+	// la respuesta no se guarde ni se repita. Los tickets del WebSocket son de
+	// un solo uso y las claves de API solo se muestran al crearlas
+	secrets := router.Group("/api/v1")
+	secrets.Use(s.audit.recordDenied(), s.authMiddleware(), s.tenantMiddleware(), s.rateLimitMiddleware(), s.openAPIValidation())
+	{
+		secrets.POST("/ws/tickets", s.issueWebSocketTicket)
+
+		apiKeys := secrets.Group("/admin/api-keys")
+		{
+			admin := requireScope(auth.ScopeAdmin)
+			apiKeys.GET("", admin, s.apiKeys.ListAPIKeys)
+			apiKeys.POST("", admin, s.apiKeys.CreateAPIKey)
+			apiKeys.DELETE("/:id", admin, s.apiKeys.RevokeAPIKey)
+		}
+	}
+
 	// API v1
 	api := router.Group("/api/v1")
+	api.Use(s.audit.recordDenied(), s.authMiddleware(), s.tenantMiddleware(), s.rateLimitMiddleware(), s.openAPIValidation(), s.idempotencyMiddleware())
 	{
+		api.POST("/auth/logout", s.users.enabled, s.users.Logout)
+
+		read := requireScope(auth.ScopeTasksRead)
+		write := requireScope(auth.ScopeTasksWrite)
+
 		tasks := api.Group("/tasks")
 		{
-			tasks.GET("", s.handler.GetTasks)
-			tasks.POST("", s.handler.CreateTask)
-			tasks.GET("/:id", s.handler.GetTask)
-			tasks.PUT("/:id", s.handler.UpdateTask)
+			tasks.GET("", read, s.handler.GetTasks)
+			tasks.GET("/export", read, s.handler.ExportTasks)
+			tasks.POST("", write, s.handler.CreateTask)
+			tasks.POST("/bulk/transition", write, s.bulk.BulkTransition)
+			tasks.POST("/bulk/update", write, s.bulk.BulkUpdate)
+			tasks.POST("/import", write, s.bulk.ImportTasks)
+			tasks.GET("/:id", read, s.handler.GetTask)
+			tasks.PUT("/:id", write, s.handler.ReplaceTask)
+			tasks.PATCH("/:id", write, s.handler.PatchTask)
+			tasks.DELETE("/:id", write, s.handler.ArchiveTask)
+		}
+
+		trash := api.Group("/trash")
+		{
+			trash.GET("", read, s.handler.GetTrash)
+			trash.POST("/:id/restore", write, s.handler.RestoreTask)
+			trash.DELETE("/:id", write, s.handler.PurgeTask)
+		}
+
+		api.GET("/changes", read, s.changes.GetChanges)
+		api.POST("/sync", write, s.sync.Sync)
+		api.POST("/batch", write, s.batch.ExecuteBatch)
+		api.GET("/jobs/:id", read, s.bulk.GetJob)
+
+		admin := requireScope(auth.ScopeAdmin)
+		api.PUT("/admin/users/:id/roles", s.users.enabled, admin, s.users.SetRoles)
+
+		api.GET("/audit", admin, s.audit.GetAudit)
+
+		api.GET("/admin/subjects/:subject/export", admin, s.privacy.ExportSubject)
+		erasures := api.Group("/admin/erasures")
+		{
+			erasures.GET("", admin, s.privacy.ListErasures)
+			erasures.POST("", admin, s.privacy.EraseSubject)
 		}
 	}
 }
@@ -78,12 +313,17 @@ func (s *Server) healthCheck(c *gin.Context) {
 		dbStatus = "disconnected"
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	health := gin.H{
 		"status":   "ok",
 		"service":  "go-tasks-microservice",
 		"database": dbStatus,
 		"rabbitmq": "connected", // Por ahora asumimos que está conectado, mejorar en el futuro
-	})
+	}
+	if s.tls != nil {
+		health["tls"] = s.tls.Status()
+	}
+
+	c.JSON(http.StatusOK, health)
 }
 
 // loggingMiddleware middleware de logging
@@ -99,18 +339,23 @@ func (s *Server) loggingMiddleware() gin.HandlerFunc {
 	})
 }
 
-// corsMiddleware middleware CORS
-func (s *Server) corsMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
+// RequestIDHeader cabecera usada para correlacionar peticiones y eventos
+const RequestIDHeader = "X-Request-ID"
 
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(http.StatusOK)
-			return
+// requestIDMiddleware propaga el ID de petición y su procedencia al
+// contexto de los comandos
+func (s *Server) requestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if requestID == "" {
+			requestID = uuid.New().String()
 		}
 
+		c.Header(RequestIDHeader, requestID)
+		ctx := cqrs.WithRequestID(c.Request.Context(), requestID)
+		ctx = cqrs.WithOrigin(ctx, cqrs.Origin{SourceIP: c.ClientIP(), UserAgent: c.Request.UserAgent()})
+		c.Request = c.Request.WithContext(ctx)
+
 		c.Next()
 	}
 }
@@ -0,0 +1,165 @@
+package http
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+	"github.com/yebrai/go-tasks-microservice/internal/task/authz"
+	"github.com/yebrai/go-tasks-microservice/internal/task/syncer"
+)
+
+// maxSyncMutations máximo de mutaciones por lote
+const maxSyncMutations = 500
+
+// SyncHandler maneja la sincronización delta de clientes offline
+type SyncHandler struct {
+	service *syncer.Service
+	policy  *authz.Policy
+}
+
+// NewSyncHandler crea una nueva instancia del handler
+func NewSyncHandler(service *syncer.Service, policy *authz.Policy) *SyncHandler {
+	return &SyncHandler{
+		service: service,
+		policy:  policy,
+	}
+}
+
+// SyncMutationRequest mutación enviada por el cliente
+type SyncMutationRequest struct {
+	MutationID      string    `json:"mutation_id" binding:"required"`
+	Op              string    `json:"op" binding:"required"`
+	TaskID          string    `json:"task_id"`
+	BaseVersion     int64     `json:"base_version"`
+	ClientTimestamp time.Time `json:"client_timestamp"`
+	Title           *string   `json:"title,omitempty"`
+	Description     *string   `json:"description,omitempty"`
+	DueDate         *string   `json:"due_date,omitempty"` // "" elimina la fecha
+}
+
+// SyncRequest lote de mutaciones y token de la última sincronización
+type SyncRequest struct {
+	SyncToken string                `json:"sync_token"`
+	Mutations []SyncMutationRequest `json:"mutations" binding:"dive"`
+}
+
+// SyncResult resultado de una mutación del lote
+type SyncResult struct {
+	MutationID string            `json:"mutation_id"`
+	TaskID     string            `json:"task_id"`
+	Status     syncer.Status     `json:"status"`
+	Resolution syncer.Resolution `json:"resolution,omitempty"`
+	Error      *Problem          `json:"error,omitempty"`
+	Task       *task.Task        `json:"task,omitempty"`
+}
+
+// SyncResponse resultados del lote y cambios del servidor
+type SyncResponse struct {
+	Results   []SyncResult `json:"results"`
+	Changes   []*task.Task `json:"changes"`
+	SyncToken string       `json:"sync_token"`
+	HasMore   bool         `json:"has_more"`
+}
+
+// Sync aplica un lote de mutaciones offline
+func (h *SyncHandler) Sync(c *gin.Context) {
+	var req SyncRequest
+	if err := bindJSON(c, &req); err != nil {
+		writeProblem(c, err)
+		return
+	}
+
+	if len(req.Mutations) > maxSyncMutations {
+		abortWithProblem(c, NewProblem(http.StatusRequestEntityTooLarge, CodePayloadTooLarge,
+			"a sync batch accepts at most 500 mutations"))
+		return
+	}
+
+	var position int64
+	if req.SyncToken != "" {
+		var err error
+		position, err = decodeSyncToken(req.SyncToken)
+		if err != nil {
+			writeProblem(c, err)
+			return
+		}
+	}
+
+	mutations := make([]syncer.Mutation, 0, len(req.Mutations))
+	for i, m := range req.Mutations {
+		mutation := syncer.Mutation{
+			MutationID:      m.MutationID,
+			Op:              syncer.Op(m.Op),
+			TaskID:          m.TaskID,
+			BaseVersion:     m.BaseVersion,
+			ClientTimestamp: m.ClientTimestamp,
+			Title:           m.Title,
+			Description:     m.Description,
+		}
+
+		if m.DueDate != nil {
+			if *m.DueDate == "" {
+				mutation.ClearDueDate = true
+			} else {
+				dueDate, err := parseDueDate(*m.DueDate)
+				if err != nil {
+					field := "mutations[" + strconv.Itoa(i) + "].due_date"
+					abortWithProblem(c, fieldError(field, "date", "expected format: YYYY-MM-DD"))
+					return
+				}
+				mutation.DueDate = dueDate
+			}
+		}
+
+		mutations = append(mutations, mutation)
+	}
+
+	result, err := h.service.Sync(c.Request.Context(), syncer.Request{
+		Position:  position,
+		Mutations: mutations,
+	})
+	if err == nil {
+		result.Changes, err = h.policy.Visible(c.Request.Context(), result.Changes)
+	}
+	if err != nil {
+		writeProblem(c, err)
+		return
+	}
+
+	results := make([]SyncResult, 0, len(result.Results))
+	for _, r := range result.Results {
+		item := SyncResult{
+			MutationID: r.MutationID,
+			TaskID:     r.TaskID,
+			Status:     r.Status,
+			Resolution: r.Resolution,
+			Task:       r.Task,
+		}
+		// Los conflictos y rechazos traen la tarea actual: se oculta si el
+		// principal no puede leerla
+		if item.Task != nil && h.policy.Authorize(c.Request.Context(), authz.ActionRead, item.Task) != nil {
+			item.Task = nil
+		}
+		if r.Err != nil {
+			item.Error = ProblemFromError(r.Err)
+			if item.Error.Status >= http.StatusInternalServerError {
+				log.Printf("❌ sync mutation %s (%s) failed: %v", r.MutationID, r.TaskID, r.Err)
+			}
+		}
+		results = append(results, item)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": SyncResponse{
+			Results:   results,
+			Changes:   result.Changes,
+			SyncToken: encodeSyncToken(result.Position),
+			HasMore:   result.HasMore,
+		},
+		"success": true,
+	})
+}
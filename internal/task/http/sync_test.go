@@ -0,0 +1,175 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+	"github.com/yebrai/go-tasks-microservice/internal/task/authz"
+	"github.com/yebrai/go-tasks-microservice/internal/task/creator"
+	"github.com/yebrai/go-tasks-microservice/internal/task/presence"
+	"github.com/yebrai/go-tasks-microservice/internal/task/syncer"
+	"github.com/yebrai/go-tasks-microservice/internal/task/tasktest"
+	"github.com/yebrai/go-tasks-microservice/pkg/auth"
+	"github.com/yebrai/go-tasks-microservice/pkg/cqrs/inmem"
+	"github.com/yebrai/go-tasks-microservice/pkg/events"
+	"github.com/yebrai/go-tasks-microservice/pkg/id"
+)
+
+func TestSync_ResultsFollowTheReadPolicy(t *testing.T) {
+	const taskID = "6f1c2a9e-3b4d-4c5e-8f70-1a2b3c4d5e6f"
+
+	policy, err := authz.NewPolicy([]authz.Rule{
+		{Actions: []authz.Action{authz.ActionCreate}, Roles: []string{authz.RoleMember}},
+		{Actions: []authz.Action{authz.ActionRead, authz.ActionUpdate}, Roles: []string{authz.RoleMember}, OwnerOnly: true},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	member := func(subject string) auth.Principal {
+		return auth.Principal{Subject: subject, Roles: []string{authz.RoleMember}, Scopes: []string{auth.ScopeTasksWrite}}
+	}
+
+	repository := tasktest.NewRepository()
+	bobTask, err := task.NewTask(taskID, "private", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bobTask.Owner = "user:bob"
+	repository.Put(bobTask)
+
+	handler := NewServer(inmem.NewCommandBus(), repository, events.NewNoOpEventBus(), presence.NewRegistry(), Options{
+		Authenticator: auth.NewStaticTokenAuthenticator([]auth.StaticToken{
+			{Token: "alice", Principal: member("user:alice")},
+			{Token: "bob", Principal: member("user:bob")},
+		}),
+		Policy: policy,
+	}).Handler()
+
+	tests := []struct {
+		name     string
+		token    string
+		mutation string
+		wantTask bool
+	}{
+		{"stale update of a hidden task", "alice", `{"mutation_id":"m1","op":"update","task_id":"` + taskID + `","base_version":0,"title":"x"}`, false},
+		{"create reusing a hidden task id", "alice", `{"mutation_id":"m2","op":"create","task_id":"` + taskID + `","title":"x"}`, false},
+		{"stale update of an owned task", "bob", `{"mutation_id":"m3","op":"update","task_id":"` + taskID + `","base_version":0,"title":"x"}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/sync", strings.NewReader(`{"mutations":[`+tt.mutation+`]}`))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			var body struct {
+				Data struct {
+					Results []struct {
+						Task *task.Task `json:"task"`
+					} `json:"results"`
+					Changes []*task.Task `json:"changes"`
+				} `json:"data"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || rec.Code != http.StatusOK || len(body.Data.Results) != 1 {
+				t.Fatalf("Expected one result, got %d: %s", rec.Code, rec.Body.String())
+			}
+			if got := body.Data.Results[0].Task != nil; got != tt.wantTask {
+				t.Errorf("Expected the task in the result: %v, got %s", tt.wantTask, rec.Body.String())
+			}
+			if got := len(body.Data.Changes) == 1; got != tt.wantTask {
+				t.Errorf("Expected the task in the changes: %v, got %s", tt.wantTask, rec.Body.String())
+			}
+		})
+	}
+}
+
+// failingRepository repositorio cuyas lecturas de tareas fallan con un error
+// del driver
+type failingRepository struct {
+	*tasktest.Repository
+}
+
+func (failingRepository) FindByID(context.Context, string) (*task.Task, error) {
+	return nil, errors.New("connection(mongo:27017[-3]) incomplete read of message header")
+}
+
+func TestSync_RejectionsAreProblems(t *testing.T) {
+	handler := NewServer(inmem.NewCommandBus(), failingRepository{tasktest.NewRepository()}, events.NewNoOpEventBus(), presence.NewRegistry(), Options{}).Handler()
+
+	tests := []struct {
+		name     string
+		mutation string
+		wantCode string
+	}{
+		{"repository failure", `{"mutation_id":"m1","op":"update","task_id":"6f1c2a9e-3b4d-4c5e-8f70-1a2b3c4d5e6f","base_version":1}`, CodeInternal},
+		{"create without task id", `{"mutation_id":"m2","op":"create","title":"x"}`, CodeInvalidSyncMutation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/sync", strings.NewReader(`{"mutations":[`+tt.mutation+`]}`))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			var body struct {
+				Data SyncResponse `json:"data"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || rec.Code != http.StatusOK || len(body.Data.Results) != 1 {
+				t.Fatalf("Expected one result, got %d: %s", rec.Code, rec.Body.String())
+			}
+			result := body.Data.Results[0]
+			if result.Status != syncer.StatusRejected || result.Error == nil || result.Error.Code != tt.wantCode {
+				t.Errorf("Expected a %s rejection, got %s", tt.wantCode, rec.Body.String())
+			}
+			if strings.Contains(rec.Body.String(), "mongo") {
+				t.Errorf("Expected the driver error to stay hidden, got %s", rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestSync_CreateCollidingWithAnotherTenant(t *testing.T) {
+	const taskID = "6f1c2a9e-3b4d-4c5e-8f70-1a2b3c4d5e6f"
+
+	repository := tasktest.NewRepository()
+	acmeTask, err := task.NewTask(taskID, "Acme secret", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	acmeTask.Tenant = "acme"
+	repository.Put(acmeTask)
+
+	bus := inmem.NewCommandBus()
+	if err := bus.Register(creator.CreateTaskCommandType, creator.NewCreateTaskCommandHandler(repository, id.NewUniqueIDGenerator(), events.NewNoOpEventBus(), creator.Quotas{})); err != nil {
+		t.Fatal(err)
+	}
+	handler := NewServer(bus, repository, events.NewNoOpEventBus(), presence.NewRegistry(), Options{}).Handler()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/sync", strings.NewReader(`{"mutations":[{"mutation_id":"m1","op":"create","task_id":"`+taskID+`","title":"x"}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	var body struct {
+		Data SyncResponse `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || rec.Code != http.StatusOK || len(body.Data.Results) != 1 {
+		t.Fatalf("Expected one result, got %d: %s", rec.Code, rec.Body.String())
+	}
+	result := body.Data.Results[0]
+	if result.Status != syncer.StatusRejected || result.Error == nil || result.Error.Status != http.StatusConflict || result.Error.Code != CodeTaskAlreadyExists {
+		t.Errorf("Expected a 409 %s rejection, got %s", CodeTaskAlreadyExists, rec.Body.String())
+	}
+	if strings.Contains(rec.Body.String(), "Acme secret") {
+		t.Errorf("Expected the other tenant's task to stay hidden, got %s", rec.Body.String())
+	}
+}
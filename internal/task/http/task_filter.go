@@ -0,0 +1,73 @@
+package http
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+)
+
+// TaskFilterRequest criterios de selección de tareas, compartidos por el
+// listado (?status=&overdue=...) y los comandos masivos
+type TaskFilterRequest struct {
+	Status    []string `json:"status,omitempty"`
+	Overdue   bool     `json:"overdue,omitempty"`
+	DueBefore string   `json:"due_before,omitempty"` // formato: "2006-01-02"
+	DueAfter  string   `json:"due_after,omitempty"`  // formato: "2006-01-02"
+	Q         string   `json:"q,omitempty"`
+}
+
+// taskFilterFromQuery lee los criterios de la query string. status admite
+// valores separados por comas o repetidos
+func taskFilterFromQuery(c *gin.Context) (TaskFilterRequest, error) {
+	req := TaskFilterRequest{
+		DueBefore: c.Query("due_before"),
+		DueAfter:  c.Query("due_after"),
+		Q:         c.Query("q"),
+	}
+
+	for _, value := range c.QueryArray("status") {
+		for _, status := range strings.Split(value, ",") {
+			if status = strings.TrimSpace(status); status != "" {
+				req.Status = append(req.Status, status)
+			}
+		}
+	}
+
+	if value := c.Query("overdue"); value != "" {
+		overdue, err := strconv.ParseBool(value)
+		if err != nil {
+			return req, fieldError("overdue", "boolean", "overdue must be true or false")
+		}
+		req.Overdue = overdue
+	}
+
+	return req, nil
+}
+
+// toFilter valida los criterios y los traduce al filtro de dominio. field
+// es el prefijo de los campos en los errores de validación
+func (r TaskFilterRequest) toFilter(field string) (task.Filter, error) {
+	filter := task.Filter{Overdue: r.Overdue, Search: r.Q}
+
+	for _, value := range r.Status {
+		status := task.Status(value)
+		switch status {
+		case task.StatusPending, task.StatusCompleted, task.StatusCancelled:
+			filter.Statuses = append(filter.Statuses, status)
+		default:
+			return filter, fieldError(field+"status", "oneof", "status must be one of: pending completed cancelled")
+		}
+	}
+
+	var err error
+	if filter.DueBefore, err = parseDueDate(r.DueBefore); err != nil {
+		return filter, fieldError(field+"due_before", "date", "expected format: YYYY-MM-DD")
+	}
+	if filter.DueAfter, err = parseDueDate(r.DueAfter); err != nil {
+		return filter, fieldError(field+"due_after", "date", "expected format: YYYY-MM-DD")
+	}
+
+	return filter, nil
+}
@@ -1,12 +1,12 @@
 package http
 
 import (
-	"log"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/yebrai/go-tasks-microservice/internal/task"
+	"github.com/yebrai/go-tasks-microservice/internal/task/authz"
 	"github.com/yebrai/go-tasks-microservice/internal/task/creator"
 	"github.com/yebrai/go-tasks-microservice/pkg/cqrs"
 )
@@ -15,18 +15,35 @@ import (
 type TaskHandler struct {
 	commandBus cqrs.CommandBus
 	repository task.Repository
-	wsHandler  *WebSocketHandler
+	policy     *authz.Policy
 }
 
 // NewTaskHandler crea una nueva instancia del handler
-func NewTaskHandler(commandBus cqrs.CommandBus, repository task.Repository, wsHandler *WebSocketHandler) *TaskHandler {
+func NewTaskHandler(commandBus cqrs.CommandBus, repository task.Repository, policy *authz.Policy) *TaskHandler {
 	return &TaskHandler{
 		commandBus: commandBus,
 		repository: repository,
-		wsHandler:  wsHandler,
+		policy:     policy,
 	}
 }
 
+// dueDateLayout formato aceptado para las fechas de vencimiento
+const dueDateLayout = "2006-01-02"
+
+// parseDueDate parsea una fecha de vencimiento opcional
+func parseDueDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	parsed, err := time.Parse(dueDateLayout, value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &parsed, nil
+}
+
 // CreateTaskRequest estructura de la petición
 type CreateTaskRequest struct {
 	Title       string `json:"title" binding:"required"`
@@ -40,15 +57,31 @@ type CreateTaskResponse struct {
 	Success bool   `json:"success"`
 }
 
-// GetTasks maneja la consulta de todas las tareas
+// GetTasks maneja la consulta de las tareas, opcionalmente filtradas por
+// ?status=, ?overdue=, ?due_before=, ?due_after= y ?q=
 func (h *TaskHandler) GetTasks(c *gin.Context) {
-	tasks, err := h.repository.FindAll(c.Request.Context())
+	query, err := taskFilterFromQuery(c)
+	if err != nil {
+		writeProblem(c, err)
+		return
+	}
+	filter, err := query.toFilter("")
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "failed to fetch tasks",
-			"message": err.Error(),
-			"success": false,
-		})
+		writeProblem(c, err)
+		return
+	}
+
+	var tasks []*task.Task
+	if filter.IsEmpty() {
+		tasks, err = h.repository.FindAll(c.Request.Context())
+	} else {
+		tasks, err = h.repository.FindMatching(c.Request.Context(), filter, "", 0)
+	}
+	if err == nil {
+		tasks, err = h.policy.Visible(c.Request.Context(), tasks)
+	}
+	if err != nil {
+		writeProblem(c, err)
 		return
 	}
 
@@ -60,32 +93,32 @@ func (h *TaskHandler) GetTasks(c *gin.Context) {
 
 // GetTask maneja la consulta de una tarea específica
 func (h *TaskHandler) GetTask(c *gin.Context) {
-	id := c.Param("id")
-	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "task id is required",
-			"success": false,
-		})
+	taskID, err := task.NewID(c.Param("id"))
+	if err != nil {
+		writeProblem(c, err)
 		return
 	}
 
-	taskID, err := task.NewID(id)
+	foundTask, err := h.repository.FindByID(c.Request.Context(), string(taskID))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "invalid task id",
-			"message": err.Error(),
-			"success": false,
-		})
+		writeProblem(c, err)
 		return
 	}
 
-	foundTask, err := h.repository.FindByID(c.Request.Context(), string(taskID))
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error":   "task not found",
-			"message": err.Error(),
-			"success": false,
-		})
+	// Las tareas de la papelera solo se consultan en /trash
+	if foundTask.IsArchived() {
+		writeProblem(c, task.ErrTaskNotFound)
+		return
+	}
+	if err := h.policy.Authorize(c.Request.Context(), authz.ActionRead, foundTask); err != nil {
+		writeProblem(c, err)
+		return
+	}
+
+	setTaskETag(c, foundTask)
+	c.Header("Accept-Patch", MergePatchContentType+", "+JSONPatchContentType)
+	if notModified(c, foundTask) {
+		c.Status(http.StatusNotModified)
 		return
 	}
 
@@ -95,60 +128,45 @@ func (h *TaskHandler) GetTask(c *gin.Context) {
 	})
 }
 
-// UpdateTaskRequest estructura de la petición de actualización
-type UpdateTaskRequest struct {
-	Title       *string `json:"title,omitempty"`
-	Description *string `json:"description,omitempty"`
-	Status      *string `json:"status,omitempty"`
-	DueDate     *string `json:"due_date,omitempty"`
+// ReplaceTaskRequest representación completa de una tarea para PUT
+type ReplaceTaskRequest struct {
+	Title       string `json:"title" binding:"required"`
+	Description string `json:"description"`
+	Status      string `json:"status" binding:"required,oneof=pending completed cancelled"`
+	DueDate     string `json:"due_date"` // formato: "2006-01-02"; vacío elimina la fecha
 }
 
-// UpdateTask maneja la actualización de tareas
-func (h *TaskHandler) UpdateTask(c *gin.Context) {
-	id := c.Param("id")
-	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "task id is required",
-			"success": false,
-		})
+// ReplaceTask reemplaza los campos editables de una tarea; los campos
+// omitidos vuelven a su valor vacío
+func (h *TaskHandler) ReplaceTask(c *gin.Context) {
+	taskID, err := task.NewID(c.Param("id"))
+	if err != nil {
+		writeProblem(c, err)
 		return
 	}
 
-	var req UpdateTaskRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "invalid request",
-			"message": err.Error(),
-			"success": false,
-		})
+	var req ReplaceTaskRequest
+	if err := bindJSON(c, &req); err != nil {
+		writeProblem(c, err)
 		return
 	}
 
-	// Por ahora, solo soportamos cambio de status a completed
-	if req.Status != nil && *req.Status == "completed" {
-		cmd := creator.CompleteTaskCommand{ID: id}
-		if err := h.commandBus.Dispatch(c.Request.Context(), cmd); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error":   "failed to complete task",
-				"message": err.Error(),
-				"success": false,
-			})
-			return
-		}
-
-		// Enviar evento WebSocket para tarea completada
-		if h.wsHandler != nil {
-			updatedTask, err := h.repository.FindByID(c.Request.Context(), id)
-			if err == nil {
-				event := task.NewTaskCompletedEvent(updatedTask)
-				h.wsHandler.BroadcastEvent(event)
-			}
-		}
+	dueDate, err := parseDueDate(req.DueDate)
+	if err != nil {
+		abortWithProblem(c, fieldError("due_date", "date", "expected format: YYYY-MM-DD"))
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Task updated successfully",
-		"success": true,
+	current, ifMatch, ok := h.loadForWrite(c, taskID)
+	if !ok {
+		return
+	}
+
+	h.applyState(c, current, ifMatch, taskState{
+		Title:       req.Title,
+		Description: req.Description,
+		Status:      task.Status(req.Status),
+		DueDate:     dueDate,
 	})
 }
 
@@ -157,28 +175,16 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 	var req CreateTaskRequest
 
 	// Validar JSON
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "invalid request",
-			"message": err.Error(),
-			"success": false,
-		})
+	if err := bindJSON(c, &req); err != nil {
+		writeProblem(c, err)
 		return
 	}
 
 	// Parsear fecha opcional
-	var dueDate *time.Time
-	if req.DueDate != "" {
-		parsed, err := time.Parse("2006-01-02", req.DueDate)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error":   "invalid due_date format",
-				"message": "expected format: YYYY-MM-DD",
-				"success": false,
-			})
-			return
-		}
-		dueDate = &parsed
+	dueDate, err := parseDueDate(req.DueDate)
+	if err != nil {
+		abortWithProblem(c, fieldError("due_date", "date", "expected format: YYYY-MM-DD"))
+		return
 	}
 
 	// Crear comando
@@ -190,36 +196,10 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 
 	// Ejecutar comando
 	if err := h.commandBus.Dispatch(c.Request.Context(), cmd); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "failed to create task",
-			"message": err.Error(),
-			"success": false,
-		})
+		writeProblem(c, err)
 		return
 	}
 
-	// Encontrar la tarea recién creada para enviar evento WebSocket
-	// Como no tenemos el ID aquí, buscaremos la tarea más reciente con el mismo título
-	if h.wsHandler != nil {
-		log.Printf("🔍 Attempting to broadcast task creation event")
-		tasks, err := h.repository.FindAll(c.Request.Context())
-		if err == nil && len(tasks) > 0 {
-			// Buscar la tarea más reciente con el mismo título
-			for i := len(tasks) - 1; i >= 0; i-- {
-				if tasks[i].Title == req.Title {
-					event := task.NewTaskCreatedEvent(tasks[i])
-					log.Printf("📡 Broadcasting task created event for task: %s", tasks[i].ID)
-					h.wsHandler.BroadcastEvent(event)
-					break
-				}
-			}
-		} else {
-			log.Printf("❌ Failed to fetch tasks for WebSocket broadcast: %v", err)
-		}
-	} else {
-		log.Printf("⚠️  WebSocket handler is nil, cannot broadcast event")
-	}
-
 	// Respuesta exitosa
 	c.JSON(http.StatusCreated, CreateTaskResponse{
 		Message: "Task created successfully",
@@ -0,0 +1,287 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+	"github.com/yebrai/go-tasks-microservice/internal/task/creator"
+	"github.com/yebrai/go-tasks-microservice/pkg/cqrs"
+	"github.com/yebrai/go-tasks-microservice/pkg/jsonpatch"
+)
+
+const (
+	// MergePatchContentType media type de JSON Merge Patch (RFC 7396)
+	MergePatchContentType = "application/merge-patch+json"
+	// JSONPatchContentType media type de JSON Patch (RFC 6902)
+	JSONPatchContentType = "application/json-patch+json"
+)
+
+// readOnlyTaskFields campos de la representación que un patch no puede
+// modificar
+var readOnlyTaskFields = []string{"id", "owner", "created_at", "updated_at", "archived_at", "version"}
+
+// editableTaskFields campos de la representación que un patch puede modificar
+var editableTaskFields = []string{"title", "description", "status", "due_date"}
+
+// taskState estado deseado de los campos editables de una tarea
+type taskState struct {
+	Title       string
+	Description string
+	Status      task.Status
+	DueDate     *time.Time
+}
+
+// PatchTask aplica un JSON Merge Patch o un JSON Patch a la representación
+// de la tarea y ejecuta los comandos que producen el resultado
+func (h *TaskHandler) PatchTask(c *gin.Context) {
+	taskID, err := task.NewID(c.Param("id"))
+	if err != nil {
+		writeProblem(c, err)
+		return
+	}
+
+	var apply func(document, patch []byte) ([]byte, error)
+	switch c.ContentType() {
+	case MergePatchContentType:
+		apply = jsonpatch.MergePatch
+	case JSONPatchContentType:
+		apply = jsonpatch.Apply
+	default:
+		c.Header("Accept-Patch", MergePatchContentType+", "+JSONPatchContentType)
+		abortWithProblem(c, NewProblem(http.StatusUnsupportedMediaType, CodeUnsupportedMediaType,
+			"PATCH accepts "+MergePatchContentType+" or "+JSONPatchContentType))
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		writeProblem(c, err)
+		return
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		writeProblem(c, errEmptyBody)
+		return
+	}
+
+	current, ifMatch, ok := h.loadForWrite(c, taskID)
+	if !ok {
+		return
+	}
+
+	document, err := json.Marshal(current)
+	if err != nil {
+		writeProblem(c, err)
+		return
+	}
+
+	patched, err := apply(document, body)
+	if err != nil {
+		writeProblem(c, err)
+		return
+	}
+
+	state, err := patchedState(document, patched)
+	if err != nil {
+		writeProblem(c, err)
+		return
+	}
+
+	h.applyState(c, current, ifMatch, state)
+}
+
+// loadForWrite obtiene la tarea y verifica la precondición If-Match.
+// Retorna la versión exigida por If-Match (0 si no hay) y false si ya se
+// respondió con un error
+func (h *TaskHandler) loadForWrite(c *gin.Context, taskID task.ID) (*task.Task, int64, bool) {
+	current, err := h.repository.FindByID(c.Request.Context(), string(taskID))
+	if err != nil {
+		writeProblem(c, err)
+		return nil, 0, false
+	}
+
+	ifMatch, err := checkIfMatch(c, current)
+	if err != nil {
+		writeProblem(c, err)
+		return nil, 0, false
+	}
+
+	return current, ifMatch, true
+}
+
+// applyState ejecuta los comandos que llevan la tarea al estado deseado y
+// responde con la tarea resultante
+func (h *TaskHandler) applyState(c *gin.Context, current *task.Task, ifMatch int64, desired taskState) {
+	ctx := c.Request.Context()
+
+	commands, err := commandsFor(current, desired)
+	if err != nil {
+		writeProblem(c, err)
+		return
+	}
+
+	for _, cmd := range commands {
+		if err := h.commandBus.Dispatch(ctx, cmd); err != nil {
+			writeProblem(c, preconditionError(err, ifMatch))
+			return
+		}
+	}
+
+	if len(commands) > 0 {
+		if current, err = h.repository.FindByID(ctx, current.ID); err != nil {
+			writeProblem(c, err)
+			return
+		}
+	}
+
+	setTaskETag(c, current)
+	c.JSON(http.StatusOK, gin.H{
+		"data":    current,
+		"message": "Task updated successfully",
+		"success": true,
+	})
+}
+
+// commandsFor traduce el estado deseado en los comandos de dominio que lo
+// producen. Cada comando espera la versión que deja el anterior, de modo que
+// una escritura concurrente interrumpe la secuencia
+func commandsFor(current *task.Task, desired taskState) ([]cqrs.Command, error) {
+	if desired.Title == "" {
+		return nil, fieldError("title", "required", "title is required")
+	}
+
+	switch desired.Status {
+	case task.StatusPending, task.StatusCompleted, task.StatusCancelled:
+	default:
+		return nil, fieldError("status", "oneof", "status must be one of: pending completed cancelled")
+	}
+
+	// Validar la transición antes de emitir ningún comando para no dejar la
+	// tarea a medio modificar
+	statusChanged := desired.Status != current.Status
+	if statusChanged {
+		switch {
+		case current.Status == task.StatusCompleted:
+			return nil, task.ErrTaskAlreadyCompleted
+		case current.Status == task.StatusCancelled:
+			return nil, task.ErrTaskAlreadyCancelled
+		case desired.Status == task.StatusPending:
+			return nil, NewProblem(http.StatusUnprocessableEntity, CodeInvalidStatusTransition,
+				"cannot change status from "+string(current.Status)+" to "+string(desired.Status))
+		}
+	}
+
+	var commands []cqrs.Command
+	version := current.Version
+
+	update := creator.UpdateTaskCommand{ID: current.ID, ExpectedVersion: version}
+	changed := false
+	if desired.Title != current.Title {
+		update.Title = &desired.Title
+		changed = true
+	}
+	if desired.Description != current.Description {
+		update.Description = &desired.Description
+		changed = true
+	}
+	switch {
+	case desired.DueDate == nil && current.DueDate != nil:
+		update.ClearDueDate = true
+		changed = true
+	case desired.DueDate != nil && (current.DueDate == nil || !desired.DueDate.Equal(*current.DueDate)):
+		update.DueDate = desired.DueDate
+		changed = true
+	}
+	if changed {
+		commands = append(commands, update)
+		version++
+	}
+
+	if statusChanged {
+		switch desired.Status {
+		case task.StatusCompleted:
+			commands = append(commands, creator.CompleteTaskCommand{ID: current.ID, ExpectedVersion: version})
+		case task.StatusCancelled:
+			commands = append(commands, creator.CancelTaskCommand{ID: current.ID, ExpectedVersion: version})
+		}
+	}
+
+	return commands, nil
+}
+
+// patchedState valida la representación resultante de un patch y extrae el
+// estado deseado
+func patchedState(original, patched []byte) (taskState, error) {
+	var before, after map[string]json.RawMessage
+	if err := json.Unmarshal(original, &before); err != nil {
+		return taskState{}, err
+	}
+	if err := json.Unmarshal(patched, &after); err != nil {
+		return taskState{}, fieldError("", "type", "the patched task must be a JSON object")
+	}
+
+	for _, field := range readOnlyTaskFields {
+		if !bytes.Equal(before[field], after[field]) {
+			return taskState{}, fieldError(field, "read_only", field+" is read-only")
+		}
+	}
+	for field := range after {
+		if !contains(readOnlyTaskFields, field) && !contains(editableTaskFields, field) {
+			return taskState{}, fieldError(field, "unknown", "unknown field "+field)
+		}
+	}
+
+	var fields struct {
+		Title       string  `json:"title"`
+		Description string  `json:"description"`
+		Status      string  `json:"status"`
+		DueDate     *string `json:"due_date"`
+	}
+	if err := json.Unmarshal(patched, &fields); err != nil {
+		return taskState{}, err
+	}
+
+	state := taskState{
+		Title:       fields.Title,
+		Description: fields.Description,
+		Status:      task.Status(fields.Status),
+	}
+	if fields.DueDate != nil {
+		dueDate, err := parsePatchedDueDate(*fields.DueDate)
+		if err != nil {
+			return taskState{}, fieldError("due_date", "date", "expected format: YYYY-MM-DD")
+		}
+		state.DueDate = dueDate
+	}
+
+	return state, nil
+}
+
+// parsePatchedDueDate acepta el formato de entrada YYYY-MM-DD y el RFC 3339
+// con el que se serializa la tarea, para que un patch pueda conservar el
+// valor leído
+func parsePatchedDueDate(value string) (*time.Time, error) {
+	if dueDate, err := parseDueDate(value); err == nil {
+		return dueDate, nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
+
+// contains indica si values incluye value
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
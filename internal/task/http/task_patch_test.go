@@ -0,0 +1,107 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+	"github.com/yebrai/go-tasks-microservice/internal/task/creator"
+	"github.com/yebrai/go-tasks-microservice/pkg/jsonpatch"
+)
+
+func newPatchTestTask(t *testing.T) *task.Task {
+	t.Helper()
+
+	dueDate := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	current, err := task.NewTask("6f1c2a9e-3b4d-4c5e-8f70-1a2b3c4d5e6f", "title", "description", &dueDate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return current
+}
+
+func TestPatchedState_MergePatchBecomesCommands(t *testing.T) {
+	current := newPatchTestTask(t)
+	document, _ := json.Marshal(current)
+
+	patched, err := jsonpatch.MergePatch(document, []byte(`{"title":"new","due_date":null,"status":"completed"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, err := patchedState(document, patched)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	commands, err := commandsFor(current, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commands) != 2 {
+		t.Fatalf("Expected update and complete commands, got %+v", commands)
+	}
+
+	update, ok := commands[0].(creator.UpdateTaskCommand)
+	if !ok || update.Title == nil || *update.Title != "new" || !update.ClearDueDate || update.Description != nil {
+		t.Errorf("Unexpected update command %+v", commands[0])
+	}
+	complete, ok := commands[1].(creator.CompleteTaskCommand)
+	if !ok || complete.ExpectedVersion != current.Version+1 {
+		t.Errorf("Expected complete at version %d, got %+v", current.Version+1, commands[1])
+	}
+}
+
+func TestPatchedState_Rejections(t *testing.T) {
+	tests := []struct {
+		name       string
+		patch      string
+		wantStatus int
+		wantCode   string
+	}{
+		{"read-only field", `[{"op":"replace","path":"/version","value":7}]`, http.StatusBadRequest, CodeValidationFailed},
+		{"unknown field", `[{"op":"add","path":"/owner","value":"x"}]`, http.StatusBadRequest, CodeValidationFailed},
+		{"empty title", `[{"op":"replace","path":"/title","value":""}]`, http.StatusBadRequest, CodeValidationFailed},
+		{"unknown status", `[{"op":"replace","path":"/status","value":"archived"}]`, http.StatusBadRequest, CodeValidationFailed},
+		{"failed test", `[{"op":"test","path":"/title","value":"other"}]`, http.StatusConflict, CodePatchTestFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := newPatchTestTask(t)
+			document, _ := json.Marshal(current)
+
+			patched, err := jsonpatch.Apply(document, []byte(tt.patch))
+			if err == nil {
+				var state taskState
+				if state, err = patchedState(document, patched); err == nil {
+					_, err = commandsFor(current, state)
+				}
+			}
+
+			problem := ProblemFromError(err)
+			if problem.Status != tt.wantStatus || problem.Code != tt.wantCode {
+				t.Errorf("Expected %d/%s, got %d/%s (%v)", tt.wantStatus, tt.wantCode, problem.Status, problem.Code, err)
+			}
+		})
+	}
+}
+
+func TestCommandsFor_StatusTransitions(t *testing.T) {
+	current := newPatchTestTask(t)
+	if err := current.Complete(); err != nil {
+		t.Fatal(err)
+	}
+
+	state := taskState{Title: current.Title, Description: current.Description, Status: task.StatusPending, DueDate: current.DueDate}
+	if _, err := commandsFor(current, state); !errors.Is(err, task.ErrTaskAlreadyCompleted) {
+		t.Errorf("Expected ErrTaskAlreadyCompleted reopening a completed task, got %v", err)
+	}
+
+	state.Status = task.StatusCompleted
+	if commands, err := commandsFor(current, state); err != nil || len(commands) != 0 {
+		t.Errorf("Expected no commands for an unchanged task, got %v %v", commands, err)
+	}
+}
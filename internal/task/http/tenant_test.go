@@ -0,0 +1,234 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+	"github.com/yebrai/go-tasks-microservice/internal/task/creator"
+	"github.com/yebrai/go-tasks-microservice/internal/task/presence"
+	"github.com/yebrai/go-tasks-microservice/internal/user"
+	"github.com/yebrai/go-tasks-microservice/pkg/auth"
+	"github.com/yebrai/go-tasks-microservice/pkg/cqrs"
+	"github.com/yebrai/go-tasks-microservice/pkg/cqrs/inmem"
+	"github.com/yebrai/go-tasks-microservice/pkg/events"
+	"github.com/yebrai/go-tasks-microservice/pkg/id"
+	"github.com/yebrai/go-tasks-microservice/pkg/tenant"
+)
+
+// tenantRecorder handler de CreateTaskCommand que guarda el tenant recibido
+// en el contexto
+type tenantRecorder struct {
+	tenant string
+}
+
+func (r *tenantRecorder) Handle(ctx context.Context, _ cqrs.Command) error {
+	r.tenant = tenant.FromContext(ctx)
+	return nil
+}
+
+func TestTenantMiddleware_ResolvesTenantFromPrincipalOrHeader(t *testing.T) {
+	authenticator := auth.NewStaticTokenAuthenticator([]auth.StaticToken{
+		{Token: "acme-token", Principal: auth.Principal{Subject: "acme-app", Scopes: []string{auth.ScopeTasksWrite}, Tenant: "acme"}},
+		{Token: "service-token", Principal: auth.Principal{Subject: "sync-service", Scopes: []string{auth.ScopeTasksWrite}}},
+	})
+
+	tests := []struct {
+		name        string
+		multiTenant bool
+		token       string
+		header      string
+		wantStatus  int
+		wantTenant  string
+	}{
+		{"disabled ignores the header", false, "service-token", "globex", http.StatusCreated, tenant.DefaultID},
+		{"bound principal", true, "acme-token", "", http.StatusCreated, "acme"},
+		{"bound principal with its own header", true, "acme-token", "acme", http.StatusCreated, "acme"},
+		{"bound principal cannot switch tenant", true, "acme-token", "globex", http.StatusForbidden, ""},
+		{"unbound principal chooses a selectable tenant", true, "service-token", "globex", http.StatusCreated, "globex"},
+		{"unbound principal cannot choose any tenant", true, "service-token", "initech", http.StatusForbidden, ""},
+		{"unbound principal without header", true, "service-token", "", http.StatusCreated, tenant.DefaultID},
+		{"invalid tenant", true, "service-token", "globex.eu", http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &tenantRecorder{}
+			bus := inmem.NewCommandBus()
+			if err := bus.Register(creator.CreateTaskCommandType, recorder); err != nil {
+				t.Fatal(err)
+			}
+			handler := NewServer(bus, nil, events.NewNoOpEventBus(), presence.NewRegistry(), Options{
+				Authenticator:     authenticator,
+				MultiTenant:       tt.multiTenant,
+				SelectableTenants: []string{"globex"},
+			}).Handler()
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/tasks", strings.NewReader(`{"title":"Nueva"}`))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			if tt.header != "" {
+				req.Header.Set(tenant.Header, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("Expected %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+			if recorder.tenant != tt.wantTenant {
+				t.Errorf("Expected the command to run in tenant %q, got %q", tt.wantTenant, recorder.tenant)
+			}
+		})
+	}
+}
+
+// sameIDRepository repositorio en el que cada tenant tiene su propia tarea
+// con el mismo ID
+type sameIDRepository struct {
+	task.Repository
+}
+
+func (sameIDRepository) FindByID(ctx context.Context, id string) (*task.Task, error) {
+	found, err := task.NewTask(id, "task", "", nil)
+	if err != nil {
+		return nil, err
+	}
+	found.Tenant = tenant.FromContext(ctx)
+	return found, nil
+}
+
+func TestWebSocket_TenantsOnlySeeTheirOwnEventsAndLeases(t *testing.T) {
+	eventBus := events.NewObservableEventBus(events.NewNoOpEventBus())
+	handler := NewServer(inmem.NewCommandBus(), sameIDRepository{}, eventBus, presence.NewRegistry(), Options{MultiTenant: true, SelectableTenants: []string{"*"}}).Handler()
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	dial := func(tenantID string) *websocket.Conn {
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws/events?tenant="+tenantID, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var welcome EventMessage
+		if err := conn.ReadJSON(&welcome); err != nil {
+			t.Fatal(err)
+		}
+		return conn
+	}
+	acme := dial("acme")
+	defer acme.Close()
+	globex := dial("globex")
+	defer globex.Close()
+
+	taskID := "8f14e45f-ceea-467f-a8f0-2b5c1a2e3d4c"
+
+	// send envía un frame y retorna, en orden, los tipos de los mensajes
+	// recibidos hasta su respuesta. Los mensajes de cada conexión llegan en
+	// orden, así que cualquier difusión anterior aparece antes
+	send := func(conn *websocket.Conn, op string) string {
+		frame, _ := json.Marshal(CommandFrame{Op: op, Payload: json.RawMessage(`{"task_id":"` + taskID + `"}`)})
+		if err := conn.WriteMessage(websocket.TextMessage, frame); err != nil {
+			t.Fatal(err)
+		}
+
+		_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		var types []string
+		for {
+			var message EventMessage
+			if err := conn.ReadJSON(&message); err != nil {
+				t.Fatalf("Expected a reply to %s: %v", op, err)
+			}
+			types = append(types, message.Type)
+			if message.Type == MessageCommandSucceeded || message.Type == MessageCommandFailed {
+				return strings.Join(types, ",")
+			}
+		}
+	}
+
+	created, _ := task.NewTask(taskID, "Acme plan", "", nil)
+	if err := eventBus.Publish(tenant.WithID(context.Background(), "acme"), task.NewTaskCreatedEvent(created)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Una reserva de acme no bloquea la misma tarea en globex ni se anuncia
+	// a sus clientes, y globex no recibe el evento de acme
+	if got, want := send(acme, OpAcquireLock), "task.created,"+MessageLockAcquired+","+MessageCommandSucceeded; got != want {
+		t.Errorf("acme: expected %q, got %q", want, got)
+	}
+	if got, want := send(globex, OpAcquireLock), MessageLockAcquired+","+MessageCommandSucceeded; got != want {
+		t.Errorf("globex: expected %q, got %q", want, got)
+	}
+	if got, want := send(acme, OpReleaseLock), MessageLockReleased+","+MessageCommandSucceeded; got != want {
+		t.Errorf("acme: expected only its own release, got %q", got)
+	}
+}
+
+// acmeRepository repositorio con una sola tarea, del tenant acme
+type acmeRepository struct {
+	task.Repository
+}
+
+func (acmeRepository) FindAll(ctx context.Context) ([]*task.Task, error) {
+	if tenant.FromContext(ctx) != "acme" {
+		return nil, nil
+	}
+	secret, _ := task.NewTask("8f14e45f-ceea-467f-a8f0-2b5c1a2e3d4c", "Acme secret", "", nil)
+	return []*task.Task{secret}, nil
+}
+
+func TestUserAccounts_CannotJoinAnotherTenant(t *testing.T) {
+	users := user.NewService(user.NewMemoryRepository(), user.NewMemorySessionRepository(), user.LogResetNotifier{}, id.NewUniqueIDGenerator(), user.Options{})
+	handler := NewServer(inmem.NewCommandBus(), acmeRepository{}, events.NewNoOpEventBus(), presence.NewRegistry(), Options{
+		Authenticator: auth.NewChainAuthenticator(auth.NewStaticTokenAuthenticator(nil), users),
+		Users:         users,
+		MultiTenant:   true,
+	}).Handler()
+
+	request := func(method, path, token, tenantID, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		if tenantID != "" {
+			req.Header.Set(tenant.Header, tenantID)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	const account = `{"email":"mallory@example.com","name":"Mallory","password":"correct horse"}`
+	if rec := request(http.MethodPost, "/api/v1/auth/register", "", "acme", account); rec.Code != http.StatusForbidden {
+		t.Fatalf("Expected registering into acme to be rejected, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := request(http.MethodPost, "/api/v1/auth/register", "", "", account); rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec := request(http.MethodPost, "/api/v1/auth/login", "", "acme", `{"email":"mallory@example.com","password":"correct horse"}`)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Expected the login to reject the acme header, got %d", rec.Code)
+	}
+	rec = request(http.MethodPost, "/api/v1/auth/login", "", "", `{"email":"mallory@example.com","password":"correct horse"}`)
+	var login struct {
+		Data user.Tokens `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &login); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("Expected a login response, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	if rec := request(http.MethodGet, "/api/v1/tasks", login.Data.AccessToken, "acme", ""); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected the acme tasks to be forbidden, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = request(http.MethodGet, "/api/v1/tasks", login.Data.AccessToken, "", "")
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), "Acme secret") {
+		t.Errorf("Expected only the default tenant tasks, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,120 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+	"github.com/yebrai/go-tasks-microservice/internal/task/creator"
+	"github.com/yebrai/go-tasks-microservice/internal/task/presence"
+	"github.com/yebrai/go-tasks-microservice/pkg/auth"
+	"github.com/yebrai/go-tasks-microservice/pkg/certs"
+	"github.com/yebrai/go-tasks-microservice/pkg/certs/certstest"
+	"github.com/yebrai/go-tasks-microservice/pkg/cqrs/inmem"
+	"github.com/yebrai/go-tasks-microservice/pkg/events"
+)
+
+// healthRepository repositorio vacío para /health
+type healthRepository struct {
+	task.Repository
+}
+
+func (healthRepository) FindAll(context.Context) ([]*task.Task, error) {
+	return nil, nil
+}
+
+func TestMutualTLS_ClientCertificatesAuthenticate(t *testing.T) {
+	dir := t.TempDir()
+	serverCA := certstest.NewCA(t, "server-ca")
+	clientCA := certstest.NewCA(t, "client-ca")
+	notAfter := time.Now().Add(7 * 24 * time.Hour).Truncate(time.Second)
+	certFile, keyFile := serverCA.IssueServer(t, "tasks", notAfter).WriteFiles(t, dir)
+	caFile := filepath.Join(dir, "clients.pem")
+	certstest.WriteFile(t, caFile, clientCA.PEM)
+
+	reloader, err := certs.NewReloader(certFile, keyFile, caFile, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := &principalRecorder{}
+	bus := inmem.NewCommandBus()
+	if err := bus.Register(creator.CreateTaskCommandType, recorder); err != nil {
+		t.Fatal(err)
+	}
+	handler := NewServer(bus, healthRepository{}, events.NewNoOpEventBus(), presence.NewRegistry(), Options{
+		Authenticator: auth.NewStaticTokenAuthenticator(nil),
+		ClientCertificates: auth.NewCertificateAuthenticator([]auth.ClientCertificate{{
+			Subject:   "CN=sync-service,O=Acme",
+			Principal: auth.Principal{Subject: "sync-service", Scopes: []string{auth.ScopeTasksWrite}},
+		}}),
+		TLS: reloader,
+	}).Handler()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{Handler: handler, TLSConfig: reloader.TLSConfig(tls.RequireAndVerifyClientCert)}
+	go server.ServeTLS(listener, "", "")
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(serverCA.Certificate)
+	client := func(subject pkix.Name) *http.Client {
+		certificate := clientCA.IssueClient(t, subject).TLSCertificate(t)
+		return &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			RootCAs:      roots,
+			Certificates: []tls.Certificate{certificate},
+			ServerName:   "localhost",
+		}}}
+	}
+	url := "https://" + listener.Addr().String()
+
+	known := client(pkix.Name{CommonName: "sync-service", Organization: []string{"Acme"}})
+	resp, err := known.Post(url+"/api/v1/tasks", "application/json", strings.NewReader(`{"title":"Nueva"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d", resp.StatusCode)
+	}
+	if recorder.principal == nil || recorder.principal.Subject != "sync-service" {
+		t.Errorf("Expected the certificate principal, got %+v", recorder.principal)
+	}
+
+	unknown := client(pkix.Name{CommonName: "intruder"})
+	resp, err = unknown.Post(url+"/api/v1/tasks", "application/json", strings.NewReader(`{"title":"Nueva"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for an unmapped certificate, got %d", resp.StatusCode)
+	}
+
+	resp, err = known.Get(url + "/health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var health struct {
+		TLS certs.Status `json:"tls"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+		t.Fatal(err)
+	}
+	if !health.TLS.NotAfter.Equal(notAfter) || health.TLS.ClientCANotAfter == nil {
+		t.Errorf("Expected the certificate expiry in /health, got %+v", health.TLS)
+	}
+}
@@ -0,0 +1,111 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+	"github.com/yebrai/go-tasks-microservice/internal/task/creator"
+	"github.com/yebrai/go-tasks-microservice/pkg/cqrs"
+)
+
+// ArchiveTask mueve la tarea a la papelera (borrado lógico)
+func (h *TaskHandler) ArchiveTask(c *gin.Context) {
+	h.dispatchTrashCommand(c, func(t *task.Task) cqrs.Command {
+		return creator.ArchiveTaskCommand{ID: t.ID, ExpectedVersion: t.Version}
+	}, "Task archived successfully")
+}
+
+// GetTrash retorna las tareas de la papelera
+func (h *TaskHandler) GetTrash(c *gin.Context) {
+	tasks, err := h.repository.FindArchived(c.Request.Context(), time.Time{})
+	if err == nil {
+		tasks, err = h.policy.Visible(c.Request.Context(), tasks)
+	}
+	if err != nil {
+		writeProblem(c, err)
+		return
+	}
+	if tasks == nil {
+		tasks = []*task.Task{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    tasks,
+		"success": true,
+	})
+}
+
+// RestoreTask saca la tarea de la papelera
+func (h *TaskHandler) RestoreTask(c *gin.Context) {
+	h.dispatchTrashCommand(c, func(t *task.Task) cqrs.Command {
+		return creator.RestoreTaskCommand{ID: t.ID, ExpectedVersion: t.Version}
+	}, "Task restored successfully")
+}
+
+// PurgeTask elimina definitivamente una tarea de la papelera
+func (h *TaskHandler) PurgeTask(c *gin.Context) {
+	taskID, err := task.NewID(c.Param("id"))
+	if err != nil {
+		writeProblem(c, err)
+		return
+	}
+
+	current, ifMatch, ok := h.loadForWrite(c, taskID)
+	if !ok {
+		return
+	}
+
+	cmd := creator.PurgeTaskCommand{ID: current.ID, ExpectedVersion: current.Version}
+	if err := h.commandBus.Dispatch(c.Request.Context(), cmd); err != nil {
+		writeProblem(c, preconditionError(err, ifMatch))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// dispatchTrashCommand ejecuta el comando construido a partir de la tarea
+// actual y responde con la tarea resultante
+func (h *TaskHandler) dispatchTrashCommand(c *gin.Context, build func(*task.Task) cqrs.Command, message string) {
+	taskID, err := task.NewID(c.Param("id"))
+	if err != nil {
+		writeProblem(c, err)
+		return
+	}
+
+	current, ifMatch, ok := h.loadForWrite(c, taskID)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.commandBus.Dispatch(ctx, build(current)); err != nil {
+		writeProblem(c, preconditionError(err, ifMatch))
+		return
+	}
+
+	updated, err := h.repository.FindByID(ctx, current.ID)
+	if err != nil {
+		writeProblem(c, err)
+		return
+	}
+
+	setTaskETag(c, updated)
+	c.JSON(http.StatusOK, gin.H{
+		"data":    updated,
+		"message": message,
+		"success": true,
+	})
+}
+
+// preconditionError traduce una modificación concurrente en un fallo de
+// precondición cuando la petición llevaba If-Match
+func preconditionError(err error, ifMatch int64) error {
+	if ifMatch != 0 && errors.Is(err, task.ErrConcurrentModification) {
+		return errPreconditionFailed
+	}
+	return err
+}
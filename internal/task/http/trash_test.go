@@ -0,0 +1,115 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+	"github.com/yebrai/go-tasks-microservice/internal/task/creator"
+	"github.com/yebrai/go-tasks-microservice/internal/task/presence"
+	"github.com/yebrai/go-tasks-microservice/internal/task/tasktest"
+	"github.com/yebrai/go-tasks-microservice/pkg/cqrs"
+	"github.com/yebrai/go-tasks-microservice/pkg/cqrs/inmem"
+	"github.com/yebrai/go-tasks-microservice/pkg/events"
+)
+
+func TestTrash_ArchiveRestoreAndPurge(t *testing.T) {
+	const taskID = "6f1c2a9e-3b4d-4c5e-8f70-1a2b3c4d5e6f"
+
+	repository := tasktest.NewRepository()
+	current, err := task.NewTask(taskID, "title", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repository.Put(current)
+
+	eventBus := events.NewNoOpEventBus()
+	bus := inmem.NewCommandBus()
+	for commandType, handler := range map[cqrs.CommandType]cqrs.CommandHandler{
+		creator.ArchiveTaskCommandType: creator.NewArchiveTaskCommandHandler(repository, eventBus),
+		creator.RestoreTaskCommandType: creator.NewRestoreTaskCommandHandler(repository, eventBus, creator.Quotas{}),
+		creator.PurgeTaskCommandType:   creator.NewPurgeTaskCommandHandler(repository, eventBus),
+	} {
+		if err := bus.Register(commandType, handler); err != nil {
+			t.Fatal(err)
+		}
+	}
+	handler := NewServer(bus, repository, eventBus, presence.NewRegistry(), Options{}).Handler()
+
+	request := func(method, path, ifMatch, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	trash := func() []task.Task {
+		var body struct {
+			Data []task.Task `json:"data"`
+		}
+		rec := request(http.MethodGet, "/api/v1/trash", "", "")
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || rec.Code != http.StatusOK {
+			t.Fatalf("Expected the trash, got %d: %s", rec.Code, rec.Body.String())
+		}
+		return body.Data
+	}
+	expect := func(rec *httptest.ResponseRecorder, status int, code string) {
+		t.Helper()
+		var problem Problem
+		if rec.Code != status || (code != "" && (json.Unmarshal(rec.Body.Bytes(), &problem) != nil || problem.Code != code)) {
+			t.Fatalf("Expected %d %s, got %d: %s", status, code, rec.Code, rec.Body.String())
+		}
+	}
+
+	// Archivar la mueve a la papelera
+	expect(request(http.MethodDelete, "/api/v1/tasks/"+taskID, "", ""), http.StatusOK, "")
+	expect(request(http.MethodGet, "/api/v1/tasks/"+taskID, "", ""), http.StatusNotFound, CodeTaskNotFound)
+	if archived := trash(); len(archived) != 1 || archived[0].ID != taskID || archived[0].ArchivedAt == nil {
+		t.Fatalf("Expected the task in the trash, got %+v", archived)
+	}
+	expect(request(http.MethodDelete, "/api/v1/tasks/"+taskID, "", ""), http.StatusConflict, CodeTaskArchived)
+
+	// Restaurarla la saca de la papelera
+	rec := request(http.MethodPost, "/api/v1/trash/"+taskID+"/restore", `"2"`, "")
+	expect(rec, http.StatusOK, "")
+	if rec.Header().Get("ETag") != `"3"` || len(trash()) != 0 {
+		t.Errorf("Expected the restored task at version 3 out of the trash, got %s", rec.Header().Get("ETag"))
+	}
+	expect(request(http.MethodPost, "/api/v1/trash/"+taskID+"/restore", "", ""), http.StatusConflict, CodeTaskNotArchived)
+	expect(request(http.MethodDelete, "/api/v1/trash/"+taskID, "", ""), http.StatusConflict, CodeTaskNotArchived)
+
+	// Purgarla la elimina y deja su marca de borrado en el feed de cambios
+	expect(request(http.MethodDelete, "/api/v1/tasks/"+taskID, "", ""), http.StatusOK, "")
+	position, _ := repository.CurrentPosition(context.Background())
+	expect(request(http.MethodDelete, "/api/v1/trash/"+taskID, `"3"`, ""), http.StatusPreconditionFailed, CodePreconditionFailed)
+	expect(request(http.MethodDelete, "/api/v1/trash/"+taskID, `"4"`, ""), http.StatusNoContent, "")
+	expect(request(http.MethodDelete, "/api/v1/trash/"+taskID, "", ""), http.StatusNotFound, CodeTaskNotFound)
+	if archived := trash(); len(archived) != 0 {
+		t.Errorf("Expected an empty trash, got %+v", archived)
+	}
+
+	var changes struct {
+		Data struct {
+			Changes []map[string]interface{} `json:"changes"`
+		} `json:"data"`
+	}
+	rec = request(http.MethodGet, "/api/v1/changes?wait=0s&since="+encodeSyncToken(position), "", "")
+	if err := json.Unmarshal(rec.Body.Bytes(), &changes); err != nil || len(changes.Data.Changes) != 1 {
+		t.Fatalf("Expected the tombstone in /changes, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if tombstone := changes.Data.Changes[0]; tombstone["id"] != taskID || tombstone["purged_at"] == nil || tombstone["version"] != float64(5) {
+		t.Errorf("Expected a tombstone at version 5, got %v", tombstone)
+	}
+
+	rec = request(http.MethodPost, "/api/v1/sync", "", `{"sync_token":"`+encodeSyncToken(position)+`","mutations":[]}`)
+	if err := json.Unmarshal(rec.Body.Bytes(), &changes); err != nil || len(changes.Data.Changes) != 1 || changes.Data.Changes[0]["purged_at"] == nil {
+		t.Errorf("Expected the tombstone in /sync, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
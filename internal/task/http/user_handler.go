@@ -0,0 +1,252 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yebrai/go-tasks-microservice/internal/user"
+	"github.com/yebrai/go-tasks-microservice/pkg/auth"
+)
+
+// UserHandler registro, login y sesiones de las cuentas de usuario propias
+type UserHandler struct {
+	service *user.Service
+}
+
+// NewUserHandler crea una nueva instancia del handler. Con service nil las
+// rutas responden 404
+func NewUserHandler(service *user.Service) *UserHandler {
+	return &UserHandler{
+		service: service,
+	}
+}
+
+// RegisterRequest datos de una cuenta nueva
+type RegisterRequest struct {
+	Email    string `json:"email" binding:"required"`
+	Name     string `json:"name" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// LoginRequest credenciales del login
+type LoginRequest struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// RefreshTokenRequest refresh token de una sesión
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// PasswordResetRequest email de la cuenta que se quiere restablecer
+type PasswordResetRequest struct {
+	Email string `json:"email" binding:"required"`
+}
+
+// SetRolesRequest roles que sustituyen a los de una cuenta
+type SetRolesRequest struct {
+	Roles []string `json:"roles" binding:"required"`
+}
+
+// PasswordResetConfirmRequest token de restablecimiento y contraseña nueva
+type PasswordResetConfirmRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// enabled responde 404 si las cuentas de usuario están deshabilitadas
+func (h *UserHandler) enabled(c *gin.Context) {
+	if h.service == nil {
+		abortWithProblem(c, NewProblem(http.StatusNotFound, CodeNotFound, "user accounts are disabled"))
+		return
+	}
+
+	c.Next()
+}
+
+// Register crea una cuenta
+func (h *UserHandler) Register(c *gin.Context) {
+	var req RegisterRequest
+	if err := bindJSON(c, &req); err != nil {
+		writeProblem(c, err)
+		return
+	}
+
+	u, err := h.service.Register(c.Request.Context(), user.RegisterRequest{
+		Email:    req.Email,
+		Name:     req.Name,
+		Password: req.Password,
+	})
+	if err != nil {
+		writeUserProblem(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"data":    u,
+		"message": "User registered",
+		"success": true,
+	})
+}
+
+// Login abre una sesión y retorna sus tokens
+func (h *UserHandler) Login(c *gin.Context) {
+	var req LoginRequest
+	if err := bindJSON(c, &req); err != nil {
+		writeProblem(c, err)
+		return
+	}
+
+	tokens, err := h.service.Login(c.Request.Context(), req.Email, req.Password)
+	if err != nil {
+		writeProblem(c, err)
+		return
+	}
+
+	respondTokens(c, tokens)
+}
+
+// Refresh renueva una sesión; el refresh token anterior deja de valer
+func (h *UserHandler) Refresh(c *gin.Context) {
+	var req RefreshTokenRequest
+	if err := bindJSON(c, &req); err != nil {
+		writeProblem(c, err)
+		return
+	}
+
+	tokens, err := h.service.Refresh(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		writeProblem(c, err)
+		return
+	}
+
+	respondTokens(c, tokens)
+}
+
+// Logout cierra la sesión del access token de la petición o, con
+// ?all=true, todas las del usuario
+func (h *UserHandler) Logout(c *gin.Context) {
+	token, ok := auth.BearerToken(c.GetHeader("Authorization"))
+	if !ok {
+		writeProblem(c, user.ErrInvalidToken)
+		return
+	}
+
+	all := c.Query("all") == "true"
+	if err := h.service.Logout(c.Request.Context(), token, all); err != nil {
+		writeProblem(c, err)
+		return
+	}
+
+	message := "Session closed"
+	if all {
+		message = "All sessions closed"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"success": true,
+	})
+}
+
+// Revoke cierra la sesión de un refresh token, para los clientes que ya no
+// tienen su access token
+func (h *UserHandler) Revoke(c *gin.Context) {
+	var req RefreshTokenRequest
+	if err := bindJSON(c, &req); err != nil {
+		writeProblem(c, err)
+		return
+	}
+
+	if err := h.service.Revoke(c.Request.Context(), req.RefreshToken); err != nil {
+		writeProblem(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Session revoked",
+		"success": true,
+	})
+}
+
+// RequestPasswordReset emite un token de restablecimiento. Responde igual
+// exista o no el email
+func (h *UserHandler) RequestPasswordReset(c *gin.Context) {
+	var req PasswordResetRequest
+	if err := bindJSON(c, &req); err != nil {
+		writeProblem(c, err)
+		return
+	}
+
+	if err := h.service.RequestPasswordReset(c.Request.Context(), req.Email); err != nil {
+		writeProblem(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "If the email is registered, a reset token has been issued",
+		"success": true,
+	})
+}
+
+// ConfirmPasswordReset cambia la contraseña con un token de
+// restablecimiento y cierra todas las sesiones de la cuenta
+func (h *UserHandler) ConfirmPasswordReset(c *gin.Context) {
+	var req PasswordResetConfirmRequest
+	if err := bindJSON(c, &req); err != nil {
+		writeProblem(c, err)
+		return
+	}
+
+	if err := h.service.ResetPassword(c.Request.Context(), req.Token, req.Password); err != nil {
+		writeUserProblem(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Password updated; all sessions were closed",
+		"success": true,
+	})
+}
+
+// SetRoles sustituye los roles de una cuenta del tenant. Es la forma de
+// conceder admin: el primero lo asigna un operador con un token de admin
+func (h *UserHandler) SetRoles(c *gin.Context) {
+	var req SetRolesRequest
+	if err := bindJSON(c, &req); err != nil {
+		writeProblem(c, err)
+		return
+	}
+
+	u, err := h.service.SetRoles(c.Request.Context(), c.Param("id"), req.Roles)
+	if err != nil {
+		writeUserProblem(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    u,
+		"success": true,
+	})
+}
+
+// respondTokens responde con los tokens de una sesión, que no se deben
+// cachear
+func respondTokens(c *gin.Context, tokens *user.Tokens) {
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{
+		"data":    tokens,
+		"success": true,
+	})
+}
+
+// writeUserProblem como writeProblem, pero conserva el motivo de los datos
+// inválidos (email o contraseña) en el detalle
+func writeUserProblem(c *gin.Context, err error) {
+	if errors.Is(err, user.ErrInvalidUserData) {
+		abortWithProblem(c, NewProblem(http.StatusUnprocessableEntity, CodeInvalidUserData, err.Error()))
+		return
+	}
+	writeProblem(c, err)
+}
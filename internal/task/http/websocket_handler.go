@@ -10,32 +10,144 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 	"github.com/yebrai/go-tasks-microservice/internal/task"
+	"github.com/yebrai/go-tasks-microservice/internal/task/authz"
+	"github.com/yebrai/go-tasks-microservice/internal/task/creator"
+	"github.com/yebrai/go-tasks-microservice/internal/task/presence"
+	"github.com/yebrai/go-tasks-microservice/pkg/auth"
+	"github.com/yebrai/go-tasks-microservice/pkg/cqrs"
 	"github.com/yebrai/go-tasks-microservice/pkg/events"
+	"github.com/yebrai/go-tasks-microservice/pkg/tenant"
 )
 
-var upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool {
-		// Allow all origins for development
-		log.Printf("🔍 WebSocket origin check: %s", r.Header.Get("Origin"))
-		return true
-	},
+// Command operations accepted over the WebSocket
+const (
+	OpCreateTask   = "create_task"
+	OpCompleteTask = "complete_task"
+)
+
+// Presence operations accepted over the WebSocket
+const (
+	OpViewTask    = "view_task"
+	OpLeaveTask   = "leave_task"
+	OpAcquireLock = "acquire_lock"
+	OpReleaseLock = "release_lock"
+)
+
+// Message types sent to WebSocket clients besides domain events
+const (
+	MessageCommandSucceeded = "command.succeeded"
+	MessageCommandFailed    = "command.failed"
+	MessagePresenceChanged  = "presence.changed"
+	MessageLockAcquired     = "lock.acquired"
+	MessageLockReleased     = "lock.released"
+)
+
+// wsClient wraps a connection so that writes from the read loop and from
+// broadcasts are serialized, as gorilla/websocket requires
+type wsClient struct {
+	id        string
+	name      string
+	principal *auth.Principal
+	tenant    string
+	conn      *websocket.Conn
+	writeMu   sync.Mutex
+}
+
+// viewer returns the presence identity of the client
+func (c *wsClient) viewer() presence.Viewer {
+	return presence.Viewer{ID: c.id, Name: c.name, Subject: c.principal.Subject}
+}
+
+// holder returns the edit lease identity of the client: the authenticated
+// subject, or the connection itself for anonymous clients
+func (c *wsClient) holder() string {
+	if c.principal.Authenticated() {
+		return c.principal.Subject
+	}
+	return c.id
+}
+
+// canSee reports whether the client's principal may receive a message: it
+// must belong to the client's tenant and the policy must let the principal
+// read the task. Presence and lease messages don't know the task owner and
+// go to every principal that may read some tasks
+func (c *wsClient) canSee(message EventMessage, policy *authz.Policy) bool {
+	if message.tenant != c.tenant || !c.principal.HasScope(auth.ScopeTasksRead) {
+		return false
+	}
+	if !message.hasOwner {
+		return policy.Decide(c.principal, authz.ActionRead) != authz.Deny
+	}
+	return policy.Allowed(c.principal, authz.ActionRead, &task.Task{ID: message.AggregateID, Owner: message.owner})
+}
+
+// presenceKey returns the registry key of a task within the client's tenant
+func (c *wsClient) presenceKey(taskID string) string {
+	return presence.Key(c.tenant, taskID)
+}
+
+// writeJSON sends a JSON message to the client
+func (c *wsClient) writeJSON(v interface{}) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.conn.WriteJSON(v)
+}
+
+// writeMessage sends a raw text message to the client
+func (c *wsClient) writeMessage(data []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.conn.WriteMessage(websocket.TextMessage, data)
 }
 
 // WebSocketHandler manages WebSocket connections and event broadcasting
 type WebSocketHandler struct {
-	clients    map[*websocket.Conn]bool
+	clients    map[*wsClient]bool
 	clientsMux sync.RWMutex
 	eventBus   events.EventBus
+	commandBus cqrs.CommandBus
+	repository task.Repository
+	presence   *presence.Registry
+	auth       *authGate
+	policy     *authz.Policy
+	upgrader   websocket.Upgrader
 }
 
-// NewWebSocketHandler creates a new WebSocket handler
-func NewWebSocketHandler(eventBus events.EventBus) *WebSocketHandler {
-	return &WebSocketHandler{
-		clients:  make(map[*websocket.Conn]bool),
-		eventBus: eventBus,
+// NewWebSocketHandler creates a new WebSocket handler and subscribes it to
+// the event bus when the bus supports local subscribers
+func NewWebSocketHandler(
+	eventBus events.EventBus,
+	commandBus cqrs.CommandBus,
+	repository task.Repository,
+	presenceRegistry *presence.Registry,
+	gate *authGate,
+	policy *authz.Policy,
+) *WebSocketHandler {
+	h := &WebSocketHandler{
+		clients:    make(map[*wsClient]bool),
+		eventBus:   eventBus,
+		commandBus: commandBus,
+		repository: repository,
+		presence:   presenceRegistry,
+		auth:       gate,
+		policy:     policy,
+		upgrader: websocket.Upgrader{
+			CheckOrigin: gate.checkOrigin,
+		},
+	}
+
+	if subscriber, ok := eventBus.(events.Subscriber); ok {
+		subscriber.Subscribe(h)
 	}
+
+	presenceRegistry.OnExpire(func(lease presence.Lease) {
+		h.broadcastLockReleased(lease, presence.ReasonExpired)
+	})
+
+	return h
 }
 
 // EventMessage represents a message sent to WebSocket clients
@@ -43,35 +155,100 @@ type EventMessage struct {
 	ID          string      `json:"id"`
 	Type        string      `json:"type"`
 	AggregateID string      `json:"aggregateId"`
+	RequestID   string      `json:"request_id,omitempty"`
 	Timestamp   time.Time   `json:"timestamp"`
 	Payload     interface{} `json:"payload"`
+	// tenant the message belongs to; only clients of that tenant receive it
+	tenant string
+	// owner of the task, known when hasOwner is set, for the read policy
+	owner    string
+	hasOwner bool
+}
+
+// CommandFrame represents a command sent by a WebSocket client
+type CommandFrame struct {
+	Op        string          `json:"op"`
+	RequestID string          `json:"request_id"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+// createTaskPayload is the payload of a create_task frame
+type createTaskPayload struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	DueDate     string `json:"due_date,omitempty"`
+}
+
+// completeTaskPayload is the payload of a complete_task frame
+type completeTaskPayload struct {
+	ID string `json:"id"`
+}
+
+// presencePayload is the payload of the presence and lock frames
+type presencePayload struct {
+	TaskID     string `json:"task_id"`
+	TTLSeconds int    `json:"ttl_seconds,omitempty"`
 }
 
 // HandleWebSocket handles WebSocket connections
 func (h *WebSocketHandler) HandleWebSocket(c *gin.Context) {
 	log.Printf("🔗 WebSocket connection attempt from: %s", c.ClientIP())
 
-	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	principal, responseHeader, err := h.auth.fromWebSocket(c.Request)
+	if err != nil {
+		log.Printf("🚫 WebSocket authentication failed from %s: %v", c.ClientIP(), err)
+		writeProblem(c, err)
+		return
+	}
+
+	// Browsers cannot set headers on the upgrade, so the tenant may also
+	// come in the query string
+	requested := c.GetHeader(tenant.Header)
+	if requested == "" {
+		requested = c.Query("tenant")
+	}
+	tenantID, err := h.auth.tenantFor(principal, requested)
+	if err != nil {
+		writeProblem(c, err)
+		return
+	}
+
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, responseHeader)
 	if err != nil {
 		log.Printf("❌ Failed to upgrade to WebSocket: %v", err)
 		return
 	}
 	defer conn.Close()
 
+	name := principal.Name
+	if !principal.Authenticated() && c.Query("name") != "" {
+		name = c.Query("name")
+	}
+
+	client := &wsClient{
+		id:        uuid.New().String(),
+		name:      name,
+		principal: principal,
+		tenant:    tenantID,
+		conn:      conn,
+	}
+
 	// Add client to the list
 	h.clientsMux.Lock()
-	h.clients[conn] = true
+	h.clients[client] = true
 	clientCount := len(h.clients)
 	h.clientsMux.Unlock()
 
 	log.Printf("✅ WebSocket client connected. Total clients: %d", clientCount)
 
-	// Remove client when disconnected
+	// Remove client when disconnected, dropping its presence and leases
 	defer func() {
 		h.clientsMux.Lock()
-		delete(h.clients, conn)
+		delete(h.clients, client)
+		remaining := len(h.clients)
 		h.clientsMux.Unlock()
-		log.Printf("WebSocket client disconnected. Total clients: %d", len(h.clients))
+		h.dropPresence(client)
+		log.Printf("WebSocket client disconnected. Total clients: %d", remaining)
 	}()
 
 	// Send welcome message
@@ -79,34 +256,302 @@ func (h *WebSocketHandler) HandleWebSocket(c *gin.Context) {
 		ID:        "welcome",
 		Type:      "connection.established",
 		Timestamp: time.Now(),
-		Payload:   map[string]string{"message": "Connected to task event stream"},
+		Payload: map[string]string{
+			"message":   "Connected to task event stream",
+			"client_id": client.id,
+			"subject":   principal.Subject,
+			"tenant":    tenantID,
+		},
 	}
-	conn.WriteJSON(welcomeMsg)
+	client.writeJSON(welcomeMsg)
 
-	// Keep connection alive and handle client messages
+	// Keep connection alive and handle client command frames
 	for {
-		_, _, err := conn.ReadMessage()
+		_, data, err := conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				log.Printf("WebSocket error: %v", err)
 			}
 			break
 		}
+
+		ctx := tenant.WithID(withPrincipal(c.Request.Context(), principal), tenantID)
+		h.handleFrame(ctx, client, data)
 	}
 }
 
-// BroadcastEvent sends an event to all connected WebSocket clients
-func (h *WebSocketHandler) BroadcastEvent(event task.DomainEvent) {
-	if len(h.clients) == 0 {
-		return // No clients connected
+// handleFrame decodes a command frame, dispatches it through the command bus
+// and replies to the client with a correlated result
+func (h *WebSocketHandler) handleFrame(ctx context.Context, client *wsClient, data []byte) {
+	var frame CommandFrame
+	if err := json.Unmarshal(data, &frame); err != nil {
+		h.reply(client, frame, fieldError("", "json", "invalid command frame: "+err.Error()))
+		return
+	}
+
+	switch frame.Op {
+	case OpViewTask, OpLeaveTask:
+		h.reply(client, frame, h.handlePresenceFrame(ctx, client, frame))
+		return
+	case OpAcquireLock, OpReleaseLock:
+		if !client.principal.HasScope(auth.ScopeTasksWrite) {
+			h.reply(client, frame, errMissingScope(auth.ScopeTasksWrite))
+			return
+		}
+		h.reply(client, frame, h.handlePresenceFrame(ctx, client, frame))
+		return
 	}
 
+	if !client.principal.HasScope(auth.ScopeTasksWrite) {
+		h.reply(client, frame, errMissingScope(auth.ScopeTasksWrite))
+		return
+	}
+
+	cmd, err := h.buildCommand(frame)
+	if err != nil {
+		h.reply(client, frame, err)
+		return
+	}
+
+	// Correlate the events produced by the command with the frame request ID
+	// and identify the client for the edit lease checks
+	ctx = cqrs.WithRequestID(ctx, frame.RequestID)
+	ctx = presence.WithHolder(ctx, client.holder())
+	h.reply(client, frame, h.commandBus.Dispatch(ctx, cmd))
+}
+
+// buildCommand maps a command frame to the corresponding CQRS command
+func (h *WebSocketHandler) buildCommand(frame CommandFrame) (cqrs.Command, error) {
+	switch frame.Op {
+	case OpCreateTask:
+		var payload createTaskPayload
+		if err := json.Unmarshal(frame.Payload, &payload); err != nil {
+			return nil, invalidPayload(frame.Op, err)
+		}
+		if payload.Title == "" {
+			return nil, fieldError("title", "required", "title is required")
+		}
+		dueDate, err := parseDueDate(payload.DueDate)
+		if err != nil {
+			return nil, fieldError("due_date", "date", "expected format: YYYY-MM-DD")
+		}
+		return creator.CreateTaskCommand{
+			Title:       payload.Title,
+			Description: payload.Description,
+			DueDate:     dueDate,
+		}, nil
+	case OpCompleteTask:
+		var payload completeTaskPayload
+		if err := json.Unmarshal(frame.Payload, &payload); err != nil {
+			return nil, invalidPayload(frame.Op, err)
+		}
+		return creator.CompleteTaskCommand{ID: payload.ID}, nil
+	default:
+		return nil, fieldError("op", "oneof", fmt.Sprintf("unknown op: %q", frame.Op))
+	}
+}
+
+// handlePresenceFrame updates the viewers and edit leases of a task and
+// broadcasts the resulting changes. Viewing a task requires being allowed to
+// read it and locking it requires being allowed to update it
+func (h *WebSocketHandler) handlePresenceFrame(ctx context.Context, client *wsClient, frame CommandFrame) error {
+	var payload presencePayload
+	if err := json.Unmarshal(frame.Payload, &payload); err != nil {
+		return invalidPayload(frame.Op, err)
+	}
+	if _, err := task.NewID(payload.TaskID); err != nil {
+		return fmt.Errorf("invalid task_id: %w", err)
+	}
+
+	key := client.presenceKey(payload.TaskID)
+	switch frame.Op {
+	case OpViewTask:
+		if err := h.authorizeTask(ctx, payload.TaskID, authz.ActionRead); err != nil {
+			return err
+		}
+		viewers := h.presence.View(key, client.viewer())
+		h.broadcastPresence(key, viewers)
+	case OpLeaveTask:
+		viewers := h.presence.Leave(key, client.id)
+		h.broadcastPresence(key, viewers)
+	case OpAcquireLock:
+		if err := h.authorizeTask(ctx, payload.TaskID, authz.ActionUpdate); err != nil {
+			return err
+		}
+		ttl := time.Duration(payload.TTLSeconds) * time.Second
+		lease, err := h.presence.Acquire(key, client.holder(), ttl)
+		if err != nil {
+			return err
+		}
+		tenantID, taskID := presence.SplitKey(lease.TaskID)
+		lease.TaskID = taskID
+		h.broadcastMessage(EventMessage{
+			ID:          fmt.Sprintf("lock_%d", time.Now().UnixNano()),
+			Type:        MessageLockAcquired,
+			AggregateID: taskID,
+			RequestID:   frame.RequestID,
+			Timestamp:   time.Now(),
+			Payload:     lease,
+			tenant:      tenantID,
+		})
+	case OpReleaseLock:
+		lease, err := h.presence.Release(key, client.holder())
+		if err != nil {
+			return err
+		}
+		h.broadcastLockReleased(lease, presence.ReasonReleased)
+	}
+
+	return nil
+}
+
+// authorizeTask loads a task of the client's tenant and checks that the
+// policy lets the principal perform the action on it
+func (h *WebSocketHandler) authorizeTask(ctx context.Context, taskID string, action authz.Action) error {
+	t, err := h.repository.FindByID(ctx, taskID)
+	if err != nil {
+		return err
+	}
+	return h.policy.Authorize(ctx, action, t)
+}
+
+// dropPresence removes a disconnected client from every task it was viewing
+// and releases its edit leases unless the holder has other connections open
+func (h *WebSocketHandler) dropPresence(client *wsClient) {
+	for key, viewers := range h.presence.LeaveAll(client.id) {
+		h.broadcastPresence(key, viewers)
+	}
+
+	holder := client.holder()
+	h.clientsMux.RLock()
+	for other := range h.clients {
+		if other.holder() == holder {
+			h.clientsMux.RUnlock()
+			return
+		}
+	}
+	h.clientsMux.RUnlock()
+
+	for _, lease := range h.presence.ReleaseAll(holder) {
+		h.broadcastLockReleased(lease, presence.ReasonDisconnected)
+	}
+}
+
+// invalidPayload reports a command frame whose payload does not match its op
+func invalidPayload(op string, err error) error {
+	return fieldError("payload", "json", fmt.Sprintf("invalid %s payload: %v", op, err))
+}
+
+// errMissingScope reports a command frame rejected for lack of scope
+func errMissingScope(scope string) error {
+	return NewProblem(http.StatusForbidden, CodeForbidden, "missing scope "+scope)
+}
+
+// broadcastPresence notifies the current viewers of the task registered
+// under key to the clients of its tenant
+func (h *WebSocketHandler) broadcastPresence(key string, viewers []presence.Viewer) {
+	tenantID, taskID := presence.SplitKey(key)
+	h.broadcastMessage(EventMessage{
+		ID:          fmt.Sprintf("presence_%d", time.Now().UnixNano()),
+		Type:        MessagePresenceChanged,
+		AggregateID: taskID,
+		Timestamp:   time.Now(),
+		Payload: map[string]interface{}{
+			"task_id": taskID,
+			"viewers": viewers,
+		},
+		tenant: tenantID,
+	})
+}
+
+// broadcastLockReleased notifies the clients of the lease's tenant that an
+// edit lease is no longer held
+func (h *WebSocketHandler) broadcastLockReleased(lease presence.Lease, reason string) {
+	tenantID, taskID := presence.SplitKey(lease.TaskID)
+	h.broadcastMessage(EventMessage{
+		ID:          fmt.Sprintf("lock_%d", time.Now().UnixNano()),
+		Type:        MessageLockReleased,
+		AggregateID: taskID,
+		Timestamp:   time.Now(),
+		Payload: map[string]interface{}{
+			"task_id": taskID,
+			"holder":  lease.Holder,
+			"reason":  reason,
+		},
+		tenant: tenantID,
+	})
+}
+
+// reply sends the success or error result of a command frame to the client
+func (h *WebSocketHandler) reply(client *wsClient, frame CommandFrame, err error) {
+	message := EventMessage{
+		ID:        fmt.Sprintf("reply_%d", time.Now().UnixNano()),
+		Type:      MessageCommandSucceeded,
+		RequestID: frame.RequestID,
+		Timestamp: time.Now(),
+		Payload:   map[string]interface{}{"op": frame.Op},
+	}
+	if err != nil {
+		problem := ProblemFromError(err)
+		message.Type = MessageCommandFailed
+		message.Payload = map[string]interface{}{
+			"op":     frame.Op,
+			"code":   problem.Code,
+			"status": problem.Status,
+			"error":  problem.Detail,
+		}
+	}
+
+	if writeErr := client.writeJSON(message); writeErr != nil {
+		log.Printf("Failed to send command reply to WebSocket client: %v", writeErr)
+	}
+}
+
+// Handle implements events.EventHandler so the handler can subscribe to the
+// event bus and forward every published event to the connected clients
+func (h *WebSocketHandler) Handle(_ context.Context, event task.DomainEvent) error {
+	h.BroadcastEvent(event)
+	return nil
+}
+
+// BroadcastEvent sends an event to the connected WebSocket clients of the
+// event's tenant
+func (h *WebSocketHandler) BroadcastEvent(event task.DomainEvent) {
 	message := EventMessage{
 		ID:          fmt.Sprintf("event_%d", time.Now().UnixNano()),
 		Type:        event.EventName(),
 		AggregateID: event.AggregateID(),
 		Timestamp:   event.OccurredOn(),
 		Payload:     h.extractEventPayload(event),
+		tenant:      tenant.DefaultID,
+	}
+	if correlated, ok := event.(task.CorrelatedEvent); ok {
+		message.RequestID = correlated.CorrelationID()
+	}
+	if scoped, ok := event.(task.TenantEvent); ok && scoped.TenantID() != "" {
+		message.tenant = scoped.TenantID()
+	}
+	if owned, ok := event.(task.OwnedEvent); ok {
+		message.owner, message.hasOwner = owned.TaskOwner(), true
+	}
+
+	h.broadcastMessage(message)
+}
+
+// broadcastMessage sends a message to the connected WebSocket clients whose
+// principal may see it
+func (h *WebSocketHandler) broadcastMessage(message EventMessage) {
+	h.clientsMux.RLock()
+	clients := make([]*wsClient, 0, len(h.clients))
+	for client := range h.clients {
+		if client.canSee(message, h.policy) {
+			clients = append(clients, client)
+		}
+	}
+	h.clientsMux.RUnlock()
+
+	if len(clients) == 0 {
+		return // No clients connected
 	}
 
 	messageJSON, err := json.Marshal(message)
@@ -115,26 +560,26 @@ func (h *WebSocketHandler) BroadcastEvent(event task.DomainEvent) {
 		return
 	}
 
-	h.clientsMux.RLock()
-	defer h.clientsMux.RUnlock()
-
-	var disconnectedClients []*websocket.Conn
+	var disconnectedClients []*wsClient
 
-	for client := range h.clients {
-		err := client.WriteMessage(websocket.TextMessage, messageJSON)
-		if err != nil {
+	for _, client := range clients {
+		if err := client.writeMessage(messageJSON); err != nil {
 			log.Printf("Failed to send message to WebSocket client: %v", err)
 			disconnectedClients = append(disconnectedClients, client)
 		}
 	}
 
 	// Clean up disconnected clients
-	for _, client := range disconnectedClients {
-		delete(h.clients, client)
-		client.Close()
+	if len(disconnectedClients) > 0 {
+		h.clientsMux.Lock()
+		for _, client := range disconnectedClients {
+			delete(h.clients, client)
+			client.conn.Close()
+		}
+		h.clientsMux.Unlock()
 	}
 
-	log.Printf("Broadcasted event %s to %d clients", event.EventName(), len(h.clients))
+	log.Printf("Broadcasted %s to %d clients", message.Type, len(clients)-len(disconnectedClients))
 }
 
 // extractEventPayload extracts the relevant payload from different event types
@@ -147,6 +592,13 @@ func (h *WebSocketHandler) extractEventPayload(event task.DomainEvent) interface
 			"description": e.Description,
 			"due_date":    e.DueDate,
 		}
+	case *task.TaskUpdatedEvent:
+		return map[string]interface{}{
+			"task_id":     e.TaskID,
+			"title":       e.Title,
+			"description": e.Description,
+			"due_date":    e.DueDate,
+		}
 	case *task.TaskCompletedEvent:
 		return map[string]interface{}{
 			"task_id": e.TaskID,
@@ -155,6 +607,19 @@ func (h *WebSocketHandler) extractEventPayload(event task.DomainEvent) interface
 		return map[string]interface{}{
 			"task_id": e.TaskID,
 		}
+	case *task.TaskArchivedEvent:
+		return map[string]interface{}{
+			"task_id":     e.TaskID,
+			"archived_at": e.ArchivedAt,
+		}
+	case *task.TaskRestoredEvent:
+		return map[string]interface{}{
+			"task_id": e.TaskID,
+		}
+	case *task.TaskPurgedEvent:
+		return map[string]interface{}{
+			"task_id": e.TaskID,
+		}
 	default:
 		return map[string]interface{}{
 			"aggregate_id": event.AggregateID(),
@@ -164,7 +629,7 @@ func (h *WebSocketHandler) extractEventPayload(event task.DomainEvent) interface
 
 // StartEventListener starts listening for events from RabbitMQ (if implemented)
 func (h *WebSocketHandler) StartEventListener(ctx context.Context) {
-	// This would connect to RabbitMQ to listen for events
-	// For now, we'll rely on the command handlers to call BroadcastEvent directly
+	// Events published through the observable event bus reach the handler
+	// via Handle, so there is nothing to consume from RabbitMQ yet
 	log.Println("WebSocket event listener started (direct mode)")
 }
@@ -0,0 +1,364 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+	"github.com/yebrai/go-tasks-microservice/internal/task/authz"
+	"github.com/yebrai/go-tasks-microservice/internal/task/creator"
+	"github.com/yebrai/go-tasks-microservice/internal/task/presence"
+	"github.com/yebrai/go-tasks-microservice/internal/task/tasktest"
+	"github.com/yebrai/go-tasks-microservice/pkg/auth"
+	"github.com/yebrai/go-tasks-microservice/pkg/cqrs/inmem"
+	"github.com/yebrai/go-tasks-microservice/pkg/events"
+	"github.com/yebrai/go-tasks-microservice/pkg/id"
+)
+
+// dialEvents abre una conexión al endpoint de eventos y descarta el mensaje
+// de bienvenida
+func dialEvents(t *testing.T, server *httptest.Server, dialer *websocket.Dialer, header http.Header) *websocket.Conn {
+	t.Helper()
+
+	conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws/events", header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var welcome EventMessage
+	if err := conn.ReadJSON(&welcome); err != nil {
+		t.Fatal(err)
+	}
+	return conn
+}
+
+// sendFrame envía un frame y retorna, en orden, los mensajes recibidos hasta
+// su respuesta
+func sendFrame(t *testing.T, conn *websocket.Conn, frame string) []EventMessage {
+	t.Helper()
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(frame)); err != nil {
+		t.Fatal(err)
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var messages []EventMessage
+	for {
+		var message EventMessage
+		if err := conn.ReadJSON(&message); err != nil {
+			t.Fatalf("Expected a reply to %s: %v", frame, err)
+		}
+		messages = append(messages, message)
+		if message.Type == MessageCommandSucceeded || message.Type == MessageCommandFailed {
+			return messages
+		}
+	}
+}
+
+func TestWebSocket_InvalidFramesAreRejectedAsBadRequests(t *testing.T) {
+	handler := NewServer(inmem.NewCommandBus(), nil, events.NewNoOpEventBus(), presence.NewRegistry(), Options{}).Handler()
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	conn := dialEvents(t, server, websocket.DefaultDialer, nil)
+	defer conn.Close()
+
+	tests := []struct {
+		name  string
+		frame string
+		code  string
+	}{
+		{"malformed frame", `{"op":`, CodeValidationFailed},
+		{"unknown op", `{"op":"delete_everything","request_id":"r1"}`, CodeValidationFailed},
+		{"missing title", `{"op":"create_task","payload":{"title":""}}`, CodeValidationFailed},
+		{"bad due date", `{"op":"create_task","payload":{"title":"x","due_date":"tomorrow"}}`, CodeValidationFailed},
+		{"bad payload", `{"op":"complete_task","payload":[1]}`, CodeValidationFailed},
+		{"bad presence payload", `{"op":"view_task","payload":"x"}`, CodeValidationFailed},
+		{"bad task id", `{"op":"view_task","payload":{"task_id":"nope"}}`, CodeInvalidTaskID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages := sendFrame(t, conn, tt.frame)
+			reply := messages[len(messages)-1]
+			payload, _ := reply.Payload.(map[string]interface{})
+			if reply.Type != MessageCommandFailed || payload["status"] != float64(http.StatusBadRequest) || payload["code"] != tt.code {
+				t.Errorf("Expected a 400 %s reply, got %s %v", tt.code, reply.Type, reply.Payload)
+			}
+		})
+	}
+}
+
+func TestWebSocket_EventsFollowTheReadPolicy(t *testing.T) {
+	policy, err := authz.NewPolicy([]authz.Rule{
+		{Actions: []authz.Action{authz.ActionRead}, Roles: []string{authz.RoleMember}, OwnerOnly: true},
+		{Actions: []authz.Action{authz.ActionAll}, Roles: []string{authz.RoleAdmin}},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	member := func(subject string) auth.Principal {
+		return auth.Principal{Subject: subject, Roles: []string{authz.RoleMember}, Scopes: []string{auth.ScopeTasksRead}}
+	}
+
+	eventBus := events.NewObservableEventBus(events.NewNoOpEventBus())
+	handler := NewServer(inmem.NewCommandBus(), nil, eventBus, presence.NewRegistry(), Options{
+		Authenticator: auth.NewStaticTokenAuthenticator([]auth.StaticToken{
+			{Token: "alice", Principal: member("user:alice")},
+			{Token: "bob", Principal: member("user:bob")},
+		}),
+		Policy: policy,
+	}).Handler()
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	dial := func(token string) *websocket.Conn {
+		return dialEvents(t, server, &websocket.Dialer{Subprotocols: []string{webSocketAuthProtocol, token}}, nil)
+	}
+	alice := dial("alice")
+	defer alice.Close()
+	bob := dial("bob")
+	defer bob.Close()
+
+	publish := func(id, owner string) {
+		created, _ := task.NewTask(id, "task", "", nil)
+		created.Owner = owner
+		if err := eventBus.Publish(context.Background(), task.NewTaskCreatedEvent(created)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	aliceTask := "8f14e45f-ceea-467f-a8f0-2b5c1a2e3d4c"
+	bobTask := "6f1c2a9e-3b4d-4c5e-8f70-1a2b3c4d5e6f"
+	publish(aliceTask, "user:alice")
+	publish(bobTask, "user:bob")
+
+	// Cada uno recibe primero, y solo, el evento de su propia tarea
+	for conn, want := range map[*websocket.Conn]string{alice: aliceTask, bob: bobTask} {
+		_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		var message EventMessage
+		if err := conn.ReadJSON(&message); err != nil {
+			t.Fatal(err)
+		}
+		if message.AggregateID != want {
+			t.Errorf("Expected only the event of %s, got %s", want, message.AggregateID)
+		}
+	}
+}
+
+func TestWebSocket_Authentication(t *testing.T) {
+	eventBus := events.NewObservableEventBus(events.NewNoOpEventBus())
+	handler := NewServer(inmem.NewCommandBus(), nil, eventBus, presence.NewRegistry(), Options{
+		Authenticator: auth.NewStaticTokenAuthenticator([]auth.StaticToken{
+			{Token: "reader", Principal: auth.Principal{Subject: "svc:reader", Scopes: []string{auth.ScopeTasksRead}}},
+			{Token: "writer", Principal: auth.Principal{Subject: "svc:writer", Scopes: []string{auth.ScopeTasksWrite}}},
+		}),
+		AllowedOrigins: []string{"https://app.example.com"},
+	}).Handler()
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws/events"
+
+	issueTicket := func() string {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/ws/tickets", nil)
+		req.Header.Set("Authorization", "Bearer reader")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		var body struct {
+			Data struct {
+				Ticket string `json:"ticket"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || rec.Code != http.StatusCreated || body.Data.Ticket == "" {
+			t.Fatalf("Expected a ticket, got %d: %s", rec.Code, rec.Body.String())
+		}
+		return body.Data.Ticket
+	}
+	ticket := issueTicket()
+
+	tests := []struct {
+		name       string
+		query      string
+		protocols  []string
+		header     http.Header
+		wantStatus int
+		// wantProtocol subprotocolo que debe aceptar el servidor
+		wantProtocol string
+	}{
+		{"no credentials", "", nil, nil, http.StatusUnauthorized, ""},
+		{"bearer subprotocol", "", []string{webSocketAuthProtocol, "reader"}, nil, http.StatusSwitchingProtocols, webSocketAuthProtocol},
+		{"invalid subprotocol token", "", []string{webSocketAuthProtocol, "nope"}, nil, http.StatusUnauthorized, ""},
+		{"authorization header", "", nil, http.Header{"Authorization": {"Bearer reader"}}, http.StatusSwitchingProtocols, ""},
+		{"ticket", "?ticket=" + ticket, nil, nil, http.StatusSwitchingProtocols, ""},
+		{"ticket already redeemed", "?ticket=" + ticket, nil, nil, http.StatusUnauthorized, ""},
+		{"unknown ticket", "?ticket=forged", nil, nil, http.StatusUnauthorized, ""},
+		{"allowed origin", "", nil, http.Header{"Authorization": {"Bearer reader"}, "Origin": {"https://app.example.com"}}, http.StatusSwitchingProtocols, ""},
+		{"foreign origin", "", nil, http.Header{"Authorization": {"Bearer reader"}, "Origin": {"https://evil.example"}}, http.StatusForbidden, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dialer := websocket.Dialer{Subprotocols: tt.protocols}
+			conn, resp, err := dialer.Dial(url+tt.query, tt.header)
+			if conn != nil {
+				defer conn.Close()
+			}
+			if resp == nil {
+				t.Fatalf("Expected an HTTP response: %v", err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("Expected %d, got %d (%v)", tt.wantStatus, resp.StatusCode, err)
+			}
+			if got := resp.Header.Get("Sec-WebSocket-Protocol"); got != tt.wantProtocol {
+				t.Errorf("Expected subprotocol %q, got %q", tt.wantProtocol, got)
+			}
+		})
+	}
+
+	// Solo los principales con tasks:read reciben los eventos
+	reader := dialEvents(t, server, &websocket.Dialer{Subprotocols: []string{webSocketAuthProtocol, "reader"}}, nil)
+	defer reader.Close()
+	writer := dialEvents(t, server, &websocket.Dialer{Subprotocols: []string{webSocketAuthProtocol, "writer"}}, nil)
+	defer writer.Close()
+
+	created, _ := task.NewTask("8f14e45f-ceea-467f-a8f0-2b5c1a2e3d4c", "task", "", nil)
+	if err := eventBus.Publish(context.Background(), task.NewTaskCreatedEvent(created)); err != nil {
+		t.Fatal(err)
+	}
+
+	_ = reader.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var message EventMessage
+	if err := reader.ReadJSON(&message); err != nil || message.AggregateID != created.ID {
+		t.Errorf("Expected the reader to receive the event, got %+v (%v)", message, err)
+	}
+	_ = writer.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	if err := writer.ReadJSON(&message); err == nil {
+		t.Errorf("Expected no events without tasks:read, got %+v", message)
+	}
+}
+
+func TestWebSocket_CommandFramesCorrelateRepliesAndEvents(t *testing.T) {
+	repository := tasktest.NewRepository()
+	eventBus := events.NewObservableEventBus(events.NewNoOpEventBus())
+	bus := inmem.NewCommandBus()
+	if err := bus.Register(creator.CreateTaskCommandType, creator.NewCreateTaskCommandHandler(repository, id.NewUniqueIDGenerator(), eventBus, creator.Quotas{})); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Register(creator.CompleteTaskCommandType, creator.NewCompleteTaskCommandHandler(repository, eventBus)); err != nil {
+		t.Fatal(err)
+	}
+	handler := NewServer(bus, repository, eventBus, presence.NewRegistry(), Options{}).Handler()
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	sender := dialEvents(t, server, websocket.DefaultDialer, nil)
+	defer sender.Close()
+	observer := dialEvents(t, server, websocket.DefaultDialer, nil)
+	defer observer.Close()
+
+	// observed lee el siguiente evento que recibe otro cliente
+	observed := func() EventMessage {
+		t.Helper()
+		_ = observer.SetReadDeadline(time.Now().Add(5 * time.Second))
+		var message EventMessage
+		if err := observer.ReadJSON(&message); err != nil {
+			t.Fatal(err)
+		}
+		return message
+	}
+
+	messages := sendFrame(t, sender, `{"op":"create_task","request_id":"req-1","payload":{"title":"From the socket"}}`)
+	if len(messages) != 2 || messages[0].Type != "task.created" || messages[1].Type != MessageCommandSucceeded {
+		t.Fatalf("Expected the created event and the reply, got %+v", messages)
+	}
+	for _, message := range messages {
+		if message.RequestID != "req-1" {
+			t.Errorf("Expected %s to carry request_id req-1, got %q", message.Type, message.RequestID)
+		}
+	}
+	created := messages[0].AggregateID
+	if event := observed(); event.Type != "task.created" || event.RequestID != "req-1" || event.AggregateID != created {
+		t.Errorf("Expected other clients to see the correlated event, got %+v", event)
+	}
+
+	messages = sendFrame(t, sender, `{"op":"complete_task","request_id":"req-2","payload":{"id":"`+created+`"}}`)
+	if reply := messages[len(messages)-1]; reply.Type != MessageCommandSucceeded || reply.RequestID != "req-2" || messages[0].RequestID != "req-2" {
+		t.Errorf("Expected the completion correlated with req-2, got %+v", messages)
+	}
+	if event := observed(); event.Type != "task.completed" || event.RequestID != "req-2" {
+		t.Errorf("Expected the completed event correlated with req-2, got %+v", event)
+	}
+
+	messages = sendFrame(t, sender, `{"op":"complete_task","request_id":"req-3","payload":{"id":"6f1c2a9e-3b4d-4c5e-8f70-1a2b3c4d5e6f"}}`)
+	reply := messages[len(messages)-1]
+	payload, _ := reply.Payload.(map[string]interface{})
+	if len(messages) != 1 || reply.Type != MessageCommandFailed || reply.RequestID != "req-3" || payload["code"] != CodeTaskNotFound {
+		t.Errorf("Expected only a failed reply correlated with req-3, got %+v", messages)
+	}
+}
+
+func TestWebSocket_PresenceFramesFollowThePolicy(t *testing.T) {
+	const (
+		bobTask     = "6f1c2a9e-3b4d-4c5e-8f70-1a2b3c4d5e6f"
+		missingTask = "8f14e45f-ceea-467f-a8f0-2b5c1a2e3d4c"
+	)
+
+	policy, err := authz.NewPolicy([]authz.Rule{
+		{Actions: []authz.Action{authz.ActionRead, authz.ActionUpdate}, Roles: []string{authz.RoleMember}, OwnerOnly: true},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	member := func(subject string) auth.Principal {
+		return auth.Principal{Subject: subject, Roles: []string{authz.RoleMember}, Scopes: []string{auth.ScopeTasksRead, auth.ScopeTasksWrite}}
+	}
+
+	repository := tasktest.NewRepository()
+	owned, _ := task.NewTask(bobTask, "task", "", nil)
+	owned.Owner = "user:bob"
+	repository.Put(owned)
+
+	handler := NewServer(inmem.NewCommandBus(), repository, events.NewNoOpEventBus(), presence.NewRegistry(), Options{
+		Authenticator: auth.NewStaticTokenAuthenticator([]auth.StaticToken{
+			{Token: "alice", Principal: member("user:alice")},
+			{Token: "bob", Principal: member("user:bob")},
+		}),
+		Policy: policy,
+	}).Handler()
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		token      string
+		frame      string
+		wantStatus int
+	}{
+		{"view a hidden task", "alice", `{"op":"view_task","payload":{"task_id":"` + bobTask + `"}}`, http.StatusForbidden},
+		{"lock a task of someone else", "alice", `{"op":"acquire_lock","payload":{"task_id":"` + bobTask + `"}}`, http.StatusForbidden},
+		{"lock a missing task", "alice", `{"op":"acquire_lock","payload":{"task_id":"` + missingTask + `"}}`, http.StatusNotFound},
+		{"view an owned task", "bob", `{"op":"view_task","payload":{"task_id":"` + bobTask + `"}}`, 0},
+		{"lock an owned task", "bob", `{"op":"acquire_lock","payload":{"task_id":"` + bobTask + `"}}`, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := dialEvents(t, server, &websocket.Dialer{Subprotocols: []string{webSocketAuthProtocol, tt.token}}, nil)
+			defer conn.Close()
+
+			messages := sendFrame(t, conn, tt.frame)
+			reply := messages[len(messages)-1]
+			payload, _ := reply.Payload.(map[string]interface{})
+			if tt.wantStatus == 0 && reply.Type != MessageCommandSucceeded {
+				t.Errorf("Expected the frame to succeed, got %v", reply.Payload)
+			}
+			if tt.wantStatus != 0 && (reply.Type != MessageCommandFailed || payload["status"] != float64(tt.wantStatus)) {
+				t.Errorf("Expected a %d reply, got %s %v", tt.wantStatus, reply.Type, reply.Payload)
+			}
+		})
+	}
+}
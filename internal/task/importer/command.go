@@ -0,0 +1,27 @@
+package importer
+
+import (
+	"github.com/yebrai/go-tasks-microservice/internal/task/bulk"
+	"github.com/yebrai/go-tasks-microservice/pkg/cqrs"
+)
+
+const ImportTasksCommandType cqrs.CommandType = "task.command.import"
+
+// ImportTasksCommand comando para crear una tarea por cada fila importada
+type ImportTasksCommand struct {
+	// Rows filas ya validadas; las filas con errores se omiten
+	Rows []Row
+	// JobID job donde se registra el progreso; vacío no lo registra
+	JobID string
+}
+
+// Type implementa la interfaz Command
+func (c ImportTasksCommand) Type() cqrs.CommandType {
+	return ImportTasksCommandType
+}
+
+// WithJob implementa la interfaz bulk.Command
+func (c ImportTasksCommand) WithJob(jobID string) bulk.Command {
+	c.JobID = jobID
+	return c
+}
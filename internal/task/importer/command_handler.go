@@ -0,0 +1,110 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+	"github.com/yebrai/go-tasks-microservice/internal/task/bulk"
+	"github.com/yebrai/go-tasks-microservice/internal/task/creator"
+	"github.com/yebrai/go-tasks-microservice/pkg/cqrs"
+	"github.com/yebrai/go-tasks-microservice/pkg/id"
+)
+
+// ImportTasksCommandHandler maneja el comando ImportTasksCommand
+type ImportTasksCommandHandler struct {
+	commandBus  cqrs.CommandBus
+	jobs        bulk.JobStore
+	idGenerator id.Generator
+}
+
+// NewImportTasksCommandHandler crea una nueva instancia del handler
+func NewImportTasksCommandHandler(
+	commandBus cqrs.CommandBus,
+	jobs bulk.JobStore,
+	idGenerator id.Generator,
+) *ImportTasksCommandHandler {
+	return &ImportTasksCommandHandler{
+		commandBus:  commandBus,
+		jobs:        jobs,
+		idGenerator: idGenerator,
+	}
+}
+
+// Handle maneja el comando ImportTasksCommand. Cada fila se crea con
+// CreateTaskCommand y, si no está pendiente, se completa o cancela, de modo
+// que emite los mismos eventos que una tarea creada por la API
+func (h *ImportTasksCommandHandler) Handle(ctx context.Context, cmd cqrs.Command) error {
+	importCmd, ok := cmd.(ImportTasksCommand)
+	if !ok {
+		return fmt.Errorf("invalid command type: expected ImportTasksCommand")
+	}
+
+	h.update(importCmd.JobID, func(job *bulk.Job) {
+		job.Start(int64(len(importCmd.Rows)))
+	})
+
+	for _, row := range importCmd.Rows {
+		if err := ctx.Err(); err != nil {
+			h.update(importCmd.JobID, func(job *bulk.Job) {
+				job.Fail(err)
+			})
+			return fmt.Errorf("import failed: %w", err)
+		}
+
+		taskID, err := h.importRow(ctx, row)
+		h.update(importCmd.JobID, func(job *bulk.Job) {
+			job.Processed++
+			switch {
+			case !row.Valid():
+				job.Skipped++
+			case err == nil:
+				job.Succeeded++
+			default:
+				job.RecordError(bulk.JobError{TaskID: taskID, Row: row.Line, Error: err.Error()})
+			}
+		})
+	}
+
+	h.update(importCmd.JobID, func(job *bulk.Job) {
+		job.Complete()
+	})
+	return nil
+}
+
+// importRow crea la tarea de una fila y retorna su ID
+func (h *ImportTasksCommandHandler) importRow(ctx context.Context, row Row) (string, error) {
+	if !row.Valid() {
+		return "", nil
+	}
+
+	taskID := h.idGenerator.Generate()
+	err := h.commandBus.Dispatch(ctx, creator.CreateTaskCommand{
+		ID:          taskID,
+		Title:       row.Title,
+		Description: row.Description,
+		DueDate:     row.DueDate,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// La tarea recién creada está en la versión 1
+	switch row.Status {
+	case task.StatusCompleted:
+		err = h.commandBus.Dispatch(ctx, creator.CompleteTaskCommand{ID: taskID, ExpectedVersion: 1})
+	case task.StatusCancelled:
+		err = h.commandBus.Dispatch(ctx, creator.CancelTaskCommand{ID: taskID, ExpectedVersion: 1})
+	}
+	return taskID, err
+}
+
+// update modifica el job si el comando tiene uno
+func (h *ImportTasksCommandHandler) update(jobID string, fn func(job *bulk.Job)) {
+	if jobID == "" || h.jobs == nil {
+		return
+	}
+	if err := h.jobs.Update(jobID, fn); err != nil {
+		fmt.Printf("⚠️  Failed to update job %s: %v\n", jobID, err)
+	}
+}
@@ -0,0 +1,145 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+)
+
+// csvColumns nombres de columna aceptados para cada campo en CSV genérico,
+// en minúsculas
+var csvColumns = map[string][]string{
+	"title":       {"title", "name", "summary", "task"},
+	"description": {"description", "desc", "notes"},
+	"due_date":    {"due_date", "due", "due date"},
+	"status":      {"status", "state"},
+}
+
+// jiraColumns columnas de la exportación CSV de Jira, en minúsculas
+var jiraColumns = map[string][]string{
+	"title":       {"summary"},
+	"description": {"description"},
+	"due_date":    {"due date", "due"},
+	"status":      {"status"},
+	"resolution":  {"resolution"},
+}
+
+// jiraStatuses estados de Jira que no quedan pendientes
+var jiraStatuses = map[string]task.Status{
+	"done":      task.StatusCompleted,
+	"closed":    task.StatusCompleted,
+	"resolved":  task.StatusCompleted,
+	"cancelled": task.StatusCancelled,
+	"canceled":  task.StatusCancelled,
+	"won't do":  task.StatusCancelled,
+	"won't fix": task.StatusCancelled,
+	"rejected":  task.StatusCancelled,
+	"declined":  task.StatusCancelled,
+}
+
+// csvRecord fila CSV indexada por campo
+type csvRecord map[string]string
+
+// readCSV lee un CSV con cabecera y mapea sus columnas a campos. Si una
+// columna aparece repetida (Jira exporta varias "Labels") se usa la primera
+func readCSV(format string, r io.Reader, columns map[string][]string) ([]csvRecord, []int, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, malformed(format, err)
+	}
+
+	index := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\uFEFF")))
+		for field, aliases := range columns {
+			if _, seen := index[field]; seen {
+				continue
+			}
+			for _, alias := range aliases {
+				if name == alias {
+					index[field] = i
+				}
+			}
+		}
+	}
+	if _, ok := index["title"]; !ok {
+		return nil, nil, malformed(format, errors.New("missing title column"))
+	}
+
+	var records []csvRecord
+	var lines []int
+	for {
+		fields, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, malformed(format, err)
+		}
+
+		record := make(csvRecord, len(index))
+		for field, i := range index {
+			if i < len(fields) {
+				record[field] = fields[i]
+			}
+		}
+		line, _ := reader.FieldPos(0)
+		records = append(records, record)
+		lines = append(lines, line)
+	}
+
+	return records, lines, nil
+}
+
+// parseCSV lee un CSV genérico con columnas title, description, due_date y
+// status (o sus alias), como el de la exportación
+func parseCSV(r io.Reader) ([]Row, error) {
+	records, lines, err := readCSV("csv", r, csvColumns)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]Row, 0, len(records))
+	for i, record := range records {
+		row := Row{Line: lines[i], Title: record["title"], Description: record["description"]}
+		row.setDueDate(record["due_date"])
+		row.setStatus(record["status"])
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// parseJiraCSV lee la exportación CSV de Jira. Los estados de Jira se
+// traducen por nombre; los desconocidos quedan pendientes
+func parseJiraCSV(r io.Reader) ([]Row, error) {
+	records, lines, err := readCSV("jira", r, jiraColumns)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]Row, 0, len(records))
+	for i, record := range records {
+		row := Row{Line: lines[i], Title: record["title"], Description: record["description"]}
+		row.setDueDate(record["due_date"])
+
+		row.Status = task.StatusPending
+		if status, ok := jiraStatuses[strings.ToLower(strings.TrimSpace(record["status"]))]; ok {
+			row.Status = status
+		}
+		if status, ok := jiraStatuses[strings.ToLower(strings.TrimSpace(record["resolution"]))]; ok && status == task.StatusCancelled {
+			row.Status = status
+		}
+
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
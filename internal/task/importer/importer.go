@@ -0,0 +1,165 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+)
+
+// MaxRows máximo de filas por importación
+const MaxRows = 10000
+
+var (
+	// ErrUnknownFormat el formato de importación no existe
+	ErrUnknownFormat = errors.New("unknown import format")
+	// ErrMalformedFile el fichero no se puede leer en el formato indicado
+	ErrMalformedFile = errors.New("malformed import file")
+	// ErrTooManyRows el fichero supera MaxRows
+	ErrTooManyRows = errors.New("too many rows to import")
+)
+
+// RowError campo inválido de una fila
+type RowError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Row tarea leída del fichero, con los errores que impiden crearla
+type Row struct {
+	// Line posición de la fila en el fichero (línea, o índice desde 1 en
+	// los formatos JSON)
+	Line        int
+	Title       string
+	Description string
+	DueDate     *time.Time
+	Status      task.Status
+	Errors      []RowError
+}
+
+// Valid indica si la fila se puede importar
+func (r *Row) Valid() bool {
+	return len(r.Errors) == 0
+}
+
+// addError registra un campo inválido
+func (r *Row) addError(field, code, message string) {
+	r.Errors = append(r.Errors, RowError{Field: field, Code: code, Message: message})
+}
+
+// validate comprueba los campos obligatorios tras el mapeo. Las filas
+// ilegibles ya tienen un error de fila completa y no se comprueban
+func (r *Row) validate() {
+	r.Title = strings.TrimSpace(r.Title)
+	if len(r.Errors) > 0 && r.Errors[0].Field == "" {
+		return
+	}
+	if r.Title == "" {
+		r.addError("title", "required", "title is required")
+	}
+	if r.Status == "" {
+		r.Status = task.StatusPending
+	}
+}
+
+// parser lee las filas de un formato
+type parser func(r io.Reader) ([]Row, error)
+
+// parsers formatos de importación por nombre
+var parsers = map[string]parser{
+	"csv":     parseCSV,
+	"jsonl":   parseJSONL,
+	"todotxt": parseTodoTxt,
+	"trello":  parseTrello,
+	"jira":    parseJiraCSV,
+}
+
+// Formats lista los formatos de importación
+func Formats() []string {
+	names := make([]string, 0, len(parsers))
+	for name := range parsers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Parse lee y valida las filas de un fichero. Los errores de cada fila se
+// guardan en Row.Errors; solo los ficheros ilegibles retornan error
+func Parse(format string, r io.Reader) ([]Row, error) {
+	parse, ok := parsers[format]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+
+	rows, err := parse(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) > MaxRows {
+		return nil, fmt.Errorf("%w: the limit is %d", ErrTooManyRows, MaxRows)
+	}
+
+	for i := range rows {
+		rows[i].validate()
+	}
+	return rows, nil
+}
+
+// malformed envuelve un error de lectura del fichero
+func malformed(format string, err error) error {
+	return fmt.Errorf("%w: %s: %v", ErrMalformedFile, format, err)
+}
+
+// dateLayouts formatos de fecha aceptados en los ficheros
+var dateLayouts = []string{
+	"2006-01-02",
+	time.RFC3339,
+	"2006-01-02T15:04:05.000Z07:00",
+	"2006-01-02 15:04",
+	"2006-01-02 15:04:05",
+	// Formatos de exportación de Jira
+	"02/Jan/06 3:04 PM",
+	"02/Jan/06 15:04",
+	"02/Jan/06",
+}
+
+// parseDate acepta las fechas de los formatos soportados
+func parseDate(value string) (*time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range dateLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return &parsed, nil
+		}
+	}
+	return nil, fmt.Errorf("unrecognized date %q", value)
+}
+
+// setDueDate asigna la fecha de vencimiento o registra el error
+func (r *Row) setDueDate(value string) {
+	if strings.TrimSpace(value) == "" {
+		return
+	}
+	dueDate, err := parseDate(value)
+	if err != nil {
+		r.addError("due_date", "date", "expected format: YYYY-MM-DD")
+		return
+	}
+	r.DueDate = dueDate
+}
+
+// setStatus asigna uno de los estados del servicio o registra el error
+func (r *Row) setStatus(value string) {
+	switch status := task.Status(strings.ToLower(strings.TrimSpace(value))); status {
+	case "":
+	case task.StatusPending, task.StatusCompleted, task.StatusCancelled:
+		r.Status = status
+	default:
+		r.addError("status", "oneof", "status must be one of: pending completed cancelled")
+	}
+}
@@ -0,0 +1,131 @@
+package importer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+)
+
+func parse(t *testing.T, format, input string) []Row {
+	t.Helper()
+
+	rows, err := Parse(format, strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rows
+}
+
+func assertRow(t *testing.T, row Row, title string, status task.Status, due string) {
+	t.Helper()
+
+	if !row.Valid() {
+		t.Fatalf("Row %d should be valid, got errors %v", row.Line, row.Errors)
+	}
+	if row.Title != title {
+		t.Errorf("Row %d: expected title %q, got %q", row.Line, title, row.Title)
+	}
+	if row.Status != status {
+		t.Errorf("Row %d: expected status %s, got %s", row.Line, status, row.Status)
+	}
+
+	got := ""
+	if row.DueDate != nil {
+		got = row.DueDate.Format("2006-01-02")
+	}
+	if got != due {
+		t.Errorf("Row %d: expected due date %q, got %q", row.Line, due, got)
+	}
+}
+
+func TestParse_CSVReportsRowErrors(t *testing.T) {
+	rows := parse(t, "csv", "Name,Notes,Due,State\n"+
+		"Comprar pan,,2025-06-01,pending\n"+
+		",sin título,,\n"+
+		"Fecha mala,,01/06/2025,done\n")
+
+	if len(rows) != 3 {
+		t.Fatalf("Expected 3 rows, got %d", len(rows))
+	}
+	assertRow(t, rows[0], "Comprar pan", task.StatusPending, "2025-06-01")
+
+	if rows[1].Line != 3 || len(rows[1].Errors) != 1 || rows[1].Errors[0].Field != "title" {
+		t.Errorf("Expected a title error on line 3, got %+v", rows[1])
+	}
+	if len(rows[2].Errors) != 2 {
+		t.Errorf("Expected due_date and status errors, got %v", rows[2].Errors)
+	}
+}
+
+func TestParse_JSONLSkipsBlankLines(t *testing.T) {
+	rows := parse(t, "jsonl", `{"title":"Uno","status":"completed","due_date":"2025-06-01T00:00:00Z"}`+"\n\n{oops\n")
+
+	if len(rows) != 2 {
+		t.Fatalf("Expected 2 rows, got %d", len(rows))
+	}
+	assertRow(t, rows[0], "Uno", task.StatusCompleted, "2025-06-01")
+	if rows[1].Line != 3 || len(rows[1].Errors) != 1 || rows[1].Errors[0].Field != "" {
+		t.Errorf("Expected a single invalid JSON error on line 3, got %+v", rows[1])
+	}
+}
+
+func TestParse_TodoTxt(t *testing.T) {
+	rows := parse(t, "todotxt", "(A) 2025-05-01 Llamar a mamá +familia @tel due:2025-06-01\n"+
+		"x 2025-05-03 2025-05-01 Pagar factura\n")
+
+	assertRow(t, rows[0], "Llamar a mamá +familia @tel", task.StatusPending, "2025-06-01")
+	assertRow(t, rows[1], "Pagar factura", task.StatusCompleted, "")
+}
+
+func TestParse_Trello(t *testing.T) {
+	rows := parse(t, "trello", `{
+		"lists": [{"id": "l1", "name": "To Do"}, {"id": "l2", "name": "Done"}],
+		"cards": [
+			{"name": "Diseño", "idList": "l1", "due": "2025-06-01T10:00:00.000Z"},
+			{"name": "Hecha", "idList": "l2"},
+			{"name": "Archivada", "idList": "l1", "closed": true},
+			{"name": "Vencimiento completado", "idList": "l1", "dueComplete": true}
+		]
+	}`)
+
+	assertRow(t, rows[0], "Diseño", task.StatusPending, "2025-06-01")
+	assertRow(t, rows[1], "Hecha", task.StatusCompleted, "")
+	assertRow(t, rows[2], "Archivada", task.StatusCancelled, "")
+	assertRow(t, rows[3], "Vencimiento completado", task.StatusCompleted, "")
+}
+
+func TestParse_JiraCSV(t *testing.T) {
+	rows := parse(t, "jira", "Summary,Issue key,Status,Resolution,Due Date,Description\n"+
+		"Migrar BD,PRJ-1,In Progress,,01/Jun/25 5:00 PM,Pasar a Mongo 7\n"+
+		"Login,PRJ-2,Done,Done,,\n"+
+		"Viejo,PRJ-3,Closed,Won't Do,,\n")
+
+	assertRow(t, rows[0], "Migrar BD", task.StatusPending, "2025-06-01")
+	if rows[0].Description != "Pasar a Mongo 7" {
+		t.Errorf("Unexpected description %q", rows[0].Description)
+	}
+	assertRow(t, rows[1], "Login", task.StatusCompleted, "")
+	assertRow(t, rows[2], "Viejo", task.StatusCancelled, "")
+}
+
+func TestParse_RejectsUnreadableFiles(t *testing.T) {
+	if _, err := Parse("xlsx", strings.NewReader("")); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("Expected ErrUnknownFormat, got %v", err)
+	}
+	if _, err := Parse("csv", strings.NewReader("foo,bar\n1,2\n")); !errors.Is(err, ErrMalformedFile) {
+		t.Errorf("Expected ErrMalformedFile for a CSV without title column, got %v", err)
+	}
+	if _, err := Parse("trello", strings.NewReader("[")); !errors.Is(err, ErrMalformedFile) {
+		t.Errorf("Expected ErrMalformedFile for invalid Trello JSON, got %v", err)
+	}
+}
+
+func TestParseDate_ReturnsMidnightForDates(t *testing.T) {
+	got, err := parseDate("2025-06-01")
+	if err != nil || !got.Equal(time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected date %v (%v)", got, err)
+	}
+}
@@ -0,0 +1,56 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+// maxJSONLLine longitud máxima de una línea JSON Lines
+const maxJSONLLine = 1 << 20
+
+// jsonlTask campos leídos de cada línea; el resto se ignora, de modo que se
+// puede importar la exportación jsonl
+type jsonlTask struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	DueDate     string `json:"due_date"`
+	Status      string `json:"status"`
+}
+
+// parseJSONL lee un objeto JSON por línea. Una línea que no es JSON válido
+// invalida solo esa fila
+func parseJSONL(r io.Reader) ([]Row, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxJSONLLine)
+
+	var rows []Row
+	line := 0
+	for scanner.Scan() {
+		line++
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		row := Row{Line: line}
+		var fields jsonlTask
+		if err := json.Unmarshal(data, &fields); err != nil {
+			row.addError("", "json", "invalid JSON: "+err.Error())
+			rows = append(rows, row)
+			continue
+		}
+
+		row.Title = fields.Title
+		row.Description = fields.Description
+		row.setDueDate(fields.DueDate)
+		row.setStatus(fields.Status)
+		rows = append(rows, row)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, malformed("jsonl", err)
+	}
+	return rows, nil
+}
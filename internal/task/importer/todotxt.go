@@ -0,0 +1,60 @@
+package importer
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+)
+
+var (
+	// todoDate fecha de creación o de completado al inicio de la línea
+	todoDate = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}\s+`)
+	// todoPriority prioridad "(A) " al inicio de la línea
+	todoPriority = regexp.MustCompile(`^\([A-Z]\)\s+`)
+	// todoDue etiqueta due:YYYY-MM-DD
+	todoDue = regexp.MustCompile(`(^|\s)due:(\S+)`)
+)
+
+// parseTodoTxt lee el formato todo.txt: "x" marca las completadas, se
+// descartan prioridad y fechas iniciales y due:AAAA-MM-DD es el vencimiento.
+// Los +proyectos y @contextos se conservan en el título
+func parseTodoTxt(r io.Reader) ([]Row, error) {
+	scanner := bufio.NewScanner(r)
+
+	var rows []Row
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		row := Row{Line: line, Status: task.StatusPending}
+		if strings.HasPrefix(text, "x ") {
+			row.Status = task.StatusCompleted
+			text = strings.TrimSpace(text[2:])
+		}
+		text = todoPriority.ReplaceAllString(text, "")
+		// Fecha de completado y de creación
+		for i := 0; i < 2; i++ {
+			text = todoDate.ReplaceAllString(text, "")
+		}
+
+		if match := todoDue.FindStringSubmatch(text); match != nil {
+			row.setDueDate(match[2])
+			text = todoDue.ReplaceAllString(text, "$1")
+		}
+
+		row.Title = strings.Join(strings.Fields(text), " ")
+		rows = append(rows, row)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, malformed("todotxt", err)
+	}
+	return rows, nil
+}
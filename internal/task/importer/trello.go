@@ -0,0 +1,67 @@
+package importer
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+)
+
+// trelloDoneLists nombres de lista que indican tareas terminadas
+var trelloDoneLists = map[string]bool{
+	"done":       true,
+	"completed":  true,
+	"hecho":      true,
+	"terminado":  true,
+	"completado": true,
+}
+
+// trelloBoard campos usados de la exportación JSON de un tablero de Trello
+type trelloBoard struct {
+	Lists []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"lists"`
+	Cards []struct {
+		Name        string `json:"name"`
+		Desc        string `json:"desc"`
+		Due         string `json:"due"`
+		DueComplete bool   `json:"dueComplete"`
+		Closed      bool   `json:"closed"`
+		IDList      string `json:"idList"`
+	} `json:"cards"`
+}
+
+// parseTrello lee las tarjetas de un tablero de Trello. Las tarjetas con el
+// vencimiento completado o en una lista "Done" se importan completadas, y
+// las archivadas, canceladas
+func parseTrello(r io.Reader) ([]Row, error) {
+	var board trelloBoard
+	if err := json.NewDecoder(r).Decode(&board); err != nil {
+		return nil, malformed("trello", err)
+	}
+
+	doneLists := make(map[string]bool)
+	for _, list := range board.Lists {
+		if trelloDoneLists[strings.ToLower(strings.TrimSpace(list.Name))] {
+			doneLists[list.ID] = true
+		}
+	}
+
+	rows := make([]Row, 0, len(board.Cards))
+	for i, card := range board.Cards {
+		row := Row{Line: i + 1, Title: card.Name, Description: card.Desc, Status: task.StatusPending}
+		row.setDueDate(card.Due)
+
+		switch {
+		case card.DueComplete || doneLists[card.IDList]:
+			row.Status = task.StatusCompleted
+		case card.Closed:
+			row.Status = task.StatusCancelled
+		}
+
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
@@ -2,25 +2,69 @@ package mongo
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"regexp"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"github.com/yebrai/go-tasks-microservice/internal/task"
+	"github.com/yebrai/go-tasks-microservice/pkg/tenant"
 )
 
+// sequenceCounterID documento de la colección counters con la secuencia de
+// escrituras de tareas
+const sequenceCounterID = "tasks"
+
+// sequenceReservationTimeout tiempo tras el que una posición reservada deja
+// de retener el feed de cambios aunque no se haya liberado, por ejemplo
+// porque el proceso que escribía terminó a mitad
+const sequenceReservationTimeout = 30 * time.Second
+
 // TaskRepository implementación MongoDB del repositorio de tareas
 type TaskRepository struct {
 	collection *mongo.Collection
+	counters   *mongo.Collection
+	// tombstones marcas de borrado de las tareas purgadas para el feed de
+	// cambios. Están aparte para que el resto de consultas no las vean
+	tombstones *mongo.Collection
 }
 
 // NewTaskRepository crea una nueva instancia del repositorio
 func NewTaskRepository(db *mongo.Database) *TaskRepository {
 	return &TaskRepository{
 		collection: db.Collection("tasks"),
+		counters:   db.Collection("counters"),
+		tombstones: db.Collection("task_tombstones"),
+	}
+}
+
+// EnsureIndexes crea los índices que necesitan las consultas del repositorio
+func (r *TaskRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "sequence", Value: 1}}},
+		{Keys: bson.D{{Key: "archived_at", Value: 1}}},
+		{Keys: bson.D{{Key: "tenant", Value: 1}, {Key: "sequence", Value: 1}}},
+		{Keys: bson.D{{Key: "tenant", Value: 1}, {Key: "archived_at", Value: 1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create task indexes: %w", err)
 	}
+
+	_, err = r.tombstones.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "sequence", Value: 1}}},
+		{Keys: bson.D{{Key: "tenant", Value: 1}, {Key: "sequence", Value: 1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create task tombstone indexes: %w", err)
+	}
+
+	return nil
 }
 
 // TaskDocument representa la estructura de documento en MongoDB
@@ -30,14 +74,34 @@ type TaskDocument struct {
 	Description string     `bson:"description"`
 	Status      string     `bson:"status"`
 	CreatedAt   time.Time  `bson:"created_at"`
+	UpdatedAt   time.Time  `bson:"updated_at"`
 	DueDate     *time.Time `bson:"due_date,omitempty"`
+	ArchivedAt  *time.Time `bson:"archived_at,omitempty"`
+	Owner       string     `bson:"owner,omitempty"`
+	Tenant      string     `bson:"tenant,omitempty"`
+	Version     int64      `bson:"version"`
+	Sequence    int64      `bson:"sequence"`
+	PurgedAt    *time.Time `bson:"purged_at,omitempty"`
+	// FieldsUpdatedAt última modificación de cada campo editable
+	FieldsUpdatedAt map[string]time.Time `bson:"fields_updated_at,omitempty"`
 }
 
-// Save guarda una tarea en la base de datos
+// Save guarda una tarea en la base de datos, en el tenant del contexto
 func (r *TaskRepository) Save(ctx context.Context, t *task.Task) error {
+	t.Tenant = ownTenant(ctx, t)
 	doc := r.toDocument(t)
 
-	_, err := r.collection.InsertOne(ctx, doc)
+	sequence, err := r.reserveSequence(ctx)
+	if err != nil {
+		return err
+	}
+	defer r.releaseSequence(ctx, sequence)
+	doc.Sequence = sequence
+
+	_, err = r.collection.InsertOne(ctx, doc)
+	if mongo.IsDuplicateKeyError(err) {
+		return task.ErrTaskAlreadyExists
+	}
 	if err != nil {
 		return fmt.Errorf("failed to save task: %w", err)
 	}
@@ -49,7 +113,7 @@ func (r *TaskRepository) Save(ctx context.Context, t *task.Task) error {
 func (r *TaskRepository) FindByID(ctx context.Context, id string) (*task.Task, error) {
 	var doc TaskDocument
 
-	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&doc)
+	err := r.collection.FindOne(ctx, scoped(ctx, bson.M{"_id": id})).Decode(&doc)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, task.ErrTaskNotFound
@@ -60,9 +124,112 @@ func (r *TaskRepository) FindByID(ctx context.Context, id string) (*task.Task, e
 	return r.fromDocument(&doc), nil
 }
 
-// FindAll obtiene todas las tareas
+// FindAll obtiene todas las tareas que no están en la papelera
 func (r *TaskRepository) FindAll(ctx context.Context) ([]*task.Task, error) {
-	cursor, err := r.collection.Find(ctx, bson.M{})
+	return r.find(ctx, scoped(ctx, bson.M{"archived_at": nil}))
+}
+
+// FindMatching obtiene por páginas ordenadas por ID las tareas que cumplen
+// el filtro
+func (r *TaskRepository) FindMatching(ctx context.Context, filter task.Filter, afterID string, limit int) ([]*task.Task, error) {
+	query := matchingFilter(ctx, filter, time.Now())
+	if afterID != "" {
+		query = append(query, bson.E{Key: "_id", Value: bson.M{"$gt": afterID}})
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	if limit > 0 {
+		opts.SetLimit(int64(limit))
+	}
+
+	return r.find(ctx, query, opts)
+}
+
+// StreamMatching recorre con un cursor las tareas que cumplen el filtro
+func (r *TaskRepository) StreamMatching(ctx context.Context, filter task.Filter, fn func(*task.Task) error) error {
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	cursor, err := r.collection.Find(ctx, matchingFilter(ctx, filter, time.Now()), opts)
+	if err != nil {
+		return fmt.Errorf("failed to find tasks: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var doc TaskDocument
+		if err := cursor.Decode(&doc); err != nil {
+			return fmt.Errorf("failed to decode task: %w", err)
+		}
+		if err := fn(r.fromDocument(&doc)); err != nil {
+			return err
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return fmt.Errorf("failed to iterate tasks: %w", err)
+	}
+	return nil
+}
+
+// CountMatching cuenta las tareas que cumplen el filtro
+func (r *TaskRepository) CountMatching(ctx context.Context, filter task.Filter) (int64, error) {
+	count, err := r.collection.CountDocuments(ctx, matchingFilter(ctx, filter, time.Now()))
+	if err != nil {
+		return 0, fmt.Errorf("failed to count tasks: %w", err)
+	}
+
+	return count, nil
+}
+
+// matchingFilter traduce un filtro de dominio a una consulta de MongoDB
+// dentro del tenant del contexto. Cada criterio es una condición del $and
+// para que no se pisen las claves
+func matchingFilter(ctx context.Context, filter task.Filter, now time.Time) bson.D {
+	conditions := bson.A{tenantCondition(ctx), bson.M{"archived_at": nil}}
+
+	if len(filter.Statuses) > 0 {
+		statuses := make(bson.A, 0, len(filter.Statuses))
+		for _, status := range filter.Statuses {
+			statuses = append(statuses, string(status))
+		}
+		conditions = append(conditions, bson.M{"status": bson.M{"$in": statuses}})
+	}
+	if filter.DueBefore != nil {
+		conditions = append(conditions, bson.M{"due_date": bson.M{"$lt": *filter.DueBefore}})
+	}
+	if filter.DueAfter != nil {
+		conditions = append(conditions, bson.M{"due_date": bson.M{"$gte": *filter.DueAfter}})
+	}
+	if filter.Overdue {
+		conditions = append(conditions, bson.M{
+			"status":   string(task.StatusPending),
+			"due_date": bson.M{"$lt": now},
+		})
+	}
+	if filter.Search != "" {
+		pattern := primitive.Regex{Pattern: regexp.QuoteMeta(filter.Search), Options: "i"}
+		conditions = append(conditions, bson.M{"$or": bson.A{
+			bson.M{"title": pattern},
+			bson.M{"description": pattern},
+		}})
+	}
+
+	return bson.D{{Key: "$and", Value: conditions}}
+}
+
+// FindArchived obtiene las tareas de la papelera archivadas antes del
+// instante indicado, o todas si es el instante cero
+func (r *TaskRepository) FindArchived(ctx context.Context, archivedBefore time.Time) ([]*task.Task, error) {
+	condition := bson.M{"$ne": nil}
+	if !archivedBefore.IsZero() {
+		condition["$lt"] = archivedBefore
+	}
+
+	return r.find(ctx, scoped(ctx, bson.M{"archived_at": condition}), options.Find().SetSort(bson.D{{Key: "archived_at", Value: 1}}))
+}
+
+// find obtiene las tareas que cumplen el filtro
+func (r *TaskRepository) find(ctx context.Context, filter interface{}, opts ...*options.FindOptions) ([]*task.Task, error) {
+	cursor, err := r.collection.Find(ctx, filter, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find tasks: %w", err)
 	}
@@ -80,28 +247,308 @@ func (r *TaskRepository) FindAll(ctx context.Context) ([]*task.Task, error) {
 	return tasks, nil
 }
 
-// Update actualiza una tarea existente
+// Update actualiza una tarea existente siempre que el documento almacenado
+// siga en la versión anterior a t.Version; si otra escritura se adelantó
+// retorna task.ErrConcurrentModification
 func (r *TaskRepository) Update(ctx context.Context, t *task.Task) error {
+	t.Tenant = ownTenant(ctx, t)
 	doc := r.toDocument(t)
 
-	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": t.ID}, doc)
+	sequence, err := r.reserveSequence(ctx)
+	if err != nil {
+		return err
+	}
+	defer r.releaseSequence(ctx, sequence)
+	doc.Sequence = sequence
+
+	result, err := r.collection.ReplaceOne(ctx, scoped(ctx, versionFilter(t.ID, t.Version-1)), doc)
 	if err != nil {
 		return fmt.Errorf("failed to update task: %w", err)
 	}
 
+	if result.MatchedCount == 0 {
+		count, err := r.collection.CountDocuments(ctx, scoped(ctx, bson.M{"_id": t.ID}))
+		if err != nil {
+			return fmt.Errorf("failed to update task: %w", err)
+		}
+		if count == 0 {
+			return task.ErrTaskNotFound
+		}
+		return task.ErrConcurrentModification
+	}
+
 	return nil
 }
 
-// Delete elimina una tarea por ID
+// versionFilter selecciona la tarea en la versión indicada. Los documentos
+// anteriores al control de versiones no tienen campo version y equivalen a
+// la versión 1
+func versionFilter(id string, version int64) bson.M {
+	if version <= 1 {
+		return bson.M{
+			"_id": id,
+			"$or": bson.A{
+				bson.M{"version": version},
+				bson.M{"version": bson.M{"$exists": false}},
+				bson.M{"version": 0},
+			},
+		}
+	}
+
+	return bson.M{"_id": id, "version": version}
+}
+
+// Delete elimina una tarea por ID y guarda su marca de borrado con la
+// siguiente posición del feed de cambios
 func (r *TaskRepository) Delete(ctx context.Context, id string) error {
-	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	sequence, err := r.reserveSequence(ctx)
+	if err != nil {
+		return err
+	}
+	defer r.releaseSequence(ctx, sequence)
+
+	var doc TaskDocument
+	err = r.collection.FindOneAndDelete(ctx, scoped(ctx, bson.M{"_id": id})).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("failed to delete task: %w", err)
 	}
 
+	tombstone := r.toDocument(r.fromDocument(&doc).Tombstone(time.Now()))
+	tombstone.Sequence = sequence
+	opts := options.Replace().SetUpsert(true)
+	if _, err := r.tombstones.ReplaceOne(ctx, bson.M{"_id": id}, tombstone, opts); err != nil {
+		return fmt.Errorf("failed to save task tombstone: %w", err)
+	}
+
 	return nil
 }
 
+// tenantCondition limita una consulta al tenant del contexto. Los documentos
+// anteriores a los tenants no tienen el campo y pertenecen al tenant por
+// defecto
+func tenantCondition(ctx context.Context) bson.M {
+	if tenant.AllTenants(ctx) {
+		return bson.M{}
+	}
+
+	id := tenant.FromContext(ctx)
+	if id == tenant.DefaultID {
+		return bson.M{"tenant": bson.M{"$in": bson.A{nil, tenant.DefaultID}}}
+	}
+	return bson.M{"tenant": id}
+}
+
+// scoped combina un filtro con la condición del tenant del contexto
+func scoped(ctx context.Context, filter interface{}) bson.D {
+	return bson.D{{Key: "$and", Value: bson.A{tenantCondition(ctx), filter}}}
+}
+
+// ownTenant tenant con el que se escribe la tarea: el del contexto, salvo en
+// los procesos sobre todos los tenants, que conservan el de la tarea
+func ownTenant(ctx context.Context, t *task.Task) string {
+	if tenant.AllTenants(ctx) {
+		if t.Tenant == "" {
+			return tenant.DefaultID
+		}
+		return t.Tenant
+	}
+	return tenant.FromContext(ctx)
+}
+
+// illegalOperationCode código de error de MongoDB al abrir una transacción
+// en un servidor standalone
+const illegalOperationCode = 20
+
+// WithTransaction ejecuta fn en una transacción multi-documento. El driver
+// reintenta fn ante errores transitorios. Requiere un replica set
+func (r *TaskRepository) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	session, err := r.collection.Database().Client().StartSession()
+	if err != nil {
+		return fmt.Errorf("failed to start session: %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessionCtx)
+	})
+
+	var commandErr mongo.CommandError
+	if errors.As(err, &commandErr) && commandErr.Code == illegalOperationCode {
+		return task.ErrTransactionsUnsupported
+	}
+	return err
+}
+
+// FindChangedSince obtiene las tareas escritas después de la posición
+// indicada, ordenadas por secuencia de escritura
+func (r *TaskRepository) FindChangedSince(ctx context.Context, position int64, limit int) ([]*task.Task, int64, error) {
+	// Se lee el contador antes que las tareas: una escritura que aún no ha
+	// terminado no debe quedar por detrás de otras ya retornadas
+	visible, err := r.CurrentPosition(ctx)
+	if err != nil {
+		return nil, position, err
+	}
+	if visible <= position {
+		return nil, position, nil
+	}
+
+	filter := scoped(ctx, bson.M{"sequence": bson.M{"$gt": position, "$lte": visible}})
+	changed, err := r.findChanged(ctx, r.collection, filter, limit)
+	if err != nil {
+		return nil, position, err
+	}
+	purged, err := r.findChanged(ctx, r.tombstones, filter, limit)
+	if err != nil {
+		return nil, position, err
+	}
+
+	var tasks []*task.Task
+	last := position
+	for _, doc := range mergeBySequence(changed, purged, limit) {
+		tasks = append(tasks, r.fromDocument(doc))
+		last = doc.Sequence
+	}
+
+	return tasks, last, nil
+}
+
+// findChanged obtiene de la colección, ordenados por secuencia, hasta limit
+// documentos que cumplen el filtro
+func (r *TaskRepository) findChanged(ctx context.Context, collection *mongo.Collection, filter interface{}, limit int) ([]*TaskDocument, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "sequence", Value: 1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find changed tasks: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var docs []*TaskDocument
+	for cursor.Next(ctx) {
+		var doc TaskDocument
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("failed to decode task: %w", err)
+		}
+		docs = append(docs, &doc)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate changed tasks: %w", err)
+	}
+
+	return docs, nil
+}
+
+// mergeBySequence mezcla dos listas ordenadas por secuencia y retorna las
+// limit primeras; limit 0 las retorna todas
+func mergeBySequence(a, b []*TaskDocument, limit int) []*TaskDocument {
+	merged := make([]*TaskDocument, 0, len(a)+len(b))
+	for (limit <= 0 || len(merged) < limit) && (len(a) > 0 || len(b) > 0) {
+		if len(b) == 0 || (len(a) > 0 && a[0].Sequence < b[0].Sequence) {
+			merged, a = append(merged, a[0]), a[1:]
+		} else {
+			merged, b = append(merged, b[0]), b[1:]
+		}
+	}
+	return merged
+}
+
+// CurrentPosition obtiene la última posición hasta la que han terminado
+// todas las escrituras
+func (r *TaskRepository) CurrentPosition(ctx context.Context) (int64, error) {
+	var counter sequenceCounter
+
+	err := r.counters.FindOne(ctx, bson.M{"_id": sequenceCounterID}).Decode(&counter)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to read task sequence: %w", err)
+	}
+
+	return counter.visiblePosition(time.Now()), nil
+}
+
+// sequenceCounter documento de la secuencia de escrituras: la última
+// posición reservada y las reservas cuya escritura no ha terminado
+type sequenceCounter struct {
+	Value   int64                 `bson:"value"`
+	Pending []sequenceReservation `bson:"pending,omitempty"`
+}
+
+// sequenceReservation posición reservada por una escritura en curso
+type sequenceReservation struct {
+	Sequence   int64     `bson:"sequence"`
+	ReservedAt time.Time `bson:"reserved_at"`
+}
+
+// visiblePosition posición anterior a la reserva en curso más antigua, o la
+// última reservada si no hay ninguna. Las posiciones se reservan antes de
+// escribir, así que dos escrituras concurrentes pueden terminar en distinto
+// orden; leer más allá de una en curso haría que los clientes la saltaran
+func (c sequenceCounter) visiblePosition(now time.Time) int64 {
+	visible := c.Value
+	for _, reservation := range c.Pending {
+		if now.Sub(reservation.ReservedAt) < sequenceReservationTimeout && reservation.Sequence <= visible {
+			visible = reservation.Sequence - 1
+		}
+	}
+	return visible
+}
+
+// reserveSequence reserva la siguiente posición de la secuencia de escrituras
+// y la registra como en curso en la misma actualización atómica
+func (r *TaskRepository) reserveSequence(ctx context.Context) (int64, error) {
+	var counter sequenceCounter
+
+	opts := options.FindOneAndUpdate().
+		SetUpsert(true).
+		SetReturnDocument(options.After)
+
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"value": bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$value", int64(0)}}, int64(1)}},
+		}}},
+		{{Key: "$set", Value: bson.M{
+			"pending": bson.M{"$concatArrays": bson.A{
+				bson.M{"$ifNull": bson.A{"$pending", bson.A{}}},
+				bson.A{bson.M{"sequence": "$value", "reserved_at": time.Now()}},
+			}},
+		}}},
+	}
+
+	err := r.counters.FindOneAndUpdate(ctx, bson.M{"_id": sequenceCounterID}, update, opts).Decode(&counter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment task sequence: %w", err)
+	}
+
+	return counter.Value, nil
+}
+
+// releaseSequence marca como terminada la escritura de la posición, haya
+// fallado o no, y descarta las reservas caducadas. Si no se puede liberar,
+// la reserva deja de retener el feed al caducar
+func (r *TaskRepository) releaseSequence(ctx context.Context, sequence int64) {
+	ctx = context.WithoutCancel(ctx)
+	expired := time.Now().Add(-sequenceReservationTimeout)
+
+	_, err := r.counters.UpdateOne(ctx,
+		bson.M{"_id": sequenceCounterID},
+		bson.M{"$pull": bson.M{"pending": bson.M{"$or": bson.A{
+			bson.M{"sequence": sequence},
+			bson.M{"reserved_at": bson.M{"$lt": expired}},
+		}}}},
+	)
+	if err != nil {
+		log.Printf("⚠️  Failed to release task sequence %d: %v", sequence, err)
+	}
+}
+
 // toDocument convierte una tarea del dominio a documento MongoDB
 func (r *TaskRepository) toDocument(t *task.Task) *TaskDocument {
 	return &TaskDocument{
@@ -110,18 +557,49 @@ func (r *TaskRepository) toDocument(t *task.Task) *TaskDocument {
 		Description: t.Description,
 		Status:      string(t.Status),
 		CreatedAt:   t.CreatedAt,
+		UpdatedAt:   t.UpdatedAt,
 		DueDate:     t.DueDate,
+		ArchivedAt:  t.ArchivedAt,
+		Owner:       t.Owner,
+		Tenant:      t.Tenant,
+		Version:     t.Version,
+		PurgedAt:    t.PurgedAt,
+
+		FieldsUpdatedAt: t.FieldsUpdatedAt,
 	}
 }
 
 // fromDocument convierte un documento MongoDB a tarea del dominio
 func (r *TaskRepository) fromDocument(doc *TaskDocument) *task.Task {
+	// Los documentos anteriores a updated_at y version se consideran sin
+	// modificar
+	updatedAt := doc.UpdatedAt
+	if updatedAt.IsZero() {
+		updatedAt = doc.CreatedAt
+	}
+	version := doc.Version
+	if version == 0 {
+		version = 1
+	}
+	tenantID := doc.Tenant
+	if tenantID == "" {
+		tenantID = tenant.DefaultID
+	}
+
 	return &task.Task{
 		ID:          doc.ID,
 		Title:       doc.Title,
 		Description: doc.Description,
 		Status:      task.Status(doc.Status),
 		CreatedAt:   doc.CreatedAt,
+		UpdatedAt:   updatedAt,
 		DueDate:     doc.DueDate,
+		ArchivedAt:  doc.ArchivedAt,
+		Owner:       doc.Owner,
+		Tenant:      tenantID,
+		Version:     version,
+		PurgedAt:    doc.PurgedAt,
+
+		FieldsUpdatedAt: doc.FieldsUpdatedAt,
 	}
 }
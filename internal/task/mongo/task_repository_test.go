@@ -0,0 +1,253 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+	"github.com/yebrai/go-tasks-microservice/pkg/tenant"
+)
+
+func TestTenantCondition(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want bson.M
+	}{
+		{"default tenant includes documents without tenant", ctx, bson.M{"tenant": bson.M{"$in": bson.A{nil, tenant.DefaultID}}}},
+		{"other tenants match exactly", tenant.WithID(ctx, "acme"), bson.M{"tenant": "acme"}},
+		{"internal jobs span every tenant", tenant.WithAllTenants(ctx), bson.M{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tenantCondition(tt.ctx); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestVersionFilter(t *testing.T) {
+	legacy := bson.M{"_id": "t1", "$or": bson.A{
+		bson.M{"version": int64(1)},
+		bson.M{"version": bson.M{"$exists": false}},
+		bson.M{"version": 0},
+	}}
+
+	tests := []struct {
+		name    string
+		version int64
+		want    bson.M
+	}{
+		{"first version matches documents without version", 1, legacy},
+		{"later versions match exactly", 4, bson.M{"_id": "t1", "version": int64(4)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := versionFilter("t1", tt.version); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestQueriesAreScopedByTenant(t *testing.T) {
+	ctx := tenant.WithID(context.Background(), "acme")
+	want := bson.M{"tenant": "acme"}
+
+	scopedQuery := scoped(ctx, bson.M{"_id": "x"})
+	if conditions := scopedQuery[0].Value.(bson.A); !reflect.DeepEqual(conditions[0], want) {
+		t.Errorf("Expected scoped queries to start with %v, got %v", want, conditions[0])
+	}
+
+	matching := matchingFilter(ctx, task.Filter{Search: "x"}, time.Now())
+	if conditions := matching[0].Value.(bson.A); !reflect.DeepEqual(conditions[0], want) {
+		t.Errorf("Expected filtered queries to start with %v, got %v", want, conditions[0])
+	}
+}
+
+func TestOwnTenant_WritesIgnoreTheTaskTenant(t *testing.T) {
+	foreign := &task.Task{ID: "x", Tenant: "globex"}
+
+	if got := ownTenant(tenant.WithID(context.Background(), "acme"), foreign); got != "acme" {
+		t.Errorf("Expected writes to use the context tenant, got %q", got)
+	}
+	if got := ownTenant(tenant.WithAllTenants(context.Background()), foreign); got != "globex" {
+		t.Errorf("Expected internal jobs to keep the task tenant, got %q", got)
+	}
+}
+
+func TestSequenceCounter_VisiblePosition(t *testing.T) {
+	now := time.Now()
+	reserved := func(sequence int64, age time.Duration) sequenceReservation {
+		return sequenceReservation{Sequence: sequence, ReservedAt: now.Add(-age)}
+	}
+
+	tests := []struct {
+		name    string
+		counter sequenceCounter
+		want    int64
+	}{
+		{"no writes in flight", sequenceCounter{Value: 7}, 7},
+		{"stops before the oldest write in flight", sequenceCounter{Value: 7, Pending: []sequenceReservation{reserved(7, 0), reserved(5, time.Second)}}, 4},
+		{"expired reservations are ignored", sequenceCounter{Value: 7, Pending: []sequenceReservation{reserved(3, sequenceReservationTimeout), reserved(6, 0)}}, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.counter.visiblePosition(now); got != tt.want {
+				t.Errorf("Expected %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestMergeBySequence(t *testing.T) {
+	docs := func(sequences ...int64) []*TaskDocument {
+		var docs []*TaskDocument
+		for _, sequence := range sequences {
+			docs = append(docs, &TaskDocument{Sequence: sequence})
+		}
+		return docs
+	}
+
+	tests := []struct {
+		name  string
+		tasks []*TaskDocument
+		purge []*TaskDocument
+		limit int
+		want  []int64
+	}{
+		{"interleaves both lists", docs(1, 4, 5), docs(2, 3, 6), 10, []int64{1, 2, 3, 4, 5, 6}},
+		{"stops at the limit", docs(1, 4, 5), docs(2, 3, 6), 4, []int64{1, 2, 3, 4}},
+		{"only tombstones", nil, docs(7), 10, []int64{7}},
+		{"no limit", docs(2), docs(1), 0, []int64{1, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int64
+			for _, doc := range mergeBySequence(tt.tasks, tt.purge, tt.limit) {
+				got = append(got, doc.Sequence)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestTaskRepository_TenantIsolation prueba el aislamiento contra un MongoDB
+// real. Se omite si TASKS_TEST_MONGO_URI no está definida
+func TestTaskRepository_TenantIsolation(t *testing.T) {
+	uri := os.Getenv("TASKS_TEST_MONGO_URI")
+	if uri == "" {
+		t.Skip("TASKS_TEST_MONGO_URI not set")
+	}
+
+	ctx := context.Background()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Disconnect(ctx)
+
+	db := client.Database("tasks_test_" + uuid.New().String()[:8])
+	defer db.Drop(ctx)
+	repository := NewTaskRepository(db)
+
+	acme := tenant.WithID(ctx, "acme")
+	globex := tenant.WithID(ctx, "globex")
+
+	owned, _ := task.NewTask(uuid.New().String(), "Acme plan", "", nil)
+	if err := repository.Save(acme, owned); err != nil {
+		t.Fatal(err)
+	}
+	archived, _ := task.NewTask(uuid.New().String(), "Acme archive", "", nil)
+	if err := repository.Save(acme, archived); err != nil {
+		t.Fatal(err)
+	}
+	_ = archived.Archive()
+	if err := repository.Update(acme, archived); err != nil {
+		t.Fatal(err)
+	}
+
+	// Lecturas
+	if _, err := repository.FindByID(globex, owned.ID); !errors.Is(err, task.ErrTaskNotFound) {
+		t.Errorf("FindByID: expected ErrTaskNotFound, got %v", err)
+	}
+	if tasks, _ := repository.FindAll(globex); len(tasks) != 0 {
+		t.Errorf("FindAll: expected no tasks, got %d", len(tasks))
+	}
+	if tasks, _ := repository.FindMatching(globex, task.Filter{}, "", 0); len(tasks) != 0 {
+		t.Errorf("FindMatching: expected no tasks, got %d", len(tasks))
+	}
+	if count, _ := repository.CountMatching(globex, task.Filter{}); count != 0 {
+		t.Errorf("CountMatching: expected 0, got %d", count)
+	}
+	if tasks, _, _ := repository.FindChangedSince(globex, 0, 100); len(tasks) != 0 {
+		t.Errorf("FindChangedSince: expected no tasks, got %d", len(tasks))
+	}
+	if tasks, _ := repository.FindArchived(globex, time.Time{}); len(tasks) != 0 {
+		t.Errorf("FindArchived: expected no tasks, got %d", len(tasks))
+	}
+	streamed := 0
+	_ = repository.StreamMatching(globex, task.Filter{}, func(*task.Task) error {
+		streamed++
+		return nil
+	})
+	if streamed != 0 {
+		t.Errorf("StreamMatching: expected no tasks, got %d", streamed)
+	}
+
+	// Escrituras
+	hijacked := *owned
+	hijacked.Title = "Hijacked"
+	hijacked.Version++
+	if err := repository.Update(globex, &hijacked); !errors.Is(err, task.ErrTaskNotFound) {
+		t.Errorf("Update: expected ErrTaskNotFound, got %v", err)
+	}
+	if err := repository.Delete(globex, owned.ID); err != nil {
+		t.Fatal(err)
+	}
+	duplicate, _ := task.NewTask(owned.ID, "Globex plan", "", nil)
+	if err := repository.Save(globex, duplicate); !errors.Is(err, task.ErrTaskAlreadyExists) {
+		t.Errorf("Save: expected ErrTaskAlreadyExists, got %v", err)
+	}
+
+	stored, err := repository.FindByID(acme, owned.ID)
+	if err != nil {
+		t.Fatalf("Expected the owner tenant to keep its task: %v", err)
+	}
+	if stored.Title != "Acme plan" || stored.Tenant != "acme" {
+		t.Errorf("Expected the task to be untouched, got %+v", stored)
+	}
+	if tasks, _ := repository.FindArchived(tenant.WithAllTenants(ctx), time.Time{}); len(tasks) != 1 {
+		t.Errorf("Expected internal jobs to see the archived task, got %d", len(tasks))
+	}
+
+	// Marcas de borrado
+	if err := repository.Delete(acme, archived.ID); err != nil {
+		t.Fatal(err)
+	}
+	changes, _, err := repository.FindChangedSince(acme, 0, 100)
+	if err != nil || len(changes) != 2 || changes[1].ID != archived.ID || !changes[1].IsPurged() {
+		t.Errorf("Expected the purged task last in the feed as a tombstone, got %+v (%v)", changes, err)
+	}
+	if tasks, _, _ := repository.FindChangedSince(globex, 0, 100); len(tasks) != 0 {
+		t.Errorf("FindChangedSince: expected no tombstones of other tenants, got %d", len(tasks))
+	}
+}
@@ -0,0 +1,61 @@
+package presence
+
+import (
+	"context"
+	"strings"
+
+	"github.com/yebrai/go-tasks-microservice/pkg/cqrs"
+	"github.com/yebrai/go-tasks-microservice/pkg/tenant"
+)
+
+type contextKey string
+
+const holderKey contextKey = "presence.holder"
+
+// WithHolder asocia al contexto la identidad del cliente que ejecuta el
+// comando, usada para comprobar las reservas de edición
+func WithHolder(ctx context.Context, holder string) context.Context {
+	if holder == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, holderKey, holder)
+}
+
+// HolderFromContext obtiene la identidad del cliente del contexto
+func HolderFromContext(ctx context.Context) string {
+	holder, _ := ctx.Value(holderKey).(string)
+	return holder
+}
+
+// Key clave con la que se registran los lectores y reservas de una tarea:
+// su tenant y su ID, para que un tenant no pueda bloquear ni observar las
+// tareas de otro
+func Key(tenantID, taskID string) string {
+	return tenantID + "/" + taskID
+}
+
+// SplitKey separa una clave del registro en el tenant y el ID de la tarea
+func SplitKey(key string) (tenantID, taskID string) {
+	tenantID, taskID, found := strings.Cut(key, "/")
+	if !found {
+		return tenant.DefaultID, key
+	}
+	return tenantID, taskID
+}
+
+// LeaseGuard rechaza los comandos sobre tareas con una reserva de edición
+// activa de otro cliente del mismo tenant
+func LeaseGuard(registry *Registry) cqrs.Middleware {
+	return func(next cqrs.CommandHandler) cqrs.CommandHandler {
+		return cqrs.CommandHandlerFunc(func(ctx context.Context, cmd cqrs.Command) error {
+			if aggregateCmd, ok := cmd.(cqrs.AggregateCommand); ok {
+				key := Key(tenant.FromContext(ctx), aggregateCmd.AggregateID())
+				if err := registry.CheckWrite(key, HolderFromContext(ctx)); err != nil {
+					return err
+				}
+			}
+
+			return next.Handle(ctx, cmd)
+		})
+	}
+}
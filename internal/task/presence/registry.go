@@ -0,0 +1,256 @@
+package presence
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+)
+
+const (
+	// DefaultLeaseTTL duración de una reserva de edición si no se indica otra
+	DefaultLeaseTTL = 30 * time.Second
+	// MaxLeaseTTL duración máxima de una reserva de edición
+	MaxLeaseTTL = 5 * time.Minute
+)
+
+// Motivos por los que se libera una reserva
+const (
+	ReasonReleased     = "released"
+	ReasonExpired      = "expired"
+	ReasonDisconnected = "disconnected"
+)
+
+var ErrLeaseNotHeld = errors.New("lease not held by this client")
+
+// Viewer identifica a un cliente que está viendo una tarea
+type Viewer struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Subject string `json:"subject,omitempty"`
+}
+
+// Lease representa una reserva de edición sobre una tarea
+type Lease struct {
+	TaskID    string    `json:"task_id"`
+	Holder    string    `json:"holder"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ExpireFunc se invoca cuando una reserva caduca sin haber sido liberada
+type ExpireFunc func(lease Lease)
+
+type leaseEntry struct {
+	lease Lease
+	timer *time.Timer
+}
+
+// Registry guarda en memoria quién ve cada tarea y quién tiene la reserva
+// de edición
+type Registry struct {
+	viewers  map[string]map[string]Viewer
+	leases   map[string]*leaseEntry
+	onExpire ExpireFunc
+	mu       sync.Mutex
+}
+
+// NewRegistry crea un registro de presencia vacío
+func NewRegistry() *Registry {
+	return &Registry{
+		viewers: make(map[string]map[string]Viewer),
+		leases:  make(map[string]*leaseEntry),
+	}
+}
+
+// OnExpire registra la función que se notifica al caducar una reserva
+func (r *Registry) OnExpire(fn ExpireFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.onExpire = fn
+}
+
+// View marca al cliente como espectador de la tarea y retorna los
+// espectadores actuales
+func (r *Registry) View(taskID string, viewer Viewer) []Viewer {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.viewers[taskID] == nil {
+		r.viewers[taskID] = make(map[string]Viewer)
+	}
+	r.viewers[taskID][viewer.ID] = viewer
+
+	return r.viewersOf(taskID)
+}
+
+// Leave elimina al cliente de los espectadores de la tarea y retorna los
+// espectadores restantes
+func (r *Registry) Leave(taskID, viewerID string) []Viewer {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.removeViewer(taskID, viewerID)
+	return r.viewersOf(taskID)
+}
+
+// LeaveAll elimina al cliente de todas las tareas y retorna los espectadores
+// restantes de cada tarea afectada
+func (r *Registry) LeaveAll(viewerID string) map[string][]Viewer {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	affected := make(map[string][]Viewer)
+	for taskID, viewers := range r.viewers {
+		if _, ok := viewers[viewerID]; ok {
+			r.removeViewer(taskID, viewerID)
+			affected[taskID] = r.viewersOf(taskID)
+		}
+	}
+
+	return affected
+}
+
+// Viewers retorna los espectadores actuales de la tarea
+func (r *Registry) Viewers(taskID string) []Viewer {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.viewersOf(taskID)
+}
+
+// Acquire concede o renueva la reserva de edición de la tarea. Falla con
+// task.ErrTaskLocked si otro cliente tiene una reserva activa
+func (r *Registry) Acquire(taskID, holder string, ttl time.Duration) (Lease, error) {
+	if ttl <= 0 {
+		ttl = DefaultLeaseTTL
+	}
+	if ttl > MaxLeaseTTL {
+		ttl = MaxLeaseTTL
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if entry, ok := r.leases[taskID]; ok {
+		if entry.lease.Holder != holder {
+			return entry.lease, task.ErrTaskLocked
+		}
+		entry.timer.Stop()
+	}
+
+	lease := Lease{
+		TaskID:    taskID,
+		Holder:    holder,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	r.leases[taskID] = &leaseEntry{
+		lease: lease,
+		timer: time.AfterFunc(ttl, func() { r.expire(lease) }),
+	}
+
+	return lease, nil
+}
+
+// Release libera la reserva de la tarea si pertenece al cliente
+func (r *Registry) Release(taskID, holder string) (Lease, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.leases[taskID]
+	if !ok || entry.lease.Holder != holder {
+		return Lease{}, ErrLeaseNotHeld
+	}
+
+	entry.timer.Stop()
+	delete(r.leases, taskID)
+	return entry.lease, nil
+}
+
+// ReleaseAll libera todas las reservas del cliente y las retorna
+func (r *Registry) ReleaseAll(holder string) []Lease {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var released []Lease
+	for taskID, entry := range r.leases {
+		if entry.lease.Holder == holder {
+			entry.timer.Stop()
+			delete(r.leases, taskID)
+			released = append(released, entry.lease)
+		}
+	}
+
+	return released
+}
+
+// ActiveLease retorna la reserva activa de la tarea, si existe
+func (r *Registry) ActiveLease(taskID string) (Lease, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.leases[taskID]
+	if !ok || time.Now().After(entry.lease.ExpiresAt) {
+		return Lease{}, false
+	}
+
+	return entry.lease, true
+}
+
+// CheckWrite verifica que el cliente puede modificar la tarea: no hay
+// reserva activa o la tiene él mismo
+func (r *Registry) CheckWrite(taskID, holder string) error {
+	lease, ok := r.ActiveLease(taskID)
+	if ok && lease.Holder != holder {
+		return task.ErrTaskLocked
+	}
+
+	return nil
+}
+
+// expire elimina una reserva caducada y notifica su liberación
+func (r *Registry) expire(lease Lease) {
+	r.mu.Lock()
+	entry, ok := r.leases[lease.TaskID]
+	if !ok || entry.lease != lease {
+		// Renovada o liberada mientras el timer disparaba
+		r.mu.Unlock()
+		return
+	}
+	delete(r.leases, lease.TaskID)
+	onExpire := r.onExpire
+	r.mu.Unlock()
+
+	if onExpire != nil {
+		onExpire(lease)
+	}
+}
+
+// removeViewer elimina un espectador; requiere tener el lock
+func (r *Registry) removeViewer(taskID, viewerID string) {
+	viewers, ok := r.viewers[taskID]
+	if !ok {
+		return
+	}
+
+	delete(viewers, viewerID)
+	if len(viewers) == 0 {
+		delete(r.viewers, taskID)
+	}
+}
+
+// viewersOf retorna los espectadores ordenados; requiere tener el lock
+func (r *Registry) viewersOf(taskID string) []Viewer {
+	viewers := make([]Viewer, 0, len(r.viewers[taskID]))
+	for _, viewer := range r.viewers[taskID] {
+		viewers = append(viewers, viewer)
+	}
+
+	sort.Slice(viewers, func(i, j int) bool {
+		return viewers[i].ID < viewers[j].ID
+	})
+
+	return viewers
+}
@@ -0,0 +1,82 @@
+package presence
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+)
+
+const testTaskID = "6f1c2a9e-3b4d-4c5e-8f70-1a2b3c4d5e6f"
+
+func TestRegistry_LeaseRejectsOtherHolders(t *testing.T) {
+	registry := NewRegistry()
+
+	if _, err := registry.Acquire(testTaskID, "alice", time.Minute); err != nil {
+		t.Fatalf("Acquire should succeed on a free task: %v", err)
+	}
+
+	if _, err := registry.Acquire(testTaskID, "bob", time.Minute); !errors.Is(err, task.ErrTaskLocked) {
+		t.Errorf("Expected ErrTaskLocked for a second holder, got %v", err)
+	}
+
+	if err := registry.CheckWrite(testTaskID, "bob"); !errors.Is(err, task.ErrTaskLocked) {
+		t.Errorf("Expected writes from non-holders to be rejected, got %v", err)
+	}
+
+	if err := registry.CheckWrite(testTaskID, "alice"); err != nil {
+		t.Errorf("Holder should be allowed to write: %v", err)
+	}
+
+	if _, err := registry.Release(testTaskID, "bob"); !errors.Is(err, ErrLeaseNotHeld) {
+		t.Errorf("Expected ErrLeaseNotHeld when releasing someone else's lease, got %v", err)
+	}
+
+	if _, err := registry.Release(testTaskID, "alice"); err != nil {
+		t.Errorf("Holder should be able to release: %v", err)
+	}
+
+	if err := registry.CheckWrite(testTaskID, "bob"); err != nil {
+		t.Errorf("Writes should be allowed once the lease is released: %v", err)
+	}
+}
+
+func TestRegistry_LeaseExpires(t *testing.T) {
+	registry := NewRegistry()
+
+	expired := make(chan Lease, 1)
+	registry.OnExpire(func(lease Lease) { expired <- lease })
+
+	if _, err := registry.Acquire(testTaskID, "alice", 20*time.Millisecond); err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+
+	select {
+	case lease := <-expired:
+		if lease.Holder != "alice" {
+			t.Errorf("Expected alice's lease to expire, got %s", lease.Holder)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Lease did not expire")
+	}
+
+	if err := registry.CheckWrite(testTaskID, "bob"); err != nil {
+		t.Errorf("Writes should be allowed after expiry: %v", err)
+	}
+}
+
+func TestRegistry_Viewers(t *testing.T) {
+	registry := NewRegistry()
+
+	registry.View(testTaskID, Viewer{ID: "a", Name: "Alice"})
+	viewers := registry.View(testTaskID, Viewer{ID: "b", Name: "Bob"})
+	if len(viewers) != 2 {
+		t.Fatalf("Expected 2 viewers, got %d", len(viewers))
+	}
+
+	affected := registry.LeaveAll("a")
+	if len(affected[testTaskID]) != 1 || affected[testTaskID][0].ID != "b" {
+		t.Errorf("Expected only Bob to remain, got %+v", affected[testTaskID])
+	}
+}
@@ -2,13 +2,55 @@ package task
 
 import (
 	"context"
+	"errors"
+	"time"
 )
 
+// ErrTransactionsUnsupported el almacenamiento no admite transacciones (por
+// ejemplo, un MongoDB standalone sin replica set)
+var ErrTransactionsUnsupported = errors.New("transactions are not supported by the storage")
+
 // Repository define las operaciones disponibles para persistir tareas
 type Repository interface {
 	Save(ctx context.Context, task *Task) error
 	FindByID(ctx context.Context, id string) (*Task, error)
+	// FindAll retorna las tareas que no están en la papelera
 	FindAll(ctx context.Context) ([]*Task, error)
+	// FindMatching retorna, ordenadas por ID, hasta limit tareas fuera de la
+	// papelera que cumplen el filtro y cuyo ID es posterior a afterID. limit
+	// 0 las retorna todas
+	FindMatching(ctx context.Context, filter Filter, afterID string, limit int) ([]*Task, error)
+	// StreamMatching recorre en orden de ID las tareas que cumplen el filtro
+	// sin cargarlas todas en memoria. Un error de fn detiene el recorrido y
+	// se retorna
+	StreamMatching(ctx context.Context, filter Filter, fn func(*Task) error) error
+	// CountMatching cuenta las tareas fuera de la papelera que cumplen el
+	// filtro
+	CountMatching(ctx context.Context, filter Filter) (int64, error)
+	// FindArchived retorna las tareas de la papelera archivadas antes de
+	// archivedBefore; el instante cero las retorna todas
+	FindArchived(ctx context.Context, archivedBefore time.Time) ([]*Task, error)
+	// Update persiste la tarea si la versión almacenada es la anterior a
+	// task.Version; si no, retorna ErrConcurrentModification
 	Update(ctx context.Context, task *Task) error
+	// Delete elimina la tarea y deja su marca de borrado en el feed de
+	// cambios
 	Delete(ctx context.Context, id string) error
+
+	// FindChangedSince retorna, en orden de escritura, hasta limit tareas
+	// modificadas después de la posición indicada junto con la posición de
+	// la última retornada. Las tareas purgadas se retornan como su marca de
+	// borrado (Tombstone)
+	FindChangedSince(ctx context.Context, position int64, limit int) ([]*Task, int64, error)
+	// CurrentPosition retorna la posición de la última escritura
+	CurrentPosition(ctx context.Context) (int64, error)
+}
+
+// Transactor lo implementan los repositorios capaces de ejecutar varias
+// operaciones en una transacción
+type Transactor interface {
+	// WithTransaction ejecuta fn en una transacción que se confirma si fn no
+	// retorna error y se descarta en caso contrario. fn debe usar el contexto
+	// recibido y puede ejecutarse más de una vez si la transacción se reintenta
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
 }
@@ -0,0 +1,85 @@
+package retention
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+	"github.com/yebrai/go-tasks-microservice/internal/task/creator"
+	"github.com/yebrai/go-tasks-microservice/pkg/cqrs"
+	"github.com/yebrai/go-tasks-microservice/pkg/tenant"
+)
+
+// DefaultInterval frecuencia por defecto de la purga
+const DefaultInterval = time.Hour
+
+// Purger elimina definitivamente las tareas que llevan en la papelera más
+// tiempo que el periodo de retención
+type Purger struct {
+	commandBus cqrs.CommandBus
+	repository task.Repository
+	retention  time.Duration
+	interval   time.Duration
+}
+
+// NewPurger crea el job de purga. Un intervalo no positivo usa DefaultInterval
+func NewPurger(commandBus cqrs.CommandBus, repository task.Repository, retention, interval time.Duration) *Purger {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	return &Purger{
+		commandBus: commandBus,
+		repository: repository,
+		retention:  retention,
+		interval:   interval,
+	}
+}
+
+// Run purga periódicamente hasta que se cancela el contexto
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		if purged, err := p.PurgeExpired(ctx); err != nil {
+			fmt.Printf("⚠️  Trash purge failed: %v\n", err)
+		} else if purged > 0 {
+			fmt.Printf("🗑️  Purged %d archived tasks\n", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PurgeExpired purga las tareas archivadas antes del periodo de retención
+// en todos los tenants. Cada tarea se elimina con PurgeTaskCommand, en el
+// contexto de su tenant, para que se publique su evento; las que fallan se
+// reintentan en la siguiente ejecución
+func (p *Purger) PurgeExpired(ctx context.Context) (int, error) {
+	expired, err := p.repository.FindArchived(tenant.WithAllTenants(ctx), time.Now().Add(-p.retention))
+	if err != nil {
+		return 0, fmt.Errorf("failed to find expired tasks: %w", err)
+	}
+
+	purged := 0
+	for _, t := range expired {
+		if ctx.Err() != nil {
+			return purged, ctx.Err()
+		}
+
+		cmd := creator.PurgeTaskCommand{ID: t.ID, ExpectedVersion: t.Version}
+		if err := p.commandBus.Dispatch(tenant.WithID(ctx, t.Tenant), cmd); err != nil {
+			fmt.Printf("⚠️  Failed to purge task %s: %v\n", t.ID, err)
+			continue
+		}
+		purged++
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,72 @@
+package retention
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+	"github.com/yebrai/go-tasks-microservice/internal/task/creator"
+	"github.com/yebrai/go-tasks-microservice/internal/task/tasktest"
+	"github.com/yebrai/go-tasks-microservice/pkg/cqrs/inmem"
+	"github.com/yebrai/go-tasks-microservice/pkg/events"
+	"github.com/yebrai/go-tasks-microservice/pkg/tenant"
+)
+
+func TestPurger_PurgeExpired(t *testing.T) {
+	const (
+		expiredDefault = "1b4e28ba-2fa1-4d3b-a3f5-ef19b5a7633b"
+		expiredAcme    = "2c5f39cb-3ab2-4e4c-b4a6-f02ac6b8744c"
+		recent         = "3d6a4adc-4bc3-4f5d-85b7-a13bd7c9855d"
+		open           = "4e7b5bed-5cd4-4a6e-96c8-b24ce8da966e"
+	)
+
+	repository := tasktest.NewRepository()
+	seed := func(id, tenantID string, archivedAgo time.Duration) {
+		seeded, err := task.NewTask(id, "task", "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		seeded.Tenant = tenantID
+		if archivedAgo > 0 {
+			archivedAt := time.Now().Add(-archivedAgo)
+			seeded.ArchivedAt = &archivedAt
+		}
+		repository.Put(seeded)
+	}
+	seed(expiredDefault, "", 48*time.Hour)
+	seed(expiredAcme, "acme", 25*time.Hour)
+	seed(recent, "acme", time.Hour)
+	seed(open, "acme", 0)
+
+	bus := inmem.NewCommandBus()
+	if err := bus.Register(creator.PurgeTaskCommandType, creator.NewPurgeTaskCommandHandler(repository, events.NewNoOpEventBus())); err != nil {
+		t.Fatal(err)
+	}
+	purger := NewPurger(bus, repository, 24*time.Hour, 0)
+
+	purged, err := purger.PurgeExpired(context.Background())
+	if err != nil || purged != 2 {
+		t.Fatalf("Expected 2 tasks purged, got %d (%v)", purged, err)
+	}
+
+	var remaining []string
+	for _, kept := range repository.Tasks() {
+		remaining = append(remaining, kept.ID)
+	}
+	if len(remaining) != 2 || remaining[0] != recent || remaining[1] != open {
+		t.Errorf("Expected the open and recent tasks to remain, got %v", remaining)
+	}
+
+	// Cada tenant recibe la marca de borrado de su tarea
+	for tenantID, want := range map[string]string{tenant.DefaultID: expiredDefault, "acme": expiredAcme} {
+		changes, _, err := repository.FindChangedSince(tenant.WithID(context.Background(), tenantID), 4, 0)
+		if err != nil || len(changes) != 1 || changes[0].ID != want || !changes[0].IsPurged() {
+			t.Errorf("%s: expected the tombstone of %s, got %+v (%v)", tenantID, want, changes, err)
+		}
+	}
+
+	if purged, err := purger.PurgeExpired(context.Background()); err != nil || purged != 0 {
+		t.Errorf("Expected nothing left to purge, got %d (%v)", purged, err)
+	}
+}
@@ -0,0 +1,363 @@
+package syncer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+	"github.com/yebrai/go-tasks-microservice/internal/task/creator"
+	"github.com/yebrai/go-tasks-microservice/pkg/auth"
+	"github.com/yebrai/go-tasks-microservice/pkg/cqrs"
+)
+
+// Policy política de resolución de conflictos
+type Policy string
+
+const (
+	// PolicyReject rechaza toda mutación basada en una versión antigua
+	PolicyReject Policy = "reject"
+	// PolicyLastWriterWins aplica cada campo enviado por el cliente si su
+	// mutación es posterior a la última modificación de ese campo en el
+	// servidor
+	PolicyLastWriterWins Policy = "last_writer_wins"
+)
+
+// Op tipo de mutación
+type Op string
+
+const (
+	OpCreate   Op = "create"
+	OpUpdate   Op = "update"
+	OpComplete Op = "complete"
+	OpCancel   Op = "cancel"
+)
+
+// Status resultado de aplicar una mutación
+type Status string
+
+const (
+	StatusApplied  Status = "applied"
+	StatusConflict Status = "conflict"
+	StatusRejected Status = "rejected"
+)
+
+// Resolution cómo se resolvió un conflicto
+type Resolution string
+
+const (
+	ResolutionClientWins Resolution = "client_wins"
+	ResolutionServerWins Resolution = "server_wins"
+	// ResolutionMerged se aplicaron solo los campos en los que ganó el
+	// cliente
+	ResolutionMerged Resolution = "merged"
+)
+
+// ChangesPageSize máximo de cambios del servidor por respuesta
+const ChangesPageSize = 500
+
+var (
+	ErrUnknownPolicy = errors.New("unknown conflict policy")
+	// ErrInvalidMutation la mutación no se puede aplicar tal como llega
+	ErrInvalidMutation = errors.New("invalid sync mutation")
+)
+
+// ParsePolicy valida el nombre de una política; vacío equivale a reject
+func ParsePolicy(value string) (Policy, error) {
+	switch Policy(value) {
+	case "", PolicyReject:
+		return PolicyReject, nil
+	case PolicyLastWriterWins:
+		return PolicyLastWriterWins, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnknownPolicy, value)
+	}
+}
+
+// Mutation cambio realizado por el cliente sin conexión
+type Mutation struct {
+	MutationID      string
+	Op              Op
+	TaskID          string
+	BaseVersion     int64
+	ClientTimestamp time.Time
+	Title           *string
+	Description     *string
+	DueDate         *time.Time
+	ClearDueDate    bool
+}
+
+// Result resultado de una mutación con el estado actual de la tarea. Err
+// es el motivo del rechazo
+type Result struct {
+	MutationID string
+	TaskID     string
+	Status     Status
+	Resolution Resolution
+	Err        error
+	Task       *task.Task
+}
+
+// Request lote de mutaciones y posición de la última sincronización
+type Request struct {
+	Position  int64
+	Mutations []Mutation
+}
+
+// Response resultados del lote y cambios del servidor desde la posición
+type Response struct {
+	Results  []Result
+	Changes  []*task.Task
+	Position int64
+	HasMore  bool
+}
+
+// Service aplica lotes de mutaciones offline a través del command bus
+type Service struct {
+	commandBus cqrs.CommandBus
+	repository task.Repository
+	policy     Policy
+}
+
+// NewService crea el servicio de sincronización con la política indicada
+func NewService(commandBus cqrs.CommandBus, repository task.Repository, policy Policy) *Service {
+	return &Service{
+		commandBus: commandBus,
+		repository: repository,
+		policy:     policy,
+	}
+}
+
+// Sync aplica las mutaciones en orden y retorna sus resultados junto a los
+// cambios del servidor desde la posición del cliente
+func (s *Service) Sync(ctx context.Context, req Request) (*Response, error) {
+	results := make([]Result, 0, len(req.Mutations))
+	for _, mutation := range req.Mutations {
+		results = append(results, s.apply(ctx, mutation))
+	}
+
+	changes, position, err := s.repository.FindChangedSince(ctx, req.Position, ChangesPageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch server changes: %w", err)
+	}
+	if changes == nil {
+		changes = []*task.Task{}
+	}
+
+	return &Response{
+		Results:  results,
+		Changes:  changes,
+		Position: position,
+		HasMore:  len(changes) == ChangesPageSize,
+	}, nil
+}
+
+// apply resuelve y aplica una mutación
+func (s *Service) apply(ctx context.Context, mutation Mutation) Result {
+	result := Result{
+		MutationID: mutation.MutationID,
+		TaskID:     mutation.TaskID,
+	}
+
+	if mutation.Op == OpCreate {
+		return s.applyCreate(ctx, mutation, result)
+	}
+
+	current, err := s.repository.FindByID(ctx, mutation.TaskID)
+	if err != nil {
+		return s.reject(result, err)
+	}
+
+	expected := mutation.BaseVersion
+	if current.Version != mutation.BaseVersion {
+		result.Task = current
+		won, lost := s.resolve(mutation, current)
+		if len(won) == 0 {
+			result.Status = StatusConflict
+			if s.policy == PolicyLastWriterWins {
+				result.Resolution = ResolutionServerWins
+			}
+			return result
+		}
+		result.Resolution = ResolutionClientWins
+		if len(lost) > 0 {
+			result.Resolution = ResolutionMerged
+			mutation = mutation.without(lost)
+		}
+		expected = current.Version
+	}
+
+	cmd, err := s.command(mutation, expected)
+	if err != nil {
+		return s.reject(result, err)
+	}
+
+	if err := s.commandBus.Dispatch(ctx, cmd); err != nil {
+		if errors.Is(err, task.ErrConcurrentModification) {
+			result.Status = StatusConflict
+			result.Task = s.current(ctx, mutation.TaskID)
+			return result
+		}
+		result.Task = current
+		return s.reject(result, err)
+	}
+
+	result.Status = StatusApplied
+	result.Task = s.current(ctx, mutation.TaskID)
+	return result
+}
+
+// applyCreate crea la tarea con el ID generado por el cliente. Reenviar una
+// creación ya aplicada no la duplica; si el ID es de otra tarea, es un
+// conflicto
+func (s *Service) applyCreate(ctx context.Context, mutation Mutation, result Result) Result {
+	if mutation.TaskID == "" {
+		return s.reject(result, fmt.Errorf("%w: create mutations require a client-generated task_id", ErrInvalidMutation))
+	}
+
+	title := ""
+	if mutation.Title != nil {
+		title = *mutation.Title
+	}
+	description := ""
+	if mutation.Description != nil {
+		description = *mutation.Description
+	}
+
+	if existing, err := s.repository.FindByID(ctx, mutation.TaskID); err == nil {
+		result.Task = existing
+		result.Status = StatusConflict
+		if sameCreate(ctx, existing, title, description, mutation.DueDate) {
+			result.Status = StatusApplied
+		}
+		return result
+	}
+
+	cmd := creator.CreateTaskCommand{
+		ID:          mutation.TaskID,
+		Title:       title,
+		Description: description,
+		DueDate:     mutation.DueDate,
+	}
+	if err := s.commandBus.Dispatch(ctx, cmd); err != nil {
+		return s.reject(result, err)
+	}
+
+	result.Status = StatusApplied
+	result.Task = s.current(ctx, mutation.TaskID)
+	return result
+}
+
+// sameCreate indica si la tarea existente es la que creó un envío anterior
+// de la misma mutación: del mismo principal y con el mismo contenido
+func sameCreate(ctx context.Context, existing *task.Task, title, description string, dueDate *time.Time) bool {
+	owner := ""
+	if principal, _ := auth.PrincipalFromContext(ctx); principal.Authenticated() {
+		owner = principal.Subject
+	}
+	if existing.Owner != owner || existing.Title != title || existing.Description != description {
+		return false
+	}
+	if existing.DueDate == nil || dueDate == nil {
+		return existing.DueDate == nil && dueDate == nil
+	}
+	return existing.DueDate.Equal(*dueDate)
+}
+
+// resolve reparte los campos de una mutación sobre una versión antigua
+// entre los que gana el cliente y los que conserva el servidor. Con
+// last_writer_wins el cliente gana cada campo que el servidor no modificó
+// después de client_timestamp
+func (s *Service) resolve(mutation Mutation, current *task.Task) (won, lost []string) {
+	fields := mutation.fields()
+	if s.policy != PolicyLastWriterWins {
+		return nil, fields
+	}
+
+	// Un reloj de cliente adelantado no debe ganar siempre
+	clientTime := mutation.ClientTimestamp
+	if now := time.Now(); clientTime.After(now) {
+		clientTime = now
+	}
+
+	for _, field := range fields {
+		if clientTime.After(current.FieldUpdatedAt(field)) {
+			won = append(won, field)
+		} else {
+			lost = append(lost, field)
+		}
+	}
+	return won, lost
+}
+
+// fields campos de la tarea que modifica la mutación
+func (m Mutation) fields() []string {
+	if m.Op == OpComplete || m.Op == OpCancel {
+		return []string{task.FieldStatus}
+	}
+
+	var fields []string
+	if m.Title != nil {
+		fields = append(fields, task.FieldTitle)
+	}
+	if m.Description != nil {
+		fields = append(fields, task.FieldDescription)
+	}
+	if m.DueDate != nil || m.ClearDueDate {
+		fields = append(fields, task.FieldDueDate)
+	}
+	return fields
+}
+
+// without retorna la mutación sin los campos indicados
+func (m Mutation) without(fields []string) Mutation {
+	for _, field := range fields {
+		switch field {
+		case task.FieldTitle:
+			m.Title = nil
+		case task.FieldDescription:
+			m.Description = nil
+		case task.FieldDueDate:
+			m.DueDate, m.ClearDueDate = nil, false
+		}
+	}
+	return m
+}
+
+// command construye el comando de una mutación sobre la versión esperada
+func (s *Service) command(mutation Mutation, expected int64) (cqrs.Command, error) {
+	switch mutation.Op {
+	case OpUpdate:
+		return creator.UpdateTaskCommand{
+			ID:              mutation.TaskID,
+			Title:           mutation.Title,
+			Description:     mutation.Description,
+			DueDate:         mutation.DueDate,
+			ClearDueDate:    mutation.ClearDueDate,
+			ExpectedVersion: expected,
+		}, nil
+	case OpComplete:
+		return creator.CompleteTaskCommand{ID: mutation.TaskID, ExpectedVersion: expected}, nil
+	case OpCancel:
+		return creator.CancelTaskCommand{ID: mutation.TaskID, ExpectedVersion: expected}, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown op %q", ErrInvalidMutation, mutation.Op)
+	}
+}
+
+// current obtiene el estado actual de la tarea, si existe
+func (s *Service) current(ctx context.Context, taskID string) *task.Task {
+	current, err := s.repository.FindByID(ctx, taskID)
+	if err != nil {
+		return nil
+	}
+	return current
+}
+
+// reject marca la mutación como rechazada
+func (s *Service) reject(result Result, err error) Result {
+	result.Status = StatusRejected
+	result.Err = err
+	return result
+}
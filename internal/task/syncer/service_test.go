@@ -0,0 +1,228 @@
+package syncer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+	"github.com/yebrai/go-tasks-microservice/internal/task/creator"
+	"github.com/yebrai/go-tasks-microservice/internal/task/tasktest"
+	"github.com/yebrai/go-tasks-microservice/pkg/auth"
+	"github.com/yebrai/go-tasks-microservice/pkg/cqrs/inmem"
+	"github.com/yebrai/go-tasks-microservice/pkg/events"
+	"github.com/yebrai/go-tasks-microservice/pkg/id"
+)
+
+const testTaskID = "6f1c2a9e-3b4d-4c5e-8f70-1a2b3c4d5e6f"
+
+func newTestService(t *testing.T, policy Policy) (*Service, *tasktest.Repository) {
+	t.Helper()
+
+	repository := tasktest.NewRepository()
+	eventBus := events.NewNoOpEventBus()
+	bus := inmem.NewCommandBus()
+	must := func(err error) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	must(bus.Register(creator.CreateTaskCommandType, creator.NewCreateTaskCommandHandler(repository, id.NewUniqueIDGenerator(), eventBus, creator.Quotas{})))
+	must(bus.Register(creator.UpdateTaskCommandType, creator.NewUpdateTaskCommandHandler(repository, eventBus)))
+	must(bus.Register(creator.CompleteTaskCommandType, creator.NewCompleteTaskCommandHandler(repository, eventBus)))
+	must(bus.Register(creator.CancelTaskCommandType, creator.NewCancelTaskCommandHandler(repository, eventBus)))
+
+	return NewService(bus, repository, policy), repository
+}
+
+func strPtr(s string) *string { return &s }
+
+func TestService_Sync(t *testing.T) {
+	tests := []struct {
+		name           string
+		policy         Policy
+		baseVersion    int64
+		clientTime     time.Time
+		wantStatus     Status
+		wantResolution Resolution
+		wantTitle      string
+	}{
+		{
+			name:        "current version applies",
+			policy:      PolicyReject,
+			baseVersion: 2,
+			clientTime:  time.Now(),
+			wantStatus:  StatusApplied,
+			wantTitle:   "client",
+		},
+		{
+			name:        "stale version is rejected",
+			policy:      PolicyReject,
+			baseVersion: 1,
+			clientTime:  time.Now(),
+			wantStatus:  StatusConflict,
+			wantTitle:   "server",
+		},
+		{
+			name:           "stale but newer client wins",
+			policy:         PolicyLastWriterWins,
+			baseVersion:    1,
+			clientTime:     time.Now().Add(time.Second),
+			wantStatus:     StatusApplied,
+			wantResolution: ResolutionClientWins,
+			wantTitle:      "client",
+		},
+		{
+			name:           "stale and older client loses",
+			policy:         PolicyLastWriterWins,
+			baseVersion:    1,
+			clientTime:     time.Now().Add(-time.Hour),
+			wantStatus:     StatusConflict,
+			wantResolution: ResolutionServerWins,
+			wantTitle:      "server",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, repository := newTestService(t, tt.policy)
+			ctx := context.Background()
+
+			// Versión 1 creada por el cliente, versión 2 editada en el servidor
+			resp, err := service.Sync(ctx, Request{Mutations: []Mutation{{
+				MutationID: "m1", Op: OpCreate, TaskID: testTaskID, Title: strPtr("original"),
+			}}})
+			if err != nil || resp.Results[0].Status != StatusApplied {
+				t.Fatalf("create failed: %v %+v", err, resp)
+			}
+			stored, _ := repository.FindByID(ctx, testTaskID)
+			if err := stored.Update(strPtr("server"), nil, nil, false); err != nil {
+				t.Fatal(err)
+			}
+			_ = repository.Update(ctx, stored)
+			position, _ := repository.CurrentPosition(ctx)
+
+			resp, err = service.Sync(ctx, Request{Position: position, Mutations: []Mutation{{
+				MutationID:      "m2",
+				Op:              OpUpdate,
+				TaskID:          testTaskID,
+				BaseVersion:     tt.baseVersion,
+				ClientTimestamp: tt.clientTime,
+				Title:           strPtr("client"),
+			}}})
+			if err != nil {
+				t.Fatalf("sync failed: %v", err)
+			}
+
+			result := resp.Results[0]
+			if result.Status != tt.wantStatus || result.Resolution != tt.wantResolution {
+				t.Errorf("Expected %s/%s, got %s/%s (%s)", tt.wantStatus, tt.wantResolution, result.Status, result.Resolution, result.Err)
+			}
+			if result.Task == nil || result.Task.Title != tt.wantTitle {
+				t.Errorf("Expected resulting title %q, got %+v", tt.wantTitle, result.Task)
+			}
+		})
+	}
+}
+
+func TestService_Sync_CreateIsIdempotent(t *testing.T) {
+	alice := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "user:alice"})
+	bob := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "user:bob"})
+	mutation := Mutation{MutationID: "m1", Op: OpCreate, TaskID: testTaskID, Title: strPtr("offline")}
+	other := mutation
+	other.Title = strPtr("another task")
+
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		retry      Mutation
+		wantStatus Status
+	}{
+		{"same create is applied once", alice, mutation, StatusApplied},
+		{"different content conflicts", alice, other, StatusConflict},
+		{"another creator conflicts", bob, mutation, StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, repository := newTestService(t, PolicyReject)
+
+			resp, err := service.Sync(alice, Request{Mutations: []Mutation{mutation}})
+			if err != nil || resp.Results[0].Status != StatusApplied {
+				t.Fatalf("Expected the create to apply, got %v %+v", err, resp.Results)
+			}
+			resp, err = service.Sync(tt.ctx, Request{Mutations: []Mutation{tt.retry}})
+			if err != nil || resp.Results[0].Status != tt.wantStatus {
+				t.Fatalf("Expected %s, got %v %+v", tt.wantStatus, err, resp.Results)
+			}
+
+			tasks := repository.Tasks()
+			if len(tasks) != 1 || tasks[0].Title != "offline" || tasks[0].Owner != "user:alice" {
+				t.Errorf("Expected only the original task, got %+v", tasks)
+			}
+		})
+	}
+}
+
+func TestService_Sync_LastWriterWinsPerField(t *testing.T) {
+	now := time.Now()
+	clientTime := now.Add(-30 * time.Minute)
+
+	tests := []struct {
+		name            string
+		title           *string
+		description     *string
+		wantStatus      Status
+		wantResolution  Resolution
+		wantTitle       string
+		wantDescription string
+	}{
+		{"older edit of another field applies", nil, strPtr("client"), StatusApplied, ResolutionClientWins, "server", "client"},
+		{"older edit of the same field loses", strPtr("client"), nil, StatusConflict, ResolutionServerWins, "server", "original"},
+		{"edits merge field by field", strPtr("client"), strPtr("client"), StatusApplied, ResolutionMerged, "server", "client"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, repository := newTestService(t, PolicyLastWriterWins)
+			ctx := context.Background()
+
+			// Versión 2: el servidor editó el título hace un minuto; la
+			// descripción no cambia desde hace una hora
+			repository.Put(&task.Task{
+				ID:          testTaskID,
+				Title:       "server",
+				Description: "original",
+				Status:      task.StatusPending,
+				CreatedAt:   now.Add(-time.Hour),
+				UpdatedAt:   now.Add(-time.Minute),
+				Version:     2,
+				FieldsUpdatedAt: map[string]time.Time{
+					task.FieldTitle:       now.Add(-time.Minute),
+					task.FieldDescription: now.Add(-time.Hour),
+				},
+			})
+
+			resp, err := service.Sync(ctx, Request{Mutations: []Mutation{{
+				MutationID:      "m1",
+				Op:              OpUpdate,
+				TaskID:          testTaskID,
+				BaseVersion:     1,
+				ClientTimestamp: clientTime,
+				Title:           tt.title,
+				Description:     tt.description,
+			}}})
+			if err != nil {
+				t.Fatalf("sync failed: %v", err)
+			}
+
+			result := resp.Results[0]
+			if result.Status != tt.wantStatus || result.Resolution != tt.wantResolution {
+				t.Errorf("Expected %s/%s, got %s/%s (%v)", tt.wantStatus, tt.wantResolution, result.Status, result.Resolution, result.Err)
+			}
+			if result.Task == nil || result.Task.Title != tt.wantTitle || result.Task.Description != tt.wantDescription {
+				t.Errorf("Expected %q/%q, got %+v", tt.wantTitle, tt.wantDescription, result.Task)
+			}
+		})
+	}
+}
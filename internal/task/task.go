@@ -2,6 +2,9 @@ package task
 
 import (
 	"errors"
+	"regexp"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -14,13 +17,33 @@ const (
 	StatusCancelled Status = "cancelled"
 )
 
+// Campos con su propia marca de modificación, para resolver conflictos de
+// sincronización campo a campo
+const (
+	FieldTitle       = "title"
+	FieldDescription = "description"
+	FieldDueDate     = "due_date"
+	FieldStatus      = "status"
+)
+
 // Errores de dominio
 var (
-	ErrTaskNotFound         = errors.New("task not found")
+	ErrTaskNotFound = errors.New("task not found")
+	// ErrTaskAlreadyExists ya hay una tarea con ese ID, en este u otro tenant
+	ErrTaskAlreadyExists    = errors.New("task already exists")
 	ErrInvalidTaskID        = errors.New("invalid task ID")
 	ErrInvalidTaskData      = errors.New("invalid task data")
 	ErrTaskAlreadyCompleted = errors.New("task already completed")
 	ErrTaskAlreadyCancelled = errors.New("task already cancelled")
+	ErrTaskLocked           = errors.New("task is locked by another editor")
+	// ErrConcurrentModification la tarea cambió desde la versión esperada
+	ErrConcurrentModification = errors.New("task was modified concurrently")
+	// ErrTaskArchived la tarea está en la papelera y no admite cambios
+	ErrTaskArchived = errors.New("task is archived")
+	// ErrTaskNotArchived la operación requiere una tarea en la papelera
+	ErrTaskNotArchived = errors.New("task is not archived")
+	// ErrQuotaExceeded el tenant alcanzó su cuota de tareas
+	ErrQuotaExceeded = errors.New("task quota exceeded")
 )
 
 // Task es la entidad principal del dominio
@@ -30,7 +53,20 @@ type Task struct {
 	Description string     `json:"description"`
 	Status      Status     `json:"status"`
 	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
 	DueDate     *time.Time `json:"due_date,omitempty"`
+	ArchivedAt  *time.Time `json:"archived_at,omitempty"`
+	// Owner sujeto que creó la tarea; vacío si se creó sin autenticación
+	Owner string `json:"owner,omitempty"`
+	// Tenant espacio de trabajo al que pertenece la tarea. Lo fija el
+	// repositorio a partir del contexto y no se expone en la API
+	Tenant  string `json:"-"`
+	Version int64  `json:"version"`
+	// PurgedAt instante en que la tarea se eliminó de la papelera. Solo lo
+	// llevan las marcas de borrado del feed de cambios
+	PurgedAt *time.Time `json:"purged_at,omitempty"`
+	// FieldsUpdatedAt última modificación de cada campo editable
+	FieldsUpdatedAt map[string]time.Time `json:"-"`
 }
 
 // NewTask Constructor para nuevas tareas
@@ -43,18 +79,105 @@ func NewTask(id, title, description string, dueDate *time.Time) (*Task, error) {
 		return nil, ErrInvalidTaskData
 	}
 
+	now := time.Now()
 	return &Task{
 		ID:          id,
 		Title:       title,
 		Description: description,
 		Status:      StatusPending,
-		CreatedAt:   time.Now(),
+		CreatedAt:   now,
+		UpdatedAt:   now,
 		DueDate:     dueDate,
+		Version:     1,
+		FieldsUpdatedAt: map[string]time.Time{
+			FieldTitle:       now,
+			FieldDescription: now,
+			FieldDueDate:     now,
+			FieldStatus:      now,
+		},
 	}, nil
 }
 
+// OwnedBy indica si la tarea pertenece al sujeto indicado
+func (t *Task) OwnedBy(subject string) bool {
+	return subject != "" && t.Owner == subject
+}
+
+// CheckVersion verifica que la tarea sigue en la versión esperada. Una
+// versión esperada 0 omite la comprobación
+func (t *Task) CheckVersion(expected int64) error {
+	if expected != 0 && t.Version != expected {
+		return ErrConcurrentModification
+	}
+	return nil
+}
+
+// Update modifica los datos editables de la tarea. Los campos nil no se
+// modifican; clearDueDate elimina la fecha de vencimiento
+func (t *Task) Update(title, description *string, dueDate *time.Time, clearDueDate bool) error {
+	if t.IsArchived() {
+		return ErrTaskArchived
+	}
+
+	if title != nil && *title == "" {
+		return ErrInvalidTaskData
+	}
+
+	var fields []string
+	if title != nil {
+		t.Title = *title
+		fields = append(fields, FieldTitle)
+	}
+	if description != nil {
+		t.Description = *description
+		fields = append(fields, FieldDescription)
+	}
+	if clearDueDate {
+		t.DueDate = nil
+		fields = append(fields, FieldDueDate)
+	} else if dueDate != nil {
+		t.DueDate = dueDate
+		fields = append(fields, FieldDueDate)
+	}
+
+	t.touch(fields...)
+	return nil
+}
+
+// touch registra una modificación de la tarea y de los campos indicados
+func (t *Task) touch(fields ...string) {
+	t.UpdatedAt = time.Now()
+	t.Version++
+	if len(fields) == 0 {
+		return
+	}
+
+	// Copia nueva: las copias de la tarea no deben compartir el mapa
+	updated := make(map[string]time.Time, len(t.FieldsUpdatedAt)+len(fields))
+	for field, at := range t.FieldsUpdatedAt {
+		updated[field] = at
+	}
+	for _, field := range fields {
+		updated[field] = t.UpdatedAt
+	}
+	t.FieldsUpdatedAt = updated
+}
+
+// FieldUpdatedAt instante de la última modificación del campo. Las tareas
+// guardadas sin marcas por campo usan la última modificación de la tarea
+func (t *Task) FieldUpdatedAt(field string) time.Time {
+	if at, ok := t.FieldsUpdatedAt[field]; ok {
+		return at
+	}
+	return t.UpdatedAt
+}
+
 // Complete marca una tarea como completada
 func (t *Task) Complete() error {
+	if t.IsArchived() {
+		return ErrTaskArchived
+	}
+
 	if t.Status == StatusCompleted {
 		return ErrTaskAlreadyCompleted
 	}
@@ -64,11 +187,16 @@ func (t *Task) Complete() error {
 	}
 
 	t.Status = StatusCompleted
+	t.touch(FieldStatus)
 	return nil
 }
 
 // Cancel marca una tarea como cancelada
 func (t *Task) Cancel() error {
+	if t.IsArchived() {
+		return ErrTaskArchived
+	}
+
 	if t.Status == StatusCompleted {
 		return ErrTaskAlreadyCompleted
 	}
@@ -78,5 +206,92 @@ func (t *Task) Cancel() error {
 	}
 
 	t.Status = StatusCancelled
+	t.touch(FieldStatus)
+	return nil
+}
+
+// IsArchived indica si la tarea está en la papelera
+func (t *Task) IsArchived() bool {
+	return t.ArchivedAt != nil
+}
+
+// Archive mueve la tarea a la papelera conservando su estado
+func (t *Task) Archive() error {
+	if t.IsArchived() {
+		return ErrTaskArchived
+	}
+
+	now := time.Now()
+	t.ArchivedAt = &now
+	t.touch()
+	return nil
+}
+
+// Restore saca la tarea de la papelera
+func (t *Task) Restore() error {
+	if !t.IsArchived() {
+		return ErrTaskNotArchived
+	}
+
+	t.ArchivedAt = nil
+	t.touch()
 	return nil
 }
+
+// Tombstone retorna la marca de borrado que sustituye a la tarea en el feed
+// de cambios al purgarla. Solo conserva el ID, el tenant y la versión
+// siguiente: no guarda datos personales que una supresión tendría que
+// alcanzar
+func (t *Task) Tombstone(purgedAt time.Time) *Task {
+	return &Task{
+		ID:        t.ID,
+		CreatedAt: t.CreatedAt,
+		UpdatedAt: purgedAt,
+		Tenant:    t.Tenant,
+		Version:   t.Version + 1,
+		PurgedAt:  &purgedAt,
+	}
+}
+
+// IsPurged indica si es la marca de borrado de una tarea purgada
+func (t *Task) IsPurged() bool {
+	return t.PurgedAt != nil
+}
+
+// Pseudonymize sustituye por pseudonym los datos personales de un
+// interesado: el propietario si es subject y las apariciones de terms en el
+// título y la descripción, sin distinguir mayúsculas. Se aplica también a
+// las tareas de la papelera. Retorna si la tarea cambió
+func (t *Task) Pseudonymize(subject, pseudonym string, terms []string) bool {
+	changed := false
+	if t.OwnedBy(subject) {
+		t.Owner = pseudonym
+		changed = true
+	}
+
+	if len(terms) > 0 {
+		// Una sola pasada con los términos más largos primero, para que un
+		// término contenido en otro o en el seudónimo no deje restos
+		sorted := append([]string(nil), terms...)
+		sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+		alternatives := make([]string, len(sorted))
+		for i, term := range sorted {
+			alternatives[i] = regexp.QuoteMeta(term)
+		}
+		pattern := regexp.MustCompile(`(?i)` + strings.Join(alternatives, "|"))
+
+		if title := pattern.ReplaceAllLiteralString(t.Title, pseudonym); title != t.Title {
+			t.Title = title
+			changed = true
+		}
+		if description := pattern.ReplaceAllLiteralString(t.Description, pseudonym); description != t.Description {
+			t.Description = description
+			changed = true
+		}
+	}
+
+	if changed {
+		t.touch()
+	}
+	return changed
+}
@@ -0,0 +1,294 @@
+package tasktest
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+	"github.com/yebrai/go-tasks-microservice/pkg/tenant"
+)
+
+// Repository implementación en memoria de task.Repository y task.Transactor
+// para los tests. Se comporta como el repositorio de MongoDB: separa los
+// tenants, controla la versión en Update y numera las escrituras para el
+// feed de cambios
+type Repository struct {
+	tasks map[string]task.Task
+	// tombstones marcas de borrado de las tareas eliminadas
+	tombstones map[string]task.Task
+	positions  map[string]int64
+	position   int64
+	conflicts  int
+	mu         sync.Mutex
+}
+
+// NewRepository crea un repositorio vacío
+func NewRepository() *Repository {
+	return &Repository{
+		tasks:      make(map[string]task.Task),
+		tombstones: make(map[string]task.Task),
+		positions:  make(map[string]int64),
+	}
+}
+
+// Put guarda la tarea tal cual, sin controlar la versión; sirve para preparar
+// los datos de un test. Sin tenant la tarea pertenece al tenant por defecto
+func (r *Repository) Put(t *task.Task) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := *t
+	if stored.Tenant == "" {
+		stored.Tenant = tenant.DefaultID
+	}
+	r.write(&stored)
+}
+
+// Tasks retorna, ordenadas por ID, todas las tareas de todos los tenants
+// incluidas las de la papelera
+func (r *Repository) Tasks() []*task.Task {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.sorted(func(*task.Task) bool { return true })
+}
+
+// Len retorna el número de tareas de todos los tenants
+func (r *Repository) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.tasks)
+}
+
+// FailUpdates hace que las siguientes n llamadas a Update fallen con
+// task.ErrConcurrentModification, como si otra escritura se hubiera
+// adelantado
+func (r *Repository) FailUpdates(n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.conflicts = n
+}
+
+// Save guarda una tarea nueva en el tenant del contexto
+func (r *Repository) Save(ctx context.Context, t *task.Task) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.tasks[t.ID]; exists {
+		return task.ErrTaskAlreadyExists
+	}
+	t.Tenant = ownTenant(ctx, t)
+	stored := *t
+	r.write(&stored)
+	return nil
+}
+
+// FindByID busca una tarea del tenant del contexto
+func (r *Repository) FindByID(ctx context.Context, id string) (*task.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.tasks[id]
+	if !ok || !visible(ctx, &t) {
+		return nil, task.ErrTaskNotFound
+	}
+	return &t, nil
+}
+
+// FindAll retorna las tareas del tenant que no están en la papelera
+func (r *Repository) FindAll(ctx context.Context) ([]*task.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.sorted(func(t *task.Task) bool { return visible(ctx, t) && !t.IsArchived() }), nil
+}
+
+// FindMatching retorna por páginas ordenadas por ID las tareas que cumplen
+// el filtro
+func (r *Repository) FindMatching(ctx context.Context, filter task.Filter, afterID string, limit int) ([]*task.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	tasks := r.sorted(func(t *task.Task) bool {
+		return visible(ctx, t) && t.ID > afterID && filter.Matches(t, now)
+	})
+	if limit > 0 && len(tasks) > limit {
+		tasks = tasks[:limit]
+	}
+	return tasks, nil
+}
+
+// StreamMatching recorre en orden de ID las tareas que cumplen el filtro
+func (r *Repository) StreamMatching(ctx context.Context, filter task.Filter, fn func(*task.Task) error) error {
+	tasks, err := r.FindMatching(ctx, filter, "", 0)
+	if err != nil {
+		return err
+	}
+	for _, t := range tasks {
+		if err := fn(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CountMatching cuenta las tareas que cumplen el filtro
+func (r *Repository) CountMatching(ctx context.Context, filter task.Filter) (int64, error) {
+	tasks, err := r.FindMatching(ctx, filter, "", 0)
+	return int64(len(tasks)), err
+}
+
+// FindArchived retorna las tareas de la papelera archivadas antes de
+// archivedBefore, de la más antigua a la más reciente
+func (r *Repository) FindArchived(ctx context.Context, archivedBefore time.Time) ([]*task.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tasks := r.sorted(func(t *task.Task) bool {
+		return visible(ctx, t) && t.IsArchived() && (archivedBefore.IsZero() || t.ArchivedAt.Before(archivedBefore))
+	})
+	sort.SliceStable(tasks, func(i, j int) bool { return tasks[i].ArchivedAt.Before(*tasks[j].ArchivedAt) })
+	return tasks, nil
+}
+
+// Update persiste la tarea si la almacenada sigue en la versión anterior
+func (r *Repository) Update(ctx context.Context, t *task.Task) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.tasks[t.ID]
+	if !ok || !visible(ctx, &stored) {
+		return task.ErrTaskNotFound
+	}
+	if r.conflicts > 0 {
+		r.conflicts--
+		return task.ErrConcurrentModification
+	}
+	if stored.Version != t.Version-1 {
+		return task.ErrConcurrentModification
+	}
+
+	t.Tenant = ownTenant(ctx, t)
+	updated := *t
+	r.write(&updated)
+	return nil
+}
+
+// Delete elimina una tarea del tenant del contexto y deja su marca de
+// borrado en el feed de cambios
+func (r *Repository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if t, ok := r.tasks[id]; ok && visible(ctx, &t) {
+		delete(r.tasks, id)
+		r.position++
+		r.tombstones[id] = *t.Tombstone(time.Now())
+		r.positions[id] = r.position
+	}
+	return nil
+}
+
+// FindChangedSince retorna en orden de escritura las tareas del tenant
+// modificadas después de la posición y las marcas de borrado de las
+// eliminadas
+func (r *Repository) FindChangedSince(ctx context.Context, position int64, limit int) ([]*task.Task, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	changed := func(t *task.Task) bool { return visible(ctx, t) && r.positions[t.ID] > position }
+	tasks := append(r.sorted(changed), sortedTasks(r.tombstones, changed)...)
+	sort.SliceStable(tasks, func(i, j int) bool { return r.positions[tasks[i].ID] < r.positions[tasks[j].ID] })
+	if limit > 0 && len(tasks) > limit {
+		tasks = tasks[:limit]
+	}
+
+	last := position
+	if len(tasks) > 0 {
+		last = r.positions[tasks[len(tasks)-1].ID]
+	}
+	return tasks, last, nil
+}
+
+// CurrentPosition retorna la posición de la última escritura
+func (r *Repository) CurrentPosition(_ context.Context) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.position, nil
+}
+
+// WithTransaction restaura el estado anterior si fn falla
+func (r *Repository) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	r.mu.Lock()
+	tasks := copyMap(r.tasks)
+	tombstones := copyMap(r.tombstones)
+	positions := copyMap(r.positions)
+	position := r.position
+	r.mu.Unlock()
+
+	if err := fn(ctx); err != nil {
+		r.mu.Lock()
+		r.tasks, r.tombstones, r.positions, r.position = tasks, tombstones, positions, position
+		r.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// write guarda la tarea con la siguiente posición del feed de cambios
+func (r *Repository) write(t *task.Task) {
+	r.position++
+	r.tasks[t.ID] = *t
+	delete(r.tombstones, t.ID)
+	r.positions[t.ID] = r.position
+}
+
+// sorted retorna copias ordenadas por ID de las tareas que cumplen keep
+func (r *Repository) sorted(keep func(*task.Task) bool) []*task.Task {
+	return sortedTasks(r.tasks, keep)
+}
+
+// sortedTasks retorna copias ordenadas por ID de las tareas del mapa que
+// cumplen keep
+func sortedTasks(all map[string]task.Task, keep func(*task.Task) bool) []*task.Task {
+	var tasks []*task.Task
+	for _, t := range all {
+		t := t
+		if keep(&t) {
+			tasks = append(tasks, &t)
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+	return tasks
+}
+
+// copyMap retorna una copia superficial del mapa
+func copyMap[V any](m map[string]V) map[string]V {
+	copied := make(map[string]V, len(m))
+	for k, v := range m {
+		copied[k] = v
+	}
+	return copied
+}
+
+// visible indica si la tarea pertenece al tenant del contexto
+func visible(ctx context.Context, t *task.Task) bool {
+	return tenant.AllTenants(ctx) || t.Tenant == tenant.FromContext(ctx)
+}
+
+// ownTenant tenant con el que se escribe la tarea, igual que en MongoDB
+func ownTenant(ctx context.Context, t *task.Task) string {
+	if tenant.AllTenants(ctx) {
+		if t.Tenant == "" {
+			return tenant.DefaultID
+		}
+		return t.Tenant
+	}
+	return tenant.FromContext(ctx)
+}
@@ -0,0 +1,160 @@
+package user
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryRepository implementación en memoria de Repository para una sola
+// instancia
+type MemoryRepository struct {
+	users map[string]User
+	mu    sync.RWMutex
+}
+
+// NewMemoryRepository crea un repositorio de usuarios en memoria
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		users: make(map[string]User),
+	}
+}
+
+// Save implementa la interfaz Repository
+func (r *MemoryRepository) Save(_ context.Context, u *User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.users {
+		if existing.Email == u.Email {
+			return ErrEmailTaken
+		}
+	}
+	r.users[u.ID] = *u
+	return nil
+}
+
+// FindByID implementa la interfaz Repository
+func (r *MemoryRepository) FindByID(_ context.Context, id string) (*User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	u, ok := r.users[id]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	return &u, nil
+}
+
+// FindByEmail implementa la interfaz Repository
+func (r *MemoryRepository) FindByEmail(_ context.Context, email string) (*User, error) {
+	return r.find(func(u *User) bool { return u.Email == email })
+}
+
+// FindByResetHash implementa la interfaz Repository
+func (r *MemoryRepository) FindByResetHash(_ context.Context, hash string) (*User, error) {
+	return r.find(func(u *User) bool { return u.ResetHash != "" && u.ResetHash == hash })
+}
+
+// Update implementa la interfaz Repository
+func (r *MemoryRepository) Update(_ context.Context, u *User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[u.ID]; !ok {
+		return ErrUserNotFound
+	}
+	r.users[u.ID] = *u
+	return nil
+}
+
+// find retorna el primer usuario que cumple la condición
+func (r *MemoryRepository) find(match func(*User) bool) (*User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, u := range r.users {
+		if match(&u) {
+			return &u, nil
+		}
+	}
+	return nil, ErrUserNotFound
+}
+
+// MemorySessionRepository implementación en memoria de SessionRepository
+type MemorySessionRepository struct {
+	sessions map[string]Session
+	mu       sync.RWMutex
+}
+
+// NewMemorySessionRepository crea un repositorio de sesiones en memoria
+func NewMemorySessionRepository() *MemorySessionRepository {
+	return &MemorySessionRepository{
+		sessions: make(map[string]Session),
+	}
+}
+
+// Save implementa la interfaz SessionRepository. Descarta de paso las
+// sesiones que ya no se pueden renovar
+func (r *MemorySessionRepository) Save(_ context.Context, session *Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for id, existing := range r.sessions {
+		if !now.Before(existing.RefreshExpiresAt) {
+			delete(r.sessions, id)
+		}
+	}
+	r.sessions[session.ID] = *session
+	return nil
+}
+
+// FindByAccessHash implementa la interfaz SessionRepository
+func (r *MemorySessionRepository) FindByAccessHash(_ context.Context, hash string) (*Session, error) {
+	return r.find(func(s *Session) bool { return s.AccessHash == hash })
+}
+
+// FindByRefreshHash implementa la interfaz SessionRepository
+func (r *MemorySessionRepository) FindByRefreshHash(_ context.Context, hash string) (*Session, error) {
+	return r.find(func(s *Session) bool { return s.RefreshHash == hash })
+}
+
+// Update implementa la interfaz SessionRepository
+func (r *MemorySessionRepository) Update(_ context.Context, session *Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.sessions[session.ID]; !ok {
+		return ErrInvalidToken
+	}
+	r.sessions[session.ID] = *session
+	return nil
+}
+
+// RevokeAll implementa la interfaz SessionRepository
+func (r *MemorySessionRepository) RevokeAll(_ context.Context, userID string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, session := range r.sessions {
+		if session.UserID == userID && session.RevokedAt == nil {
+			session.RevokedAt = &at
+			r.sessions[id] = session
+		}
+	}
+	return nil
+}
+
+// find retorna la primera sesión que cumple la condición
+func (r *MemorySessionRepository) find(match func(*Session) bool) (*Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, session := range r.sessions {
+		if match(&session) {
+			return &session, nil
+		}
+	}
+	return nil, ErrInvalidToken
+}
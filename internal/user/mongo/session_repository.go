@@ -0,0 +1,146 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/yebrai/go-tasks-microservice/internal/user"
+)
+
+// SessionRepository implementación MongoDB del repositorio de sesiones
+type SessionRepository struct {
+	collection *mongo.Collection
+}
+
+// NewSessionRepository crea una nueva instancia del repositorio
+func NewSessionRepository(db *mongo.Database) *SessionRepository {
+	return &SessionRepository{
+		collection: db.Collection("user_sessions"),
+	}
+}
+
+// EnsureIndexes crea los índices por hash de cada token y un índice TTL que
+// borra las sesiones cuando ya no se pueden renovar
+func (r *SessionRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "access_hash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "refresh_hash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}},
+		},
+		{
+			Keys:    bson.D{{Key: "refresh_expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create session indexes: %w", err)
+	}
+
+	return nil
+}
+
+// SessionDocument representa la estructura de documento en MongoDB
+type SessionDocument struct {
+	ID               string     `bson:"_id"`
+	UserID           string     `bson:"user_id"`
+	AccessHash       string     `bson:"access_hash"`
+	RefreshHash      string     `bson:"refresh_hash"`
+	AccessExpiresAt  time.Time  `bson:"access_expires_at"`
+	RefreshExpiresAt time.Time  `bson:"refresh_expires_at"`
+	CreatedAt        time.Time  `bson:"created_at"`
+	RevokedAt        *time.Time `bson:"revoked_at,omitempty"`
+}
+
+// Save guarda una sesión nueva
+func (r *SessionRepository) Save(ctx context.Context, session *user.Session) error {
+	if _, err := r.collection.InsertOne(ctx, toSessionDocument(session)); err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+	return nil
+}
+
+// FindByAccessHash busca la sesión de un access token
+func (r *SessionRepository) FindByAccessHash(ctx context.Context, hash string) (*user.Session, error) {
+	return r.findOne(ctx, bson.M{"access_hash": hash})
+}
+
+// FindByRefreshHash busca la sesión de un refresh token
+func (r *SessionRepository) FindByRefreshHash(ctx context.Context, hash string) (*user.Session, error) {
+	return r.findOne(ctx, bson.M{"refresh_hash": hash})
+}
+
+// Update reemplaza una sesión existente
+func (r *SessionRepository) Update(ctx context.Context, session *user.Session) error {
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": session.ID}, toSessionDocument(session))
+	if err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return user.ErrInvalidToken
+	}
+	return nil
+}
+
+// RevokeAll cierra todas las sesiones abiertas del usuario
+func (r *SessionRepository) RevokeAll(ctx context.Context, userID string, at time.Time) error {
+	_, err := r.collection.UpdateMany(ctx,
+		bson.M{"user_id": userID, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": at}},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	return nil
+}
+
+// findOne busca una sesión por el filtro indicado
+func (r *SessionRepository) findOne(ctx context.Context, filter bson.M) (*user.Session, error) {
+	var doc SessionDocument
+	err := r.collection.FindOne(ctx, filter).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, user.ErrInvalidToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find session: %w", err)
+	}
+	return fromSessionDocument(&doc), nil
+}
+
+func toSessionDocument(session *user.Session) *SessionDocument {
+	return &SessionDocument{
+		ID:               session.ID,
+		UserID:           session.UserID,
+		AccessHash:       session.AccessHash,
+		RefreshHash:      session.RefreshHash,
+		AccessExpiresAt:  session.AccessExpiresAt,
+		RefreshExpiresAt: session.RefreshExpiresAt,
+		CreatedAt:        session.CreatedAt,
+		RevokedAt:        session.RevokedAt,
+	}
+}
+
+func fromSessionDocument(doc *SessionDocument) *user.Session {
+	return &user.Session{
+		ID:               doc.ID,
+		UserID:           doc.UserID,
+		AccessHash:       doc.AccessHash,
+		RefreshHash:      doc.RefreshHash,
+		AccessExpiresAt:  doc.AccessExpiresAt,
+		RefreshExpiresAt: doc.RefreshExpiresAt,
+		CreatedAt:        doc.CreatedAt,
+		RevokedAt:        doc.RevokedAt,
+	}
+}
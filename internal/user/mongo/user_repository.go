@@ -0,0 +1,148 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/yebrai/go-tasks-microservice/internal/user"
+)
+
+// UserRepository implementación MongoDB del repositorio de usuarios
+type UserRepository struct {
+	collection *mongo.Collection
+}
+
+// NewUserRepository crea una nueva instancia del repositorio
+func NewUserRepository(db *mongo.Database) *UserRepository {
+	return &UserRepository{
+		collection: db.Collection("users"),
+	}
+}
+
+// EnsureIndexes crea el índice único por email y el de los tokens de
+// restablecimiento
+func (r *UserRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "email", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "reset_hash", Value: 1}},
+			Options: options.Index().SetSparse(true),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create user indexes: %w", err)
+	}
+
+	return nil
+}
+
+// UserDocument representa la estructura de documento en MongoDB
+type UserDocument struct {
+	ID             string     `bson:"_id"`
+	Email          string     `bson:"email"`
+	Name           string     `bson:"name"`
+	PasswordHash   string     `bson:"password_hash"`
+	Roles          []string   `bson:"roles,omitempty"`
+	Tenant         string     `bson:"tenant,omitempty"`
+	CreatedAt      time.Time  `bson:"created_at"`
+	UpdatedAt      time.Time  `bson:"updated_at"`
+	FailedLogins   int        `bson:"failed_logins,omitempty"`
+	LockedUntil    *time.Time `bson:"locked_until,omitempty"`
+	ResetHash      string     `bson:"reset_hash,omitempty"`
+	ResetExpiresAt *time.Time `bson:"reset_expires_at,omitempty"`
+}
+
+// Save guarda un usuario nuevo
+func (r *UserRepository) Save(ctx context.Context, u *user.User) error {
+	_, err := r.collection.InsertOne(ctx, toUserDocument(u))
+	if mongo.IsDuplicateKeyError(err) {
+		return user.ErrEmailTaken
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save user: %w", err)
+	}
+	return nil
+}
+
+// FindByID busca un usuario por su ID
+func (r *UserRepository) FindByID(ctx context.Context, id string) (*user.User, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+// FindByEmail busca un usuario por su email normalizado
+func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*user.User, error) {
+	return r.findOne(ctx, bson.M{"email": email})
+}
+
+// FindByResetHash busca el usuario con el token de restablecimiento
+func (r *UserRepository) FindByResetHash(ctx context.Context, hash string) (*user.User, error) {
+	return r.findOne(ctx, bson.M{"reset_hash": hash})
+}
+
+// Update reemplaza un usuario existente
+func (r *UserRepository) Update(ctx context.Context, u *user.User) error {
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": u.ID}, toUserDocument(u))
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return user.ErrUserNotFound
+	}
+	return nil
+}
+
+// findOne busca un usuario por el filtro indicado
+func (r *UserRepository) findOne(ctx context.Context, filter bson.M) (*user.User, error) {
+	var doc UserDocument
+	err := r.collection.FindOne(ctx, filter).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, user.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+	return fromUserDocument(&doc), nil
+}
+
+func toUserDocument(u *user.User) *UserDocument {
+	return &UserDocument{
+		ID:             u.ID,
+		Email:          u.Email,
+		Name:           u.Name,
+		PasswordHash:   u.PasswordHash,
+		Roles:          u.Roles,
+		Tenant:         u.Tenant,
+		CreatedAt:      u.CreatedAt,
+		UpdatedAt:      u.UpdatedAt,
+		FailedLogins:   u.FailedLogins,
+		LockedUntil:    u.LockedUntil,
+		ResetHash:      u.ResetHash,
+		ResetExpiresAt: u.ResetExpiresAt,
+	}
+}
+
+func fromUserDocument(doc *UserDocument) *user.User {
+	return &user.User{
+		ID:             doc.ID,
+		Email:          doc.Email,
+		Name:           doc.Name,
+		PasswordHash:   doc.PasswordHash,
+		Roles:          doc.Roles,
+		Tenant:         doc.Tenant,
+		CreatedAt:      doc.CreatedAt,
+		UpdatedAt:      doc.UpdatedAt,
+		FailedLogins:   doc.FailedLogins,
+		LockedUntil:    doc.LockedUntil,
+		ResetHash:      doc.ResetHash,
+		ResetExpiresAt: doc.ResetExpiresAt,
+	}
+}
@@ -0,0 +1,18 @@
+package user
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// LogResetNotifier escribe los tokens de restablecimiento en el log del
+// servicio. Solo para desarrollo: cualquiera que lea el log puede tomar la
+// cuenta, así que nunca se usa salvo que se active users.log_reset_tokens
+type LogResetNotifier struct{}
+
+// NotifyPasswordReset implementa la interfaz ResetNotifier
+func (LogResetNotifier) NotifyPasswordReset(_ context.Context, u *User, token string, expiresAt time.Time) error {
+	log.Printf("🔑 Password reset for %s (user %s): token %s, expires %s", u.Email, u.ID, token, expiresAt.Format(time.RFC3339))
+	return nil
+}
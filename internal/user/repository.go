@@ -0,0 +1,34 @@
+package user
+
+import (
+	"context"
+	"time"
+)
+
+// Repository persistencia de los usuarios
+type Repository interface {
+	// Save guarda un usuario nuevo; retorna ErrEmailTaken si el email ya
+	// está registrado
+	Save(ctx context.Context, u *User) error
+	FindByID(ctx context.Context, id string) (*User, error)
+	FindByEmail(ctx context.Context, email string) (*User, error)
+	// FindByResetHash busca el usuario con el token de restablecimiento
+	FindByResetHash(ctx context.Context, hash string) (*User, error)
+	Update(ctx context.Context, u *User) error
+}
+
+// SessionRepository persistencia de las sesiones. Las búsquedas por hash
+// retornan ErrInvalidToken si no hay ninguna sesión con ese token
+type SessionRepository interface {
+	Save(ctx context.Context, session *Session) error
+	FindByAccessHash(ctx context.Context, hash string) (*Session, error)
+	FindByRefreshHash(ctx context.Context, hash string) (*Session, error)
+	Update(ctx context.Context, session *Session) error
+	// RevokeAll cierra todas las sesiones abiertas del usuario
+	RevokeAll(ctx context.Context, userID string, at time.Time) error
+}
+
+// ResetNotifier entrega al usuario el token para restablecer su contraseña
+type ResetNotifier interface {
+	NotifyPasswordReset(ctx context.Context, u *User, token string, expiresAt time.Time) error
+}
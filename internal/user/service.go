@@ -0,0 +1,399 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yebrai/go-tasks-microservice/pkg/auth"
+	"github.com/yebrai/go-tasks-microservice/pkg/id"
+	"github.com/yebrai/go-tasks-microservice/pkg/tenant"
+)
+
+// Valores por defecto de Options
+const (
+	DefaultAccessTTL       = 15 * time.Minute
+	DefaultRefreshTTL      = 30 * 24 * time.Hour
+	DefaultResetTTL        = time.Hour
+	DefaultMaxFailedLogins = 5
+	DefaultLockoutDuration = 15 * time.Minute
+)
+
+// Options configuración de las sesiones, el bloqueo y el restablecimiento.
+// Los valores cero usan los de por defecto
+type Options struct {
+	AccessTTL       time.Duration
+	RefreshTTL      time.Duration
+	ResetTTL        time.Duration
+	MaxFailedLogins int
+	LockoutDuration time.Duration
+}
+
+// RegisterRequest datos de una cuenta nueva
+type RegisterRequest struct {
+	Email    string
+	Name     string
+	Password string
+}
+
+// Service gestiona las cuentas y sesiones de usuario y autentica los access
+// tokens. Implementa auth.Authenticator
+type Service struct {
+	users       Repository
+	sessions    SessionRepository
+	notifier    ResetNotifier
+	idGenerator id.Generator
+	options     Options
+	now         func() time.Time
+
+	// dummyHash se compara cuando el email no existe, para que el login
+	// tarde lo mismo y no revele qué emails están registrados
+	dummyHash     string
+	dummyHashOnce sync.Once
+}
+
+// NewService crea el servicio de usuarios. Con notifier nil no se pueden
+// restablecer contraseñas
+func NewService(users Repository, sessions SessionRepository, notifier ResetNotifier, idGenerator id.Generator, options Options) *Service {
+	if options.AccessTTL <= 0 {
+		options.AccessTTL = DefaultAccessTTL
+	}
+	if options.RefreshTTL <= 0 {
+		options.RefreshTTL = DefaultRefreshTTL
+	}
+	if options.ResetTTL <= 0 {
+		options.ResetTTL = DefaultResetTTL
+	}
+	if options.MaxFailedLogins <= 0 {
+		options.MaxFailedLogins = DefaultMaxFailedLogins
+	}
+	if options.LockoutDuration <= 0 {
+		options.LockoutDuration = DefaultLockoutDuration
+	}
+
+	return &Service{
+		users:       users,
+		sessions:    sessions,
+		notifier:    notifier,
+		idGenerator: idGenerator,
+		options:     options,
+		now:         time.Now,
+	}
+}
+
+// Register crea una cuenta sin roles ligada al tenant del contexto. Los
+// emails no se verifican, así que el registro nunca concede roles: los
+// asigna un administrador con SetRoles
+func (s *Service) Register(ctx context.Context, req RegisterRequest) (*User, error) {
+	u, err := New(s.idGenerator.Generate(), req.Email, req.Name, req.Password, nil, s.now())
+	if err != nil {
+		return nil, err
+	}
+	u.Tenant = tenant.FromContext(ctx)
+
+	if err := s.users.Save(ctx, u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// Login comprueba la contraseña y abre una sesión. Tras MaxFailedLogins
+// intentos fallidos seguidos la cuenta se bloquea LockoutDuration
+func (s *Service) Login(ctx context.Context, email, password string) (*Tokens, error) {
+	now := s.now()
+
+	u, err := s.findByEmail(ctx, email)
+	if errors.Is(err, ErrUserNotFound) {
+		s.comparePasswordDummy(password)
+		return nil, ErrInvalidLogin
+	}
+	if err != nil {
+		return nil, err
+	}
+	if u.Locked(now) {
+		return nil, ErrAccountLocked
+	}
+
+	if !u.CheckPassword(password) {
+		locked := u.RecordFailedLogin(s.options.MaxFailedLogins, s.options.LockoutDuration, now)
+		if err := s.users.Update(ctx, u); err != nil {
+			return nil, fmt.Errorf("failed to record failed login: %w", err)
+		}
+		if locked {
+			return nil, ErrAccountLocked
+		}
+		return nil, ErrInvalidLogin
+	}
+
+	if u.RecordLogin() {
+		if err := s.users.Update(ctx, u); err != nil {
+			return nil, fmt.Errorf("failed to record login: %w", err)
+		}
+	}
+
+	session := &Session{ID: s.idGenerator.Generate(), UserID: u.ID, CreatedAt: now}
+	tokens, err := session.rotate(s.options.AccessTTL, s.options.RefreshTTL, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.sessions.Save(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to save session: %w", err)
+	}
+	return tokens, nil
+}
+
+// Refresh renueva la sesión del refresh token. Ambos tokens se rotan, así
+// que el refresh token anterior deja de valer
+func (s *Service) Refresh(ctx context.Context, refreshToken string) (*Tokens, error) {
+	now := s.now()
+
+	session, err := s.sessions.FindByRefreshHash(ctx, HashToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+	if !session.RefreshActive(now) {
+		return nil, ErrInvalidToken
+	}
+
+	// Una cuenta borrada o bloqueada no renueva sus sesiones
+	u, err := s.users.FindByID(ctx, session.UserID)
+	if errors.Is(err, ErrUserNotFound) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if u.Locked(now) {
+		return nil, ErrAccountLocked
+	}
+
+	tokens, err := session.rotate(s.options.AccessTTL, s.options.RefreshTTL, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.sessions.Update(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to update session: %w", err)
+	}
+	return tokens, nil
+}
+
+// Logout cierra la sesión del access token o, con all, todas las del
+// usuario
+func (s *Service) Logout(ctx context.Context, accessToken string, all bool) error {
+	session, err := s.sessions.FindByAccessHash(ctx, HashToken(accessToken))
+	if err != nil {
+		return err
+	}
+	if !session.AccessActive(s.now()) {
+		return ErrInvalidToken
+	}
+
+	if all {
+		return s.sessions.RevokeAll(ctx, session.UserID, s.now())
+	}
+	return s.revoke(ctx, session)
+}
+
+// Revoke cierra la sesión del refresh token. Revocar una sesión ya cerrada
+// no es un error
+func (s *Service) Revoke(ctx context.Context, refreshToken string) error {
+	session, err := s.sessions.FindByRefreshHash(ctx, HashToken(refreshToken))
+	if errors.Is(err, ErrInvalidToken) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return s.revoke(ctx, session)
+}
+
+// RequestPasswordReset emite un token de restablecimiento y lo entrega con
+// el notifier. No indica si el email existe. Sin notifier retorna
+// ErrPasswordResetUnavailable
+func (s *Service) RequestPasswordReset(ctx context.Context, email string) error {
+	if s.notifier == nil {
+		return ErrPasswordResetUnavailable
+	}
+
+	u, err := s.findByEmail(ctx, email)
+	if errors.Is(err, ErrUserNotFound) || errors.Is(err, ErrInvalidUserData) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	token, err := newToken(ResetTokenPrefix)
+	if err != nil {
+		return err
+	}
+	expiresAt := s.now().Add(s.options.ResetTTL)
+	u.ResetHash = HashToken(token)
+	u.ResetExpiresAt = &expiresAt
+
+	if err := s.users.Update(ctx, u); err != nil {
+		return fmt.Errorf("failed to save password reset: %w", err)
+	}
+	return s.notifier.NotifyPasswordReset(ctx, u, token, expiresAt)
+}
+
+// ResetPassword cambia la contraseña con un token de restablecimiento,
+// desbloquea la cuenta y cierra todas sus sesiones
+func (s *Service) ResetPassword(ctx context.Context, token, password string) error {
+	now := s.now()
+
+	u, err := s.users.FindByResetHash(ctx, HashToken(token))
+	if errors.Is(err, ErrUserNotFound) {
+		return ErrInvalidToken
+	}
+	if err != nil {
+		return err
+	}
+	if u.ResetExpiresAt == nil || !now.Before(*u.ResetExpiresAt) {
+		return ErrInvalidToken
+	}
+
+	if err := u.SetPassword(password, now); err != nil {
+		return err
+	}
+	if err := s.users.Update(ctx, u); err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	return s.sessions.RevokeAll(ctx, u.ID, now)
+}
+
+// Authenticate implementa auth.Authenticator para los access tokens
+func (s *Service) Authenticate(ctx context.Context, credential string) (*auth.Principal, error) {
+	if credential == "" {
+		return nil, auth.ErrMissingCredentials
+	}
+	if !strings.HasPrefix(credential, AccessTokenPrefix) {
+		return nil, fmt.Errorf("%w: not an access token", auth.ErrInvalidCredentials)
+	}
+
+	session, err := s.sessions.FindByAccessHash(ctx, HashToken(credential))
+	if errors.Is(err, ErrInvalidToken) {
+		return nil, fmt.Errorf("%w: %v", auth.ErrInvalidCredentials, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !session.AccessActive(s.now()) {
+		return nil, fmt.Errorf("%w: %v", auth.ErrInvalidCredentials, ErrInvalidToken)
+	}
+
+	// Se lee el usuario para que los cambios de rol apliquen sin esperar a
+	// la siguiente renovación
+	u, err := s.users.FindByID(ctx, session.UserID)
+	if errors.Is(err, ErrUserNotFound) {
+		return nil, fmt.Errorf("%w: %v", auth.ErrInvalidCredentials, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return u.Principal(), nil
+}
+
+// SetRoles sustituye los roles de una cuenta del tenant del contexto. Se
+// aplican en la siguiente petición de sus sesiones abiertas
+func (s *Service) SetRoles(ctx context.Context, id string, roles []string) (*User, error) {
+	cleaned := make([]string, 0, len(roles))
+	for _, role := range roles {
+		role = strings.TrimSpace(role)
+		if role == "" {
+			return nil, fmt.Errorf("%w: roles cannot be empty", ErrInvalidUserData)
+		}
+		cleaned = append(cleaned, role)
+	}
+
+	u, err := s.FindBySubject(ctx, "user:"+id)
+	if err != nil {
+		return nil, err
+	}
+	u.Roles = cleaned
+	u.UpdatedAt = s.now()
+	if err := s.users.Update(ctx, u); err != nil {
+		return nil, fmt.Errorf("failed to update roles: %w", err)
+	}
+	return u, nil
+}
+
+// Pseudonymize sustituye el nombre y el email de la cuenta del subject por
+// el seudónimo y la cierra: anula su contraseña y sus sesiones. Retorna
+// false si el subject no tiene cuenta en el tenant del contexto
+func (s *Service) Pseudonymize(ctx context.Context, subject, pseudonym string) (bool, error) {
+	u, err := s.FindBySubject(ctx, subject)
+	if errors.Is(err, ErrUserNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	now := s.now()
+	u.Name = pseudonym
+	// El email sigue siendo único para el índice, pero ya no identifica a
+	// nadie ni se puede usar para entrar
+	u.Email = u.ID + ErasedEmailDomain
+	u.PasswordHash = ""
+	u.ResetHash = ""
+	u.ResetExpiresAt = nil
+	u.UpdatedAt = now
+	if err := s.users.Update(ctx, u); err != nil {
+		return false, fmt.Errorf("failed to pseudonymize user: %w", err)
+	}
+	if err := s.sessions.RevokeAll(ctx, u.ID, now); err != nil {
+		return false, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	return true, nil
+}
+
+// FindBySubject retorna la cuenta del subject de sus principales
+// ("user:<id>") si pertenece al tenant del contexto
+func (s *Service) FindBySubject(ctx context.Context, subject string) (*User, error) {
+	id, ok := strings.CutPrefix(subject, "user:")
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	u, err := s.users.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if u.TenantID() != tenant.FromContext(ctx) {
+		return nil, ErrUserNotFound
+	}
+	return u, nil
+}
+
+// revoke cierra una sesión
+func (s *Service) revoke(ctx context.Context, session *Session) error {
+	if session.RevokedAt != nil {
+		return nil
+	}
+	session.Revoke(s.now())
+	if err := s.sessions.Update(ctx, session); err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	return nil
+}
+
+// findByEmail busca un usuario por su email normalizado
+func (s *Service) findByEmail(ctx context.Context, email string) (*User, error) {
+	email, err := NormalizeEmail(email)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	return s.users.FindByEmail(ctx, email)
+}
+
+// comparePasswordDummy compara la contraseña con un hash que nunca coincide
+func (s *Service) comparePasswordDummy(password string) {
+	s.dummyHashOnce.Do(func() {
+		s.dummyHash, _ = hashPassword("not-a-real-password")
+	})
+	dummy := User{PasswordHash: s.dummyHash}
+	dummy.CheckPassword(password)
+}
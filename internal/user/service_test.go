@@ -0,0 +1,232 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/yebrai/go-tasks-microservice/pkg/auth"
+	"github.com/yebrai/go-tasks-microservice/pkg/id"
+	"github.com/yebrai/go-tasks-microservice/pkg/tenant"
+)
+
+func init() {
+	passwordCost = bcrypt.MinCost
+}
+
+// resetRecorder notifier que guarda el último token emitido
+type resetRecorder struct {
+	token string
+}
+
+func (r *resetRecorder) NotifyPasswordReset(_ context.Context, _ *User, token string, _ time.Time) error {
+	r.token = token
+	return nil
+}
+
+func newTestService() (*Service, *resetRecorder, *time.Time) {
+	notifier := &resetRecorder{}
+	service := NewService(NewMemoryRepository(), NewMemorySessionRepository(), notifier, id.NewUniqueIDGenerator(), Options{})
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+	return service, notifier, &now
+}
+
+func register(t *testing.T, service *Service, email string) *User {
+	t.Helper()
+	u, err := service.Register(context.Background(), RegisterRequest{Email: email, Name: "Ada", Password: "correct horse"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func TestService_RegisterValidatesAndNormalizes(t *testing.T) {
+	service, _, _ := newTestService()
+	ctx := context.Background()
+
+	u := register(t, service, " Ada@Example.com ")
+	if u.Email != "ada@example.com" || u.PasswordHash == "" || strings.Contains(u.PasswordHash, "correct horse") {
+		t.Errorf("Unexpected user %+v", u)
+	}
+
+	tests := []struct {
+		name string
+		req  RegisterRequest
+		want error
+	}{
+		{"duplicate email", RegisterRequest{Email: "ADA@example.com", Name: "Ada", Password: "correct horse"}, ErrEmailTaken},
+		{"invalid email", RegisterRequest{Email: "ada", Name: "Ada", Password: "correct horse"}, ErrInvalidUserData},
+		{"short password", RegisterRequest{Email: "bob@example.com", Name: "Bob", Password: "short"}, ErrInvalidUserData},
+		{"long password", RegisterRequest{Email: "bob@example.com", Name: "Bob", Password: strings.Repeat("x", 73)}, ErrInvalidUserData},
+		{"missing name", RegisterRequest{Email: "bob@example.com", Password: "correct horse"}, ErrInvalidUserData},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.Register(ctx, tt.req); !errors.Is(err, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+
+	if len(u.Roles) != 0 || u.Principal().HasScope(auth.ScopeAdmin) {
+		t.Errorf("Expected registration not to grant roles, got %v", u.Roles)
+	}
+}
+
+func TestService_SetRolesGrantsAdminToOpenSessions(t *testing.T) {
+	service, _, _ := newTestService()
+	ctx := context.Background()
+
+	u := register(t, service, "ada@example.com")
+	tokens, err := service.Login(ctx, "ada@example.com", "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := service.SetRoles(ctx, u.ID, []string{RoleAdmin}); err != nil {
+		t.Fatal(err)
+	}
+	principal, err := service.Authenticate(ctx, tokens.AccessToken)
+	if err != nil || !principal.HasScope(auth.ScopeAdmin) {
+		t.Errorf("Expected the open session to get the admin scope, got %+v (%v)", principal, err)
+	}
+
+	if _, err := service.SetRoles(ctx, u.ID, []string{" "}); !errors.Is(err, ErrInvalidUserData) {
+		t.Errorf("Expected empty roles to be rejected, got %v", err)
+	}
+	if _, err := service.SetRoles(tenant.WithID(ctx, "acme"), u.ID, nil); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Expected accounts of other tenants to be hidden, got %v", err)
+	}
+}
+
+func TestService_SessionLifecycle(t *testing.T) {
+	service, _, now := newTestService()
+	ctx := context.Background()
+	u := register(t, service, "ada@example.com")
+
+	tokens, err := service.Login(ctx, "ADA@example.com", "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	principal, err := service.Authenticate(ctx, tokens.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if principal.Subject != "user:"+u.ID || !principal.HasScope(auth.ScopeTasksWrite) {
+		t.Errorf("Unexpected principal %+v", principal)
+	}
+
+	// El access token caduca; el refresh token emite otro par y deja de valer
+	*now = now.Add(DefaultAccessTTL)
+	if _, err := service.Authenticate(ctx, tokens.AccessToken); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Errorf("Expected an expired access token to be rejected, got %v", err)
+	}
+	refreshed, err := service.Refresh(ctx, tokens.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.Refresh(ctx, tokens.RefreshToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected a rotated refresh token to be rejected, got %v", err)
+	}
+	if _, err := service.Authenticate(ctx, refreshed.AccessToken); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := service.Logout(ctx, refreshed.AccessToken, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.Authenticate(ctx, refreshed.AccessToken); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Errorf("Expected a logged out session to be rejected, got %v", err)
+	}
+	if _, err := service.Refresh(ctx, refreshed.RefreshToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected a logged out session not to refresh, got %v", err)
+	}
+
+	again, _ := service.Login(ctx, "ada@example.com", "correct horse")
+	if err := service.Revoke(ctx, again.RefreshToken); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.Authenticate(ctx, again.AccessToken); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Errorf("Expected a revoked session to be rejected, got %v", err)
+	}
+}
+
+func TestService_LocksAccountAfterRepeatedFailures(t *testing.T) {
+	service, _, now := newTestService()
+	ctx := context.Background()
+	register(t, service, "ada@example.com")
+
+	if _, err := service.Login(ctx, "nobody@example.com", "correct horse"); !errors.Is(err, ErrInvalidLogin) {
+		t.Errorf("Expected ErrInvalidLogin for an unknown email, got %v", err)
+	}
+
+	for i := 1; i < DefaultMaxFailedLogins; i++ {
+		if _, err := service.Login(ctx, "ada@example.com", "wrong password"); !errors.Is(err, ErrInvalidLogin) {
+			t.Fatalf("Attempt %d: expected ErrInvalidLogin, got %v", i, err)
+		}
+	}
+	if _, err := service.Login(ctx, "ada@example.com", "wrong password"); !errors.Is(err, ErrAccountLocked) {
+		t.Fatalf("Expected the last attempt to lock the account, got %v", err)
+	}
+	if _, err := service.Login(ctx, "ada@example.com", "correct horse"); !errors.Is(err, ErrAccountLocked) {
+		t.Errorf("Expected a locked account to reject the right password, got %v", err)
+	}
+
+	*now = now.Add(DefaultLockoutDuration)
+	if _, err := service.Login(ctx, "ada@example.com", "correct horse"); err != nil {
+		t.Errorf("Expected the lock to expire, got %v", err)
+	}
+}
+
+func TestService_PasswordResetRevokesSessions(t *testing.T) {
+	service, notifier, now := newTestService()
+	ctx := context.Background()
+	register(t, service, "ada@example.com")
+	tokens, _ := service.Login(ctx, "ada@example.com", "correct horse")
+
+	if err := service.RequestPasswordReset(ctx, "unknown@example.com"); err != nil || notifier.token != "" {
+		t.Fatalf("Expected unknown emails to be ignored silently, got %v", err)
+	}
+	if err := service.RequestPasswordReset(ctx, "ada@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(notifier.token, ResetTokenPrefix) {
+		t.Fatalf("Expected a reset token, got %q", notifier.token)
+	}
+
+	if err := service.ResetPassword(ctx, notifier.token, "short"); !errors.Is(err, ErrInvalidUserData) {
+		t.Errorf("Expected the new password to be validated, got %v", err)
+	}
+	if err := service.ResetPassword(ctx, notifier.token, "battery staple"); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.ResetPassword(ctx, notifier.token, "battery staple"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected the reset token to be single use, got %v", err)
+	}
+	if _, err := service.Authenticate(ctx, tokens.AccessToken); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Errorf("Expected existing sessions to be revoked, got %v", err)
+	}
+	if _, err := service.Login(ctx, "ada@example.com", "battery staple"); err != nil {
+		t.Errorf("Expected the new password to work, got %v", err)
+	}
+
+	_ = service.RequestPasswordReset(ctx, "ada@example.com")
+	*now = now.Add(DefaultResetTTL)
+	if err := service.ResetPassword(ctx, notifier.token, "another password"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected an expired reset token to be rejected, got %v", err)
+	}
+}
+
+func TestService_PasswordResetRequiresANotifier(t *testing.T) {
+	service := NewService(NewMemoryRepository(), NewMemorySessionRepository(), nil, id.NewUniqueIDGenerator(), Options{})
+	register(t, service, "ada@example.com")
+
+	if err := service.RequestPasswordReset(context.Background(), "ada@example.com"); !errors.Is(err, ErrPasswordResetUnavailable) {
+		t.Errorf("Expected ErrPasswordResetUnavailable, got %v", err)
+	}
+}
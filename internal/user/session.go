@@ -0,0 +1,99 @@
+package user
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// Prefijos de los tokens emitidos, para reconocerlos en logs y en
+// escáneres de secretos
+const (
+	AccessTokenPrefix  = "tsa_"
+	RefreshTokenPrefix = "tsr_"
+	ResetTokenPrefix   = "tsp_"
+)
+
+// Session sesión abierta por un login. Solo se guardan los hashes de sus
+// tokens; el refresh token se rota en cada renovación
+type Session struct {
+	ID               string
+	UserID           string
+	AccessHash       string
+	RefreshHash      string
+	AccessExpiresAt  time.Time
+	RefreshExpiresAt time.Time
+	CreatedAt        time.Time
+	RevokedAt        *time.Time
+}
+
+// Tokens credenciales que recibe el cliente al iniciar o renovar sesión
+type Tokens struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	TokenType    string    `json:"token_type"`
+	ExpiresIn    int64     `json:"expires_in"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// rotate genera un par de tokens nuevo para la sesión y retorna sus valores
+// en claro
+func (s *Session) rotate(accessTTL, refreshTTL time.Duration, now time.Time) (*Tokens, error) {
+	access, err := newToken(AccessTokenPrefix)
+	if err != nil {
+		return nil, err
+	}
+	refresh, err := newToken(RefreshTokenPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	s.AccessHash = HashToken(access)
+	s.RefreshHash = HashToken(refresh)
+	s.AccessExpiresAt = now.Add(accessTTL)
+	s.RefreshExpiresAt = now.Add(refreshTTL)
+
+	return &Tokens{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(accessTTL / time.Second),
+		ExpiresAt:    s.AccessExpiresAt,
+	}, nil
+}
+
+// AccessActive indica si el access token de la sesión sigue siendo válido
+func (s *Session) AccessActive(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.AccessExpiresAt)
+}
+
+// RefreshActive indica si la sesión se puede renovar
+func (s *Session) RefreshActive(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.RefreshExpiresAt)
+}
+
+// Revoke cierra la sesión
+func (s *Session) Revoke(now time.Time) {
+	if s.RevokedAt == nil {
+		s.RevokedAt = &now
+	}
+}
+
+// HashToken calcula el hash con el que se guarda y busca un token. Los
+// tokens tienen 256 bits aleatorios, así que basta un hash rápido sin sal
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// newToken genera un token aleatorio con el prefijo indicado
+func newToken(prefix string) (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return prefix + base64.RawURLEncoding.EncodeToString(secret), nil
+}
@@ -0,0 +1,195 @@
+package user
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/yebrai/go-tasks-microservice/pkg/auth"
+	"github.com/yebrai/go-tasks-microservice/pkg/tenant"
+)
+
+// Límites de las contraseñas. bcrypt solo usa los primeros 72 bytes, así
+// que las más largas se rechazan en lugar de truncarlas en silencio
+const (
+	MinPasswordLength = 8
+	MaxPasswordLength = 72
+)
+
+// RoleAdmin rol que concede además el scope admin
+const RoleAdmin = "admin"
+
+// ErasedEmailDomain dominio de los emails de las cuentas seudonimizadas; es
+// un dominio reservado que no recibe correo
+const ErasedEmailDomain = "@erased.invalid"
+
+// passwordCost coste de bcrypt; los tests lo reducen
+var passwordCost = bcrypt.DefaultCost
+
+var (
+	ErrUserNotFound    = errors.New("user not found")
+	ErrEmailTaken      = errors.New("email already registered")
+	ErrInvalidUserData = errors.New("invalid user data")
+	ErrInvalidLogin    = errors.New("invalid email or password")
+	ErrAccountLocked   = errors.New("account temporarily locked after repeated failed logins")
+	ErrInvalidToken    = errors.New("invalid or expired token")
+	// ErrPasswordResetUnavailable no hay forma de entregar los tokens de
+	// restablecimiento
+	ErrPasswordResetUnavailable = errors.New("password reset is not available")
+)
+
+// User cuenta de usuario con contraseña. Ni el hash ni el estado del
+// bloqueo o del restablecimiento salen en las respuestas
+type User struct {
+	ID             string     `json:"id"`
+	Email          string     `json:"email"`
+	Name           string     `json:"name"`
+	PasswordHash   string     `json:"-"`
+	Roles          []string   `json:"roles,omitempty"`
+	Tenant         string     `json:"tenant,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	FailedLogins   int        `json:"-"`
+	LockedUntil    *time.Time `json:"-"`
+	ResetHash      string     `json:"-"`
+	ResetExpiresAt *time.Time `json:"-"`
+}
+
+// New crea un usuario con la contraseña ya cifrada
+func New(id, email, name, password string, roles []string, now time.Time) (*User, error) {
+	email, err := NormalizeEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidUserData)
+	}
+
+	hash, err := hashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	return &User{
+		ID:           id,
+		Email:        email,
+		Name:         name,
+		PasswordHash: hash,
+		Roles:        roles,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}, nil
+}
+
+// NormalizeEmail valida el email y lo pasa a minúsculas, que es como se
+// guarda y se busca
+func NormalizeEmail(email string) (string, error) {
+	address, err := mail.ParseAddress(strings.TrimSpace(email))
+	if err != nil || address.Name != "" {
+		return "", fmt.Errorf("%w: email is not valid", ErrInvalidUserData)
+	}
+	return strings.ToLower(address.Address), nil
+}
+
+// CheckPassword indica si la contraseña coincide con la del usuario
+func (u *User) CheckPassword(password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)) == nil
+}
+
+// SetPassword cambia la contraseña y anula el bloqueo y el restablecimiento
+// pendientes
+func (u *User) SetPassword(password string, now time.Time) error {
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	u.PasswordHash = hash
+	u.FailedLogins = 0
+	u.LockedUntil = nil
+	u.ResetHash = ""
+	u.ResetExpiresAt = nil
+	u.UpdatedAt = now
+	return nil
+}
+
+// Locked indica si la cuenta está bloqueada
+func (u *User) Locked(now time.Time) bool {
+	return u.LockedUntil != nil && now.Before(*u.LockedUntil)
+}
+
+// RecordFailedLogin cuenta un intento fallido y bloquea la cuenta durante
+// lockout al llegar a maxAttempts. Retorna si la cuenta queda bloqueada
+func (u *User) RecordFailedLogin(maxAttempts int, lockout time.Duration, now time.Time) bool {
+	u.FailedLogins++
+	if u.FailedLogins < maxAttempts {
+		return false
+	}
+
+	until := now.Add(lockout)
+	u.LockedUntil = &until
+	u.FailedLogins = 0
+	return true
+}
+
+// RecordLogin anula los intentos fallidos tras un login correcto. Retorna
+// si había algo que anular
+func (u *User) RecordLogin() bool {
+	if u.FailedLogins == 0 && u.LockedUntil == nil {
+		return false
+	}
+	u.FailedLogins = 0
+	u.LockedUntil = nil
+	return true
+}
+
+// Principal retorna el principal con el que se autentican las sesiones del
+// usuario. Todos pueden leer y escribir tareas; el rol admin da el scope
+// admin
+func (u *User) Principal() *auth.Principal {
+	scopes := []string{auth.ScopeTasksRead, auth.ScopeTasksWrite}
+	for _, role := range u.Roles {
+		if role == RoleAdmin {
+			scopes = append(scopes, auth.ScopeAdmin)
+			break
+		}
+	}
+
+	return &auth.Principal{
+		Subject: "user:" + u.ID,
+		Name:    u.Name,
+		Roles:   append([]string(nil), u.Roles...),
+		Scopes:  scopes,
+		Tenant:  u.TenantID(),
+	}
+}
+
+// TenantID tenant de la cuenta. Las cuentas anteriores a los tenants
+// pertenecen al tenant por defecto
+func (u *User) TenantID() string {
+	if u.Tenant == "" {
+		return tenant.DefaultID
+	}
+	return u.Tenant
+}
+
+// hashPassword valida la longitud de la contraseña y la cifra con bcrypt
+func hashPassword(password string) (string, error) {
+	if len(password) < MinPasswordLength {
+		return "", fmt.Errorf("%w: password must have at least %d characters", ErrInvalidUserData, MinPasswordLength)
+	}
+	if len(password) > MaxPasswordLength {
+		return "", fmt.Errorf("%w: password must have at most %d bytes", ErrInvalidUserData, MaxPasswordLength)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), passwordCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hash), nil
+}
@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"strings"
+)
+
+var (
+	ErrMissingCredentials = errors.New("missing credentials")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Authenticator valida una credencial y retorna el principal asociado
+type Authenticator interface {
+	Authenticate(ctx context.Context, credential string) (*Principal, error)
+}
+
+// Esquemas aceptados en la cabecera Authorization
+const (
+	SchemeBearer = "Bearer"
+	SchemeAPIKey = "ApiKey"
+)
+
+// Credentials separa una cabecera Authorization "<esquema> <credencial>".
+// El esquema se retorna tal cual; se compara sin distinguir mayúsculas
+func Credentials(header string) (scheme, credential string, ok bool) {
+	scheme, credential, found := strings.Cut(strings.TrimSpace(header), " ")
+	credential = strings.TrimSpace(credential)
+	if !found || scheme == "" || credential == "" {
+		return "", "", false
+	}
+	return scheme, credential, true
+}
+
+// BearerToken extrae el token de una cabecera Authorization "Bearer <token>"
+func BearerToken(header string) (string, bool) {
+	scheme, token, ok := Credentials(header)
+	if !ok || !strings.EqualFold(scheme, SchemeBearer) {
+		return "", false
+	}
+	return token, true
+}
+
+// StaticToken asocia un token fijo a un principal
+type StaticToken struct {
+	Token     string
+	Principal Principal
+}
+
+// StaticTokenAuthenticator autentica contra una lista fija de tokens
+type StaticTokenAuthenticator struct {
+	tokens []StaticToken
+}
+
+// NewStaticTokenAuthenticator crea un autenticador de tokens fijos
+func NewStaticTokenAuthenticator(tokens []StaticToken) *StaticTokenAuthenticator {
+	return &StaticTokenAuthenticator{
+		tokens: tokens,
+	}
+}
+
+// Authenticate busca el token comparando en tiempo constante
+func (a *StaticTokenAuthenticator) Authenticate(_ context.Context, credential string) (*Principal, error) {
+	if credential == "" {
+		return nil, ErrMissingCredentials
+	}
+
+	for _, token := range a.tokens {
+		if subtle.ConstantTimeCompare([]byte(token.Token), []byte(credential)) == 1 {
+			principal := token.Principal
+			return &principal, nil
+		}
+	}
+
+	return nil, ErrInvalidCredentials
+}
+
+// ChainAuthenticator prueba varios autenticadores en orden
+type ChainAuthenticator struct {
+	authenticators []Authenticator
+}
+
+// NewChainAuthenticator crea un autenticador que acepta la credencial si
+// alguno de los indicados la acepta
+func NewChainAuthenticator(authenticators ...Authenticator) *ChainAuthenticator {
+	return &ChainAuthenticator{
+		authenticators: authenticators,
+	}
+}
+
+// Authenticate retorna el primer principal autenticado. Si ninguno acepta
+// la credencial, prevalecen los errores distintos de credencial inválida
+// (por ejemplo, claves no disponibles)
+func (a *ChainAuthenticator) Authenticate(ctx context.Context, credential string) (*Principal, error) {
+	if credential == "" {
+		return nil, ErrMissingCredentials
+	}
+
+	err := ErrInvalidCredentials
+	for _, authenticator := range a.authenticators {
+		principal, authErr := authenticator.Authenticate(ctx, credential)
+		if authErr == nil {
+			return principal, nil
+		}
+		if !errors.Is(authErr, ErrInvalidCredentials) && !errors.Is(authErr, ErrMissingCredentials) {
+			err = authErr
+		}
+	}
+
+	return nil, err
+}
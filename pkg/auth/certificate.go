@@ -0,0 +1,43 @@
+package auth
+
+import "crypto/tls"
+
+// ClientCertificate asocia el subject de un certificado de cliente a un
+// principal. Subject usa el formato de pkix.Name.String(), como
+// "CN=sync-service,O=Acme"
+type ClientCertificate struct {
+	Subject   string
+	Principal Principal
+}
+
+// CertificateAuthenticator autentica por el certificado de cliente
+// verificado en el handshake mTLS
+type CertificateAuthenticator struct {
+	principals map[string]Principal
+}
+
+// NewCertificateAuthenticator crea un autenticador de certificados de
+// cliente
+func NewCertificateAuthenticator(certificates []ClientCertificate) *CertificateAuthenticator {
+	principals := make(map[string]Principal, len(certificates))
+	for _, certificate := range certificates {
+		principals[certificate.Subject] = certificate.Principal
+	}
+
+	return &CertificateAuthenticator{principals: principals}
+}
+
+// AuthenticateConnection retorna el principal del certificado de cliente
+// de la conexión. Solo cuenta un certificado verificado contra las CAs de
+// cliente; uno verificado pero sin principal asociado es inválido
+func (a *CertificateAuthenticator) AuthenticateConnection(state *tls.ConnectionState) (*Principal, error) {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil, ErrMissingCredentials
+	}
+
+	principal, ok := a.principals[state.VerifiedChains[0][0].Subject.String()]
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+	return &principal, nil
+}
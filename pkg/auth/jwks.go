@@ -0,0 +1,330 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultJWKSRefresh intervalo de recarga del JWKS si no se configura otro
+	DefaultJWKSRefresh = 10 * time.Minute
+	// jwksMinRefresh tiempo mínimo entre recargas forzadas por un kid
+	// desconocido o tras una recarga fallida, para que tokens con kids
+	// inventados o un emisor caído no saturen el emisor
+	jwksMinRefresh = 30 * time.Second
+	// jwksFetchTimeout tiempo máximo de una descarga del JWKS
+	jwksFetchTimeout = 10 * time.Second
+	// maxJWKSBytes tamaño máximo de un documento JWKS
+	maxJWKSBytes = 1 << 20
+)
+
+var (
+	// ErrKeySetUnavailable no se pudo cargar el JWKS y no hay claves en caché
+	ErrKeySetUnavailable = errors.New("signing keys unavailable")
+	// errKeyNotFound ninguna clave del JWKS corresponde al token
+	errKeyNotFound = errors.New("signing key not found")
+)
+
+// JSONWebKey clave pública (RSA, EC) o secreto (oct) de un JWKS
+type JSONWebKey struct {
+	KeyID     string
+	Algorithm string
+	// Key es *rsa.PublicKey, *ecdsa.PublicKey o []byte
+	Key interface{}
+}
+
+// supports indica si la clave puede verificar el algoritmo indicado
+func (k *JSONWebKey) supports(alg string) bool {
+	if k.Algorithm != "" && k.Algorithm != alg {
+		return false
+	}
+
+	switch key := k.Key.(type) {
+	case *rsa.PublicKey:
+		return alg == "RS256"
+	case *ecdsa.PublicKey:
+		return alg == "ES256" && key.Curve == elliptic.P256()
+	case []byte:
+		return alg == "HS256"
+	}
+	return false
+}
+
+// rawJWK representación JSON de una clave (RFC 7517)
+type rawJWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+}
+
+// ParseJWKS decodifica un documento JWKS. Las claves de cifrado y los tipos
+// no soportados se ignoran
+func ParseJWKS(data []byte) ([]*JSONWebKey, error) {
+	var document struct {
+		Keys []rawJWK `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %w", err)
+	}
+
+	keys := make([]*JSONWebKey, 0, len(document.Keys))
+	for _, raw := range document.Keys {
+		if raw.Use != "" && raw.Use != "sig" {
+			continue
+		}
+
+		key, err := raw.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWK %q: %w", raw.Kid, err)
+		}
+		if key == nil {
+			continue
+		}
+		keys = append(keys, &JSONWebKey{KeyID: raw.Kid, Algorithm: raw.Alg, Key: key})
+	}
+
+	return keys, nil
+}
+
+// publicKey construye la clave según su tipo; nil si no está soportado
+func (r rawJWK) publicKey() (interface{}, error) {
+	switch r.Kty {
+	case "RSA":
+		n, err := decodeBigInt(r.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(r.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		if r.Crv != "P-256" {
+			return nil, nil
+		}
+		x, err := decodeBigInt(r.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(r.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !elliptic.P256().IsOnCurve(x, y) {
+			return nil, errors.New("point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+
+	case "oct":
+		secret, err := base64.RawURLEncoding.DecodeString(r.K)
+		if err != nil || len(secret) == 0 {
+			return nil, errors.New("invalid symmetric key")
+		}
+		return secret, nil
+	}
+
+	return nil, nil
+}
+
+// decodeBigInt decodifica un entero base64url sin padding
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(data) == 0 {
+		return nil, errors.New("invalid base64url integer")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
+
+// JWKS conjunto de claves cargado de un fichero o una URL. Las claves se
+// cachean y se recargan cada refreshInterval, o antes si llega un token
+// firmado con un kid desconocido (rotación de claves). Solo hay una recarga
+// en curso: las peticiones que la necesitan esperan a esa misma
+type JWKS struct {
+	load            func(ctx context.Context) ([]byte, error)
+	source          string
+	refreshInterval time.Duration
+	now             func() time.Time
+
+	mu        sync.Mutex
+	keys      []*JSONWebKey
+	loadedAt  time.Time
+	attemptAt time.Time
+	// failed indica si el último intento de recarga falló
+	failed bool
+	// attempts recargas iniciadas, para no repetir una que ya terminó
+	attempts int
+	// loading se cierra al terminar la recarga en curso; nil si no hay
+	loading chan struct{}
+}
+
+// NewJWKSFromURL crea un JWKS que descarga las claves de la URL
+func NewJWKSFromURL(url string, refreshInterval time.Duration) *JWKS {
+	client := &http.Client{Timeout: jwksFetchTimeout}
+
+	return newJWKS(url, refreshInterval, func(ctx context.Context) ([]byte, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/json")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+		}
+		return io.ReadAll(io.LimitReader(resp.Body, maxJWKSBytes))
+	})
+}
+
+// NewJWKSFromFile crea un JWKS que lee las claves de un fichero local
+func NewJWKSFromFile(path string, refreshInterval time.Duration) *JWKS {
+	return newJWKS(path, refreshInterval, func(context.Context) ([]byte, error) {
+		return os.ReadFile(path)
+	})
+}
+
+func newJWKS(source string, refreshInterval time.Duration, load func(ctx context.Context) ([]byte, error)) *JWKS {
+	if refreshInterval <= 0 {
+		refreshInterval = DefaultJWKSRefresh
+	}
+
+	return &JWKS{
+		load:            load,
+		source:          source,
+		refreshInterval: refreshInterval,
+		now:             time.Now,
+	}
+}
+
+// Key retorna la clave que verifica un token con el kid y algoritmo
+// indicados. Sin kid solo se acepta si una única clave admite el algoritmo
+func (s *JWKS) Key(ctx context.Context, kid, alg string) (*JSONWebKey, error) {
+	s.mu.Lock()
+	now := s.now()
+	stale := s.keys == nil || now.Sub(s.loadedAt) >= s.refreshInterval
+	backoff := s.failed && now.Sub(s.attemptAt) < jwksMinRefresh
+	attempts := s.attempts
+	s.mu.Unlock()
+
+	if stale && !backoff {
+		s.refresh(ctx, attempts)
+	}
+
+	s.mu.Lock()
+	keys, attemptAt, attempts := s.keys, s.attemptAt, s.attempts
+	s.mu.Unlock()
+	if keys == nil {
+		return nil, ErrKeySetUnavailable
+	}
+
+	key, err := findKey(keys, kid, alg)
+	if errors.Is(err, errKeyNotFound) && now.Sub(attemptAt) >= jwksMinRefresh {
+		s.refresh(ctx, attempts)
+		s.mu.Lock()
+		keys = s.keys
+		s.mu.Unlock()
+		key, err = findKey(keys, kid, alg)
+	}
+	return key, err
+}
+
+// refresh recarga las claves fuera del lock; si falla se conservan las
+// anteriores. Si ya hay una recarga en curso espera a que termine, y no
+// hace nada si alguna empezó después de que el llamante leyera attempts
+func (s *JWKS) refresh(ctx context.Context, attempts int) {
+	s.mu.Lock()
+	if loading := s.loading; loading != nil {
+		s.mu.Unlock()
+		select {
+		case <-loading:
+		case <-ctx.Done():
+		}
+		return
+	}
+	if s.attempts != attempts {
+		s.mu.Unlock()
+		return
+	}
+	loading := make(chan struct{})
+	s.loading = loading
+	s.attempts++
+	now := s.now()
+	s.attemptAt = now
+	s.mu.Unlock()
+
+	// La descarga sirve a todas las peticiones que esperan: no se cancela
+	// con la que la inició
+	data, err := s.load(context.WithoutCancel(ctx))
+	var keys []*JSONWebKey
+	if err == nil {
+		keys, err = ParseJWKS(data)
+	}
+
+	s.mu.Lock()
+	if err == nil {
+		s.keys = keys
+		s.loadedAt = now
+	}
+	s.failed = err != nil
+	s.loading = nil
+	s.mu.Unlock()
+	close(loading)
+
+	if err != nil {
+		log.Printf("⚠️  Failed to load JWKS from %s: %v", s.source, err)
+	}
+}
+
+// findKey busca la clave entre las cacheadas
+func findKey(keys []*JSONWebKey, kid, alg string) (*JSONWebKey, error) {
+	var match *JSONWebKey
+	for _, key := range keys {
+		if !key.supports(alg) {
+			continue
+		}
+		if kid != "" && key.KeyID == kid {
+			return key, nil
+		}
+		if kid == "" {
+			if match != nil {
+				return nil, fmt.Errorf("%w: several keys match and the token has no kid", errKeyNotFound)
+			}
+			match = key
+		}
+	}
+
+	if match == nil {
+		return nil, errKeyNotFound
+	}
+	return match, nil
+}
@@ -0,0 +1,278 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// DefaultJWTLeeway margen para las diferencias de reloj con el emisor
+const DefaultJWTLeeway = 30 * time.Second
+
+// JWTAlgorithms algoritmos de firma soportados
+var JWTAlgorithms = []string{"RS256", "ES256", "HS256"}
+
+// KeyProvider resuelve la clave que verifica la firma de un token
+type KeyProvider interface {
+	Key(ctx context.Context, kid, alg string) (*JSONWebKey, error)
+}
+
+// JWTOptions reglas de validación de los tokens
+type JWTOptions struct {
+	// Issuer valor exigido en el claim iss
+	Issuer string
+	// Audience valor que debe contener el claim aud
+	Audience string
+	// Algorithms algoritmos aceptados; vacío acepta JWTAlgorithms
+	Algorithms []string
+	// Leeway margen aplicado a exp y nbf; 0 usa DefaultJWTLeeway
+	Leeway time.Duration
+}
+
+// Claims claims registrados y los que se trasladan al principal
+type Claims struct {
+	Issuer    string      `json:"iss"`
+	Subject   string      `json:"sub"`
+	Audience  audience    `json:"aud"`
+	ExpiresAt numericDate `json:"exp"`
+	NotBefore numericDate `json:"nbf"`
+	IssuedAt  numericDate `json:"iat"`
+	Name      string      `json:"name"`
+	Roles     []string    `json:"roles"`
+	// Scope scopes separados por espacios (RFC 8693); Scp es la variante en
+	// lista que emiten algunos proveedores
+	Scope string   `json:"scope"`
+	Scp   []string `json:"scp"`
+	// Tenant espacio de trabajo del usuario del token
+	Tenant string `json:"tenant"`
+}
+
+// Principal construye el principal autenticado por el token
+func (c *Claims) Principal() *Principal {
+	scopes := append(strings.Fields(c.Scope), c.Scp...)
+	return &Principal{
+		Subject: c.Subject,
+		Name:    c.Name,
+		Roles:   c.Roles,
+		Scopes:  scopes,
+		Tenant:  c.Tenant,
+	}
+}
+
+// audience claim aud: una cadena o una lista
+type audience []string
+
+// UnmarshalJSON acepta una cadena o una lista de cadenas
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+// contains indica si la audiencia incluye el valor
+func (a audience) contains(value string) bool {
+	for _, aud := range a {
+		if aud == value {
+			return true
+		}
+	}
+	return false
+}
+
+// numericDate segundos desde epoch (RFC 7519); cero si falta
+type numericDate struct {
+	time.Time
+}
+
+// UnmarshalJSON acepta segundos enteros o con decimales
+func (d *numericDate) UnmarshalJSON(data []byte) error {
+	var seconds float64
+	if err := json.Unmarshal(data, &seconds); err != nil {
+		return err
+	}
+	whole := int64(seconds)
+	d.Time = time.Unix(whole, int64((seconds-float64(whole))*1e9))
+	return nil
+}
+
+// JWTAuthenticator autentica tokens JWT firmados con RS256, ES256 o HS256
+// contra las claves de un KeyProvider, normalmente un JWKS
+type JWTAuthenticator struct {
+	keys       KeyProvider
+	issuer     string
+	audience   string
+	algorithms []string
+	leeway     time.Duration
+	now        func() time.Time
+}
+
+// NewJWTAuthenticator crea un autenticador de tokens JWT
+func NewJWTAuthenticator(keys KeyProvider, options JWTOptions) *JWTAuthenticator {
+	if len(options.Algorithms) == 0 {
+		options.Algorithms = JWTAlgorithms
+	}
+	if options.Leeway <= 0 {
+		options.Leeway = DefaultJWTLeeway
+	}
+
+	return &JWTAuthenticator{
+		keys:       keys,
+		issuer:     options.Issuer,
+		audience:   options.Audience,
+		algorithms: options.Algorithms,
+		leeway:     options.Leeway,
+		now:        time.Now,
+	}
+}
+
+// Authenticate verifica la firma y los claims del token
+func (a *JWTAuthenticator) Authenticate(ctx context.Context, credential string) (*Principal, error) {
+	claims, err := a.Verify(ctx, credential)
+	if err != nil {
+		return nil, err
+	}
+	return claims.Principal(), nil
+}
+
+// Verify verifica la firma y los claims del token y los retorna. Los
+// errores de token envuelven ErrInvalidCredentials
+func (a *JWTAuthenticator) Verify(ctx context.Context, token string) (*Claims, error) {
+	if token == "" {
+		return nil, ErrMissingCredentials
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, invalidToken("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, invalidToken("malformed header")
+	}
+	if !a.allows(header.Alg) {
+		return nil, invalidToken("algorithm " + header.Alg + " is not allowed")
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, invalidToken("malformed signature")
+	}
+
+	key, err := a.keys.Key(ctx, header.Kid, header.Alg)
+	if errors.Is(err, errKeyNotFound) {
+		return nil, invalidToken(err.Error())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !verifySignature(header.Alg, key, parts[0]+"."+parts[1], signature) {
+		return nil, invalidToken("invalid signature")
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, invalidToken("malformed claims")
+	}
+	if err := a.validate(&claims); err != nil {
+		return nil, err
+	}
+
+	return &claims, nil
+}
+
+// validate comprueba emisor, audiencia y vigencia
+func (a *JWTAuthenticator) validate(claims *Claims) error {
+	now := a.now()
+
+	switch {
+	case claims.Subject == "":
+		return invalidToken("missing sub")
+	case a.issuer != "" && claims.Issuer != a.issuer:
+		return invalidToken("unexpected iss")
+	case a.audience != "" && !claims.Audience.contains(a.audience):
+		return invalidToken("unexpected aud")
+	case claims.ExpiresAt.IsZero():
+		return invalidToken("missing exp")
+	case !now.Before(claims.ExpiresAt.Add(a.leeway)):
+		return invalidToken("token expired")
+	case !claims.NotBefore.IsZero() && now.Add(a.leeway).Before(claims.NotBefore.Time):
+		return invalidToken("token not yet valid")
+	}
+
+	return nil
+}
+
+// allows indica si el algoritmo está aceptado. "none" nunca lo está
+func (a *JWTAuthenticator) allows(alg string) bool {
+	for _, allowed := range a.algorithms {
+		if alg == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// verifySignature verifica la firma con una clave del tipo del algoritmo,
+// de modo que una clave pública no se puede usar como secreto HMAC
+func verifySignature(alg string, key *JSONWebKey, signingInput string, signature []byte) bool {
+	digest := sha256.Sum256([]byte(signingInput))
+
+	switch k := key.Key.(type) {
+	case *rsa.PublicKey:
+		return alg == "RS256" && rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], signature) == nil
+	case *ecdsa.PublicKey:
+		if alg != "ES256" || len(signature) != 64 {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		return ecdsa.Verify(k, digest[:], r, s)
+	case []byte:
+		if alg != "HS256" {
+			return false
+		}
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(signingInput))
+		return hmac.Equal(mac.Sum(nil), signature)
+	}
+
+	return false
+}
+
+// decodeSegment decodifica un segmento base64url de JSON
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// invalidToken envuelve ErrInvalidCredentials con el motivo
+func invalidToken(reason string) error {
+	return fmt.Errorf("%w: %s", ErrInvalidCredentials, reason)
+}
@@ -0,0 +1,311 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const (
+	testIssuer   = "https://issuer.example.com"
+	testAudience = "tasks-api"
+)
+
+var b64 = base64.RawURLEncoding
+
+// testKeys claves locales con las que se firman los tokens de los tests
+type testKeys struct {
+	rsa    *rsa.PrivateKey
+	ec     *ecdsa.PrivateKey
+	secret []byte
+}
+
+func newTestKeys(t *testing.T) testKeys {
+	t.Helper()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testKeys{rsa: rsaKey, ec: ecKey, secret: []byte("0123456789abcdef0123456789abcdef")}
+}
+
+// jwks publica las claves con los kids rsa-1, ec-1 y hs-1
+func (k testKeys) jwks(rsaKid string) []byte {
+	data, _ := json.Marshal(map[string]interface{}{"keys": []map[string]string{
+		{"kty": "RSA", "kid": rsaKid, "use": "sig", "alg": "RS256", "n": b64.EncodeToString(k.rsa.N.Bytes()), "e": b64.EncodeToString(big.NewInt(int64(k.rsa.E)).Bytes())},
+		{"kty": "EC", "kid": "ec-1", "crv": "P-256", "x": b64.EncodeToString(k.ec.X.FillBytes(make([]byte, 32))), "y": b64.EncodeToString(k.ec.Y.FillBytes(make([]byte, 32)))},
+		{"kty": "oct", "kid": "hs-1", "k": b64.EncodeToString(k.secret)},
+	}})
+	return data
+}
+
+// mint firma un token con la clave local del algoritmo
+func (k testKeys) mint(t *testing.T, alg, kid string, claims map[string]interface{}) string {
+	t.Helper()
+
+	header, _ := json.Marshal(map[string]string{"alg": alg, "typ": "JWT", "kid": kid})
+	payload, _ := json.Marshal(claims)
+	input := b64.EncodeToString(header) + "." + b64.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(input))
+
+	var signature []byte
+	switch alg {
+	case "RS256":
+		var err error
+		if signature, err = rsa.SignPKCS1v15(rand.Reader, k.rsa, crypto.SHA256, digest[:]); err != nil {
+			t.Fatal(err)
+		}
+	case "ES256":
+		r, s, err := ecdsa.Sign(rand.Reader, k.ec, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	case "HS256":
+		mac := hmac.New(sha256.New, k.secret)
+		mac.Write([]byte(input))
+		signature = mac.Sum(nil)
+	}
+
+	return input + "." + b64.EncodeToString(signature)
+}
+
+func validClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"iss":   testIssuer,
+		"aud":   []string{"other", testAudience},
+		"sub":   "user-1",
+		"name":  "Ada",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"iat":   time.Now().Unix(),
+		"roles": []string{"editor"},
+		"scope": "tasks:read tasks:write",
+	}
+}
+
+func newFileAuthenticator(t *testing.T, keys testKeys) *JWTAuthenticator {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, keys.jwks("rsa-1"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return NewJWTAuthenticator(NewJWKSFromFile(path, time.Minute), JWTOptions{Issuer: testIssuer, Audience: testAudience})
+}
+
+func TestJWTAuthenticator_AcceptsSupportedAlgorithms(t *testing.T) {
+	keys := newTestKeys(t)
+	authenticator := newFileAuthenticator(t, keys)
+
+	for alg, kid := range map[string]string{"RS256": "rsa-1", "ES256": "ec-1", "HS256": "hs-1"} {
+		t.Run(alg, func(t *testing.T) {
+			principal, err := authenticator.Authenticate(context.Background(), keys.mint(t, alg, kid, validClaims()))
+			if err != nil {
+				t.Fatalf("Expected a valid token, got %v", err)
+			}
+			if principal.Subject != "user-1" || principal.Name != "Ada" || !principal.HasRole("editor") || !principal.HasScope(ScopeTasksWrite) {
+				t.Errorf("Unexpected principal %+v", principal)
+			}
+		})
+	}
+}
+
+func TestJWTAuthenticator_RejectsInvalidTokens(t *testing.T) {
+	keys := newTestKeys(t)
+	authenticator := newFileAuthenticator(t, keys)
+
+	with := func(key string, value interface{}) map[string]interface{} {
+		claims := validClaims()
+		if value == nil {
+			delete(claims, key)
+		} else {
+			claims[key] = value
+		}
+		return claims
+	}
+
+	tampered := keys.mint(t, "RS256", "rsa-1", validClaims())
+	tampered = tampered[:len(tampered)-4] + "AAAA"
+
+	other := newTestKeys(t)
+
+	tests := map[string]string{
+		"expired":          keys.mint(t, "RS256", "rsa-1", with("exp", time.Now().Add(-time.Hour).Unix())),
+		"missing exp":      keys.mint(t, "RS256", "rsa-1", with("exp", nil)),
+		"not yet valid":    keys.mint(t, "RS256", "rsa-1", with("nbf", time.Now().Add(time.Hour).Unix())),
+		"wrong issuer":     keys.mint(t, "RS256", "rsa-1", with("iss", "https://evil.example.com")),
+		"wrong audience":   keys.mint(t, "RS256", "rsa-1", with("aud", "other")),
+		"missing subject":  keys.mint(t, "RS256", "rsa-1", with("sub", nil)),
+		"tampered":         tampered,
+		"unknown key":      other.mint(t, "RS256", "rsa-1", validClaims()),
+		"wrong key type":   keys.mint(t, "HS256", "rsa-1", validClaims()),
+		"alg none":         b64.EncodeToString([]byte(`{"alg":"none"}`)) + "." + b64.EncodeToString([]byte(`{"sub":"x"}`)) + ".",
+		"not a jwt":        "static-token",
+		"garbage segments": "a.b.c",
+	}
+
+	for name, token := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := authenticator.Authenticate(context.Background(), token); !errors.Is(err, ErrInvalidCredentials) {
+				t.Errorf("Expected ErrInvalidCredentials, got %v", err)
+			}
+		})
+	}
+}
+
+func TestJWKS_ReloadsOnKeyRotation(t *testing.T) {
+	keys := newTestKeys(t)
+	var kid atomic.Value
+	kid.Store("rsa-1")
+	var fetches atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		w.Write(keys.jwks(kid.Load().(string)))
+	}))
+	defer server.Close()
+
+	jwks := NewJWKSFromURL(server.URL, time.Hour)
+	now := time.Now()
+	jwks.now = func() time.Time { return now }
+	authenticator := NewJWTAuthenticator(jwks, JWTOptions{Issuer: testIssuer, Audience: testAudience})
+
+	if _, err := authenticator.Authenticate(context.Background(), keys.mint(t, "RS256", "rsa-1", validClaims())); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := authenticator.Authenticate(context.Background(), keys.mint(t, "RS256", "rsa-1", validClaims())); err != nil {
+		t.Fatal(err)
+	}
+	if fetches.Load() != 1 {
+		t.Fatalf("Expected the keys to be cached, got %d fetches", fetches.Load())
+	}
+
+	// El emisor rota la clave: el kid nuevo fuerza una recarga
+	kid.Store("rsa-2")
+	now = now.Add(jwksMinRefresh)
+	if _, err := authenticator.Authenticate(context.Background(), keys.mint(t, "RS256", "rsa-2", validClaims())); err != nil {
+		t.Fatalf("Expected the rotated key to be loaded, got %v", err)
+	}
+
+	// Los kids desconocidos no recargan más de una vez por intervalo
+	for i := 0; i < 3; i++ {
+		authenticator.Authenticate(context.Background(), keys.mint(t, "RS256", "rsa-9", validClaims()))
+	}
+	if fetches.Load() != 2 {
+		t.Errorf("Expected 2 fetches, got %d", fetches.Load())
+	}
+}
+
+func TestJWKS_UnavailableWithoutCachedKeys(t *testing.T) {
+	keys := newTestKeys(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	authenticator := NewJWTAuthenticator(NewJWKSFromURL(server.URL, time.Hour), JWTOptions{Issuer: testIssuer, Audience: testAudience})
+	_, err := authenticator.Authenticate(context.Background(), keys.mint(t, "RS256", "rsa-1", validClaims()))
+	if !errors.Is(err, ErrKeySetUnavailable) {
+		t.Errorf("Expected ErrKeySetUnavailable, got %v", err)
+	}
+}
+
+func TestJWKS_BacksOffAfterFailedRefresh(t *testing.T) {
+	var fetches atomic.Int32
+	jwks := newJWKS("test", time.Hour, func(context.Context) ([]byte, error) {
+		fetches.Add(1)
+		return nil, errors.New("connection refused")
+	})
+	now := time.Now()
+	jwks.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if _, err := jwks.Key(context.Background(), "rsa-1", "RS256"); !errors.Is(err, ErrKeySetUnavailable) {
+			t.Fatalf("Expected ErrKeySetUnavailable, got %v", err)
+		}
+	}
+	if fetches.Load() != 1 {
+		t.Fatalf("Expected a single fetch within the backoff, got %d", fetches.Load())
+	}
+
+	now = now.Add(jwksMinRefresh)
+	jwks.Key(context.Background(), "rsa-1", "RS256")
+	if fetches.Load() != 2 {
+		t.Errorf("Expected a new fetch after the backoff, got %d", fetches.Load())
+	}
+}
+
+func TestJWKS_SharesConcurrentRefreshes(t *testing.T) {
+	keys := newTestKeys(t)
+	release := make(chan struct{})
+	var fetches atomic.Int32
+	jwks := newJWKS("test", time.Hour, func(context.Context) ([]byte, error) {
+		fetches.Add(1)
+		<-release
+		return keys.jwks("rsa-1"), nil
+	})
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := jwks.Key(context.Background(), "rsa-1", "RS256")
+			errs <- err
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Expected every request to get the key, got %v", err)
+		}
+	}
+	if fetches.Load() != 1 {
+		t.Errorf("Expected a single fetch, got %d", fetches.Load())
+	}
+}
+
+func TestChainAuthenticator_FallsBackToJWT(t *testing.T) {
+	keys := newTestKeys(t)
+	chain := NewChainAuthenticator(
+		NewStaticTokenAuthenticator([]StaticToken{{Token: "static", Principal: Principal{Subject: "svc"}}}),
+		newFileAuthenticator(t, keys),
+	)
+
+	if principal, err := chain.Authenticate(context.Background(), "static"); err != nil || principal.Subject != "svc" {
+		t.Errorf("Expected the static token to authenticate, got %v %v", principal, err)
+	}
+	if principal, err := chain.Authenticate(context.Background(), keys.mint(t, "ES256", "ec-1", validClaims())); err != nil || principal.Subject != "user-1" {
+		t.Errorf("Expected the JWT to authenticate, got %v %v", principal, err)
+	}
+	if _, err := chain.Authenticate(context.Background(), "nope"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected ErrInvalidCredentials, got %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+package auth
+
+import "context"
+
+// Scopes de acceso a la API
+const (
+	ScopeTasksRead  = "tasks:read"
+	ScopeTasksWrite = "tasks:write"
+	ScopeAdmin      = "admin"
+)
+
+// Principal identifica a quien realiza una petición
+type Principal struct {
+	Subject string   `json:"subject"`
+	Name    string   `json:"name,omitempty"`
+	Roles   []string `json:"roles,omitempty"`
+	Scopes  []string `json:"scopes,omitempty"`
+	// Tenant espacio de trabajo al que está ligado el principal; vacío si
+	// puede elegirlo con la cabecera X-Tenant-ID
+	Tenant string `json:"tenant,omitempty"`
+	// APIKeyID clave de API con la que se autenticó; el principal actúa en
+	// nombre de su propietario. Vacío con el resto de credenciales
+	APIKeyID string `json:"api_key_id,omitempty"`
+}
+
+// Anonymous retorna el principal usado cuando la autenticación está
+// deshabilitada: sin identidad y con todos los scopes
+func Anonymous() *Principal {
+	return &Principal{
+		Name:   "anonymous",
+		Scopes: []string{ScopeTasksRead, ScopeTasksWrite, ScopeAdmin},
+	}
+}
+
+// Authenticated indica si el principal tiene una identidad verificada
+func (p *Principal) Authenticated() bool {
+	return p != nil && p.Subject != ""
+}
+
+// HasScope indica si el principal tiene el scope indicado. El scope admin
+// implica todos los demás
+func (p *Principal) HasScope(scope string) bool {
+	if p == nil {
+		return false
+	}
+
+	for _, s := range p.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasRole indica si el principal tiene el rol indicado
+func (p *Principal) HasRole(role string) bool {
+	if p == nil {
+		return false
+	}
+
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+type contextKey string
+
+const principalKey contextKey = "auth.principal"
+
+// WithPrincipal asocia el principal autenticado al contexto
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey, principal)
+}
+
+// PrincipalFromContext obtiene el principal autenticado del contexto
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey).(*Principal)
+	return principal, ok && principal != nil
+}
@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"sync"
+	"time"
+)
+
+// DefaultTicketTTL vigencia de un ticket si no se configura otra
+const DefaultTicketTTL = 30 * time.Second
+
+var ErrInvalidTicket = errors.New("invalid or expired ticket")
+
+type ticketEntry struct {
+	principal Principal
+	expiresAt time.Time
+}
+
+// TicketStore emite tickets de un solo uso y corta duración que permiten
+// abrir un WebSocket sin enviar el token en la URL
+type TicketStore struct {
+	ttl     time.Duration
+	tickets map[string]ticketEntry
+	mu      sync.Mutex
+}
+
+// NewTicketStore crea un almacén de tickets con la vigencia indicada
+func NewTicketStore(ttl time.Duration) *TicketStore {
+	if ttl <= 0 {
+		ttl = DefaultTicketTTL
+	}
+
+	return &TicketStore{
+		ttl:     ttl,
+		tickets: make(map[string]ticketEntry),
+	}
+}
+
+// Issue emite un ticket para el principal
+func (s *TicketStore) Issue(principal *Principal) (string, time.Time, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", time.Time{}, err
+	}
+	ticket := base64.RawURLEncoding.EncodeToString(buf)
+	expiresAt := time.Now().Add(s.ttl)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.purgeExpired()
+	s.tickets[ticket] = ticketEntry{
+		principal: *principal,
+		expiresAt: expiresAt,
+	}
+
+	return ticket, expiresAt, nil
+}
+
+// Redeem consume el ticket y retorna su principal
+func (s *TicketStore) Redeem(ticket string) (*Principal, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.tickets[ticket]
+	if !ok {
+		return nil, ErrInvalidTicket
+	}
+	delete(s.tickets, ticket)
+
+	if time.Now().After(entry.expiresAt) {
+		return nil, ErrInvalidTicket
+	}
+
+	principal := entry.principal
+	return &principal, nil
+}
+
+// purgeExpired elimina los tickets caducados; requiere tener el lock
+func (s *TicketStore) purgeExpired() {
+	now := time.Now()
+	for ticket, entry := range s.tickets {
+		if now.After(entry.expiresAt) {
+			delete(s.tickets, ticket)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultReloadInterval frecuencia con la que se comprueban los ficheros si
+// no se configura otra
+const DefaultReloadInterval = time.Minute
+
+// ErrNoClientCAs el bundle de CAs de cliente no contiene certificados
+var ErrNoClientCAs = errors.New("client CA bundle contains no certificates")
+
+// Status estado de los certificados cargados, para /health
+type Status struct {
+	Subject  string    `json:"subject"`
+	NotAfter time.Time `json:"not_after"`
+	// ExpiresInSeconds segundos hasta que caduca; negativo si ya caducó
+	ExpiresInSeconds int64 `json:"expires_in_seconds"`
+	// ClientCANotAfter caducidad más próxima de las CAs de cliente
+	ClientCANotAfter *time.Time `json:"client_ca_not_after,omitempty"`
+	ReloadedAt       time.Time  `json:"reloaded_at"`
+	// LastError error de la última recarga; se siguen sirviendo los
+	// certificados anteriores
+	LastError string `json:"last_error,omitempty"`
+}
+
+// fileStamp identifica la versión de un fichero
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// loaded certificados leídos de los ficheros
+type loaded struct {
+	certificate *tls.Certificate
+	leaf        *x509.Certificate
+	clientCAs   *x509.CertPool
+	caNotAfter  *time.Time
+}
+
+// Reloader sirve el certificado del servidor y las CAs de cliente desde
+// ficheros y los recarga cuando cambian, sin reiniciar el servidor. Una
+// recarga fallida mantiene los certificados anteriores
+type Reloader struct {
+	certFile     string
+	keyFile      string
+	clientCAFile string
+	interval     time.Duration
+
+	current    *loaded
+	stamps     map[string]fileStamp
+	reloadedAt time.Time
+	lastErr    error
+	mu         sync.RWMutex
+}
+
+// NewReloader carga el par cert/key y, si se indica clientCAFile, el bundle
+// PEM de CAs con el que se verifican los certificados de cliente. Un
+// interval no positivo usa DefaultReloadInterval
+func NewReloader(certFile, keyFile, clientCAFile string, interval time.Duration) (*Reloader, error) {
+	if interval <= 0 {
+		interval = DefaultReloadInterval
+	}
+
+	r := &Reloader{
+		certFile:     certFile,
+		keyFile:      keyFile,
+		clientCAFile: clientCAFile,
+		interval:     interval,
+	}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Reload vuelve a leer los ficheros
+func (r *Reloader) Reload() error {
+	stamps := r.readStamps()
+	current, err := r.load()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.stamps = stamps
+	r.lastErr = err
+	if err != nil {
+		return err
+	}
+	r.current = current
+	r.reloadedAt = time.Now()
+	return nil
+}
+
+// Run comprueba los ficheros cada intervalo y los recarga si cambian, hasta
+// que se cancela el contexto
+func (r *Reloader) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !r.changed() {
+				continue
+			}
+			if err := r.Reload(); err != nil {
+				log.Printf("⚠️  TLS certificate reload failed, keeping the previous one: %v", err)
+				continue
+			}
+			log.Printf("🔐 TLS certificates reloaded (expire %s)", r.Status().NotAfter.Format(time.RFC3339))
+		}
+	}
+}
+
+// GetCertificate implementa tls.Config.GetCertificate
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.current.certificate, nil
+}
+
+// TLSConfig retorna la configuración del servidor. Con CAs de cliente cada
+// handshake usa el bundle vigente y clientAuth decide si el certificado de
+// cliente es obligatorio
+func (r *Reloader) TLSConfig(clientAuth tls.ClientAuthType) *tls.Config {
+	config := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: r.GetCertificate,
+	}
+	if r.clientCAFile == "" {
+		return config
+	}
+
+	config.ClientAuth = clientAuth
+	config.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		r.mu.RLock()
+		clientCAs := r.current.clientCAs
+		r.mu.RUnlock()
+
+		return &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: r.GetCertificate,
+			ClientAuth:     clientAuth,
+			ClientCAs:      clientCAs,
+			NextProtos:     []string{"h2", "http/1.1"},
+		}, nil
+	}
+	return config
+}
+
+// Status retorna el estado de los certificados cargados
+func (r *Reloader) Status() Status {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	status := Status{
+		Subject:          r.current.leaf.Subject.String(),
+		NotAfter:         r.current.leaf.NotAfter,
+		ExpiresInSeconds: int64(time.Until(r.current.leaf.NotAfter).Seconds()),
+		ClientCANotAfter: r.current.caNotAfter,
+		ReloadedAt:       r.reloadedAt,
+	}
+	if r.lastErr != nil {
+		status.LastError = r.lastErr.Error()
+	}
+	return status
+}
+
+// load lee el par cert/key y el bundle de CAs de cliente
+func (r *Reloader) load() (*loaded, error) {
+	certificate, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse TLS certificate: %w", err)
+	}
+	result := &loaded{certificate: &certificate, leaf: leaf}
+
+	if r.clientCAFile == "" {
+		return result, nil
+	}
+
+	data, err := os.ReadFile(r.clientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client CA bundle: %w", err)
+	}
+	result.clientCAs = x509.NewCertPool()
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		ca, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse client CA: %w", err)
+		}
+		result.clientCAs.AddCert(ca)
+		if result.caNotAfter == nil || ca.NotAfter.Before(*result.caNotAfter) {
+			notAfter := ca.NotAfter
+			result.caNotAfter = &notAfter
+		}
+	}
+	if result.caNotAfter == nil {
+		return nil, ErrNoClientCAs
+	}
+
+	return result, nil
+}
+
+// files ficheros vigilados
+func (r *Reloader) files() []string {
+	files := []string{r.certFile, r.keyFile}
+	if r.clientCAFile != "" {
+		files = append(files, r.clientCAFile)
+	}
+	return files
+}
+
+// readStamps lee la versión actual de los ficheros. Los que no se pueden
+// leer quedan sin versión y cuentan como cambiados cuando reaparecen
+func (r *Reloader) readStamps() map[string]fileStamp {
+	stamps := make(map[string]fileStamp, 3)
+	for _, file := range r.files() {
+		if info, err := os.Stat(file); err == nil {
+			stamps[file] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+		}
+	}
+	return stamps
+}
+
+// changed indica si algún fichero cambió desde la última recarga
+func (r *Reloader) changed() bool {
+	stamps := r.readStamps()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, file := range r.files() {
+		if stamps[file] != r.stamps[file] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,156 @@
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yebrai/go-tasks-microservice/pkg/certs/certstest"
+)
+
+// serveTLS arranca un servidor HTTPS con la configuración del reloader
+func serveTLS(t *testing.T, reloader *Reloader) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{
+		Handler:   http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}),
+		TLSConfig: reloader.TLSConfig(tls.RequireAndVerifyClientCert),
+	}
+	go server.ServeTLS(listener, "", "")
+	t.Cleanup(func() { server.Close() })
+
+	return "https://" + listener.Addr().String()
+}
+
+// handshake hace una petición con una conexión nueva y retorna el
+// certificado del servidor
+func handshake(url string, roots *x509.CertPool, client tls.Certificate) (*x509.Certificate, error) {
+	httpClient := &http.Client{Transport: &http.Transport{
+		DisableKeepAlives: true,
+		TLSClientConfig: &tls.Config{
+			RootCAs:      roots,
+			Certificates: []tls.Certificate{client},
+			ServerName:   "localhost",
+		},
+	}}
+
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return resp.TLS.PeerCertificates[0], nil
+}
+
+func TestReloader_HotReloadsCertificatesAndClientCAs(t *testing.T) {
+	dir := t.TempDir()
+	serverCA := certstest.NewCA(t, "server-ca")
+	firstClients := certstest.NewCA(t, "first-client-ca")
+	secondClients := certstest.NewCA(t, "second-client-ca")
+
+	first := serverCA.IssueServer(t, "tasks", time.Now().Add(2*time.Hour))
+	certFile, keyFile := first.WriteFiles(t, dir)
+	caFile := filepath.Join(dir, "clients.pem")
+	certstest.WriteFile(t, caFile, firstClients.PEM)
+
+	reloader, err := NewReloader(certFile, keyFile, caFile, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go reloader.Run(ctx)
+
+	url := serveTLS(t, reloader)
+	roots := x509.NewCertPool()
+	roots.AddCert(serverCA.Certificate)
+	firstClient := firstClients.IssueClient(t, pkix.Name{CommonName: "sync-service"}).TLSCertificate(t)
+	secondClient := secondClients.IssueClient(t, pkix.Name{CommonName: "sync-service"}).TLSCertificate(t)
+
+	served, err := handshake(url, roots, firstClient)
+	if err != nil {
+		t.Fatalf("Expected the first client CA to be trusted: %v", err)
+	}
+	if served.SerialNumber.Cmp(first.Certificate.SerialNumber) != 0 {
+		t.Fatal("Expected the initial certificate to be served")
+	}
+	if _, err := handshake(url, roots, secondClient); err == nil {
+		t.Fatal("Expected clients of an unknown CA to be rejected")
+	}
+
+	// Rotación sin reiniciar el servidor. El bundle se escribe antes, así
+	// que cualquier recarga que vea el certificado nuevo también lo ve
+	certstest.WriteFile(t, caFile, secondClients.PEM)
+	second := serverCA.IssueServer(t, "tasks", time.Now().Add(48*time.Hour))
+	second.WriteFiles(t, dir)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !reloader.Status().NotAfter.Equal(second.Certificate.NotAfter) {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the rotated certificate to be loaded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	served, err = handshake(url, roots, secondClient)
+	if err != nil {
+		t.Fatalf("Expected the new client CA to be trusted: %v", err)
+	}
+	if served.SerialNumber.Cmp(second.Certificate.SerialNumber) != 0 {
+		t.Error("Expected the rotated certificate to be served")
+	}
+	if _, err := handshake(url, roots, firstClient); err == nil {
+		t.Error("Expected the removed client CA to be rejected")
+	}
+
+	// Un fichero inválido no interrumpe el servicio
+	certstest.WriteFile(t, certFile, []byte("not a certificate"))
+	if err := reloader.Reload(); err == nil {
+		t.Fatal("Expected an invalid certificate to fail")
+	}
+	status := reloader.Status()
+	if status.LastError == "" || !status.NotAfter.Equal(second.Certificate.NotAfter) {
+		t.Errorf("Expected the previous certificate and the error to be reported, got %+v", status)
+	}
+	if _, err := handshake(url, roots, secondClient); err != nil {
+		t.Errorf("Expected the previous certificate to keep being served: %v", err)
+	}
+}
+
+func TestReloader_Status(t *testing.T) {
+	dir := t.TempDir()
+	ca := certstest.NewCA(t, "server-ca")
+	notAfter := time.Now().Add(30 * 24 * time.Hour).Truncate(time.Second)
+	certFile, keyFile := ca.IssueServer(t, "tasks.example.com", notAfter).WriteFiles(t, dir)
+	caFile := filepath.Join(dir, "clients.pem")
+	certstest.WriteFile(t, caFile, ca.PEM)
+
+	reloader, err := NewReloader(certFile, keyFile, caFile, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status := reloader.Status()
+	if status.Subject != "CN=tasks.example.com" || !status.NotAfter.Equal(notAfter) {
+		t.Errorf("Unexpected status %+v", status)
+	}
+	if days := status.ExpiresInSeconds / 86400; days != 29 {
+		t.Errorf("Expected 29 full days left, got %d", days)
+	}
+	if status.ClientCANotAfter == nil || !status.ClientCANotAfter.Equal(ca.Certificate.NotAfter) {
+		t.Errorf("Expected the client CA expiry, got %v", status.ClientCANotAfter)
+	}
+
+	certstest.WriteFile(t, caFile, []byte("no certificates here"))
+	if err := reloader.Reload(); err != ErrNoClientCAs {
+		t.Errorf("Expected ErrNoClientCAs, got %v", err)
+	}
+}
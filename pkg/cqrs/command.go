@@ -1,6 +1,12 @@
 package cqrs
 
-import "context"
+import (
+	"context"
+	"errors"
+)
+
+// ErrHandlerNotFound no hay handler registrado para el tipo de comando
+var ErrHandlerNotFound = errors.New("no handler registered for command type")
 
 // CommandType representa el tipo de comando
 type CommandType string
@@ -20,3 +26,22 @@ type CommandBus interface {
 	Register(cmdType CommandType, handler CommandHandler) error
 	Dispatch(ctx context.Context, cmd Command) error
 }
+
+// AggregateCommand lo implementan los comandos que actúan sobre un agregado
+// existente
+type AggregateCommand interface {
+	Command
+	AggregateID() string
+}
+
+// CommandHandlerFunc adapta una función al contrato CommandHandler
+type CommandHandlerFunc func(ctx context.Context, cmd Command) error
+
+// Handle implementa la interfaz CommandHandler
+func (f CommandHandlerFunc) Handle(ctx context.Context, cmd Command) error {
+	return f(ctx, cmd)
+}
+
+// Middleware envuelve un handler para añadir comportamiento transversal
+// (bloqueos, autorización, auditoría...)
+type Middleware func(next CommandHandler) CommandHandler
@@ -0,0 +1,42 @@
+package cqrs
+
+import "context"
+
+type contextKey string
+
+const requestIDKey contextKey = "cqrs.request_id"
+
+// WithRequestID asocia un ID de petición al contexto para correlacionar
+// comandos con los eventos que producen
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	if requestID == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestIDFromContext obtiene el ID de petición del contexto, si existe
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
+
+const originKey contextKey = "cqrs.origin"
+
+// Origin procedencia de la petición que emite los comandos
+type Origin struct {
+	SourceIP  string
+	UserAgent string
+}
+
+// WithOrigin asocia al contexto la procedencia de la petición
+func WithOrigin(ctx context.Context, origin Origin) context.Context {
+	return context.WithValue(ctx, originKey, origin)
+}
+
+// OriginFromContext obtiene la procedencia de la petición del contexto; vacía
+// en los procesos internos
+func OriginFromContext(ctx context.Context) Origin {
+	origin, _ := ctx.Value(originKey).(Origin)
+	return origin
+}
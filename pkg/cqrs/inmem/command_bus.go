@@ -10,8 +10,9 @@ import (
 
 // CommandBus implementación en memoria del bus de comandos
 type CommandBus struct {
-	handlers map[cqrs.CommandType]cqrs.CommandHandler
-	mu       sync.RWMutex
+	handlers    map[cqrs.CommandType]cqrs.CommandHandler
+	middlewares []cqrs.Middleware
+	mu          sync.RWMutex
 }
 
 // NewCommandBus crea una nueva instancia del bus de comandos
@@ -41,6 +42,15 @@ func (b *CommandBus) Register(cmdType cqrs.CommandType, handler cqrs.CommandHand
 	return nil
 }
 
+// Use añade middlewares que envuelven a todos los handlers. El primero
+// registrado es el más externo
+func (b *CommandBus) Use(middlewares ...cqrs.Middleware) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.middlewares = append(b.middlewares, middlewares...)
+}
+
 // Dispatch ejecuta un comando usando el handler registrado
 func (b *CommandBus) Dispatch(ctx context.Context, cmd cqrs.Command) error {
 	if cmd == nil {
@@ -51,10 +61,15 @@ func (b *CommandBus) Dispatch(ctx context.Context, cmd cqrs.Command) error {
 
 	b.mu.RLock()
 	handler, exists := b.handlers[cmdType]
+	middlewares := b.middlewares
 	b.mu.RUnlock()
 
 	if !exists {
-		return fmt.Errorf("no handler registered for command type: %s", cmdType)
+		return fmt.Errorf("%w: %s", cqrs.ErrHandlerNotFound, cmdType)
+	}
+
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
 	}
 
 	return handler.Handle(ctx, cmd)
@@ -0,0 +1,63 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+)
+
+type bufferKey struct{}
+
+// bufferedEvent evento retenido junto al bus que debe publicarlo
+type bufferedEvent struct {
+	bus   EventBus
+	event task.DomainEvent
+}
+
+// Buffer retiene los eventos publicados durante una transacción para
+// publicarlos solo si se confirma
+type Buffer struct {
+	mu     sync.Mutex
+	events []bufferedEvent
+}
+
+// WithBuffer retorna un contexto en el que ObservableEventBus retiene los
+// eventos en el buffer en lugar de publicarlos
+func WithBuffer(ctx context.Context) (context.Context, *Buffer) {
+	buffer := &Buffer{}
+	return context.WithValue(ctx, bufferKey{}, buffer), buffer
+}
+
+// bufferFromContext obtiene el buffer del contexto, si existe
+func bufferFromContext(ctx context.Context) *Buffer {
+	buffer, _ := ctx.Value(bufferKey{}).(*Buffer)
+	return buffer
+}
+
+// add retiene un evento
+func (b *Buffer) add(bus EventBus, event task.DomainEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.events = append(b.events, bufferedEvent{bus: bus, event: event})
+}
+
+// Flush publica en orden los eventos retenidos y vacía el buffer. ctx no
+// debe contener el propio buffer
+func (b *Buffer) Flush(ctx context.Context) error {
+	b.mu.Lock()
+	pending := b.events
+	b.events = nil
+	b.mu.Unlock()
+
+	var errs []error
+	for _, buffered := range pending {
+		if err := buffered.bus.Publish(ctx, buffered.event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
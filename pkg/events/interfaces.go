@@ -13,3 +13,8 @@ type EventBus interface {
 type EventHandler interface {
 	Handle(ctx context.Context, event task.DomainEvent) error
 }
+
+// Subscriber lo implementan los buses que admiten suscriptores locales
+type Subscriber interface {
+	Subscribe(handler EventHandler)
+}
@@ -0,0 +1,82 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/yebrai/go-tasks-microservice/internal/task"
+	"github.com/yebrai/go-tasks-microservice/pkg/auth"
+	"github.com/yebrai/go-tasks-microservice/pkg/cqrs"
+	"github.com/yebrai/go-tasks-microservice/pkg/tenant"
+)
+
+// ObservableEventBus decora un EventBus notificando además a los
+// suscriptores locales (por ejemplo, los clientes WebSocket)
+type ObservableEventBus struct {
+	inner       EventBus
+	subscribers []EventHandler
+	mu          sync.RWMutex
+}
+
+// NewObservableEventBus crea un EventBus observable sobre el bus indicado
+func NewObservableEventBus(inner EventBus) *ObservableEventBus {
+	return &ObservableEventBus{
+		inner: inner,
+	}
+}
+
+// Subscribe registra un handler que recibirá todos los eventos publicados
+func (b *ObservableEventBus) Subscribe(handler EventHandler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.subscribers = append(b.subscribers, handler)
+}
+
+// Publish correlaciona el evento con la petición en curso, su tenant y su
+// actor, lo publica en el bus subyacente y lo entrega a los suscriptores
+// locales. Dentro de una transacción (WithBuffer) el evento se retiene
+// hasta la confirmación
+func (b *ObservableEventBus) Publish(ctx context.Context, event task.DomainEvent) error {
+	if correlated, ok := event.(task.CorrelatedEvent); ok && correlated.CorrelationID() == "" {
+		correlated.Correlate(cqrs.RequestIDFromContext(ctx))
+	}
+	if scoped, ok := event.(task.TenantEvent); ok && scoped.TenantID() == "" {
+		scoped.AssignTenant(tenant.FromContext(ctx))
+	}
+	if attributed, ok := event.(task.AttributedEvent); ok && attributed.EventActor() == nil {
+		if principal, ok := auth.PrincipalFromContext(ctx); ok {
+			attributed.AssignActor(task.Actor{Subject: principal.Subject, Name: principal.Name})
+		}
+	}
+	if trace := traceFromContext(ctx); trace != nil {
+		trace.add(event.AggregateID())
+	}
+
+	if buffer := bufferFromContext(ctx); buffer != nil {
+		buffer.add(b, event)
+		return nil
+	}
+
+	// Los suscriptores locales reciben el evento aunque falle el bus externo
+	publishErr := b.inner.Publish(ctx, event)
+
+	b.mu.RLock()
+	subscribers := make([]EventHandler, len(b.subscribers))
+	copy(subscribers, b.subscribers)
+	b.mu.RUnlock()
+
+	for _, subscriber := range subscribers {
+		if err := subscriber.Handle(ctx, event); err != nil {
+			fmt.Printf("⚠️  Event subscriber failed for %s: %v\n", event.EventName(), err)
+		}
+	}
+
+	return publishErr
+}
+
+// Close cierra el bus subyacente
+func (b *ObservableEventBus) Close() error {
+	return b.inner.Close()
+}
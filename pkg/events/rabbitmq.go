@@ -7,6 +7,7 @@ import (
 
 	"github.com/yebrai/go-tasks-microservice/internal/task"
 	"github.com/yebrai/go-tasks-microservice/pkg/rabbitmq"
+	"github.com/yebrai/go-tasks-microservice/pkg/tenant"
 )
 
 type RabbitMQEventBus struct {
@@ -19,15 +20,14 @@ func NewRabbitMQEventBus(client *rabbitmq.Client) *RabbitMQEventBus {
 	}
 }
 
-func (r *RabbitMQEventBus) Publish(_ context.Context, event task.DomainEvent) error {
+func (r *RabbitMQEventBus) Publish(ctx context.Context, event task.DomainEvent) error {
 	// Serializar evento a JSON
 	payload, err := json.Marshal(event)
 	if err != nil {
 		return fmt.Errorf("failed to marshal event: %w", err)
 	}
 
-	// Usar event name como routing key
-	routingKey := event.EventName()
+	routingKey := RoutingKey(ctx, event)
 
 	// Publicar a RabbitMQ con context handling
 	if err := r.client.Publish(routingKey, payload); err != nil {
@@ -35,11 +35,22 @@ func (r *RabbitMQEventBus) Publish(_ context.Context, event task.DomainEvent) er
 	}
 
 	fmt.Printf("📤 Event published to RabbitMQ: %s - %s\n",
-		event.EventName(),
+		routingKey,
 		event.AggregateID())
 	return nil
 }
 
+// RoutingKey routing key de un evento: su tenant seguido del nombre del
+// evento (<tenant>.task.created), para que cada consumidor pueda enlazar
+// solo los tenants que le interesan
+func RoutingKey(ctx context.Context, event task.DomainEvent) string {
+	tenantID := tenant.FromContext(ctx)
+	if scoped, ok := event.(task.TenantEvent); ok && scoped.TenantID() != "" {
+		tenantID = scoped.TenantID()
+	}
+	return tenantID + "." + event.EventName()
+}
+
 func (r *RabbitMQEventBus) Close() error {
 	fmt.Printf("✅ RabbitMQ EventBus closed\n")
 	return nil
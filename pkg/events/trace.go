@@ -0,0 +1,49 @@
+package events
+
+import (
+	"context"
+	"sync"
+)
+
+type traceKey struct{}
+
+// Trace registra los agregados de los eventos publicados con un contexto,
+// para saber a qué tareas afectó un comando
+type Trace struct {
+	mu  sync.Mutex
+	ids []string
+}
+
+// WithTrace retorna un contexto en el que ObservableEventBus anota en la
+// traza el agregado de cada evento publicado
+func WithTrace(ctx context.Context) (context.Context, *Trace) {
+	trace := &Trace{}
+	return context.WithValue(ctx, traceKey{}, trace), trace
+}
+
+// traceFromContext obtiene la traza del contexto, si existe
+func traceFromContext(ctx context.Context) *Trace {
+	trace, _ := ctx.Value(traceKey{}).(*Trace)
+	return trace
+}
+
+// add anota un agregado una sola vez
+func (t *Trace) add(id string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, existing := range t.ids {
+		if existing == id {
+			return
+		}
+	}
+	t.ids = append(t.ids, id)
+}
+
+// AggregateIDs retorna, en orden de publicación, los agregados anotados
+func (t *Trace) AggregateIDs() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return append([]string(nil), t.ids...)
+}
@@ -0,0 +1,110 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memoryEntry registro en memoria y expiración de su reserva
+type memoryEntry struct {
+	record      Record
+	lockedUntil time.Time
+}
+
+// MemoryStore implementación en memoria de Store para una sola instancia
+type MemoryStore struct {
+	ttl     time.Duration
+	entries map[string]*memoryEntry
+	mu      sync.Mutex
+}
+
+// NewMemoryStore crea un almacén en memoria. Un ttl no positivo usa
+// DefaultTTL
+func NewMemoryStore(ttl time.Duration) *MemoryStore {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+
+	return &MemoryStore{
+		ttl:     ttl,
+		entries: make(map[string]*memoryEntry),
+	}
+}
+
+// Acquire implementa Store
+func (s *MemoryStore) Acquire(_ context.Context, key, owner, fingerprint string, lockTTL time.Duration) (*Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	entry, ok := s.entries[key]
+	if ok && now.After(entry.record.ExpiresAt) {
+		delete(s.entries, key)
+		ok = false
+	}
+
+	if ok {
+		if entry.record.Completed() || now.Before(entry.lockedUntil) {
+			record := entry.record
+			return &record, false, nil
+		}
+		entry.lockedUntil = now.Add(lockTTL)
+		record := entry.record
+		return &record, true, nil
+	}
+
+	entry = &memoryEntry{
+		record: Record{
+			Key:         key,
+			Owner:       owner,
+			Fingerprint: fingerprint,
+			ExpiresAt:   now.Add(s.ttl),
+		},
+		lockedUntil: now.Add(lockTTL),
+	}
+	s.entries[key] = entry
+
+	record := entry.record
+	return &record, true, nil
+}
+
+// Complete implementa Store
+func (s *MemoryStore) Complete(_ context.Context, key string, response Response) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok || entry.record.Completed() {
+		return ErrNotAcquired
+	}
+
+	entry.record.Response = &response
+	return nil
+}
+
+// Release implementa Store
+func (s *MemoryStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.entries[key]; ok && !entry.record.Completed() {
+		delete(s.entries, key)
+	}
+	return nil
+}
+
+// Purge implementa Store
+func (s *MemoryStore) Purge(_ context.Context, owner string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	purged := 0
+	for key, entry := range s.entries {
+		if entry.record.Owner == owner {
+			delete(s.entries, key)
+			purged++
+		}
+	}
+	return purged, nil
+}
@@ -0,0 +1,151 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// mongoDocument documento de la colección de claves de idempotencia
+type mongoDocument struct {
+	Key         string    `bson:"_id"`
+	Owner       string    `bson:"owner"`
+	Fingerprint string    `bson:"fingerprint"`
+	Response    *Response `bson:"response,omitempty"`
+	LockedUntil time.Time `bson:"locked_until"`
+	ExpiresAt   time.Time `bson:"expires_at"`
+}
+
+// MongoStore implementación MongoDB de Store. Los documentos caducan con un
+// índice TTL sobre expires_at
+type MongoStore struct {
+	collection *mongo.Collection
+	ttl        time.Duration
+}
+
+// NewMongoStore crea el almacén sobre la colección idempotency_keys. Un ttl
+// no positivo usa DefaultTTL
+func NewMongoStore(db *mongo.Database, ttl time.Duration) *MongoStore {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+
+	return &MongoStore{
+		collection: db.Collection("idempotency_keys"),
+		ttl:        ttl,
+	}
+}
+
+// EnsureIndexes crea el índice TTL de la colección y el índice por
+// propietario que usa Purge
+func (s *MongoStore) EnsureIndexes(ctx context.Context) error {
+	_, err := s.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+		{Keys: bson.D{{Key: "owner", Value: 1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create idempotency indexes: %w", err)
+	}
+
+	return nil
+}
+
+// Acquire implementa Store
+func (s *MongoStore) Acquire(ctx context.Context, key, owner, fingerprint string, lockTTL time.Duration) (*Record, bool, error) {
+	now := time.Now()
+	doc := mongoDocument{
+		Key:         key,
+		Owner:       owner,
+		Fingerprint: fingerprint,
+		LockedUntil: now.Add(lockTTL),
+		ExpiresAt:   now.Add(s.ttl),
+	}
+
+	_, err := s.collection.InsertOne(ctx, doc)
+	if err == nil {
+		return doc.record(), true, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return nil, false, fmt.Errorf("failed to acquire idempotency key: %w", err)
+	}
+
+	// La clave ya existe: ocuparla si la petición que la reservó la abandonó
+	var existing mongoDocument
+	err = s.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": key, "response": nil, "locked_until": bson.M{"$lt": now}},
+		bson.M{"$set": bson.M{"locked_until": now.Add(lockTTL)}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&existing)
+	if err == nil {
+		return existing.record(), true, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, false, fmt.Errorf("failed to acquire idempotency key: %w", err)
+	}
+
+	err = s.collection.FindOne(ctx, bson.M{"_id": key}).Decode(&existing)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// Caducó o se liberó entre ambas consultas
+		return s.Acquire(ctx, key, owner, fingerprint, lockTTL)
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to find idempotency key: %w", err)
+	}
+
+	return existing.record(), false, nil
+}
+
+// Complete implementa Store
+func (s *MongoStore) Complete(ctx context.Context, key string, response Response) error {
+	result, err := s.collection.UpdateOne(ctx,
+		bson.M{"_id": key, "response": nil},
+		bson.M{"$set": bson.M{"response": response}},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to store idempotent response: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotAcquired
+	}
+
+	return nil
+}
+
+// Release implementa Store
+func (s *MongoStore) Release(ctx context.Context, key string) error {
+	_, err := s.collection.DeleteOne(ctx, bson.M{"_id": key, "response": nil})
+	if err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+// Purge implementa Store
+func (s *MongoStore) Purge(ctx context.Context, owner string) (int, error) {
+	result, err := s.collection.DeleteMany(ctx, bson.M{"owner": owner})
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge idempotency keys: %w", err)
+	}
+
+	return int(result.DeletedCount), nil
+}
+
+// record convierte el documento al registro del almacén
+func (d *mongoDocument) record() *Record {
+	return &Record{
+		Key:         d.Key,
+		Owner:       d.Owner,
+		Fingerprint: d.Fingerprint,
+		Response:    d.Response,
+		ExpiresAt:   d.ExpiresAt,
+	}
+}
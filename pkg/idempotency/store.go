@@ -0,0 +1,75 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"time"
+)
+
+const (
+	// DefaultTTL tiempo por defecto que se conserva una respuesta
+	DefaultTTL = 24 * time.Hour
+	// DefaultLockTTL tiempo tras el que una petición en curso se considera
+	// abandonada y otra puede ocupar su clave
+	DefaultLockTTL = 30 * time.Second
+)
+
+// ErrNotAcquired la clave no está reservada por quien intenta completarla
+var ErrNotAcquired = errors.New("idempotency key not acquired")
+
+// Response respuesta almacenada para reproducirla en los reintentos
+type Response struct {
+	Status int         `bson:"status"`
+	Header http.Header `bson:"header"`
+	Body   []byte      `bson:"body"`
+}
+
+// Record estado de una clave de idempotencia
+type Record struct {
+	Key string
+	// Owner resumen del tenant y el sujeto que usaron la clave; permite
+	// borrar sus respuestas sin guardar el sujeto en claro
+	Owner       string
+	Fingerprint string
+	// Response nil mientras la petición original sigue en curso
+	Response  *Response
+	ExpiresAt time.Time
+}
+
+// Completed indica si la petición original ya respondió
+func (r *Record) Completed() bool {
+	return r.Response != nil
+}
+
+// Store persiste las claves de idempotencia y sus respuestas
+type Store interface {
+	// Acquire reserva la clave para una nueva petición. Si ya existe retorna
+	// su registro y false, salvo que la petición que la reservó la haya
+	// abandonado más de lockTTL, en cuyo caso la reserva de nuevo
+	Acquire(ctx context.Context, key, owner, fingerprint string, lockTTL time.Duration) (*Record, bool, error)
+	// Complete guarda la respuesta de una clave reservada
+	Complete(ctx context.Context, key string, response Response) error
+	// Release libera una clave reservada sin respuesta para que pueda
+	// reintentarse
+	Release(ctx context.Context, key string) error
+	// Purge borra los registros de un propietario y retorna cuántos borró
+	Purge(ctx context.Context, owner string) (int, error)
+}
+
+// Owner resume el tenant y el sujeto que usan una clave
+func Owner(tenantID, subject string) string {
+	return Fingerprint([]byte(tenantID), []byte(subject))
+}
+
+// Fingerprint resume los datos que identifican una petición
+func Fingerprint(parts ...[]byte) string {
+	hash := sha256.New()
+	for _, part := range parts {
+		hash.Write(part)
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,97 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func assertJSON(t *testing.T, got []byte, want string) {
+	t.Helper()
+
+	var g, w interface{}
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatalf("invalid result %s: %v", got, err)
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatalf("invalid expectation %s: %v", want, err)
+	}
+	if !reflect.DeepEqual(g, w) {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	got, err := MergePatch(
+		[]byte(`{"title":"a","due_date":"2024-01-01","tags":{"x":1,"y":2}}`),
+		[]byte(`{"title":"b","due_date":null,"tags":{"y":null,"z":3}}`),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, got, `{"title":"b","tags":{"x":1,"z":3}}`)
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		patch   string
+		want    string
+		wantErr error
+	}{
+		{
+			name:  "add, replace and remove",
+			doc:   `{"title":"a","description":"d","list":[1,3]}`,
+			patch: `[{"op":"replace","path":"/title","value":"b"},{"op":"remove","path":"/description"},{"op":"add","path":"/list/1","value":2},{"op":"add","path":"/list/-","value":4}]`,
+			want:  `{"title":"b","list":[1,2,3,4]}`,
+		},
+		{
+			name:  "move and copy",
+			doc:   `{"a":{"b":"x"},"c":[]}`,
+			patch: `[{"op":"copy","from":"/a/b","path":"/c/0"},{"op":"move","from":"/a/b","path":"/d"}]`,
+			want:  `{"a":{},"c":["x"],"d":"x"}`,
+		},
+		{
+			name:  "escaped pointer",
+			doc:   `{"a/b":1,"m~n":2}`,
+			patch: `[{"op":"replace","path":"/a~1b","value":3},{"op":"remove","path":"/m~0n"}]`,
+			want:  `{"a/b":3}`,
+		},
+		{
+			name:    "failed test aborts the patch",
+			doc:     `{"version":2}`,
+			patch:   `[{"op":"test","path":"/version","value":1},{"op":"replace","path":"/version","value":3}]`,
+			wantErr: ErrTestFailed,
+		},
+		{
+			name:    "replace missing member",
+			doc:     `{}`,
+			patch:   `[{"op":"replace","path":"/title","value":"a"}]`,
+			wantErr: ErrPathNotFound,
+		},
+		{
+			name:    "unknown op",
+			doc:     `{}`,
+			patch:   `[{"op":"merge","path":"/a","value":1}]`,
+			wantErr: ErrInvalidPatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply([]byte(tt.doc), []byte(tt.patch))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertJSON(t, got, tt.want)
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Document subconjunto de un documento OpenAPI 3.1 necesario para validar
+// peticiones
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+// Components definiciones reutilizables del documento
+type Components struct {
+	Schemas    map[string]*Schema    `json:"schemas"`
+	Parameters map[string]*Parameter `json:"parameters"`
+}
+
+// PathItem operaciones de una ruta indexadas por método en minúsculas
+type PathItem map[string]json.RawMessage
+
+// Operation operación de una ruta
+type Operation struct {
+	OperationID string       `json:"operationId"`
+	Parameters  []*Parameter `json:"parameters"`
+	RequestBody *RequestBody `json:"requestBody"`
+}
+
+// Parameter parámetro de ruta, query o cabecera
+type Parameter struct {
+	Ref      string  `json:"$ref"`
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+// RequestBody cuerpo aceptado por una operación
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// MediaType esquema del cuerpo para un media type
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// methods métodos HTTP que puede definir un PathItem
+var methods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// Parse decodifica un documento OpenAPI en formato JSON
+func Parse(data []byte) (*Document, error) {
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, fmt.Errorf("unsupported OpenAPI version %q", doc.OpenAPI)
+	}
+
+	for path, item := range doc.Paths {
+		for method := range item {
+			if !isMethod(method) {
+				continue
+			}
+			if _, err := doc.Operation(strings.ToUpper(method), path); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &doc, nil
+}
+
+// Operation retorna la operación de un método y ruta en formato OpenAPI
+// (/tasks/{id}), o nil si el documento no la define
+func (d *Document) Operation(method, path string) (*Operation, error) {
+	item, ok := d.Paths[path]
+	if !ok {
+		return nil, nil
+	}
+
+	raw, ok := item[strings.ToLower(method)]
+	if !ok {
+		return nil, nil
+	}
+
+	var operation Operation
+	if err := json.Unmarshal(raw, &operation); err != nil {
+		return nil, fmt.Errorf("invalid operation %s %s: %w", method, path, err)
+	}
+
+	// Los parámetros comunes de la ruta aplican a todas sus operaciones
+	if common, ok := item["parameters"]; ok {
+		var parameters []*Parameter
+		if err := json.Unmarshal(common, &parameters); err != nil {
+			return nil, fmt.Errorf("invalid parameters for %s: %w", path, err)
+		}
+		operation.Parameters = append(parameters, operation.Parameters...)
+	}
+
+	for i, parameter := range operation.Parameters {
+		if parameter.Ref == "" {
+			continue
+		}
+		resolved, ok := d.Components.Parameters[strings.TrimPrefix(parameter.Ref, "#/components/parameters/")]
+		if !ok {
+			return nil, fmt.Errorf("unresolved reference %s in %s %s", parameter.Ref, method, path)
+		}
+		operation.Parameters[i] = resolved
+	}
+
+	return &operation, nil
+}
+
+// Operations lista las operaciones definidas como "MÉTODO ruta"
+func (d *Document) Operations() []string {
+	var operations []string
+	for path, item := range d.Paths {
+		for method := range item {
+			if isMethod(method) {
+				operations = append(operations, strings.ToUpper(method)+" "+path)
+			}
+		}
+	}
+	return operations
+}
+
+// isMethod indica si la clave de un PathItem es un método HTTP
+func isMethod(key string) bool {
+	for _, method := range methods {
+		if key == method {
+			return true
+		}
+	}
+	return false
+}
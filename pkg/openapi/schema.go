@@ -0,0 +1,299 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Schema subconjunto de JSON Schema (2020-12) usado por el documento
+type Schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 SchemaType         `json:"type"`
+	Format               string             `json:"format"`
+	Enum                 []interface{}      `json:"enum"`
+	Properties           map[string]*Schema `json:"properties"`
+	Required             []string           `json:"required"`
+	AdditionalProperties *bool              `json:"additionalProperties"`
+	Items                *Schema            `json:"items"`
+	OneOf                []*Schema          `json:"oneOf"`
+	MinLength            *int               `json:"minLength"`
+	MaxLength            *int               `json:"maxLength"`
+	MinItems             *int               `json:"minItems"`
+	MaxItems             *int               `json:"maxItems"`
+	Minimum              *float64           `json:"minimum"`
+	Maximum              *float64           `json:"maximum"`
+	Pattern              string             `json:"pattern"`
+}
+
+// SchemaType tipo o lista de tipos admitidos ("null" para valores nulos)
+type SchemaType []string
+
+// UnmarshalJSON acepta un tipo o una lista de tipos
+func (t *SchemaType) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = SchemaType{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*t = list
+	return nil
+}
+
+// ValidationError valor que no cumple el esquema
+type ValidationError struct {
+	Field   string
+	Code    string
+	Message string
+}
+
+// Error implementa la interfaz error
+func (e ValidationError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return e.Field + ": " + e.Message
+}
+
+var (
+	patterns   = make(map[string]*regexp.Regexp)
+	patternsMu sync.Mutex
+)
+
+// compilePattern compila y cachea las expresiones de pattern
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	patternsMu.Lock()
+	defer patternsMu.Unlock()
+
+	if re, ok := patterns[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	patterns[pattern] = re
+	return re, nil
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// resolve sigue las referencias a components/schemas
+func (d *Document) resolve(schema *Schema) (*Schema, error) {
+	for schema != nil && schema.Ref != "" {
+		resolved, ok := d.Components.Schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")]
+		if !ok {
+			return nil, fmt.Errorf("unresolved reference %s", schema.Ref)
+		}
+		schema = resolved
+	}
+	return schema, nil
+}
+
+// validateValue valida un valor JSON decodificado contra el esquema y
+// acumula los errores con la ruta del campo
+func (d *Document) validateValue(schema *Schema, value interface{}, field string, errs *[]ValidationError) {
+	schema, err := d.resolve(schema)
+	if err != nil {
+		*errs = append(*errs, ValidationError{Field: field, Code: "schema", Message: err.Error()})
+		return
+	}
+	if schema == nil {
+		return
+	}
+
+	if len(schema.OneOf) > 0 {
+		matches := 0
+		for _, candidate := range schema.OneOf {
+			var candidateErrs []ValidationError
+			d.validateValue(candidate, value, field, &candidateErrs)
+			if len(candidateErrs) == 0 {
+				matches++
+			}
+		}
+		if matches != 1 {
+			*errs = append(*errs, ValidationError{Field: field, Code: "oneOf", Message: "must match exactly one schema"})
+		}
+		return
+	}
+
+	if len(schema.Type) > 0 && !matchesType(schema.Type, value) {
+		*errs = append(*errs, ValidationError{
+			Field:   field,
+			Code:    "type",
+			Message: "expected " + strings.Join(schema.Type, " or "),
+		})
+		return
+	}
+
+	if len(schema.Enum) > 0 && !inEnum(schema.Enum, value) {
+		*errs = append(*errs, ValidationError{Field: field, Code: "enum", Message: "must be one of: " + enumList(schema.Enum)})
+		return
+	}
+
+	switch v := value.(type) {
+	case string:
+		d.validateString(schema, v, field, errs)
+	case float64:
+		if schema.Minimum != nil && v < *schema.Minimum {
+			*errs = append(*errs, ValidationError{Field: field, Code: "minimum", Message: "must be at least " + formatNumber(*schema.Minimum)})
+		}
+		if schema.Maximum != nil && v > *schema.Maximum {
+			*errs = append(*errs, ValidationError{Field: field, Code: "maximum", Message: "must be at most " + formatNumber(*schema.Maximum)})
+		}
+	case []interface{}:
+		if schema.MinItems != nil && len(v) < *schema.MinItems {
+			*errs = append(*errs, ValidationError{Field: field, Code: "minItems", Message: "must have at least " + strconv.Itoa(*schema.MinItems) + " items"})
+		}
+		if schema.MaxItems != nil && len(v) > *schema.MaxItems {
+			*errs = append(*errs, ValidationError{Field: field, Code: "maxItems", Message: "must have at most " + strconv.Itoa(*schema.MaxItems) + " items"})
+		}
+		if schema.Items != nil {
+			for i, item := range v {
+				d.validateValue(schema.Items, item, field+"["+strconv.Itoa(i)+"]", errs)
+			}
+		}
+	case map[string]interface{}:
+		for _, name := range schema.Required {
+			if _, ok := v[name]; !ok {
+				*errs = append(*errs, ValidationError{Field: join(field, name), Code: "required", Message: "is required"})
+			}
+		}
+
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			property, ok := schema.Properties[name]
+			if !ok {
+				if schema.AdditionalProperties != nil && !*schema.AdditionalProperties {
+					*errs = append(*errs, ValidationError{Field: join(field, name), Code: "unknown", Message: "is not allowed"})
+				}
+				continue
+			}
+			d.validateValue(property, v[name], join(field, name), errs)
+		}
+	}
+}
+
+// validateString aplica las restricciones de cadenas
+func (d *Document) validateString(schema *Schema, value, field string, errs *[]ValidationError) {
+	length := len([]rune(value))
+	if schema.MinLength != nil && length < *schema.MinLength {
+		code, message := "minLength", "must be at least "+strconv.Itoa(*schema.MinLength)+" characters"
+		if *schema.MinLength == 1 {
+			code, message = "required", "must not be empty"
+		}
+		*errs = append(*errs, ValidationError{Field: field, Code: code, Message: message})
+	}
+	if schema.MaxLength != nil && length > *schema.MaxLength {
+		*errs = append(*errs, ValidationError{Field: field, Code: "maxLength", Message: "must be at most " + strconv.Itoa(*schema.MaxLength) + " characters"})
+	}
+
+	if schema.Pattern != "" {
+		re, err := compilePattern(schema.Pattern)
+		if err != nil {
+			*errs = append(*errs, ValidationError{Field: field, Code: "schema", Message: err.Error()})
+		} else if !re.MatchString(value) {
+			*errs = append(*errs, ValidationError{Field: field, Code: "pattern", Message: "must match " + schema.Pattern})
+		}
+	}
+
+	var err error
+	switch schema.Format {
+	case "date":
+		_, err = time.Parse("2006-01-02", value)
+	case "date-time":
+		_, err = time.Parse(time.RFC3339, value)
+	case "uuid":
+		if !uuidPattern.MatchString(value) {
+			err = fmt.Errorf("invalid uuid")
+		}
+	}
+	if err != nil {
+		*errs = append(*errs, ValidationError{Field: field, Code: "format", Message: "must be a valid " + schema.Format})
+	}
+}
+
+// matchesType indica si el valor es de alguno de los tipos
+func matchesType(types SchemaType, value interface{}) bool {
+	for _, t := range types {
+		switch t {
+		case "null":
+			if value == nil {
+				return true
+			}
+		case "string":
+			if _, ok := value.(string); ok {
+				return true
+			}
+		case "boolean":
+			if _, ok := value.(bool); ok {
+				return true
+			}
+		case "number":
+			if _, ok := value.(float64); ok {
+				return true
+			}
+		case "integer":
+			if n, ok := value.(float64); ok && n == math.Trunc(n) {
+				return true
+			}
+		case "array":
+			if _, ok := value.([]interface{}); ok {
+				return true
+			}
+		case "object":
+			if _, ok := value.(map[string]interface{}); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// inEnum indica si el valor es uno de los permitidos
+func inEnum(enum []interface{}, value interface{}) bool {
+	for _, allowed := range enum {
+		if allowed == value {
+			return true
+		}
+	}
+	return false
+}
+
+// enumList formatea los valores permitidos
+func enumList(enum []interface{}) string {
+	values := make([]string, 0, len(enum))
+	for _, value := range enum {
+		values = append(values, fmt.Sprint(value))
+	}
+	return strings.Join(values, " ")
+}
+
+// formatNumber formatea un límite numérico
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+// join compone la ruta de un campo anidado
+func join(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
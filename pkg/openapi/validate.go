@@ -0,0 +1,120 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// ErrUnsupportedMediaType el cuerpo usa un media type que la operación no
+// declara
+var ErrUnsupportedMediaType = errors.New("unsupported media type")
+
+// Request datos de una petición a validar
+type Request struct {
+	Method string
+	// Path ruta en formato OpenAPI, por ejemplo /api/v1/tasks/{id}
+	Path        string
+	PathParams  map[string]string
+	Query       url.Values
+	Header      http.Header
+	ContentType string
+	Body        []byte
+}
+
+// ValidateRequest valida los parámetros y el cuerpo de la petición contra
+// su operación. Las operaciones que el documento no define no se validan
+func (d *Document) ValidateRequest(req Request) ([]ValidationError, error) {
+	operation, err := d.Operation(req.Method, req.Path)
+	if err != nil || operation == nil {
+		return nil, err
+	}
+
+	var errs []ValidationError
+	for _, parameter := range operation.Parameters {
+		d.validateParameter(parameter, req, &errs)
+	}
+
+	if operation.RequestBody == nil {
+		return errs, nil
+	}
+
+	body := bytes.TrimSpace(req.Body)
+	if len(body) == 0 {
+		if operation.RequestBody.Required {
+			errs = append(errs, ValidationError{Code: "json", Message: "request body is empty"})
+		}
+		return errs, nil
+	}
+
+	mediaType, ok := operation.RequestBody.Content[req.ContentType]
+	if !ok {
+		return errs, ErrUnsupportedMediaType
+	}
+	if mediaType.Schema == nil {
+		return errs, nil
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return append(errs, ValidationError{Code: "json", Message: "invalid JSON: " + err.Error()}), nil
+	}
+	d.validateValue(mediaType.Schema, value, "", &errs)
+
+	return errs, nil
+}
+
+// validateParameter valida un parámetro de ruta, query o cabecera
+func (d *Document) validateParameter(parameter *Parameter, req Request, errs *[]ValidationError) {
+	var raw string
+	var present bool
+	switch parameter.In {
+	case "path":
+		raw, present = req.PathParams[parameter.Name]
+	case "query":
+		if values, ok := req.Query[parameter.Name]; ok && len(values) > 0 {
+			raw, present = values[0], true
+		}
+	case "header":
+		if values := req.Header.Values(parameter.Name); len(values) > 0 {
+			raw, present = values[0], true
+		}
+	default:
+		return
+	}
+
+	if !present {
+		if parameter.Required {
+			*errs = append(*errs, ValidationError{Field: parameter.Name, Code: "required", Message: "is required"})
+		}
+		return
+	}
+
+	schema, err := d.resolve(parameter.Schema)
+	if err != nil || schema == nil {
+		return
+	}
+
+	d.validateValue(schema, parameterValue(schema, raw), parameter.Name, errs)
+}
+
+// parameterValue convierte el texto del parámetro al tipo del esquema para
+// validarlo como un valor JSON
+func parameterValue(schema *Schema, raw string) interface{} {
+	for _, t := range schema.Type {
+		switch t {
+		case "integer", "number":
+			if n, err := strconv.ParseFloat(raw, 64); err == nil {
+				return n
+			}
+		case "boolean":
+			if b, err := strconv.ParseBool(raw); err == nil {
+				return b
+			}
+		}
+	}
+	return raw
+}
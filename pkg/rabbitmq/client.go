@@ -62,7 +62,7 @@ func NewClient(config ClientConfig) (*Client, error) {
 	// 3. BINDING QUEUE AL EXCHANGE
 	err = channel.QueueBind(
 		config.Queue,    // queue name
-		"task.*",        // routing key pattern (captura task.created, task.completed, etc.)
+		"*.task.*",      // routing key pattern (<tenant>.task.created, <tenant>.task.completed, etc.)
 		config.Exchange, // exchange
 		false,           // no-wait
 		nil,             // args
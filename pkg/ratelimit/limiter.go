@@ -0,0 +1,87 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limit configuración de un token bucket: admite ráfagas de Burst
+// peticiones y repone Requests tokens cada Period
+type Limit struct {
+	Requests int
+	Period   time.Duration
+	// Burst capacidad del bucket; 0 usa Requests
+	Burst int
+}
+
+// PerMinute límite de n peticiones por minuto con ráfagas de burst
+func PerMinute(n, burst int) Limit {
+	return Limit{Requests: n, Period: time.Minute, Burst: burst}
+}
+
+// Enabled indica si el límite restringe algo
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Period > 0
+}
+
+// capacity número máximo de tokens del bucket
+func (l Limit) capacity() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+	return float64(l.Requests)
+}
+
+// rate tokens repuestos por segundo
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// fillTime tiempo que tarda un bucket vacío en llenarse
+func (l Limit) fillTime() time.Duration {
+	return seconds(l.capacity() / l.rate())
+}
+
+// Decision resultado de consumir un token
+type Decision struct {
+	Allowed bool
+	// Limit capacidad del bucket
+	Limit int
+	// Remaining tokens que quedan tras la petición
+	Remaining int
+	// Reset tiempo hasta que el bucket vuelva a estar lleno
+	Reset time.Duration
+	// RetryAfter tiempo hasta el próximo token; 0 si la petición se admitió
+	RetryAfter time.Duration
+}
+
+// Limiter decide si una petición identificada por key puede pasar
+type Limiter interface {
+	// Allow consume un token del bucket de key según limit
+	Allow(ctx context.Context, key string, limit Limit) (Decision, error)
+}
+
+// decide construye la decisión a partir de los tokens que quedan en el
+// bucket tras la petición
+func decide(limit Limit, tokens float64, allowed bool) Decision {
+	decision := Decision{
+		Allowed:   allowed,
+		Limit:     int(limit.capacity()),
+		Remaining: int(math.Floor(tokens)),
+		Reset:     seconds((limit.capacity() - tokens) / limit.rate()),
+	}
+	if !allowed {
+		decision.RetryAfter = seconds((1 - tokens) / limit.rate())
+	}
+
+	return decision
+}
+
+// seconds convierte segundos fraccionarios a time.Duration
+func seconds(s float64) time.Duration {
+	if s <= 0 {
+		return 0
+	}
+	return time.Duration(s * float64(time.Second))
+}
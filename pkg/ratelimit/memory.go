@@ -0,0 +1,76 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval frecuencia con la que se descartan los buckets llenos
+const sweepInterval = time.Minute
+
+// bucket estado de un token bucket
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	// full instante en que el bucket estará lleno si no recibe peticiones
+	full time.Time
+}
+
+// MemoryLimiter implementación en memoria de Limiter para una sola instancia
+type MemoryLimiter struct {
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+	mu        sync.Mutex
+}
+
+// NewMemoryLimiter crea un limitador en memoria
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Allow implementa Limiter
+func (l *MemoryLimiter) Allow(_ context.Context, key string, limit Limit) (Decision, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: limit.capacity(), updated: now}
+		l.buckets[key] = b
+	}
+
+	elapsed := now.Sub(b.updated).Seconds()
+	b.tokens = math.Min(limit.capacity(), b.tokens+math.Max(0, elapsed)*limit.rate())
+	b.updated = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	b.full = now.Add(seconds((limit.capacity() - b.tokens) / limit.rate()))
+
+	return decide(limit, b.tokens, allowed), nil
+}
+
+// sweep descarta los buckets que ya se han llenado: uno nuevo es equivalente
+func (l *MemoryLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		if !now.Before(b.full) {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryLimiter_TokenBucket(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewMemoryLimiter()
+	limiter.now = func() time.Time { return now }
+	limit := PerMinute(60, 3)
+	ctx := context.Background()
+
+	steps := []struct {
+		name          string
+		advance       time.Duration
+		key           string
+		wantAllowed   bool
+		wantRemaining int
+		wantRetry     time.Duration
+	}{
+		{"burst starts full", 0, "a", true, 2, 0},
+		{"burst", 0, "a", true, 1, 0},
+		{"burst exhausted", 0, "a", true, 0, 0},
+		{"empty bucket", 0, "a", false, 0, time.Second},
+		{"buckets are per key", 0, "b", true, 2, 0},
+		{"partial refill is not enough", 500 * time.Millisecond, "a", false, 0, 500 * time.Millisecond},
+		{"one token per second", 500 * time.Millisecond, "a", true, 0, 0},
+		{"refill never exceeds the burst", time.Hour, "a", true, 2, 0},
+	}
+
+	for _, step := range steps {
+		now = now.Add(step.advance)
+		decision, err := limiter.Allow(ctx, step.key, limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decision.Allowed != step.wantAllowed || decision.Remaining != step.wantRemaining || decision.RetryAfter != step.wantRetry {
+			t.Errorf("%s: expected allowed=%v remaining=%d retry=%v, got %+v",
+				step.name, step.wantAllowed, step.wantRemaining, step.wantRetry, decision)
+		}
+		if decision.Limit != 3 {
+			t.Errorf("%s: expected limit 3, got %d", step.name, decision.Limit)
+		}
+	}
+}
+
+func TestMemoryLimiter_SweepsFullBuckets(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewMemoryLimiter()
+	limiter.now = func() time.Time { return now }
+
+	_, _ = limiter.Allow(context.Background(), "idle", PerMinute(60, 3))
+	now = now.Add(2 * sweepInterval)
+	_, _ = limiter.Allow(context.Background(), "active", PerMinute(60, 3))
+
+	if _, ok := limiter.buckets["idle"]; ok {
+		t.Error("Expected the refilled bucket to be discarded")
+	}
+	if _, ok := limiter.buckets["active"]; !ok {
+		t.Error("Expected the active bucket to be kept")
+	}
+}
@@ -0,0 +1,86 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// mongoDocument estado de un bucket tras una petición
+type mongoDocument struct {
+	Tokens  float64 `bson:"tokens"`
+	Allowed bool    `bson:"allowed"`
+}
+
+// MongoLimiter implementación MongoDB de Limiter para varias instancias. Cada
+// petición repone y consume tokens en una única actualización atómica con el
+// reloj del servidor, y los buckets inactivos caducan con un índice TTL
+// sobre expires_at
+type MongoLimiter struct {
+	collection *mongo.Collection
+}
+
+// NewMongoLimiter crea el limitador sobre la colección rate_limits
+func NewMongoLimiter(db *mongo.Database) *MongoLimiter {
+	return &MongoLimiter{collection: db.Collection("rate_limits")}
+}
+
+// EnsureIndexes crea el índice TTL de la colección
+func (l *MongoLimiter) EnsureIndexes(ctx context.Context) error {
+	_, err := l.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create rate limit TTL index: %w", err)
+	}
+
+	return nil
+}
+
+// Allow implementa Limiter
+func (l *MongoLimiter) Allow(ctx context.Context, key string, limit Limit) (Decision, error) {
+	var doc mongoDocument
+	err := l.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": key},
+		takePipeline(limit),
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&doc)
+	if mongo.IsDuplicateKeyError(err) {
+		// Otra instancia creó el bucket a la vez: ya existe y basta repetir
+		return l.Allow(ctx, key, limit)
+	}
+	if err != nil {
+		return Decision{}, fmt.Errorf("failed to take rate limit token: %w", err)
+	}
+
+	return decide(limit, doc.Tokens, doc.Allowed), nil
+}
+
+// takePipeline actualización que repone los tokens transcurridos desde la
+// última petición y consume uno si hay disponible. Un bucket nuevo empieza
+// lleno
+func takePipeline(limit Limit) mongo.Pipeline {
+	capacity := limit.capacity()
+	elapsedMs := bson.M{"$max": bson.A{0, bson.M{"$subtract": bson.A{"$$NOW", bson.M{"$ifNull": bson.A{"$updated_at", "$$NOW"}}}}}}
+	refilled := bson.M{"$min": bson.A{
+		capacity,
+		bson.M{"$add": bson.A{
+			bson.M{"$ifNull": bson.A{"$tokens", capacity}},
+			bson.M{"$multiply": bson.A{elapsedMs, limit.rate() / 1000}},
+		}},
+	}}
+
+	return mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"tokens": refilled, "updated_at": "$$NOW"}}},
+		{{Key: "$set", Value: bson.M{"allowed": bson.M{"$gte": bson.A{"$tokens", 1}}}}},
+		{{Key: "$set", Value: bson.M{
+			"tokens": bson.M{"$cond": bson.A{"$allowed", bson.M{"$subtract": bson.A{"$tokens", 1}}, "$tokens"}},
+			// Pasado fillTime el bucket estaría lleno: equivale a uno nuevo
+			"expires_at": bson.M{"$add": bson.A{"$$NOW", limit.fillTime().Milliseconds()}},
+		}}},
+	}
+}
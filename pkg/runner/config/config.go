@@ -40,8 +40,9 @@ func Load() (*Config, error) {
 }
 
 // Unmarshal deserializa la configuración en la estructura proporcionada
+// usando las etiquetas `mapstructure` de sus campos
 func (c *Config) Unmarshal(v interface{}) error {
-	return c.k.Unmarshal("", v)
+	return c.k.UnmarshalWithConf("", v, koanf.UnmarshalConf{Tag: "mapstructure"})
 }
 
 // setDefaults establece valores por defecto
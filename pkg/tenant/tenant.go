@@ -0,0 +1,95 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"regexp"
+)
+
+// DefaultID tenant de las peticiones que no indican ninguno y de los datos
+// anteriores a los tenants
+const DefaultID = "default"
+
+// Header cabecera HTTP con la que un cliente elige su tenant
+const Header = "X-Tenant-ID"
+
+var (
+	// ErrInvalidTenant el ID de tenant no tiene un formato válido
+	ErrInvalidTenant = errors.New("invalid tenant ID")
+	// ErrTenantMismatch el tenant pedido no es el del principal autenticado
+	ErrTenantMismatch = errors.New("tenant does not match the authenticated principal")
+	// ErrTenantNotAllowed un principal sin tenant pidió uno que no se puede
+	// elegir con la cabecera
+	ErrTenantNotAllowed = errors.New("tenant cannot be selected by this principal")
+)
+
+// idPattern letras, dígitos, guiones y guiones bajos; sin puntos, para que
+// el ID sea un segmento válido de las routing keys de RabbitMQ
+var idPattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]{0,63}$`)
+
+// Validate comprueba el formato de un ID de tenant
+func Validate(id string) error {
+	if !idPattern.MatchString(id) {
+		return ErrInvalidTenant
+	}
+	return nil
+}
+
+// Resolve decide el tenant de una petición. El del principal autenticado
+// manda: pedir otro es un error. Sin tenant en el principal solo vale el
+// pedido si está en selectable ("*" admite cualquiera) y, si no pide
+// ninguno, DefaultID
+func Resolve(principalTenant, requested string, selectable []string) (string, error) {
+	if principalTenant != "" {
+		if requested != "" && requested != principalTenant {
+			return "", ErrTenantMismatch
+		}
+		return principalTenant, nil
+	}
+
+	if requested == "" || requested == DefaultID {
+		return DefaultID, nil
+	}
+	if err := Validate(requested); err != nil {
+		return "", err
+	}
+	for _, allowed := range selectable {
+		if allowed == "*" || allowed == requested {
+			return requested, nil
+		}
+	}
+	return "", ErrTenantNotAllowed
+}
+
+type contextKey string
+
+const (
+	idKey  contextKey = "tenant.id"
+	allKey contextKey = "tenant.all"
+)
+
+// WithID asocia el tenant al contexto
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, idKey, id)
+}
+
+// FromContext obtiene el tenant del contexto o DefaultID si no tiene
+func FromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(idKey).(string); ok && id != "" {
+		return id
+	}
+	return DefaultID
+}
+
+// WithAllTenants marca el contexto de un proceso interno (la purga de la
+// papelera, por ejemplo) que opera sobre los datos de todos los tenants.
+// Nunca se debe derivar de una petición
+func WithAllTenants(ctx context.Context) context.Context {
+	return context.WithValue(ctx, allKey, true)
+}
+
+// AllTenants indica si el contexto opera sobre todos los tenants
+func AllTenants(ctx context.Context) bool {
+	all, _ := ctx.Value(allKey).(bool)
+	return all
+}
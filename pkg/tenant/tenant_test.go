@@ -0,0 +1,57 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name            string
+		principalTenant string
+		requested       string
+		selectable      []string
+		want            string
+		wantErr         error
+	}{
+		{"nothing uses the default tenant", "", "", nil, DefaultID, nil},
+		{"the default tenant is always selectable", "", DefaultID, nil, DefaultID, nil},
+		{"header chooses a selectable tenant", "", "globex", []string{"acme", "globex"}, "globex", nil},
+		{"wildcard allows any tenant", "", "globex", []string{"*"}, "globex", nil},
+		{"unbound principal cannot pick any tenant", "", "globex", []string{"acme"}, "", ErrTenantNotAllowed},
+		{"nothing selectable by default", "", "globex", nil, "", ErrTenantNotAllowed},
+		{"principal tenant without header", "acme", "", nil, "acme", nil},
+		{"header matching the principal", "acme", "acme", nil, "acme", nil},
+		{"header cannot switch the principal tenant", "acme", "globex", []string{"*"}, "", ErrTenantMismatch},
+		{"dots would break routing keys", "", "acme.eu", []string{"*"}, "", ErrInvalidTenant},
+		{"leading dash", "", "-acme", []string{"*"}, "", ErrInvalidTenant},
+		{"too long", "", strings.Repeat("a", 65), []string{"*"}, "", ErrInvalidTenant},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Resolve(tt.principalTenant, tt.requested, tt.selectable)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected tenant %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestContext(t *testing.T) {
+	ctx := context.Background()
+	if got := FromContext(ctx); got != DefaultID {
+		t.Errorf("Expected %q without a tenant, got %q", DefaultID, got)
+	}
+	if got := FromContext(WithID(ctx, "acme")); got != "acme" {
+		t.Errorf("Expected acme, got %q", got)
+	}
+	if AllTenants(WithID(ctx, "acme")) || !AllTenants(WithAllTenants(ctx)) {
+		t.Errorf("Expected only WithAllTenants to span every tenant")
+	}
+}
@@ -38,7 +38,7 @@ api.interceptors.response.use(
       
       switch (status) {
         case 400:
-          throw new Error(data?.message || 'Bad Request')
+          throw new Error(data?.detail || data?.message || 'Bad Request')
         case 401:
           throw new Error('Unauthorized')
         case 403:
@@ -48,7 +48,7 @@ api.interceptors.response.use(
         case 500:
           throw new Error('Internal Server Error')
         default:
-          throw new Error(data?.message || `Server Error: ${status}`)
+          throw new Error(data?.detail || data?.message || `Server Error: ${status}`)
       }
     } else if (error.request) {
       // Network error
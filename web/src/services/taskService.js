@@ -54,11 +54,13 @@ export const taskService = {
   },
 
   /**
-   * Update an existing task
+   * Update an existing task with a JSON Merge Patch of the changed fields
    */
   async updateTask(id, updates) {
     try {
-      const response = await api.put(`/v1/tasks/${id}`, updates)
+      const response = await api.patch(`/v1/tasks/${id}`, updates, {
+        headers: { 'Content-Type': 'application/merge-patch+json' }
+      })
       return response.data
     } catch (error) {
       throw new Error(`Failed to update task ${id}: ${error.message}`)